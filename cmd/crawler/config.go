@@ -0,0 +1,157 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// fileSettings are the config file options that have no flag equivalent.
+type fileSettings struct {
+	// headers are extra request headers, e.g. Authorization
+	headers map[string]string
+	// include and exclude are URL regular expressions filtering the crawl
+	include []string
+	exclude []string
+	// hostRateLimits override -host-rate-ms for specific hosts
+	hostRateLimits map[string]time.Duration
+}
+
+// repeatableFlag is a flag that accumulates a value per use, such as
+// -path-budget.
+type repeatableFlag interface {
+	flag.Value
+	repeatable()
+}
+
+// loadConfigFile reads a YAML (.yaml, .yml) or TOML (.toml) crawl config.
+// Every key except headers, include, exclude, and host-rate-ms names a flag
+// (without the dash) and sets it, unless the flag was given on the command
+// line: flags always win over the file.
+func loadConfigFile(path string, flags *flag.FlagSet) (fileSettings, error) {
+	var settings fileSettings
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return settings, err
+	}
+	values := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return settings, fmt.Errorf("unsupported config format %q (want .yaml, .yml, or .toml)", ext)
+	}
+	if err != nil {
+		return settings, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values[key]
+		switch key {
+		case "headers":
+			headers, ok := value.(map[string]any)
+			if !ok {
+				return settings, fmt.Errorf("headers: want a map of header names to values")
+			}
+			settings.headers = make(map[string]string, len(headers))
+			for name, v := range headers {
+				settings.headers[name] = fmt.Sprint(v)
+			}
+		case "include", "exclude":
+			patterns, ok := value.([]any)
+			if !ok {
+				return settings, fmt.Errorf("%s: want a list of regular expressions", key)
+			}
+			for _, p := range patterns {
+				if key == "include" {
+					settings.include = append(settings.include, fmt.Sprint(p))
+				} else {
+					settings.exclude = append(settings.exclude, fmt.Sprint(p))
+				}
+			}
+		case "host-rate-ms":
+			hosts, ok := value.(map[string]any)
+			if !ok {
+				return settings, fmt.Errorf("host-rate-ms: want a map of hosts to milliseconds; use the -host-rate-ms flag for a default")
+			}
+			settings.hostRateLimits = make(map[string]time.Duration, len(hosts))
+			for host, v := range hosts {
+				ms, ok := intValue(v)
+				if !ok || ms < 0 {
+					return settings, fmt.Errorf("host-rate-ms: %s: want a non-negative number of milliseconds, got %v", host, v)
+				}
+				settings.hostRateLimits[host] = time.Duration(ms) * time.Millisecond
+			}
+		case "config":
+			return settings, fmt.Errorf("config: config files cannot include other config files")
+		default:
+			if flags.Lookup(key) == nil {
+				return settings, fmt.Errorf("unknown setting %q", key)
+			}
+			if explicit[key] {
+				continue
+			}
+			// Repeatable flags take each list item as a separate value
+			if list, ok := value.([]any); ok {
+				if _, ok := flags.Lookup(key).Value.(repeatableFlag); ok {
+					for _, item := range list {
+						if err := flags.Set(key, fmt.Sprint(item)); err != nil {
+							return settings, fmt.Errorf("%s: %w", key, err)
+						}
+					}
+					continue
+				}
+			}
+			if err := flags.Set(key, flagValue(value)); err != nil {
+				return settings, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+	return settings, nil
+}
+
+// flagValue formats a config value as flag text. Lists become
+// comma-separated, matching flags such as -lang.
+func flagValue(value any) string {
+	list, ok := value.([]any)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	items := make([]string, len(list))
+	for i, v := range list {
+		items[i] = fmt.Sprint(v)
+	}
+	return strings.Join(items, ",")
+}
+
+// intValue converts a decoded YAML (int) or TOML (int64) number.
+func intValue(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+	return 0, false
+}
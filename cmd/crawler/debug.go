@@ -0,0 +1,49 @@
+package main
+
+import (
+	"expvar"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/cametumbling/web-crawler/internal/crawler"
+)
+
+// startDebugServer serves the pprof profiles under /debug/pprof/, the
+// expvar variables, including the crawl's progress counters, under
+// /debug/vars, and POST /debug/pause and /debug/resume to control the
+// crawl. It listens before returning so a bad address fails the run up
+// front.
+func startDebugServer(addr string, coord *crawler.Coordinator) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	expvar.Publish("crawl", expvar.Func(func() any { return coord.Progress() }))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("POST /debug/pause", func(w http.ResponseWriter, r *http.Request) {
+		coord.Pause()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("POST /debug/resume", func(w http.ResponseWriter, r *http.Request) {
+		coord.Resume()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	go func() {
+		if err := http.Serve(ln, mux); err != nil {
+			slog.Error("Debug server stopped", "error", err)
+		}
+	}()
+	slog.Info("Debug server listening", "addr", ln.Addr().String())
+	return nil
+}
@@ -1,105 +1,1051 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
+	"runtime/debug"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/cametumbling/web-crawler/internal/crawler"
+	"github.com/cametumbling/web-crawler/internal/platform/browser"
 	"github.com/cametumbling/web-crawler/internal/platform/htmlparser"
 	"github.com/cametumbling/web-crawler/internal/platform/httpclient"
+	"github.com/cametumbling/web-crawler/internal/platform/natspub"
+	"github.com/cametumbling/web-crawler/internal/platform/replay"
+	"github.com/cametumbling/web-crawler/internal/platform/webhook"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Exit statuses beyond 1 (the crawl could not run) and 2 (bad flags)
+const (
+	// thresholdExitCode: the crawl finished but broke -fail-on-broken-links
+	// or -max-error-rate
+	thresholdExitCode = 3
+	// abortExitCode: the crawl was stopped early by -max-errors or
+	// -max-consecutive-errors
+	abortExitCode = 4
+)
+
+// version is the crawler build, set with -ldflags "-X main.version=v1.2.3"
+// (default: the module version recorded in the binary)
+var version string
+
+// crawlerVersion returns the version reported in output metadata.
+func crawlerVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.Main.Version
+	}
+	return "unknown"
+}
+
 func main() {
+	os.Exit(run())
+}
+
+// run is the body of main. It returns the exit status rather than calling
+// os.Exit, so deferred cleanup such as removing the frontier spill file
+// runs however the crawl ends.
+func run() int {
 	// Parse command line flags
 	url := flag.String("url", "", "Starting URL (required)")
 	workers := flag.Int("workers", 8, "Number of concurrent workers")
+	autoScale := flag.Bool("autoscale", false, "Grow and shrink the active workers, up to -workers, from queue depth, latency, and error rates")
+	minWorkers := flag.Int("min-workers", 1, "Fewest workers -autoscale keeps busy")
 	maxPages := flag.Int("max-pages", 0, "Maximum pages to visit (0 = unlimited)")
-	rateMs := flag.Int("rate-ms", 0, "Minimum milliseconds between requests (0 = no limit)")
-	format := flag.String("format", "text", "Output format: text or json")
+	rateMs := flag.Int("rate-ms", 0, "Minimum milliseconds between requests across all hosts (0 = no limit)")
+	rateBurst := flag.Int("rate-burst", 1, "Requests allowed back-to-back before the global rate limit applies")
+	maxRPS := flag.Float64("max-rps", 0, "Alias of -rate-ms given as requests per second across all hosts (0 = no limit)")
+	maxPagesPerSec := flag.Float64("max-pages-per-sec", 0, "Maximum pages per second handed to workers across the whole crawl (0 = no limit)")
+	hostRateMs := flag.Int("host-rate-ms", 0, "Minimum milliseconds between requests to the same host (0 = no limit)")
+	format := flag.String("format", "text", "Output format: text, json, or ndjson (json; one record per line, flushed as it is crawled)")
+	outputBuffer := flag.Int("output-buffer", 256, "Pages queued for the output writer so a slow stdout doesn't stall the crawl (0 = write synchronously)")
+	headPrecheck := flag.Bool("head-precheck", false, "Issue HEAD before fetching likely-binary URLs and skip non-HTML or oversized bodies")
+	eventsFile := flag.String("events-file", "", "Write structured lifecycle events as JSON lines to this file")
+	langs := flag.String("lang", "", "Comma-separated language tags to restrict the crawl to, e.g. en,fr (empty = all)")
+	linkStats := flag.Int("link-stats", 0, "Report in/out link counts in the summary, listing N pages per section (0 = disabled)")
+	slowTop := flag.Int("slow-top", 0, "Report the N slowest pages in the summary (0 = disabled)")
+	slowMs := flag.Int("slow-threshold-ms", 0, "Report pages whose fetch took longer than this many milliseconds (0 = disabled)")
+	largeTop := flag.Int("large-top", 0, "Report the N largest pages by HTML size in the summary (0 = disabled)")
+	largeBytes := flag.Int("large-threshold-bytes", 0, "Report pages whose HTML exceeds this many bytes (0 = disabled)")
+	noindexLinks := flag.Int("noindex-min-links", 0, "Report noindexed pages linked from at least N internal pages (0 = disabled)")
+	nofollowReport := flag.Bool("nofollow-report", false, "Report pages reachable only through rel=nofollow links")
+	blockPrivate := flag.Bool("block-private", false, "Refuse to connect to loopback, private, and link-local addresses")
+	userAgent := flag.String("user-agent", httpclient.DefaultUserAgent, "User-Agent header; {from} and {info} are replaced with -from and -crawl-info-url")
+	from := flag.String("from", "", "Contact email sent in the From header")
+	crawlInfoURL := flag.String("crawl-info-url", "", "URL describing this crawl, added to the User-Agent")
+	checkFragments := flag.Bool("check-fragments", false, "Report links whose #fragment matches no id or name in the target page")
+	adaptive := flag.Bool("adaptive-throttle", false, "Slow down per host on 429/503 responses or latency spikes, recovering gradually")
+	indexFile := flag.String("index-file", "", "Write a search-index export (JSON lines: id, url, title, lang, content) to this file")
+	includeAssets := flag.Bool("assets", false, "Also report img, script, link, and iframe URLs for each page")
+	followAssets := flag.Bool("follow-assets", false, "Crawl in-scope asset URLs as well as anchors (implies -assets)")
+	externalDomains := flag.Bool("external-domains", false, "Summarize external domains referenced by the site")
+	validateSchema := flag.Bool("validate-schema", false, "Report JSON-LD Article, Product, and BreadcrumbList items missing required fields")
+	respectRobots := flag.Bool("respect-robots-meta", false, "Don't follow links on pages whose robots meta or X-Robots-Tag says nofollow, and mark noindex pages")
+	dedupCanonical := flag.Bool("dedup-canonical", false, "Skip pages whose rel=canonical URL was already seen and report them grouped by canonical")
+	nearDuplicates := flag.Bool("near-duplicates", false, "Report groups of pages with nearly identical text, found by simhash")
+	nearDupDistance := flag.Int("near-duplicate-distance", crawler.DefaultNearDuplicateDistance, "Most simhash bits (of 64) that -near-duplicates pages may differ in")
+	hostReport := flag.Bool("host-report", false, "Report TLS certificate hostname mismatches and inconsistent www/apex redirects")
+	budgetReport := flag.Bool("budget-report", false, "Break down fetched pages by path prefix, depth, and content type")
+	hintsReport := flag.Bool("hints-report", false, "Audit preload/prefetch/preconnect/dns-prefetch hints for missing or unused targets")
+	captureHeaders := flag.String("capture-headers", "", "Comma-separated response headers to add to JSON output records, e.g. Cache-Control,Server (requires -format json)")
+	linkDetails := flag.Bool("link-details", false, "Add each link's anchor text, rel, and tag to JSON output records (requires -format json)")
+	maxBytesPerSec := flag.Int64("max-bytes-per-sec", 0, "Maximum response body bytes read per second across all requests (0 = no limit)")
+	maxTotalBytes := flag.Int64("max-total-bytes", 0, "Stop the crawl once this many response body bytes have been downloaded (0 = no limit)")
+	maxBodyBytes := flag.Int64("max-body-bytes", httpclient.DefaultMaxBodySize, "Maximum bytes read from HTML and CSS responses")
+	maxOtherBodyBytes := flag.Int64("max-other-body-bytes", 0, "Bytes read from other content types, sniffing octet-stream for mislabelled HTML (0 = skip the body)")
+	extractText := flag.Bool("extract-text", false, "Add each page's visible text (scripts and styles stripped) to JSON output records (requires -format json)")
+	harvestMetadata := flag.Bool("metadata", false, "Add OpenGraph properties and JSON-LD blocks to JSON output records (requires -format json)")
+	timeoutMs := flag.Int("timeout-ms", 10000, "Total time allowed per request, including reading the body")
+	connectTimeoutMs := flag.Int("connect-timeout-ms", 0, "Time allowed to establish a TCP connection (0 = 30s)")
+	tlsTimeoutMs := flag.Int("tls-timeout-ms", 0, "Time allowed for the TLS handshake (0 = 10s)")
+	headerTimeoutMs := flag.Int("header-timeout-ms", 0, "Time allowed to receive response headers once the request is sent (0 = only -timeout-ms applies)")
+	maxDuration := flag.Duration("max-duration", 0, "Stop the crawl cleanly after this long, e.g. 30m, and still print the summary (0 = no limit)")
+	breakerFailures := flag.Int("breaker-failures", 0, "Pause a host after N consecutive failures or timeouts (0 = disabled)")
+	breakerCooldownMs := flag.Int("breaker-cooldown-ms", 30000, "Milliseconds a host stays paused after -breaker-failures is reached")
+	traceOut := flag.String("trace-out", "", "Write OpenTelemetry spans for the crawl, each page, and its fetch, parse, and process steps as JSON to this file")
+	debugAddr := flag.String("debug-addr", "", "Serve pprof profiles, expvar counters, and pause/resume endpoints on this address during the crawl (e.g. localhost:6060)")
+	checkpointFile := flag.String("checkpoint", "", "When the crawl ends or is interrupted, save the visited set and pending frontier to this file")
+	resumeFile := flag.String("resume", "", "Continue the interrupted crawl saved in this checkpoint file")
+	stateFile := flag.String("state", "", "Incremental recrawl: revalidate pages with the ETags and Last-Modified times stored in this file by the previous crawl, then update it")
+	errorsOut := flag.String("errors-out", "", "Write every failed URL with its error category, attempt count, and referrer as JSON lines to this file")
+	auditLogFile := flag.String("audit-log", "", "Append crawl decisions (config snapshot, seeds, skips, robots decisions, budgets hit) as JSON lines to this file")
+	stripTracking := flag.Bool("strip-tracking-params", false, "Remove tracking query parameters (see -tracking-params) from every URL before it is printed or deduplicated")
+	trackingParams := flag.String("tracking-params", strings.Join(crawler.DefaultTrackingParams, ","), "Comma-separated query parameters removed by -strip-tracking-params; a trailing * matches a prefix")
+	dropParams := flag.String("drop-params", "", "Comma-separated query parameters to remove from every URL, e.g. sessionid,sort; a trailing * matches a prefix")
+	sortQuery := flag.Bool("sort-query", false, "Treat URLs whose query parameters differ only in order as the same page")
+	caseInsensitive := flag.Bool("case-insensitive-paths", false, "Treat URL paths differing only in case as the same page, for case-insensitive servers such as IIS")
+	detectLang := flag.Bool("detect-lang", false, "Guess the language of pages without a lang attribute from their text")
+	render := flag.String("render", "http", "How pages are fetched: http, or browser to render JavaScript in headless Chrome")
+	chromePath := flag.String("chrome-path", "", "Chrome or Chromium executable for -render=browser (default: found in PATH)")
+	includeHTML := flag.Bool("include-html", false, "Embed each page's raw HTML in JSON output records (requires -format json)")
+	htmlMaxBytes := flag.Int("html-max-bytes", 0, "Truncate embedded HTML to this many bytes (0 = no limit)")
+	htmlBase64 := flag.Bool("html-base64", false, "Base64-encode embedded HTML")
+	webhookURL := flag.String("webhook", "", "POST every page result as JSON to this URL, retrying failed deliveries")
+	webhookSecretFile := flag.String("webhook-secret-file", "", "Sign webhook bodies with HMAC-SHA256 using the secret in this file (X-Crawler-Signature-256 header)")
+	webhookRetries := flag.Int("webhook-retries", webhook.DefaultRetries, "Retries for a webhook delivery that failed with a network error, 429, or 5xx")
+	natsURL := flag.String("nats-url", "", "Publish page results and discovered links to the NATS server at this URL, e.g. nats://localhost:4222")
+	natsSubject := flag.String("nats-subject", natspub.DefaultSubject, "Subject prefix for -nats-url: pages go to <prefix>.pages, links to <prefix>.links")
+	redirectMapFile := flag.String("redirect-map", "", "Write observed permanent redirects as webserver rules to this file")
+	var pathBudgets pathBudgetFlag
+	flag.Var(&pathBudgets, "path-budget", "Cap URLs scheduled under a path prefix (/tag/=200) or matching a regex (~[?&]sort==50); repeatable")
+	maxURLLength := flag.Int("max-url-length", 0, "Refuse to schedule discovered URLs longer than this many characters (0 = no limit)")
+	maxQueryParams := flag.Int("max-query-params", 0, "Refuse to schedule discovered URLs with more query parameters than this (0 = no limit)")
+	pdfLinks := flag.Bool("pdf-links", false, "Extract and follow links from PDF documents")
+	checkExternal := flag.Bool("check-external", false, "Check that out-of-scope links resolve (HEAD, without following them) and print the dead ones after all pages")
+	crawlMetadata := flag.Bool("crawl-metadata", false, "Print a header record (start URL, flags, time, crawler version) before the first page and a summary record after the last, both with the output schema version (requires -format json)")
+	maxErrors := flag.Int("max-errors", 0, "Abort the crawl with exit status 4 once this many pages have failed (0 = no limit)")
+	maxConsecutiveErrors := flag.Int("max-consecutive-errors", 0, "Abort the crawl with exit status 4 once this many pages in a row have failed, e.g. when the site is down (0 = no limit)")
+	failOnBrokenLinks := flag.Bool("fail-on-broken-links", false, "Exit with status 3 if any page returned 404 or 410 (or, with -check-external, any external link is dead)")
+	maxErrorRate := flag.Float64("max-error-rate", 0, "Exit with status 3 if more than this share of pages failed, e.g. 0.05 (0 = no limit)")
+	brokenLinks := flag.Bool("broken-links", false, "Print a broken link section (404/410 URLs and the pages linking to them) after all pages")
+	graphFile := flag.String("graph", "", "Write the site graph (pages and the links between them) in Graphviz DOT format to this file")
+	pageRankFile := flag.String("pagerank", "", "Write the PageRank of every crawled page over the internal link graph to this file")
+	pageRankFormat := flag.String("pagerank-format", "csv", "PageRank file format: csv or json")
+	recordFile := flag.String("record", "", "Save every response (bodies included) to this archive, for replaying the crawl later with -replay")
+	replayFile := flag.String("replay", "", "Serve responses from an archive written by -record instead of the network; URLs missing from it fail")
+	replayWARC := flag.String("replay-warc", "", "Serve responses from this WARC archive (.warc or .warc.gz) instead of the network, to re-run reports against an archived snapshot offline; URLs missing from it fail")
+	harFile := flag.String("har", "", "Write every HTTP request and response (headers, status, sizes, timings) to this file in HAR format when the crawl ends")
+	junitFile := flag.String("junit", "", "Write a JUnit XML report to this file, with a failed test case per page or checked external link that could not be fetched, for CI systems")
+	sitemapFile := flag.String("sitemap", "", "Write a sitemap.xml of the crawled pages to this file")
+	redirectMapFormat := flag.String("redirect-map-format", "nginx", "Redirect map format: nginx, apache, or netlify")
+	order := flag.String("order", crawler.BreadthFirst, "Crawl order: bfs (breadth-first, level by level), dfs (depth-first, one branch at a time), or priority (shallow, short paths first)")
+	frontierMemory := flag.Int("frontier-memory", 0, "Keep at most this many scheduled URLs in memory and spill the rest to a temporary file (0 = unbounded; bfs order only)")
+	frontierDir := flag.String("frontier-dir", "", "Directory for the -frontier-memory spill file (default: the system temporary directory)")
+	seed := flag.Int64("seed", 0, "Seed for reproducible scheduling with a single worker (0 = disabled)")
+	logLevel := flag.String("log-level", "info", "Minimum level of stderr logs: debug, info, warn, or error")
+	logFormat := flag.String("log-format", "text", "Stderr log format: text (key=value) or json")
+	showProgress := flag.Bool("progress", true, "Keep a live progress line (pages, queued, errors, rate, elapsed) at the bottom of stderr when it is a terminal")
+	configFile := flag.String("config", "", "YAML or TOML file with crawl settings; flags given on the command line override it")
 
 	flag.Parse()
 
+	// Apply the config file before validating, so its values are checked
+	// like flags
+	var settings fileSettings
+	if *configFile != "" {
+		var err error
+		settings, err = loadConfigFile(*configFile, flag.CommandLine)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading config file: %v\n", err)
+			return 1
+		}
+	}
+
 	// Validate required flags
 	if *url == "" {
 		fmt.Fprintf(os.Stderr, "Error: -url flag is required\n")
 		flag.Usage()
-		os.Exit(1)
+		return 1
 	}
 
 	// Validate flag values
 	if *workers <= 0 {
 		fmt.Fprintf(os.Stderr, "Error: -workers must be greater than 0\n")
-		os.Exit(1)
+		return 1
+	}
+	if *minWorkers <= 0 || *minWorkers > *workers {
+		fmt.Fprintf(os.Stderr, "Error: -min-workers must be between 1 and -workers\n")
+		return 1
 	}
 	if *maxPages < 0 {
 		fmt.Fprintf(os.Stderr, "Error: -max-pages cannot be negative\n")
-		os.Exit(1)
+		return 1
 	}
 	if *rateMs < 0 {
 		fmt.Fprintf(os.Stderr, "Error: -rate-ms cannot be negative\n")
-		os.Exit(1)
+		return 1
+	}
+	if *rateBurst <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: -rate-burst must be greater than 0\n")
+		return 1
+	}
+	if *maxRPS < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -max-rps cannot be negative\n")
+		return 1
+	}
+	if *maxRPS > 0 && *rateMs > 0 {
+		fmt.Fprintf(os.Stderr, "Error: -max-rps and -rate-ms set the same global rate limit; use only one\n")
+		return 1
+	}
+	if *maxPagesPerSec < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -max-pages-per-sec cannot be negative\n")
+		return 1
+	}
+	if *timeoutMs <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: -timeout-ms must be greater than 0\n")
+		return 1
+	}
+	if *connectTimeoutMs < 0 || *tlsTimeoutMs < 0 || *headerTimeoutMs < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -connect-timeout-ms, -tls-timeout-ms, and -header-timeout-ms cannot be negative\n")
+		return 1
+	}
+	if *maxDuration < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -max-duration cannot be negative\n")
+		return 1
+	}
+	if *breakerFailures < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -breaker-failures cannot be negative\n")
+		return 1
+	}
+	if *breakerCooldownMs <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: -breaker-cooldown-ms must be greater than 0\n")
+		return 1
+	}
+	if *linkStats < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -link-stats cannot be negative\n")
+		return 1
+	}
+	if *slowTop < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -slow-top cannot be negative\n")
+		return 1
+	}
+	if *slowMs < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -slow-threshold-ms cannot be negative\n")
+		return 1
+	}
+	if *largeTop < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -large-top cannot be negative\n")
+		return 1
+	}
+	if *largeBytes < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -large-threshold-bytes cannot be negative\n")
+		return 1
+	}
+	if *noindexLinks < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -noindex-min-links cannot be negative\n")
+		return 1
+	}
+	if *hostRateMs < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -host-rate-ms cannot be negative\n")
+		return 1
+	}
+	if *format != "text" && *format != "json" && *format != "ndjson" {
+		fmt.Fprintf(os.Stderr, "Error: -format must be 'text', 'json', or 'ndjson'\n")
+		return 1
+	}
+	if *format == "ndjson" {
+		*format = "json"
+	}
+	if *maxBytesPerSec < 0 || *maxTotalBytes < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -max-bytes-per-sec and -max-total-bytes cannot be negative\n")
+		return 1
+	}
+	if *maxBodyBytes <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: -max-body-bytes must be greater than 0\n")
+		return 1
+	}
+	if *maxOtherBodyBytes < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -max-other-body-bytes cannot be negative\n")
+		return 1
+	}
+	if *linkDetails && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: -link-details requires -format json\n")
+		return 1
+	}
+	if *captureHeaders != "" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: -capture-headers requires -format json\n")
+		return 1
+	}
+	if *extractText && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: -extract-text requires -format json\n")
+		return 1
+	}
+	if *harvestMetadata && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: -metadata requires -format json\n")
+		return 1
+	}
+	if *includeHTML && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: -include-html requires -format json\n")
+		return 1
+	}
+	if *maxErrors < 0 || *maxConsecutiveErrors < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -max-errors and -max-consecutive-errors cannot be negative\n")
+		return 1
+	}
+	if *maxErrorRate < 0 || *maxErrorRate > 1 {
+		fmt.Fprintf(os.Stderr, "Error: -max-error-rate must be between 0 and 1\n")
+		return 1
+	}
+	if *crawlMetadata && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: -crawl-metadata requires -format json\n")
+		return 1
+	}
+	if *htmlMaxBytes < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -html-max-bytes cannot be negative\n")
+		return 1
+	}
+	if *outputBuffer < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -output-buffer cannot be negative\n")
+		return 1
+	}
+	if *render != "http" && *render != "browser" {
+		fmt.Fprintf(os.Stderr, "Error: -render must be 'http' or 'browser'\n")
+		return 1
+	}
+	if *replayFile != "" && *replayWARC != "" {
+		fmt.Fprintf(os.Stderr, "Error: -replay and -replay-warc cannot be used together\n")
+		return 1
+	}
+	if *recordFile != "" && (*replayFile != "" || *replayWARC != "") {
+		fmt.Fprintf(os.Stderr, "Error: -record cannot be used with -replay or -replay-warc\n")
+		return 1
+	}
+	if *render == "browser" && *blockPrivate {
+		fmt.Fprintf(os.Stderr, "Error: -render=browser cannot be used with -block-private: the browser loads pages itself, without the private address check\n")
+		return 1
+	}
+	if (*replayFile != "" || *replayWARC != "") && *render == "browser" {
+		fmt.Fprintf(os.Stderr, "Error: -replay and -replay-warc serve archived responses and cannot be used with -render=browser\n")
+		return 1
+	}
+	if *pageRankFormat != "csv" && *pageRankFormat != "json" {
+		fmt.Fprintf(os.Stderr, "Error: -pagerank-format must be 'csv' or 'json'\n")
+		return 1
 	}
-	if *format != "text" && *format != "json" {
-		fmt.Fprintf(os.Stderr, "Error: -format must be 'text' or 'json'\n")
-		os.Exit(1)
+	if *redirectMapFormat != "nginx" && *redirectMapFormat != "apache" && *redirectMapFormat != "netlify" {
+		fmt.Fprintf(os.Stderr, "Error: -redirect-map-format must be 'nginx', 'apache', or 'netlify'\n")
+		return 1
+	}
+	if *webhookRetries < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -webhook-retries cannot be negative\n")
+		return 1
+	}
+	if *webhookSecretFile != "" && *webhookURL == "" {
+		fmt.Fprintf(os.Stderr, "Error: -webhook-secret-file requires -webhook\n")
+		return 1
+	}
+	if *nearDupDistance < 1 || *nearDupDistance > 64 {
+		fmt.Fprintf(os.Stderr, "Error: -near-duplicate-distance must be between 1 and 64\n")
+		return 1
+	}
+	if *maxURLLength < 0 || *maxQueryParams < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -max-url-length and -max-query-params cannot be negative\n")
+		return 1
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*logLevel)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -log-level must be 'debug', 'info', 'warn', or 'error'\n")
+		return 1
+	}
+	if *logFormat != "text" && *logFormat != "json" {
+		fmt.Fprintf(os.Stderr, "Error: -log-format must be 'text' or 'json'\n")
+		return 1
+	}
+	frontier, err := crawler.NewFrontier(*order)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -order must be 'bfs', 'dfs', or 'priority'\n")
+		return 1
+	}
+	if *frontierMemory < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -frontier-memory cannot be negative\n")
+		return 1
+	}
+	if *frontierMemory > 0 && *order != crawler.BreadthFirst {
+		fmt.Fprintf(os.Stderr, "Error: -frontier-memory only supports -order bfs\n")
+		return 1
+	}
+
+	// On a terminal, logs are written around the live progress line;
+	// piped or redirected stderr gets plain logs
+	var logOut io.Writer = os.Stderr
+	var status *statusLine
+	if *showProgress && isTerminal(os.Stderr) {
+		status = &statusLine{out: os.Stderr}
+		logOut = status
+	}
+
+	// Route every log, including the coordinator's, through one logger
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	var logger *slog.Logger
+	if *logFormat == "json" {
+		logger = slog.New(slog.NewJSONHandler(logOut, handlerOpts))
+	} else {
+		logger = slog.New(slog.NewTextHandler(logOut, handlerOpts))
+	}
+	slog.SetDefault(logger)
+
+	// Bound the frontier's memory by spilling to disk if requested
+	if *frontierMemory > 0 {
+		spill, err := crawler.NewSpillFrontier(*frontierMemory, *frontierDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer func() {
+			if err := spill.Close(); err != nil {
+				logger.Error("Error removing frontier spill file", "error", err)
+			}
+		}()
+		frontier = spill
+	}
+
+	// Record HTTP traffic if requested; the HAR file is written when the
+	// crawl ends, so it is created up front to fail early
+	var har *httpclient.HARRecorder
+	writeHAR := func() {}
+	if *harFile != "" {
+		f, err := os.Create(*harFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating HAR file: %v\n", err)
+			return 1
+		}
+		har = httpclient.NewHARRecorder(crawlerVersion())
+		writeHAR = func() {
+			if err := har.WriteHAR(f); err != nil {
+				logger.Error("Error writing HAR file", "error", err)
+			}
+			if err := f.Close(); err != nil {
+				logger.Error("Error writing HAR file", "error", err)
+			}
+		}
 	}
 
-	// Create HTTP client with optional rate limiting
+	// Create HTTP client with optional rate limiting.
+	// -max-rps is -rate-ms expressed as a rate; at most one of them is set.
 	var rateLimit time.Duration
 	if *rateMs > 0 {
 		rateLimit = time.Duration(*rateMs) * time.Millisecond
 	}
+	if *maxRPS > 0 {
+		rateLimit = time.Duration(float64(time.Second) / *maxRPS)
+	}
+	var hostRateLimit time.Duration
+	if *hostRateMs > 0 {
+		hostRateLimit = time.Duration(*hostRateMs) * time.Millisecond
+	}
 
 	httpClient := httpclient.New(httpclient.Config{
-		Timeout:     10 * time.Second,
-		UserAgent:   "MonzoCrawler/1.0",
-		MaxBodySize: 2 * 1024 * 1024, // 2MB
-		RateLimit:   rateLimit,
+		Timeout:          time.Duration(*timeoutMs) * time.Millisecond,
+		UserAgent:        *userAgent,
+		From:             *from,
+		CrawlInfoURL:     *crawlInfoURL,
+		MaxBodySize:      *maxBodyBytes,
+		MaxOtherBodySize: *maxOtherBodyBytes,
+		ReadPDF:          *pdfLinks,
+		RateLimit:        rateLimit,
+		RateBurst:        *rateBurst,
+		HostRateLimit:    hostRateLimit,
+		HostRateLimits:   settings.hostRateLimits,
+		Headers:          settings.headers,
+		AdaptiveThrottle: *adaptive,
+		HeadPrecheck:     *headPrecheck,
+		BlockPrivateIPs:  *blockPrivate,
+		MaxBytesPerSec:   *maxBytesPerSec,
+		MaxTotalBytes:    *maxTotalBytes,
+		HAR:              har,
+		// Keep one idle connection per worker so a single-host crawl reuses
+		// connections instead of churning through new ones
+		MaxIdleConnsPerHost:   *workers,
+		DialTimeout:           time.Duration(*connectTimeoutMs) * time.Millisecond,
+		TLSHandshakeTimeout:   time.Duration(*tlsTimeoutMs) * time.Millisecond,
+		ResponseHeaderTimeout: time.Duration(*headerTimeoutMs) * time.Millisecond,
 	})
 
+	// Parse PDFs for links only if requested; their bodies are only read then
+	var parser crawler.Parser = &htmlparser.Parser{}
+	if *pdfLinks {
+		parser = &htmlparser.PDFParser{}
+	}
+
+	// Render JavaScript-built pages in a headless browser if requested
+	var fetcher crawler.Fetcher = httpClient
+	if *render == "browser" {
+		renderer, err := browser.New(httpClient, browser.Config{ChromePath: *chromePath})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error setting up browser rendering: %v\n", err)
+			return 1
+		}
+		fetcher = renderer
+	}
+
+	// Record every response, as the crawler sees it, or serve them back
+	// from a recording or a WARC archive instead of the network
+	closeRecording := func() {}
+	if *recordFile != "" {
+		f, err := os.Create(*recordFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating record file: %v\n", err)
+			return 1
+		}
+		recorder := replay.NewRecorder(fetcher, f)
+		closeRecording = func() {
+			if err := recorder.Close(); err != nil {
+				logger.Error("Error writing record file", "error", err)
+			}
+			if err := f.Close(); err != nil {
+				logger.Error("Error writing record file", "error", err)
+			}
+		}
+		fetcher = recorder
+	}
+	if *replayFile != "" {
+		f, err := os.Open(*replayFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening replay file: %v\n", err)
+			return 1
+		}
+		replayer, err := replay.Load(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading replay file: %v\n", err)
+			return 1
+		}
+		logger.Info("Replaying recorded responses", "file", *replayFile, "urls", replayer.Len())
+		fetcher = replayer
+	}
+	if *replayWARC != "" {
+		f, err := os.Open(*replayWARC)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening WARC file: %v\n", err)
+			return 1
+		}
+		replayer, err := replay.LoadWARC(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading WARC file: %v\n", err)
+			return 1
+		}
+		logger.Info("Replaying archived responses", "file", *replayWARC, "urls", replayer.Len())
+		fetcher = replayer
+	}
+
+	// Open the structured events file if requested
+	var events io.Writer
+	if *eventsFile != "" {
+		f, err := os.Create(*eventsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating events file: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		events = f
+	}
+
+	// Open the audit log if requested; it is appended to, never truncated,
+	// so one file can cover every crawl in an engagement
+	var auditLog io.Writer
+	var auditConfig map[string]string
+	var auditOverrides []string
+	if *auditLogFile != "" {
+		f, err := os.OpenFile(*auditLogFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening audit log: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		auditLog = f
+	}
+	// The flag snapshot goes to the audit log and the -crawl-metadata header
+	if auditLog != nil || *crawlMetadata {
+		auditConfig = make(map[string]string)
+		flag.VisitAll(func(f *flag.Flag) {
+			auditConfig[f.Name] = f.Value.String()
+		})
+		flag.Visit(func(f *flag.Flag) {
+			auditOverrides = append(auditOverrides, f.Name)
+		})
+	}
+
+	// Open the error report file if requested
+	var errorReport io.Writer
+	if *errorsOut != "" {
+		f, err := os.Create(*errorsOut)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating error report: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		errorReport = f
+	}
+
+	// Open the search-index export file if requested
+	var index io.Writer
+	if *indexFile != "" {
+		f, err := os.Create(*indexFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating index file: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		index = f
+	}
+
+	// Open the redirect map file if requested
+	var redirectMap io.Writer
+	if *redirectMapFile != "" {
+		f, err := os.Create(*redirectMapFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating redirect map file: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		redirectMap = f
+	}
+
+	// Open the site graph file if requested
+	var graph io.Writer
+	if *graphFile != "" {
+		f, err := os.Create(*graphFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating graph file: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		graph = f
+	}
+
+	// Open the sitemap file if requested
+	var sitemap io.Writer
+	if *sitemapFile != "" {
+		f, err := os.Create(*sitemapFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating sitemap file: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		sitemap = f
+	}
+
+	// Open the JUnit report file if requested
+	var junit io.Writer
+	if *junitFile != "" {
+		f, err := os.Create(*junitFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating JUnit report file: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		junit = f
+	}
+
+	// Open the PageRank file if requested
+	var pageRank io.Writer
+	if *pageRankFile != "" {
+		f, err := os.Create(*pageRankFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating PageRank file: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		pageRank = f
+	}
+
+	// Load the previous crawl's state; this crawl's state goes to a
+	// temporary file that replaces it once the crawl has finished
+	var previousCrawl map[string]crawler.PageState
+	var crawlState io.Writer
+	commitState := func() {}
+	if *stateFile != "" {
+		f, err := os.Open(*stateFile)
+		if err == nil {
+			previousCrawl, err = crawler.ReadCrawlState(f)
+			f.Close()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading state file: %v\n", err)
+				return 1
+			}
+		} else if !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Error opening state file: %v\n", err)
+			return 1
+		}
+
+		tmp, err := os.Create(*stateFile + ".tmp")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating state file: %v\n", err)
+			return 1
+		}
+		crawlState = tmp
+		commitState = func() {
+			if err := tmp.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing state file: %v\n", err)
+				return
+			}
+			if err := os.Rename(tmp.Name(), *stateFile); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving state file: %v\n", err)
+			}
+		}
+	}
+
+	// Trace the crawl to a file if requested
+	var tracerProvider trace.TracerProvider
+	shutdownTracing := func() {}
+	if *traceOut != "" {
+		f, err := os.Create(*traceOut)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating trace file: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating trace exporter: %v\n", err)
+			return 1
+		}
+		tp := sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "web-crawler"))),
+		)
+		tracerProvider = tp
+		shutdownTracing = func() {
+			if err := tp.Shutdown(context.Background()); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing trace file: %v\n", err)
+			}
+		}
+	}
+
+	// Collect the sinks that receive every page result as it is crawled
+	var sinks []func(crawler.PageResult)
+	var closeSinks []func()
+	if *webhookURL != "" {
+		var secret string
+		if *webhookSecretFile != "" {
+			data, err := os.ReadFile(*webhookSecretFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading webhook secret: %v\n", err)
+				return 1
+			}
+			secret = strings.TrimSpace(string(data))
+		}
+		retries := *webhookRetries
+		if retries == 0 {
+			retries = -1 // webhook.Config treats 0 as the default
+		}
+		sender := webhook.New(webhook.Config{URL: *webhookURL, Secret: secret, Retries: retries})
+		sinks = append(sinks, func(page crawler.PageResult) {
+			if err := sender.Send(context.Background(), page); err != nil {
+				logger.Warn("Webhook delivery failed", "url", page.URL, "error", err)
+			}
+		})
+	}
+	if *natsURL != "" {
+		publisher, err := natspub.Connect(*natsURL, *natsSubject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		sinks = append(sinks, func(page crawler.PageResult) {
+			if err := publisher.Publish(page); err != nil {
+				logger.Warn("NATS publish failed", "url", page.URL, "error", err)
+			}
+		})
+		closeSinks = append(closeSinks, func() {
+			if err := publisher.Close(); err != nil {
+				logger.Error("Error closing NATS connection", "error", err)
+			}
+		})
+	}
+
+	// Feed the sinks from their own goroutine, so a slow one only stalls
+	// the crawl once the buffer is full
+	var pages chan crawler.PageResult
+	finishSinks := func() {}
+	if len(sinks) > 0 {
+		pages = make(chan crawler.PageResult, 64)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for page := range pages {
+				for _, sink := range sinks {
+					sink(page)
+				}
+			}
+		}()
+		finishSinks = func() {
+			close(pages)
+			<-done
+			for _, closeSink := range closeSinks {
+				closeSink()
+			}
+		}
+	}
+
+	// Load the checkpoint to resume from; the new checkpoint goes to a
+	// temporary file so -resume and -checkpoint can name the same file
+	var resume *crawler.Checkpoint
+	if *resumeFile != "" {
+		f, err := os.Open(*resumeFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening checkpoint: %v\n", err)
+			return 1
+		}
+		resume, err = crawler.ReadCheckpoint(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading checkpoint: %v\n", err)
+			return 1
+		}
+	}
+	var checkpoint io.Writer
+	commitCheckpoint := func() {}
+	if *checkpointFile != "" {
+		tmp, err := os.Create(*checkpointFile + ".tmp")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating checkpoint: %v\n", err)
+			return 1
+		}
+		checkpoint = tmp
+		commitCheckpoint = func() {
+			if err := tmp.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing checkpoint: %v\n", err)
+				return
+			}
+			if err := os.Rename(tmp.Name(), *checkpointFile); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving checkpoint: %v\n", err)
+			}
+		}
+	}
+
+	var languages []string
+	if *langs != "" {
+		languages = strings.Split(*langs, ",")
+	}
+	var stripParams []string
+	lists := []string{*dropParams}
+	if *stripTracking {
+		lists = append(lists, *trackingParams)
+	}
+	for _, list := range lists {
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				stripParams = append(stripParams, name)
+			}
+		}
+	}
+	var headerNames []string
+	if *captureHeaders != "" {
+		headerNames = strings.Split(*captureHeaders, ",")
+	}
+
+	// Buffer stdout; the coordinator flushes after every record so piped
+	// output (e.g. into jq) still appears as pages are crawled
+	stdout := bufio.NewWriter(os.Stdout)
+	defer stdout.Flush()
+
 	// Create coordinator
+	// -min-workers only applies to -autoscale
+	autoscaleMin := 0
+	if *autoScale {
+		autoscaleMin = *minWorkers
+	}
 	coord, err := crawler.NewCoordinator(crawler.Config{
-		StartURL:     *url,
-		MaxPages:     *maxPages,
-		NumWorkers:   *workers,
-		Fetcher:      httpClient,
-		Parser:       &parserAdapter{},
-		Output:       os.Stdout,
-		OutputFormat: *format,
+		StartURL:               *url,
+		MaxPages:               *maxPages,
+		NumWorkers:             *workers,
+		AutoScale:              *autoScale,
+		MinWorkers:             autoscaleMin,
+		MaxPagesPerSec:         *maxPagesPerSec,
+		Fetcher:                fetcher,
+		Parser:                 parser,
+		Output:                 stdout,
+		OutputBuffer:           *outputBuffer,
+		OutputFormat:           *format,
+		Seed:                   *seed,
+		Frontier:               frontier,
+		Events:                 events,
+		Index:                  index,
+		AuditLog:               auditLog,
+		ErrorReport:            errorReport,
+		PathBudgets:            pathBudgets,
+		CheckExternal:          *checkExternal,
+		BreakerThreshold:       *breakerFailures,
+		BreakerCooldown:        time.Duration(*breakerCooldownMs) * time.Millisecond,
+		PreviousCrawl:          previousCrawl,
+		CrawlState:             crawlState,
+		CheckpointOut:          checkpoint,
+		Resume:                 resume,
+		TracerProvider:         tracerProvider,
+		Logger:                 logger,
+		Pages:                  pages,
+		AuditConfig:            auditConfig,
+		AuditOverrides:         auditOverrides,
+		RedirectMap:            redirectMap,
+		RedirectMapFormat:      *redirectMapFormat,
+		CrawlMetadata:          *crawlMetadata,
+		CrawlerVersion:         crawlerVersion(),
+		BrokenLinksReport:      *brokenLinks,
+		FailOnBrokenLinks:      *failOnBrokenLinks,
+		MaxErrorRate:           *maxErrorRate,
+		MaxErrors:              *maxErrors,
+		MaxConsecutiveErrors:   *maxConsecutiveErrors,
+		Graph:                  graph,
+		Sitemap:                sitemap,
+		JUnit:                  junit,
+		PageRank:               pageRank,
+		PageRankFormat:         *pageRankFormat,
+		IncludeAssets:          *includeAssets,
+		FollowAssets:           *followAssets,
+		ExternalDomainsReport:  *externalDomains,
+		Include:                settings.include,
+		Exclude:                settings.exclude,
+		StripParams:            stripParams,
+		SortQuery:              *sortQuery,
+		CaseInsensitivePaths:   *caseInsensitive,
+		MaxURLLength:           *maxURLLength,
+		MaxQueryParams:         *maxQueryParams,
+		Languages:              languages,
+		DetectLanguage:         *detectLang,
+		LinkStatsTopN:          *linkStats,
+		SlowPagesTopN:          *slowTop,
+		SlowPageThreshold:      time.Duration(*slowMs) * time.Millisecond,
+		LargePagesTopN:         *largeTop,
+		LargePageThreshold:     *largeBytes,
+		NoindexMinLinks:        *noindexLinks,
+		NofollowReport:         *nofollowReport,
+		CheckFragments:         *checkFragments,
+		ValidateStructuredData: *validateSchema,
+		DedupCanonical:         *dedupCanonical,
+		NearDuplicates:         *nearDuplicates,
+		NearDuplicateDistance:  *nearDupDistance,
+		RespectRobotsMeta:      *respectRobots,
+		HostConsistencyReport:  *hostReport,
+		BudgetReport:           *budgetReport,
+		ResourceHintsReport:    *hintsReport,
+		LinkDetails:            *linkDetails,
+		CaptureHeaders:         headerNames,
+		ExtractText:            *extractText,
+		HarvestMetadata:        *harvestMetadata,
+		IncludeHTML:            *includeHTML,
+		HTMLMaxBytes:           *htmlMaxBytes,
+		HTMLBase64:             *htmlBase64,
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating coordinator: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
 
 	// Log crawl configuration to stderr
-	log.Printf("Starting crawler")
-	log.Printf("  URL: %s", *url)
-	log.Printf("  Workers: %d", *workers)
+	attrs := []any{"url", *url, "workers", *workers}
+	if *seed != 0 {
+		attrs = []any{"url", *url, "workers", 1, "seed", *seed}
+	} else if *autoScale {
+		attrs = append(attrs, "autoscale", true, "min_workers", *minWorkers)
+	}
 	if *maxPages > 0 {
-		log.Printf("  Max pages: %d", *maxPages)
-	} else {
-		log.Printf("  Max pages: unlimited")
+		attrs = append(attrs, "max_pages", *maxPages)
 	}
-	if *rateMs > 0 {
-		log.Printf("  Rate limit: %dms between requests", *rateMs)
+	if rateLimit > 0 {
+		attrs = append(attrs, "rate_limit", rateLimit)
+	}
+	if *maxPagesPerSec > 0 {
+		attrs = append(attrs, "max_pages_per_sec", *maxPagesPerSec)
+	}
+	if hostRateLimit > 0 {
+		attrs = append(attrs, "host_rate_limit", hostRateLimit)
 	}
+	if *maxDuration > 0 {
+		attrs = append(attrs, "max_duration", *maxDuration)
+	}
+	logger.Info("Starting crawler", attrs...)
 
-	// Set up context with cancellation for graceful shutdown
+	// Set up context with cancellation for graceful shutdown, and a deadline
+	// for -max-duration
 	ctx, cancel := context.WithCancel(context.Background())
+	if *maxDuration > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), *maxDuration)
+	}
 	defer cancel()
 
 	// Set up signal handling for SIGINT (Ctrl+C)
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 
+	// Serve profiles and live counters for debugging if requested
+	if *debugAddr != "" {
+		if err := startDebugServer(*debugAddr, coord); err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting debug server: %v\n", err)
+			return 1
+		}
+	}
+
+	// Log progress on SIGUSR1 or SIGQUIT without stopping the crawl
+	if len(progressSignals) > 0 {
+		progressCh := make(chan os.Signal, 1)
+		signal.Notify(progressCh, progressSignals...)
+		go func() {
+			for range progressCh {
+				coord.RequestProgress()
+			}
+		}()
+	}
+
+	// Pause or resume on SIGUSR2, letting fetches in flight finish
+	if len(pauseSignals) > 0 {
+		pauseCh := make(chan os.Signal, 1)
+		signal.Notify(pauseCh, pauseSignals...)
+		go func() {
+			for range pauseCh {
+				if coord.Paused() {
+					coord.Resume()
+				} else {
+					coord.Pause()
+				}
+			}
+		}()
+	}
+
+	// Show live progress until the crawl returns
+	stopProgress := func() {}
+	if status != nil {
+		stopProgress = status.show(coord)
+	}
+
 	// Start crawl in a goroutine
 	errCh := make(chan error, 1)
 	go func() {
@@ -110,33 +1056,99 @@ func main() {
 	select {
 	case err := <-errCh:
 		// Crawl completed normally
-		if err != nil && err != context.Canceled {
+		stopProgress()
+		stdout.Flush()
+		if err != nil && err != context.Canceled && err != context.DeadlineExceeded && !errors.Is(err, crawler.ErrThresholdExceeded) && !errors.Is(err, crawler.ErrAborted) {
 			fmt.Fprintf(os.Stderr, "Error during crawl: %v\n", err)
-			os.Exit(1)
+			return 1
 		}
+		finishSinks()
+		shutdownTracing()
+		commitState()
+		commitCheckpoint()
+		writeHAR()
+		closeRecording()
+		return crawlExitCode(err)
 	case sig := <-sigCh:
 		// Signal received - initiate graceful shutdown
-		log.Printf("\nReceived signal %v, shutting down gracefully...", sig)
+		logger.Info("Received signal, shutting down gracefully", "signal", sig)
 		cancel() // Cancel context to stop workers and coordinator
 
 		// Wait for crawl to finish with a timeout
 		select {
 		case err := <-errCh:
-			if err != nil && err != context.Canceled {
+			stopProgress()
+			stdout.Flush()
+			if err != nil && err != context.Canceled && err != context.DeadlineExceeded && !errors.Is(err, crawler.ErrThresholdExceeded) && !errors.Is(err, crawler.ErrAborted) {
 				fmt.Fprintf(os.Stderr, "\nError during shutdown: %v\n", err)
-				os.Exit(1)
+				return 1
 			}
-			log.Println("Shutdown complete")
+			finishSinks()
+			shutdownTracing()
+			commitState()
+			commitCheckpoint()
+			writeHAR()
+			closeRecording()
+			logger.Info("Shutdown complete")
+			return crawlExitCode(err)
 		case <-time.After(5 * time.Second):
+			stopProgress()
 			fmt.Fprintf(os.Stderr, "\nShutdown timeout exceeded, forcing exit\n")
-			os.Exit(1)
+			return 1
 		}
 	}
 }
 
-// parserAdapter adapts the htmlparser package to the Parser interface.
-type parserAdapter struct{}
+// crawlExitCode returns the exit status for a crawl that finished: non-zero
+// if it broke a quality threshold or was aborted.
+func crawlExitCode(err error) int {
+	switch {
+	case errors.Is(err, crawler.ErrAborted):
+		return abortExitCode
+	case errors.Is(err, crawler.ErrThresholdExceeded):
+		return thresholdExitCode
+	}
+	return 0
+}
+
+// pathBudgetFlag collects repeated -path-budget values: PREFIX=N for a
+// path prefix, or ~REGEX=N for a pattern matched against the full URL.
+type pathBudgetFlag []crawler.PathBudget
+
+func (f *pathBudgetFlag) String() string {
+	specs := make([]string, len(*f))
+	for i, b := range *f {
+		spec := b.Prefix
+		if b.Pattern != "" {
+			spec = "~" + b.Pattern
+		}
+		specs[i] = fmt.Sprintf("%s=%d", spec, b.Limit)
+	}
+	return strings.Join(specs, " ")
+}
 
-func (p *parserAdapter) ExtractLinks(r io.Reader) ([]string, error) {
-	return htmlparser.ExtractLinks(r)
+func (f *pathBudgetFlag) Set(value string) error {
+	i := strings.LastIndex(value, "=")
+	if i < 0 {
+		return fmt.Errorf("want PREFIX=N or ~REGEX=N, got %q", value)
+	}
+	spec := value[:i]
+	limit, err := strconv.Atoi(value[i+1:])
+	if err != nil || limit < 0 {
+		return fmt.Errorf("limit must be a non-negative integer, got %q", value[i+1:])
+	}
+	budget := crawler.PathBudget{Limit: limit}
+	switch {
+	case strings.HasPrefix(spec, "~") && len(spec) > 1:
+		budget.Pattern = spec[1:]
+	case strings.HasPrefix(spec, "/"):
+		budget.Prefix = spec
+	default:
+		return fmt.Errorf("want a path prefix starting with / or ~REGEX, got %q", spec)
+	}
+	*f = append(*f, budget)
+	return nil
 }
+
+// repeatable marks the flag as accepting one Set per config file list item.
+func (f *pathBudgetFlag) repeatable() {}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cametumbling/web-crawler/internal/crawler"
+)
+
+// progressRefresh is how often the progress line is redrawn.
+const progressRefresh = 500 * time.Millisecond
+
+// clearLine returns the cursor to the start of the line and erases it.
+const clearLine = "\r\033[K"
+
+// statusLine is a single progress line kept at the bottom of a terminal.
+// Logs are written through it: the line is erased before each log record
+// and redrawn after, so the two never tear each other.
+type statusLine struct {
+	mu  sync.Mutex
+	out io.Writer
+	// text is the line currently drawn ("" = none)
+	text string
+}
+
+// Write writes a log record above the progress line.
+func (s *statusLine) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.text == "" {
+		return s.out.Write(p)
+	}
+	fmt.Fprint(s.out, clearLine)
+	n, err := s.out.Write(p)
+	fmt.Fprint(s.out, s.text)
+	return n, err
+}
+
+// set draws text as the progress line, or erases the line if text is "".
+func (s *statusLine) set(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if text == "" && s.text == "" {
+		return
+	}
+	fmt.Fprint(s.out, clearLine+text)
+	s.text = text
+}
+
+// show redraws the line from coord's progress until the returned stop
+// function is called, which erases it.
+func (s *statusLine) show(coord *crawler.Coordinator) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(progressRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.set(formatProgress(coord.Progress()))
+			case <-done:
+				s.set("")
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
+
+// formatProgress renders p as the progress line.
+func formatProgress(p crawler.Progress) string {
+	elapsed := (time.Duration(p.ElapsedMs) * time.Millisecond).Round(time.Second)
+	state := "Crawling"
+	if p.Paused {
+		state = "Paused"
+	}
+	return fmt.Sprintf("%s: %d pages, %d queued, %d errors, %.1f pages/s, %s",
+		state, p.PagesVisited, p.Queued, p.Errors, p.PagesPerSec, elapsed)
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
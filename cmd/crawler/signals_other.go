@@ -0,0 +1,12 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// progressSignals is empty where SIGUSR1 and SIGQUIT don't exist.
+var progressSignals []os.Signal
+
+// pauseSignals is empty where SIGUSR2 doesn't exist; pause through the
+// debug server instead.
+var pauseSignals []os.Signal
@@ -0,0 +1,14 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// progressSignals ask a running crawl to log its progress.
+var progressSignals = []os.Signal{syscall.SIGUSR1, syscall.SIGQUIT}
+
+// pauseSignals toggle a running crawl between paused and running.
+var pauseSignals = []os.Signal{syscall.SIGUSR2}
@@ -0,0 +1,52 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrAborted is returned by Crawl, once all output is written, when the
+// crawl stopped early because too many pages failed (MaxErrors or
+// MaxConsecutiveErrors): the site is most likely down.
+var ErrAborted = errors.New("crawl aborted after too many errors")
+
+// countFailure tallies a failed page against MaxErrors and
+// MaxConsecutiveErrors and aborts the crawl once either is reached. Pages
+// interrupted by cancellation don't count.
+func (c *Coordinator) countFailure(ctx context.Context, result Result) {
+	if interrupted(ctx, result.Err) || c.abortReason != "" {
+		return
+	}
+	c.errorStreak++
+	switch {
+	case c.maxErrorStreak > 0 && c.errorStreak >= c.maxErrorStreak:
+		c.abort(fmt.Sprintf("%d consecutive errors", c.errorStreak), result.Err)
+	case c.maxErrors > 0 && c.errorCount >= c.maxErrors:
+		c.abort(fmt.Sprintf("%d errors", c.errorCount), result.Err)
+	}
+}
+
+// abort stops the crawl: the frontier is dropped (see dropUnfetched) and
+// pages in flight finish, so the reports still cover everything fetched.
+func (c *Coordinator) abort(reason string, last error) {
+	c.abortReason = reason
+	c.log().Error("Aborting crawl", "reason", reason, "last_error", last)
+	c.audit(AuditEntry{Decision: AuditAborted, Reason: reason})
+}
+
+// abortError returns ErrAborted with its reason if the crawl was aborted.
+func (c *Coordinator) abortError() error {
+	if c.abortReason == "" {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrAborted, c.abortReason)
+}
+
+// logAbort reports the pages left unfetched by an abort.
+func (c *Coordinator) logAbort() {
+	if c.abortReason == "" {
+		return
+	}
+	c.log().Info("Stopped by error limit", "reason", c.abortReason, "unfetched", c.unfetched)
+}
@@ -0,0 +1,95 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_AbortOnErrors(t *testing.T) {
+	const numLinks = 20
+	tests := []struct {
+		name string
+		// failing reports whether link i fails
+		failing     func(i int) bool
+		maxErrors   int
+		maxStreak   int
+		wantReason  string
+		wantFetches int64
+	}{
+		{"site down", func(int) bool { return true }, 0, 3, "3 consecutive errors", 1 + 3},
+		{"intermittent failures", func(i int) bool { return i%2 == 1 }, 4, 2, "4 errors", 1 + 8},
+		{"under the limits", func(i int) bool { return i%2 == 1 }, 11, 2, "", 1 + numLinks},
+	}
+	for _, tt := range tests {
+		fetcher := &countingFetcher{mockFetcher: &mockFetcher{
+			responses: map[string][]byte{"https://example.com/": []byte("root")},
+			errors:    map[string]error{},
+		}}
+		var links []string
+		for i := 0; i < numLinks; i++ {
+			link := fmt.Sprintf("https://example.com/p%d", i)
+			links = append(links, link)
+			if tt.failing(i) {
+				fetcher.errors[link] = &HTTPError{StatusCode: 503, URL: link}
+			} else {
+				fetcher.responses[link] = []byte("leaf")
+			}
+		}
+		var out, auditLog strings.Builder
+		coord, err := NewCoordinator(Config{
+			StartURL:             "https://example.com/",
+			NumWorkers:           1,
+			Fetcher:              fetcher,
+			Parser:               &mockMetadataParser{links: map[string][]string{"root": links}},
+			Output:               &out,
+			AuditLog:             &auditLog,
+			MaxErrors:            tt.maxErrors,
+			MaxConsecutiveErrors: tt.maxStreak,
+		})
+		if err != nil {
+			t.Fatalf("%s: NewCoordinator() error = %v", tt.name, err)
+		}
+		var crawlErr error
+		logs := captureLog(t, func() {
+			crawlErr = coord.Crawl(context.Background())
+		})
+
+		if n := fetcher.fetches.Load(); n != tt.wantFetches {
+			t.Errorf("%s: fetched %d pages, want %d", tt.name, n, tt.wantFetches)
+		}
+		if tt.wantReason == "" {
+			if crawlErr != nil {
+				t.Errorf("%s: Crawl() error = %v, want nil", tt.name, crawlErr)
+			}
+			continue
+		}
+		if !errors.Is(crawlErr, ErrAborted) || !strings.HasSuffix(crawlErr.Error(), ": "+tt.wantReason) {
+			t.Errorf("%s: Crawl() error = %v, want ErrAborted: %s", tt.name, crawlErr, tt.wantReason)
+		}
+		unfetched := numLinks + 1 - int(tt.wantFetches)
+		for _, want := range []string{
+			"Aborting crawl reason=\"" + tt.wantReason + "\"",
+			"Crawl summary reason=aborted pages=" + fmt.Sprint(tt.wantFetches),
+			fmt.Sprintf("Stopped by error limit reason=%q unfetched=%d", tt.wantReason, unfetched),
+		} {
+			if !strings.Contains(logs, want) {
+				t.Errorf("%s: missing %q in logs:\n%s", tt.name, want, logs)
+			}
+		}
+		if n := strings.Count(out.String(), "Visited:"); int64(n) != tt.wantFetches {
+			t.Errorf("%s: printed %d pages, want %d", tt.name, n, tt.wantFetches)
+		}
+		if !strings.Contains(auditLog.String(), `"decision":"aborted"`) ||
+			!strings.Contains(auditLog.String(), fmt.Sprintf(`"reason":%q`, tt.wantReason)) ||
+			strings.Contains(auditLog.String(), `"budget_reached"`) {
+			t.Errorf("%s: audit log should record the abort after %s:\n%s", tt.name, tt.wantReason, auditLog.String())
+		}
+	}
+
+	if _, err := NewCoordinator(Config{StartURL: "https://example.com/", Fetcher: &mockFetcher{}, Parser: &mockParser{}, MaxErrors: -1}); err == nil {
+		t.Errorf("NewCoordinator() should reject a negative MaxErrors")
+	}
+}
@@ -0,0 +1,80 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// asyncOutput writes output records on a dedicated goroutine, so a slow
+// Output (a pager, a network pipe) only stalls the coordinator once its
+// bounded queue of records is full. The coordinator stages each record
+// with Write and queues it with Flush; records are written in order, and
+// the underlying Output is flushed whenever the queue runs empty.
+type asyncOutput struct {
+	out io.Writer
+	// record is the record being staged (coordinator goroutine only)
+	record bytes.Buffer
+	queue  chan []byte
+	done   chan struct{}
+	// err is the first write error, read once done is closed
+	err error
+}
+
+// newAsyncOutput starts the output goroutine for out, queueing up to
+// size records.
+func newAsyncOutput(out io.Writer, size int) *asyncOutput {
+	a := &asyncOutput{
+		out:   out,
+		queue: make(chan []byte, size),
+		done:  make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// Write stages p as part of the current record.
+func (a *asyncOutput) Write(p []byte) (int, error) {
+	return a.record.Write(p)
+}
+
+// Flush queues the staged record for writing.
+func (a *asyncOutput) Flush() error {
+	if a.record.Len() == 0 {
+		return nil
+	}
+	a.queue <- bytes.Clone(a.record.Bytes())
+	a.record.Reset()
+	return nil
+}
+
+// Close queues any staged record, waits until every record is written and
+// flushed, and returns the first write error.
+func (a *asyncOutput) Close() error {
+	a.Flush()
+	close(a.queue)
+	<-a.done
+	return a.err
+}
+
+// run writes queued records until the queue is closed. After a write error
+// the remaining records are discarded.
+func (a *asyncOutput) run() {
+	defer close(a.done)
+	for rec := range a.queue {
+		if a.err != nil {
+			continue
+		}
+		if _, err := a.out.Write(rec); err != nil {
+			a.err = fmt.Errorf("writing output: %w", err)
+			continue
+		}
+		if len(a.queue) == 0 {
+			if f, ok := a.out.(Flusher); ok {
+				if err := f.Flush(); err != nil {
+					a.err = fmt.Errorf("flushing output: %w", err)
+				}
+			}
+		}
+	}
+}
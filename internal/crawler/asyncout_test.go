@@ -0,0 +1,173 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flushCounter is an Output that counts its flushes.
+type flushCounter struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (f *flushCounter) Flush() error {
+	f.flushes++
+	return nil
+}
+
+// blockingWriter is an Output whose writes wait until release is closed.
+type blockingWriter struct {
+	release chan struct{}
+	buf     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.buf.Write(p)
+}
+
+// errWriter is an Output that fails every write.
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+// countingFetcher counts the fetches made through it.
+type countingFetcher struct {
+	*mockFetcher
+	fetches atomic.Int64
+}
+
+func (f *countingFetcher) Fetch(ctx context.Context, url string) (*FetchResult, error) {
+	f.fetches.Add(1)
+	return f.mockFetcher.Fetch(ctx, url)
+}
+
+func TestAsyncOutput_WritesRecordsInOrder(t *testing.T) {
+	out := &flushCounter{}
+	a := newAsyncOutput(out, 2)
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(a, "record %d\n", i)
+		fmt.Fprintf(a, "  line\n")
+		a.Flush()
+	}
+	fmt.Fprintf(a, "unflushed\n")
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var want strings.Builder
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&want, "record %d\n  line\n", i)
+	}
+	want.WriteString("unflushed\n")
+	if out.String() != want.String() {
+		t.Errorf("output = %q, want %q", out.String(), want.String())
+	}
+	if out.flushes == 0 {
+		t.Errorf("underlying output was never flushed")
+	}
+}
+
+func TestAsyncOutput_WriteError(t *testing.T) {
+	a := newAsyncOutput(errWriter{}, 1)
+	fmt.Fprintf(a, "first\n")
+	a.Flush()
+	fmt.Fprintf(a, "second\n")
+	a.Flush()
+	err := a.Close()
+	if err == nil || !strings.Contains(err.Error(), "broken pipe") {
+		t.Errorf("Close() error = %v, want the write error", err)
+	}
+}
+
+func TestCoordinator_OutputBufferDoesNotStallCrawl(t *testing.T) {
+	const numLinks = 20
+	fetcher := &countingFetcher{mockFetcher: &mockFetcher{responses: map[string][]byte{"https://example.com/": []byte("root")}}}
+	var links []string
+	for i := 0; i < numLinks; i++ {
+		link := fmt.Sprintf("https://example.com/p%d", i)
+		links = append(links, link)
+		fetcher.responses[link] = []byte("leaf")
+	}
+	parser := &mockMetadataParser{links: map[string][]string{"root": links}}
+
+	out := &blockingWriter{release: make(chan struct{})}
+	coord, err := NewCoordinator(Config{
+		StartURL:     "https://example.com/",
+		NumWorkers:   2,
+		Fetcher:      fetcher,
+		Parser:       parser,
+		Output:       out,
+		OutputBuffer: 64,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- coord.Crawl(context.Background())
+	}()
+
+	// The stuck writer holds the first record; the rest queue up while
+	// every page is still fetched
+	deadline := time.After(2 * time.Second)
+	for fetcher.fetches.Load() < numLinks+1 {
+		select {
+		case <-deadline:
+			t.Fatalf("fetched %d pages while output was stalled, want %d", fetcher.fetches.Load(), numLinks+1)
+		case <-time.After(time.Millisecond):
+		}
+	}
+	select {
+	case err := <-errCh:
+		t.Fatalf("Crawl() returned (%v) before its output was written", err)
+	default:
+	}
+
+	close(out.release)
+	if err := <-errCh; err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+	got := out.buf.String()
+	if !strings.HasPrefix(got, "Visited: https://example.com/\n") {
+		t.Errorf("output does not start with the start URL:\n%s", got)
+	}
+	if n := strings.Count(got, "Visited:"); n != numLinks+1 {
+		t.Errorf("wrote %d pages, want %d", n, numLinks+1)
+	}
+}
+
+func TestCoordinator_OutputBufferWriteError(t *testing.T) {
+	coord, err := NewCoordinator(Config{
+		StartURL:     "https://example.com/",
+		NumWorkers:   1,
+		Fetcher:      &mockFetcher{responses: map[string][]byte{"https://example.com/": []byte("root")}},
+		Parser:       &mockParser{},
+		Output:       errWriter{},
+		OutputBuffer: 4,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	logs := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Errorf("Crawl() error = %v", err)
+		}
+	})
+	if !strings.Contains(logs, "Error writing output") {
+		t.Errorf("missing output error log:\n%s", logs)
+	}
+
+	if _, err := NewCoordinator(Config{StartURL: "https://example.com/", Fetcher: &mockFetcher{}, Parser: &mockParser{}, OutputBuffer: -1}); err == nil {
+		t.Errorf("NewCoordinator() should reject a negative OutputBuffer")
+	}
+}
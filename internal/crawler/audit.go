@@ -0,0 +1,58 @@
+package crawler
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit decisions recorded in the audit log.
+const (
+	AuditCrawlStarted  = "crawl_started"
+	AuditSeed          = "seed"
+	AuditSkipped       = "skipped"
+	AuditNotFollowed   = "not_followed"
+	AuditMarkedNoindex = "marked_noindex"
+	AuditBudgetReached = "budget_reached"
+	AuditAborted       = "aborted"
+	AuditCrawlFinished = "crawl_finished"
+)
+
+// AuditEntry is a single crawl decision, written as a JSON line to the
+// audit log so a crawl can be accounted for after the fact.
+type AuditEntry struct {
+	// Decision is one of the Audit* constants
+	Decision string `json:"decision"`
+	// Time is when the decision was made
+	Time time.Time `json:"time"`
+	// URL is the page the decision applies to
+	URL string `json:"url,omitempty"`
+	// Reason explains a skip, a robots decision, an abort, or how the crawl ended
+	Reason string `json:"reason,omitempty"`
+	// Config is the configuration snapshot (crawl_started only)
+	Config map[string]string `json:"config,omitempty"`
+	// Overrides names the settings changed from their defaults (crawl_started only)
+	Overrides []string `json:"overrides,omitempty"`
+	// Limit is the budget that was hit (budget_reached only)
+	Limit int `json:"limit,omitempty"`
+	// Pages is the number of pages visited so far
+	Pages int `json:"pages"`
+}
+
+// audit appends a decision to the audit log, if one is configured.
+// Only the coordinator goroutine calls this, so writes never interleave.
+func (c *Coordinator) audit(entry AuditEntry) {
+	if c.auditLog == nil {
+		return
+	}
+	entry.Time = time.Now()
+	entry.Pages = c.visitCount
+
+	jsonBytes, err := json.Marshal(entry)
+	if err != nil {
+		c.log().Error("Error marshaling audit entry", "error", err)
+		return
+	}
+	if _, err := c.auditLog.Write(append(jsonBytes, '\n')); err != nil {
+		c.log().Error("Error writing audit log", "error", err)
+	}
+}
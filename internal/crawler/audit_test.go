@@ -0,0 +1,120 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_AuditLog(t *testing.T) {
+	auditLog := &bytes.Buffer{}
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":   []byte("root"),
+			"https://example.com/fr": []byte("fr"),
+			"https://example.com/nf": []byte("nf"),
+			"https://example.com/x":  []byte("x"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/fr", "/nf"},
+			"nf":   {"/x", "/y"},
+		},
+		meta: map[string]*PageMetadata{
+			"fr": {Lang: "fr"},
+			"nf": {NoFollow: true, NoIndex: true},
+		},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:          "https://example.com/",
+		MaxPages:          3,
+		NumWorkers:        1,
+		Fetcher:           fetcher,
+		Parser:            parser,
+		Output:            &bytes.Buffer{},
+		Languages:         []string{"en"},
+		RespectRobotsMeta: true,
+		AuditLog:          auditLog,
+		AuditConfig:       map[string]string{"max-pages": "3", "workers": "1"},
+		AuditOverrides:    []string{"max-pages"},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	var entries []AuditEntry
+	for _, line := range strings.Split(strings.TrimSpace(auditLog.String()), "\n") {
+		var entry AuditEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid audit line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	var decisions []string
+	for _, e := range entries {
+		decisions = append(decisions, e.Decision+" "+e.URL)
+	}
+	want := []string{
+		"crawl_started ",
+		"seed https://example.com/",
+		"skipped https://example.com/fr",
+		"marked_noindex https://example.com/nf",
+		"not_followed https://example.com/nf",
+		"crawl_finished ",
+	}
+	if strings.Join(decisions, "\n") != strings.Join(want, "\n") {
+		t.Errorf("decisions =\n%s\nwant\n%s", strings.Join(decisions, "\n"), strings.Join(want, "\n"))
+	}
+
+	start := entries[0]
+	if start.Config["max-pages"] != "3" || len(start.Overrides) != 1 || start.Overrides[0] != "max-pages" {
+		t.Errorf("crawl_started = %+v, want config snapshot and overrides", start)
+	}
+	if entries[2].Reason != `language "fr" not in filter` {
+		t.Errorf("skip reason = %q", entries[2].Reason)
+	}
+	if last := entries[len(entries)-1]; last.Reason != "completed" || last.Pages != 3 {
+		t.Errorf("crawl_finished = %+v, want completed with 3 pages", last)
+	}
+}
+
+func TestCoordinator_AuditLogBudget(t *testing.T) {
+	auditLog := &bytes.Buffer{}
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{"https://example.com/": []byte("root")},
+	}
+	parser := &mockMetadataParser{links: map[string][]string{"root": {"/a", "/b"}}}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		MaxPages:   1,
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     parser,
+		Output:     &bytes.Buffer{},
+		AuditLog:   auditLog,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	if got := strings.Count(auditLog.String(), `"decision":"budget_reached"`); got != 1 {
+		t.Fatalf("budget_reached entries = %d, want 1:\n%s", got, auditLog.String())
+	}
+	if !strings.Contains(auditLog.String(), `"reason":"max pages","limit":1`) {
+		t.Errorf("budget entry missing limit:\n%s", auditLog.String())
+	}
+}
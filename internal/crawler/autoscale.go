@@ -0,0 +1,143 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	// autoscaleWindow is the fewest results between two adjustments; with
+	// more workers active, a window is one result per active worker
+	autoscaleWindow = 8
+	// autoscaleErrorRate is the share of a window's results that may fail
+	// with overload errors before workers are shed
+	autoscaleErrorRate = 0.1
+	// autoscaleSlowdown is how many times slower than the fastest window so
+	// far the mean fetch may get before workers are shed
+	autoscaleSlowdown = 2.0
+)
+
+// autoscaler decides how many workers the coordinator hands work to. Each
+// window of results it adds a worker while pages are waiting, and sheds a
+// quarter of the active workers when fetches slow down or overload errors
+// (timeouts, 429, 5xx, transport failures) appear: additive increase,
+// multiplicative decrease, as in TCP congestion control.
+type autoscaler struct {
+	min, max int
+	// active is the number of workers handed work
+	active int
+	// peak is the most workers active at once
+	peak int
+	// adjustments counts changes to active
+	adjustments int
+
+	// results, overloads, fetches, and latency describe the current window
+	results   int
+	overloads int
+	fetches   int
+	latency   time.Duration
+	// baseline is the fastest mean fetch of any window, reset to the
+	// current mean after slowing down so a uniformly slow site isn't
+	// starved of workers
+	baseline time.Duration
+}
+
+// newAutoscaler returns an autoscaler between min and max workers,
+// starting at min.
+func newAutoscaler(min, max int) *autoscaler {
+	return &autoscaler{min: min, max: max, active: min, peak: min}
+}
+
+// observe records a result, with queued pages still waiting for a worker.
+// At the end of a window it adjusts active and returns the reason, or ""
+// if it was left alone.
+func (a *autoscaler) observe(result Result, queued int) string {
+	a.results++
+	if overloaded(result) {
+		a.overloads++
+	}
+	if result.FetchDuration > 0 {
+		a.fetches++
+		a.latency += result.FetchDuration
+	}
+	if a.results < max(autoscaleWindow, a.active) {
+		return ""
+	}
+
+	var mean time.Duration
+	if a.fetches > 0 {
+		mean = a.latency / time.Duration(a.fetches)
+	}
+	erroring := float64(a.overloads) > autoscaleErrorRate*float64(a.results)
+	slow := mean > 0 && a.baseline > 0 && float64(mean) > autoscaleSlowdown*float64(a.baseline)
+	if mean > 0 && (slow || a.baseline == 0 || mean < a.baseline) {
+		a.baseline = mean
+	}
+	a.results, a.overloads, a.fetches, a.latency = 0, 0, 0, 0
+
+	switch {
+	case erroring:
+		return a.resize(a.active-max(1, a.active/4), "errors")
+	case slow:
+		return a.resize(a.active-max(1, a.active/4), "latency")
+	case queued > 0:
+		return a.resize(a.active+1, "queue")
+	}
+	return ""
+}
+
+// resize sets active to n, within bounds, returning reason if it changed.
+func (a *autoscaler) resize(n int, reason string) string {
+	n = min(max(n, a.min), a.max)
+	if n == a.active {
+		return ""
+	}
+	a.active = n
+	a.peak = max(a.peak, n)
+	a.adjustments++
+	return reason
+}
+
+// overloaded reports whether a result suggests the site is struggling: a
+// timeout, a 429 or 5xx response, or a request that failed without one.
+// Missing pages, other client errors, parse errors, and abandoned streams
+// say nothing about load.
+func overloaded(result Result) bool {
+	if result.Err == nil || errors.Is(result.Err, context.Canceled) || errors.Is(result.Err, ErrDownloadBudget) {
+		return false
+	}
+	var httpErr *HTTPError
+	if errors.As(result.Err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode == http.StatusRequestTimeout ||
+			httpErr.StatusCode >= 500
+	}
+	var streamErr *StreamError
+	return result.StatusCode == 0 && !errors.As(result.Err, &streamErr)
+}
+
+// workerAvailable reports whether another page may be handed to a worker.
+func (c *Coordinator) workerAvailable() bool {
+	return c.autoscale == nil || c.inFlight < c.autoscale.active
+}
+
+// scaleWorkers feeds a result to the autoscaler, if enabled.
+func (c *Coordinator) scaleWorkers(result Result) {
+	if c.autoscale == nil {
+		return
+	}
+	from := c.autoscale.active
+	if reason := c.autoscale.observe(result, c.pending.Len()); reason != "" {
+		c.log().Debug("Scaled workers", "from", from, "to", c.autoscale.active, "reason", reason)
+	}
+}
+
+// logAutoscale reports how the autoscaler sized the crawl.
+func (c *Coordinator) logAutoscale() {
+	if c.autoscale == nil {
+		return
+	}
+	c.log().Info("Worker auto-scaling", "final", c.autoscale.active, "peak", c.autoscale.peak,
+		"adjustments", c.autoscale.adjustments, "min", c.autoscale.min, "max", c.autoscale.max)
+}
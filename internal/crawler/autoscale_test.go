@@ -0,0 +1,148 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAutoscaler_Observe(t *testing.T) {
+	ok := Result{FetchDuration: 10 * time.Millisecond}
+	window := func(a *autoscaler, result Result, queued int) string {
+		var reason string
+		for n := max(autoscaleWindow, a.active); n > 0; n-- {
+			reason = a.observe(result, queued)
+		}
+		return reason
+	}
+
+	a := newAutoscaler(2, 10)
+	if newAutoscaler(2, 10).observe(ok, 5) != "" {
+		t.Fatalf("adjusted before a full window")
+	}
+	if reason := window(a, ok, 5); reason != "queue" || a.active != 3 {
+		t.Fatalf("window with pages waiting: reason %q, active %d, want queue, 3", reason, a.active)
+	}
+	if reason := window(a, ok, 0); reason != "" || a.active != 3 {
+		t.Errorf("window with nothing waiting: reason %q, active %d, want no change", reason, a.active)
+	}
+
+	for a.active < 10 {
+		window(a, ok, 5)
+	}
+	if reason := window(a, ok, 5); reason != "" || a.active != 10 {
+		t.Errorf("grew past max: active %d", a.active)
+	}
+
+	slow := Result{FetchDuration: 30 * time.Millisecond}
+	if reason := window(a, slow, 5); reason != "latency" || a.active != 8 {
+		t.Errorf("slow window: reason %q, active %d, want latency, 8", reason, a.active)
+	}
+	// The slower latency is the new normal
+	if reason := window(a, slow, 5); reason != "queue" || a.active != 9 {
+		t.Errorf("steady slow window: reason %q, active %d, want queue, 9", reason, a.active)
+	}
+
+	failed := Result{Err: &HTTPError{StatusCode: 503}, StatusCode: 503}
+	if reason := window(a, failed, 5); reason != "errors" || a.active != 7 {
+		t.Errorf("failing window: reason %q, active %d, want errors, 7", reason, a.active)
+	}
+	for i := 0; i < 10; i++ {
+		window(a, failed, 5)
+	}
+	if a.active != 2 {
+		t.Errorf("shrank to %d, want min 2", a.active)
+	}
+	if a.peak != 10 {
+		t.Errorf("peak = %d, want 10", a.peak)
+	}
+}
+
+func TestOverloaded(t *testing.T) {
+	tests := []struct {
+		name   string
+		result Result
+		want   bool
+	}{
+		{"success", Result{StatusCode: 200}, false},
+		{"not found", Result{Err: &HTTPError{StatusCode: 404}, StatusCode: 404}, false},
+		{"too many requests", Result{Err: &HTTPError{StatusCode: 429}, StatusCode: 429}, true},
+		{"server error", Result{Err: &HTTPError{StatusCode: 502}, StatusCode: 502}, true},
+		{"transport failure", Result{Err: errors.New("connection reset")}, true},
+		{"timeout", Result{Err: fmt.Errorf("executing request: %w", context.DeadlineExceeded)}, true},
+		{"cancelled", Result{Err: context.Canceled}, false},
+		{"parse error", Result{Err: errors.New("bad html"), StatusCode: 200}, false},
+		{"stream", Result{Err: &StreamError{Reason: "still streaming"}}, false},
+	}
+	for _, tt := range tests {
+		if got := overloaded(tt.result); got != tt.want {
+			t.Errorf("%s: overloaded() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCoordinator_AutoScale(t *testing.T) {
+	const numLinks = 100
+	var links []string
+	for i := 0; i < numLinks; i++ {
+		links = append(links, fmt.Sprintf("/p%d", i))
+	}
+	parser := &mockMetadataParser{links: map[string][]string{"https://example.com/": links}}
+
+	for _, tt := range []struct {
+		name    string
+		failing bool
+	}{
+		{"healthy site grows", false},
+		{"failing site stays at the minimum", true},
+	} {
+		fetcher := &concurrencyFetcher{errors: map[string]error{}}
+		for i := 0; tt.failing && i < numLinks; i++ {
+			link := fmt.Sprintf("https://example.com/p%d", i)
+			fetcher.errors[link] = &HTTPError{StatusCode: 503, URL: link}
+		}
+		coord, err := NewCoordinator(Config{
+			StartURL:   "https://example.com/",
+			NumWorkers: 8,
+			AutoScale:  true,
+			MinWorkers: 2,
+			Fetcher:    fetcher,
+			Parser:     parser,
+			Output:     &strings.Builder{},
+		})
+		if err != nil {
+			t.Fatalf("%s: NewCoordinator() error = %v", tt.name, err)
+		}
+		logs := captureLog(t, func() {
+			if err := coord.Crawl(context.Background()); err != nil {
+				t.Errorf("%s: Crawl() error = %v", tt.name, err)
+			}
+		})
+
+		peak := fetcher.peak.Load()
+		if tt.failing {
+			if peak > 2 || coord.autoscale.peak != 2 {
+				t.Errorf("%s: %d fetches in flight, autoscaler peak %d, want at most 2", tt.name, peak, coord.autoscale.peak)
+			}
+		} else if coord.autoscale.peak <= 2 || peak > 8 {
+			t.Errorf("%s: autoscaler peak %d with %d fetches in flight, want growth up to 8", tt.name, coord.autoscale.peak, peak)
+		}
+		if !strings.Contains(logs, "Worker auto-scaling final=") {
+			t.Errorf("%s: missing auto-scaling summary:\n%s", tt.name, logs)
+		}
+	}
+
+	for _, cfg := range []Config{
+		{NumWorkers: 4, AutoScale: true, MinWorkers: 5},
+		{NumWorkers: 4, MinWorkers: 2},
+		{NumWorkers: 4, AutoScale: true, MinWorkers: -1},
+	} {
+		cfg.StartURL, cfg.Fetcher, cfg.Parser = "https://example.com/", &mockFetcher{}, &mockParser{}
+		if _, err := NewCoordinator(cfg); err == nil {
+			t.Errorf("NewCoordinator(%+v) should fail", cfg)
+		}
+	}
+}
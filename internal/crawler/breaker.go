@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// DefaultBreakerCooldown is how long a tripped host breaker stays open when
+// Config.BreakerCooldown is not set.
+const DefaultBreakerCooldown = 30 * time.Second
+
+// hostBreaker tracks consecutive failures for one host.
+type hostBreaker struct {
+	// failures is the number of consecutive failed fetches
+	failures int
+	// openUntil is when scheduling resumes after the breaker trips (zero = closed)
+	openUntil time.Time
+}
+
+// breakerFailure reports whether a fetch error says something about the
+// host rather than the page: network errors, timeouts, 5xx, and 429. Other
+// 4xx responses are about the URL and don't count.
+func breakerFailure(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode == http.StatusRequestTimeout
+	}
+	return true
+}
+
+// recordBreaker updates the breaker of the result's host: a success closes
+// it, and the Nth consecutive failure opens it for the cool-down period.
+func (c *Coordinator) recordBreaker(result Result) {
+	if c.breakerThreshold == 0 {
+		return
+	}
+	host := hostOf(result.URL)
+	b, ok := c.breakers[host]
+	if !ok {
+		b = &hostBreaker{}
+		c.breakers[host] = b
+	}
+	if result.Err == nil || !breakerFailure(result.Err) {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= c.breakerThreshold && !c.now().Before(b.openUntil) {
+		b.openUntil = c.now().Add(c.breakerCooldown)
+		c.log().Warn("Circuit breaker open", "host", host, "failures", b.failures, "pause", c.breakerCooldown)
+	}
+}
+
+// breakerOpen reports whether new URLs on the link's host are paused, and
+// notes the URL as skipped for the summary if so.
+func (c *Coordinator) breakerOpen(link string) bool {
+	if c.breakerThreshold == 0 {
+		return false
+	}
+	b, ok := c.breakers[hostOf(link)]
+	if !ok || !c.now().Before(b.openUntil) {
+		return false
+	}
+	c.breakerSkipped[c.key(link)] = true
+	c.audit(AuditEntry{Decision: AuditSkipped, URL: link, Reason: "host circuit breaker open"})
+	return true
+}
+
+// logBreakerSkips prints the URLs that were never fetched because their
+// host's circuit breaker was open.
+func (c *Coordinator) logBreakerSkips() {
+	if c.breakerThreshold == 0 {
+		return
+	}
+	var skipped []string
+	for key := range c.breakerSkipped {
+		if !c.visited[key] {
+			skipped = append(skipped, key)
+		}
+	}
+	sort.Strings(skipped)
+
+	c.log().Info("Skipped by circuit breaker", "count", len(skipped))
+	for _, u := range skipped {
+		c.log().Info("URL skipped by circuit breaker", "url", u)
+	}
+}
@@ -0,0 +1,103 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCoordinator_CircuitBreaker(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":   []byte("root"),
+			"https://example.com/ok": []byte("ok"),
+			"https://example.com/c":  []byte("c"),
+		},
+		errors: map[string]error{
+			"https://example.com/a": &HTTPError{StatusCode: 503},
+			"https://example.com/b": errors.New("connection reset"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/a", "/b", "/ok"},
+			"ok":   {"/c"},
+		},
+	}
+
+	output := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:         "https://example.com/",
+		NumWorkers:       1,
+		Fetcher:          fetcher,
+		Parser:           parser,
+		Output:           output,
+		BreakerThreshold: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	logs := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	if strings.Contains(output.String(), "Visited: https://example.com/c") {
+		t.Errorf("/c was fetched while the breaker was open:\n%s", output.String())
+	}
+	for _, want := range []string{
+		"Circuit breaker open host=example.com failures=2",
+		"Skipped by circuit breaker count=1",
+		"URL skipped by circuit breaker url=https://example.com/c",
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("logs missing %q:\n%s", want, logs)
+		}
+	}
+}
+
+func TestRecordBreaker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &Coordinator{
+		breakerThreshold: 2,
+		breakerCooldown:  time.Minute,
+		breakers:         make(map[string]*hostBreaker),
+		breakerSkipped:   make(map[string]bool),
+		now:              func() time.Time { return now },
+	}
+	fail := func(err error) {
+		c.recordBreaker(Result{URL: "https://example.com/x", Err: err})
+	}
+
+	// Dead links say nothing about the host
+	fail(&HTTPError{StatusCode: 404})
+	fail(&HTTPError{StatusCode: 404})
+	if c.breakerOpen("https://example.com/y") {
+		t.Fatal("breaker opened on 404s")
+	}
+
+	// A success in between resets the count
+	fail(errors.New("timeout"))
+	c.recordBreaker(Result{URL: "https://example.com/x"})
+	fail(&HTTPError{StatusCode: 429})
+	if c.breakerOpen("https://example.com/y") {
+		t.Fatal("breaker opened on non-consecutive failures")
+	}
+
+	fail(&HTTPError{StatusCode: 502})
+	if !c.breakerOpen("https://example.com/y") {
+		t.Fatal("breaker closed after 2 consecutive failures")
+	}
+	if c.breakerOpen("https://other.com/y") {
+		t.Error("breaker open for a different host")
+	}
+
+	now = now.Add(time.Minute)
+	if c.breakerOpen("https://example.com/y") {
+		t.Error("breaker still open after the cool-down")
+	}
+}
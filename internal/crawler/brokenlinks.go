@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// BrokenLink is a link target that returned 404 Not Found or 410 Gone.
+type BrokenLink struct {
+	URL       string   `json:"url"`
+	Status    int      `json:"status"`
+	Referrers []string `json:"referrers"`
+}
+
+// BrokenLinksReport is the JSON record printed after all pages when the
+// broken link report is enabled.
+type BrokenLinksReport struct {
+	BrokenLinks []BrokenLink `json:"broken_links"`
+}
+
+// recordBrokenLink notes a page that failed with 404 or 410, for the broken
+// link report and FailOnBrokenLinks. Its referrers are looked up when the report is written, so
+// links found after the failure are included.
+func (c *Coordinator) recordBrokenLink(result Result) {
+	if !isBrokenLink(result.Err) {
+		return
+	}
+	c.brokenLinkCount++
+	if !c.brokenLinksReport {
+		return
+	}
+	var httpErr *HTTPError
+	errors.As(result.Err, &httpErr)
+	c.brokenLinks = append(c.brokenLinks, BrokenLink{URL: result.URL, Status: httpErr.StatusCode})
+}
+
+// printBrokenLinks prints the broken link section to the output after all
+// pages, in the configured format, sorted by URL.
+func (c *Coordinator) printBrokenLinks() {
+	if !c.brokenLinksReport {
+		return
+	}
+	defer c.flushOutput()
+
+	sort.Slice(c.brokenLinks, func(i, j int) bool {
+		return c.brokenLinks[i].URL < c.brokenLinks[j].URL
+	})
+	for i := range c.brokenLinks {
+		c.brokenLinks[i].Referrers = c.referrers[c.key(c.brokenLinks[i].URL)]
+		if c.brokenLinks[i].Referrers == nil {
+			c.brokenLinks[i].Referrers = []string{} // The start URL has none
+		}
+	}
+
+	if c.outputFormat == "json" {
+		report := BrokenLinksReport{BrokenLinks: c.brokenLinks}
+		if report.BrokenLinks == nil {
+			report.BrokenLinks = []BrokenLink{}
+		}
+		jsonBytes, err := json.Marshal(report)
+		if err != nil {
+			c.log().Error("Error marshaling JSON", "error", err)
+			return
+		}
+		fmt.Fprintf(c.output, "%s\n", jsonBytes)
+		return
+	}
+
+	fmt.Fprintf(c.output, "Broken links:\n")
+	for _, bl := range c.brokenLinks {
+		fmt.Fprintf(c.output, "%d %s\n", bl.Status, bl.URL)
+		for _, ref := range bl.Referrers {
+			fmt.Fprintf(c.output, "  linked from %s\n", ref)
+		}
+	}
+}
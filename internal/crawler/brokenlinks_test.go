@@ -0,0 +1,124 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func brokenLinksFixture() (*mockFetcher, *mockMetadataParser) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":  []byte("root"),
+			"https://example.com/a": []byte("a"),
+		},
+		errors: map[string]error{
+			"https://example.com/missing": &HTTPError{StatusCode: 404, URL: "https://example.com/missing"},
+			"https://example.com/gone":    &HTTPError{StatusCode: 410, URL: "https://example.com/gone"},
+			"https://example.com/down":    &HTTPError{StatusCode: 503, URL: "https://example.com/down"},
+			"https://example.com/refused": errors.New("connection refused"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/a", "/missing", "/missing#top", "/down", "/refused"},
+			"a":    {"/missing", "/gone"},
+		},
+	}
+	return fetcher, parser
+}
+
+func TestCoordinator_BrokenLinksText(t *testing.T) {
+	fetcher, parser := brokenLinksFixture()
+	output := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:          "https://example.com/",
+		NumWorkers:        1,
+		Fetcher:           fetcher,
+		Parser:            parser,
+		Output:            output,
+		BrokenLinksReport: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	// Other failures are not broken links; repeated links are listed once
+	want := "Broken links:\n" +
+		"410 https://example.com/gone\n" +
+		"  linked from https://example.com/a\n" +
+		"404 https://example.com/missing\n" +
+		"  linked from https://example.com/\n" +
+		"  linked from https://example.com/a\n"
+	out := output.String()
+	i := strings.Index(out, "Broken links:\n")
+	if i < 0 || out[i:] != want {
+		t.Errorf("broken link section missing or wrong:\n%s\nwant at end:\n%s", out, want)
+	}
+}
+
+func TestCoordinator_BrokenLinksJSON(t *testing.T) {
+	fetcher, parser := brokenLinksFixture()
+	output := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:          "https://example.com/",
+		NumWorkers:        1,
+		Fetcher:           fetcher,
+		Parser:            parser,
+		Output:            output,
+		OutputFormat:      "json",
+		BrokenLinksReport: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	var report BrokenLinksReport
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &report); err != nil {
+		t.Fatalf("last line is not a JSON record: %v", err)
+	}
+	if len(report.BrokenLinks) != 2 {
+		t.Fatalf("got %d broken links, want 2: %+v", len(report.BrokenLinks), report.BrokenLinks)
+	}
+	missing := report.BrokenLinks[1]
+	if missing.URL != "https://example.com/missing" || missing.Status != 404 || len(missing.Referrers) != 2 {
+		t.Errorf("missing = %+v, want 404 with 2 referrers", missing)
+	}
+}
+
+func TestCoordinator_BrokenLinksNone(t *testing.T) {
+	output := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:          "https://example.com/",
+		NumWorkers:        1,
+		Fetcher:           &mockFetcher{responses: map[string][]byte{"https://example.com/": []byte("root")}},
+		Parser:            &mockMetadataParser{},
+		Output:            output,
+		OutputFormat:      "json",
+		BrokenLinksReport: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	if !strings.HasSuffix(output.String(), "{\"broken_links\":[]}\n") {
+		t.Errorf("want an empty broken_links record, got:\n%s", output.String())
+	}
+}
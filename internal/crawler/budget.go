@@ -0,0 +1,118 @@
+package crawler
+
+import (
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// maxBudgetPrefixes is how many path prefixes the budget breakdown lists.
+const maxBudgetPrefixes = 20
+
+// budgetBreakdown counts fetched pages along the dimensions users tune
+// filters by.
+type budgetBreakdown struct {
+	total       int
+	byPrefix    map[string]int
+	byDepth     map[int]int
+	byMediaType map[string]int
+}
+
+func newBudgetBreakdown() *budgetBreakdown {
+	return &budgetBreakdown{
+		byPrefix:    make(map[string]int),
+		byDepth:     make(map[int]int),
+		byMediaType: make(map[string]int),
+	}
+}
+
+// recordBudget counts a fetched page, failed or not, toward the budget
+// breakdown. Pages are bucketed by the URL that was requested, since that
+// is what the scheduler spent the budget on.
+func (c *Coordinator) recordBudget(result Result) {
+	if c.budget == nil {
+		return
+	}
+	c.budget.total++
+	c.budget.byPrefix[pathPrefix(result.URL)]++
+	c.budget.byDepth[result.Depth]++
+
+	mediaType := "(failed)"
+	if result.Err == nil {
+		mediaType = strings.ToLower(strings.TrimSpace(strings.Split(result.ContentType, ";")[0]))
+		if mediaType == "" {
+			mediaType = "(none)"
+		}
+	}
+	c.budget.byMediaType[mediaType]++
+}
+
+// pathPrefix returns the first path segment of a URL as a directory
+// ("/tag/" for /tag/go), or "/" for pages at the top level.
+func pathPrefix(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "/"
+	}
+	rest := strings.TrimPrefix(u.Path, "/")
+	if i := strings.Index(rest, "/"); i >= 0 {
+		return "/" + rest[:i+1]
+	}
+	return "/"
+}
+
+// logBudget prints the budget breakdown, largest share first within each
+// dimension and depths in order.
+func (c *Coordinator) logBudget() {
+	if c.budget == nil || c.budget.total == 0 {
+		return
+	}
+	b := c.budget
+
+	c.log().Info("Budget spent", "pages", b.total)
+
+	prefixes := sortedByCount(b.byPrefix)
+	for i, prefix := range prefixes {
+		if i == maxBudgetPrefixes {
+			c.log().Info("Budget spent by other path prefixes", "prefixes", len(prefixes)-maxBudgetPrefixes)
+			break
+		}
+		c.log().Info("Budget spent by path prefix", b.share("prefix", prefix, b.byPrefix[prefix])...)
+	}
+
+	depths := make([]int, 0, len(b.byDepth))
+	for depth := range b.byDepth {
+		depths = append(depths, depth)
+	}
+	sort.Ints(depths)
+	for _, depth := range depths {
+		c.log().Info("Budget spent by depth", b.share("depth", depth, b.byDepth[depth])...)
+	}
+
+	for _, mediaType := range sortedByCount(b.byMediaType) {
+		c.log().Info("Budget spent by content type", b.share("type", mediaType, b.byMediaType[mediaType])...)
+	}
+}
+
+// share returns the log attributes of one entry: its key and value, its
+// page count, and that count as a percentage of the total.
+func (b *budgetBreakdown) share(key string, value any, n int) []any {
+	return []any{key, value, "pages", n, "percent", math.Round(1000*float64(n)/float64(b.total)) / 10}
+}
+
+// sortedByCount returns the keys of counts, highest count first and ties
+// broken alphabetically.
+func sortedByCount(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
@@ -0,0 +1,94 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_BudgetReport(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":            []byte("root"),
+			"https://example.com/about":       []byte("about"),
+			"https://example.com/tag/go":      []byte("go"),
+			"https://example.com/tag/rust":    []byte("rust"),
+			"https://example.com/tag/zig":     []byte("zig"),
+			"https://example.com/files/a.pdf": []byte("pdf"),
+		},
+		contentTypes: map[string]string{
+			"https://example.com/files/a.pdf": "application/pdf",
+			"https://example.com/tag/go":      "text/html; charset=utf-8",
+		},
+		errors: map[string]error{
+			"https://example.com/tag/gone": errors.New("connection refused"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/about", "/tag/go", "/files/a.pdf"},
+			"go":   {"/tag/rust", "/tag/gone"},
+			"rust": {"/tag/zig"},
+		},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:     "https://example.com/",
+		NumWorkers:   2,
+		Fetcher:      fetcher,
+		Parser:       parser,
+		Output:       &bytes.Buffer{},
+		BudgetReport: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	out := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	for _, want := range []string{
+		"Budget spent pages=7",
+		"Budget spent by path prefix prefix=/tag/ pages=4 percent=57.1",
+		"Budget spent by path prefix prefix=/ pages=2 percent=28.6",
+		"Budget spent by path prefix prefix=/files/ pages=1 percent=14.3",
+		"Budget spent by depth depth=0 pages=1 percent=14.3",
+		"Budget spent by depth depth=1 pages=3 percent=42.9",
+		"Budget spent by depth depth=2 pages=2 percent=28.6",
+		"Budget spent by depth depth=3 pages=1 percent=14.3",
+		"Budget spent by content type type=text/html pages=5 percent=71.4",
+		"Budget spent by content type type=(failed) pages=1 percent=14.3",
+		"Budget spent by content type type=application/pdf pages=1 percent=14.3",
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "prefix=/tag/ ") > strings.Index(out, "prefix=/ ") {
+		t.Errorf("path prefixes not sorted by count:\n%s", out)
+	}
+}
+
+func TestPathPrefix(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/", "/"},
+		{"https://example.com", "/"},
+		{"https://example.com/about", "/"},
+		{"https://example.com/tag/go", "/tag/"},
+		{"https://example.com/tag/", "/tag/"},
+		{"https://example.com/a/b/c?x=1", "/a/"},
+	}
+	for _, tt := range tests {
+		if got := pathPrefix(tt.url); got != tt.want {
+			t.Errorf("pathPrefix(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package crawler
+
+import (
+	"net/url"
+	"sort"
+)
+
+// canonicalGroup is the set of fetched pages that share a canonical URL.
+type canonicalGroup struct {
+	// kept is the canonical page itself once fetched, else the first page
+	// to claim the canonical URL
+	kept string
+	// duplicates are the other pages with the same canonical URL
+	duplicates []string
+}
+
+// canonicalKey returns the key of a page's canonical URL. Pages without a
+// usable in-scope canonical link are their own canonical.
+func (c *Coordinator) canonicalKey(result Result) string {
+	if result.Canonical != "" {
+		if base, err := url.Parse(c.linkBase(result)); err == nil {
+			if abs, ok := c.sanitize(result.Canonical, base); ok && c.scope.InScope(abs) {
+				return c.key(abs)
+			}
+		}
+	}
+	return c.key(result.FinalURL)
+}
+
+// isCanonicalDuplicate claims the page's canonical URL on first sight and
+// reports whether another page had already claimed it. A page that is its
+// own canonical always wins its group: an earlier claimant (a variant that
+// arrived first) is demoted to a duplicate, so the canonical page is still
+// printed and expanded.
+func (c *Coordinator) isCanonicalDuplicate(result Result) bool {
+	if !c.dedupCanonical {
+		return false
+	}
+
+	key := c.canonicalKey(result)
+	group, ok := c.canonicals[key]
+	if !ok {
+		c.canonicals[key] = &canonicalGroup{kept: result.FinalURL}
+		return false
+	}
+	if c.key(group.kept) == c.key(result.FinalURL) {
+		return false
+	}
+	if key == c.key(result.FinalURL) {
+		group.duplicates = append(group.duplicates, group.kept)
+		group.kept = result.FinalURL
+		return false
+	}
+
+	c.log().Info("Skipping page: same canonical as another page", "url", result.FinalURL, "kept", group.kept)
+	c.audit(AuditEntry{Decision: AuditSkipped, URL: result.FinalURL, Reason: "same canonical as " + group.kept})
+	group.duplicates = append(group.duplicates, result.FinalURL)
+	return true
+}
+
+// logCanonicalDuplicates reports pages skipped because they share a
+// canonical URL, grouped under the page that was kept.
+func (c *Coordinator) logCanonicalDuplicates() {
+	if !c.dedupCanonical {
+		return
+	}
+
+	var keys []string
+	total := 0
+	for key, group := range c.canonicals {
+		if len(group.duplicates) > 0 {
+			keys = append(keys, key)
+			total += len(group.duplicates)
+		}
+	}
+	sort.Strings(keys)
+
+	c.log().Info("Canonical duplicates", "count", total)
+	for _, key := range keys {
+		group := c.canonicals[key]
+		for _, dup := range group.duplicates {
+			c.log().Info("Canonical duplicate", "canonical", key, "kept", group.kept, "url", dup)
+		}
+	}
+}
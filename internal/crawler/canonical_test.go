@@ -0,0 +1,122 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_DedupCanonical(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":              []byte("root"),
+			"https://example.com/shoes":         []byte("shoes"),
+			"https://example.com/shoes?color=1": []byte("red"),
+			"https://example.com/shoes?page=2":  []byte("page2"),
+			"https://example.com/hidden":        []byte("hidden"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root":  {"/shoes"},
+			"shoes": {"/shoes?color=1", "/shoes?page=2"},
+			"red":   {"/hidden"},
+		},
+		meta: map[string]*PageMetadata{
+			"shoes": {Canonical: "/shoes"},
+			"red":   {Canonical: "/shoes"},
+			"page2": {Canonical: "https://example.com/shoes"},
+		},
+	}
+
+	var out bytes.Buffer
+	coord, err := NewCoordinator(Config{
+		StartURL:       "https://example.com/",
+		NumWorkers:     1,
+		Fetcher:        fetcher,
+		Parser:         parser,
+		Output:         &out,
+		DedupCanonical: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	logs := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	if strings.Contains(out.String(), "Visited: https://example.com/shoes?") {
+		t.Errorf("duplicate pages should not be printed:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "Visited: https://example.com/hidden") {
+		t.Errorf("links from duplicate pages should not be followed")
+	}
+	if !strings.Contains(logs, "Canonical duplicates count=2") {
+		t.Errorf("wrong duplicate count:\n%s", logs)
+	}
+	if !strings.Contains(logs, "canonical=https://example.com/shoes kept=https://example.com/shoes") {
+		t.Errorf("missing canonical group:\n%s", logs)
+	}
+}
+
+func TestCoordinator_DedupCanonicalPrefersCanonicalPage(t *testing.T) {
+	// The variant is fetched first, but the canonical page must still win
+	// its group and be expanded
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":               []byte("root"),
+			"https://example.com/item?color=red": []byte("red"),
+			"https://example.com/item":           []byte("item"),
+			"https://example.com/details":        []byte("details"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/item?color=red", "/item"},
+			"item": {"/details"},
+		},
+		meta: map[string]*PageMetadata{
+			"red":  {Canonical: "/item"},
+			"item": {Canonical: "/item"},
+		},
+	}
+
+	var out bytes.Buffer
+	coord, err := NewCoordinator(Config{
+		StartURL:       "https://example.com/",
+		NumWorkers:     1,
+		Fetcher:        fetcher,
+		Parser:         parser,
+		Output:         &out,
+		DedupCanonical: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	logs := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	if !strings.Contains(out.String(), "Visited: https://example.com/item\n") {
+		t.Errorf("canonical page should be printed:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Visited: https://example.com/details") {
+		t.Errorf("links from the canonical page should be followed:\n%s", out.String())
+	}
+	if strings.Contains(logs, "url=https://example.com/item ") {
+		t.Errorf("canonical page should not be skipped:\n%s", logs)
+	}
+	if !strings.Contains(logs, "canonical=https://example.com/item kept=https://example.com/item") {
+		t.Errorf("canonical page should be kept:\n%s", logs)
+	}
+	if !strings.Contains(logs, `url="https://example.com/item?color=red"`) {
+		t.Errorf("variant should be listed as a duplicate:\n%s", logs)
+	}
+}
@@ -0,0 +1,158 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Checkpoint is the progress of an interrupted crawl: the pages already
+// scheduled and the frontier still to fetch. Written through
+// Config.CheckpointOut and read back for Config.Resume.
+type Checkpoint struct {
+	// StartURL is the start URL of the checkpointed crawl
+	StartURL string `json:"start_url"`
+	// Pages is the number of pages scheduled so far, for the max pages cap
+	Pages int `json:"pages"`
+	// Visited is the URL key of every page scheduled, including the frontier
+	Visited []string `json:"visited"`
+	// Frontier is the pages scheduled but not yet fetched
+	Frontier []FrontierItem `json:"frontier"`
+}
+
+// FrontierItem is a page waiting to be fetched.
+type FrontierItem struct {
+	URL      string `json:"url"`
+	Depth    int    `json:"depth"`
+	Referrer string `json:"referrer,omitempty"`
+}
+
+// ReadCheckpoint reads a checkpoint written through Config.CheckpointOut.
+func ReadCheckpoint(r io.Reader) (*Checkpoint, error) {
+	var cp Checkpoint
+	if err := json.NewDecoder(r).Decode(&cp); err != nil {
+		return nil, fmt.Errorf("reading checkpoint: %w", err)
+	}
+	if cp.StartURL == "" {
+		return nil, fmt.Errorf("reading checkpoint: no start URL")
+	}
+	return &cp, nil
+}
+
+// track adds a scheduled page to the frontier until its result is processed.
+func (c *Coordinator) track(item WorkItem) {
+	if c.checkpointOut == nil || item.CheckOnly {
+		return
+	}
+	c.frontier[c.key(item.URL)] = FrontierItem{URL: item.URL, Depth: item.Depth, Referrer: item.Referrer}
+}
+
+// settle removes a page from the frontier once its result arrives.
+func (c *Coordinator) settle(result Result) {
+	if c.checkpointOut == nil {
+		return
+	}
+	delete(c.frontier, c.key(result.URL))
+}
+
+// unsettle puts a page back on the frontier when the crawl was interrupted
+// before its links were all scheduled, so a resumed crawl fetches it again.
+func (c *Coordinator) unsettle(result Result) {
+	if c.checkpointOut == nil {
+		return
+	}
+	c.track(WorkItem{URL: result.URL, Depth: result.Depth, Referrer: result.Referrer})
+}
+
+// interrupted reports whether a failed fetch was cut short by the crawl
+// being cancelled rather than by the page itself.
+func interrupted(ctx context.Context, err error) bool {
+	return ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded))
+}
+
+// restoreCheckpoint marks the checkpoint's pages visited and queues its
+// frontier, in place of seeding the start URL. The frontier is reconciled
+// with this crawl's configuration: pages now out of scope, excluded by the
+// include and exclude patterns, vetoed by OnBeforeFetch, or rejected by a
+// filter are dropped and no longer count towards the max pages cap.
+func (c *Coordinator) restoreCheckpoint() {
+	for _, key := range c.resume.Visited {
+		c.visited[key] = true
+	}
+	c.visitCount = c.resume.Pages
+	dropped := 0
+	for _, fi := range c.resume.Frontier {
+		if reason := c.frontierExcluded(fi.URL, fi.Depth); reason != "" {
+			delete(c.visited, c.key(fi.URL))
+			c.visitCount--
+			dropped++
+			c.audit(AuditEntry{Decision: AuditSkipped, URL: fi.URL, Reason: reason + " on resume"})
+			continue
+		}
+		item := WorkItem{URL: fi.URL, Depth: fi.Depth, Referrer: fi.Referrer, Validators: c.validatorsFor(fi.URL)}
+		c.startPageSpan(&item)
+		c.visited[c.key(fi.URL)] = true
+		c.track(item)
+		// CRITICAL: wg.Add(1) BEFORE enqueuing; processResults feeds pending
+		// to the workers
+		c.wg.Add(1)
+		c.pending.Push(item)
+	}
+	c.log().Info("Resuming crawl", "pages", c.visitCount, "pending", len(c.resume.Frontier)-dropped, "dropped", dropped)
+}
+
+// frontierExcluded reports why a checkpointed page may no longer be
+// fetched under this crawl's configuration ("" = still allowed).
+func (c *Coordinator) frontierExcluded(link string, depth int) string {
+	switch {
+	case !c.scope.InScope(link):
+		return "out of scope"
+	case !c.patternsAllow(link):
+		return "excluded by patterns"
+	case c.onBeforeFetch != nil && !c.onBeforeFetch(link):
+		return "rejected by OnBeforeFetch"
+	}
+	if stage := c.filterRejects(link, depth); stage != nil {
+		return "rejected by filter " + stage.filter.Name()
+	}
+	return c.guardRejects(link)
+}
+
+// writeCheckpoint writes the visited set and the frontier still to fetch.
+// After a completed crawl the frontier is empty.
+func (c *Coordinator) writeCheckpoint() {
+	if c.checkpointOut == nil {
+		return
+	}
+
+	cp := Checkpoint{
+		StartURL: c.startURL.String(),
+		Pages:    c.visitCount,
+		Visited:  make([]string, 0, len(c.visited)),
+		Frontier: make([]FrontierItem, 0, len(c.frontier)),
+	}
+	for key := range c.visited {
+		cp.Visited = append(cp.Visited, key)
+	}
+	sort.Strings(cp.Visited)
+	for _, fi := range c.frontier {
+		cp.Frontier = append(cp.Frontier, fi)
+	}
+	sort.Slice(cp.Frontier, func(i, j int) bool {
+		if cp.Frontier[i].Depth != cp.Frontier[j].Depth {
+			return cp.Frontier[i].Depth < cp.Frontier[j].Depth
+		}
+		return cp.Frontier[i].URL < cp.Frontier[j].URL
+	})
+
+	if err := json.NewEncoder(c.checkpointOut).Encode(cp); err != nil {
+		c.log().Error("Error writing checkpoint", "error", err)
+		return
+	}
+	if len(cp.Frontier) > 0 {
+		c.log().Info("Checkpoint saved", "pending", len(cp.Frontier))
+	}
+}
@@ -0,0 +1,171 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// visitedURLs returns the URLs printed as visited in text output, sorted.
+func visitedURLs(output string) []string {
+	var urls []string
+	for _, m := range regexp.MustCompile(`(?m)^Visited: (\S+)`).FindAllStringSubmatch(output, -1) {
+		urls = append(urls, m[1])
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+func TestCoordinator_CheckpointAndResume(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":  []byte("<html>root</html>"),
+			"https://example.com/a": []byte("<html>a</html>"),
+			"https://example.com/b": []byte("<html>b</html>"),
+			"https://example.com/c": []byte("<html>c</html>"),
+		},
+	}
+	parser := &mockParser{links: []string{"/a", "/b", "/c"}}
+
+	// Interrupt the crawl while the first child page is being processed
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	saved := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:      "https://example.com/",
+		NumWorkers:    1,
+		Fetcher:       fetcher,
+		Parser:        parser,
+		Output:        &bytes.Buffer{},
+		CheckpointOut: saved,
+		OnResult: func(r *Result) {
+			if r.URL != "https://example.com/" {
+				cancel()
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(ctx); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	cp, err := ReadCheckpoint(saved)
+	if err != nil {
+		t.Fatalf("ReadCheckpoint() error = %v", err)
+	}
+	if cp.StartURL != "https://example.com/" || cp.Pages != 4 || len(cp.Visited) != 4 {
+		t.Errorf("checkpoint = %+v, want 4 pages visited from https://example.com/", cp)
+	}
+	if len(cp.Frontier) != 3 {
+		t.Fatalf("frontier = %+v, want the three child pages", cp.Frontier)
+	}
+	for _, fi := range cp.Frontier {
+		if fi.Depth != 1 || fi.Referrer != "https://example.com/" {
+			t.Errorf("frontier item %+v lost its depth or referrer", fi)
+		}
+	}
+
+	// Resume: only the frontier is fetched, and the new checkpoint is empty
+	output := &bytes.Buffer{}
+	resumed := &bytes.Buffer{}
+	coord, err = NewCoordinator(Config{
+		StartURL:      "https://example.com/",
+		NumWorkers:    2,
+		Fetcher:       fetcher,
+		Parser:        parser,
+		Output:        output,
+		CheckpointOut: resumed,
+		Resume:        cp,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	want := []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}
+	if got := visitedURLs(output.String()); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("resumed crawl visited %v, want %v", got, want)
+	}
+	cp, err = ReadCheckpoint(resumed)
+	if err != nil {
+		t.Fatalf("ReadCheckpoint() error = %v", err)
+	}
+	if len(cp.Frontier) != 0 || cp.Pages != 4 {
+		t.Errorf("checkpoint after completion = %+v, want 4 pages and no frontier", cp)
+	}
+}
+
+func TestCoordinator_ResumeReconcilesFrontier(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/a":       []byte("<html>a</html>"),
+			"https://example.com/private": []byte("<html>private</html>"),
+			"https://other.example/x":     []byte("<html>x</html>"),
+		},
+	}
+	cp := &Checkpoint{
+		StartURL: "https://other.example/",
+		Pages:    4,
+		Visited: []string{
+			Key("https://example.com/"), Key("https://example.com/a"),
+			Key("https://example.com/private"), Key("https://other.example/x"),
+		},
+		Frontier: []FrontierItem{
+			{URL: "https://example.com/a", Depth: 1},
+			{URL: "https://example.com/private", Depth: 1},
+			{URL: "https://other.example/x", Depth: 1},
+		},
+	}
+
+	// The resumed crawl has a new start URL and an exclude pattern
+	output := &bytes.Buffer{}
+	audit := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		MaxPages:   3,
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     &mockParser{links: []string{"/b"}},
+		Output:     output,
+		Exclude:    []string{`/private`},
+		AuditLog:   audit,
+		Resume:     cp,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	logs := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	// Dropping two pages frees room under the cap for the newly found /b
+	want := []string{"https://example.com/a", "https://example.com/b"}
+	if got := visitedURLs(output.String()); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("resumed crawl visited %v, want %v", got, want)
+	}
+	if !strings.Contains(logs, "dropped=2") {
+		t.Errorf("logs missing dropped count:\n%s", logs)
+	}
+	for _, reason := range []string{"out of scope on resume", "excluded by patterns on resume"} {
+		if !strings.Contains(audit.String(), reason) {
+			t.Errorf("audit log missing %q:\n%s", reason, audit.String())
+		}
+	}
+}
+
+func TestReadCheckpoint_Invalid(t *testing.T) {
+	for _, input := range []string{"", "not json", `{"pages": 3}`} {
+		if _, err := ReadCheckpoint(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadCheckpoint(%q) should fail", input)
+		}
+	}
+}
@@ -2,14 +2,28 @@ package crawler
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net/http"
 	"net/url"
 	"os"
+	"regexp"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 // Coordinator is the brain of the crawler.
@@ -32,6 +46,8 @@ type Coordinator struct {
 	startURL *url.URL
 	// startHost is the hostname we're crawling
 	startHost string
+	// scope decides which discovered URLs are crawled
+	scope ScopePolicy
 	// maxPages is the maximum number of pages to visit (0 = unlimited)
 	maxPages int
 	// visitCount tracks how many pages we've visited
@@ -40,28 +56,577 @@ type Coordinator struct {
 	errorCount int
 	// numWorkers is the number of worker goroutines
 	numWorkers int
+	// autoscale sizes how many workers are handed work (nil = all of them)
+	autoscale *autoscaler
+	// inFlight counts pages handed to workers whose results haven't arrived
+	inFlight int
+	// pageInterval is the least time between two pages handed to workers
+	// (0 = no page rate)
+	pageInterval time.Duration
+	// nextPage is when the page rate allows the next page to be handed out
+	nextPage time.Time
 	// output is where we write results (default: os.Stdout)
 	output io.Writer
+	// outputBuffer is how many records the output goroutine queues
+	// (0 = write synchronously)
+	outputBuffer int
 	// outputFormat is the output format: "text" or "json"
 	outputFormat string
+	// pages receives each printed page (nil = disabled)
+	pages chan<- PageResult
+	// checkExternal verifies out-of-scope links resolve
+	checkExternal bool
+	// externalChecks tracks checked out-of-scope URLs by key
+	externalChecks map[string]*externalCheck
+	// breakerThreshold opens a host's breaker after this many consecutive
+	// failures (0 = disabled)
+	breakerThreshold int
+	// breakerCooldown is how long an open breaker pauses scheduling
+	breakerCooldown time.Duration
+	// breakers tracks failures per host
+	breakers map[string]*hostBreaker
+	// breakerSkipped is the set of URL keys not scheduled while a breaker was open
+	breakerSkipped map[string]bool
+	// now returns the current time (time.Now; replaced in tests)
+	now func() time.Time
+	// previous is the state of the previous crawl, by URL key (nil = none)
+	previous map[string]PageState
+	// stateOut receives this crawl's state for the next one (nil = disabled)
+	stateOut io.Writer
+	// state is this crawl's page state, by URL key
+	state map[string]PageState
+	// unchanged counts pages the server reported not modified
+	unchanged int
+	// checkpointOut receives the visited set and frontier when the crawl
+	// ends (nil = disabled)
+	checkpointOut io.Writer
+	// frontier is the pages scheduled but not yet processed, by URL key
+	frontier map[string]FrontierItem
+	// resume is the checkpoint this crawl continues from (nil = fresh crawl)
+	resume *Checkpoint
+	// progressCh carries RequestProgress calls to the coordinator goroutine
+	progressCh chan struct{}
+	// pauseRequested is set by Pause and cleared by Resume, from any goroutine
+	pauseRequested atomic.Bool
+	// pauseCh wakes the coordinator goroutine after Pause or Resume
+	pauseCh chan struct{}
+	// paused is the pause state the coordinator acts on
+	paused bool
+	// startTime is when Crawl started
+	startTime time.Time
+	// live mirrors the counters for Progress
+	live liveProgress
+	// logger receives the coordinator's logs (nil = slog.Default())
+	logger *slog.Logger
+	// tracer starts the crawl and page spans (a no-op tracer by default)
+	tracer trace.Tracer
+	// crawlSpan is the parent of every page span
+	crawlSpan trace.Span
+	// onResult, onLinkDiscovered, and onBeforeFetch are the embedder hooks
+	// (nil = disabled)
+	onResult         func(*Result)
+	onLinkDiscovered func(from, link string)
+	onBeforeFetch    func(url string) bool
+	// rng shuffles discovered links in reproducible mode (nil = disabled)
+	rng *rand.Rand
+	// pending holds scheduled work in crawl order; processResults hands it
+	// to workCh as workers become free, so enqueueing never blocks
+	pending Frontier
+	// events receives structured lifecycle events (nil = disabled)
+	events io.Writer
+	// auditLog receives crawl decisions as JSON lines (nil = disabled)
+	auditLog io.Writer
+	// errorReport receives failed pages as JSON lines (nil = disabled)
+	errorReport io.Writer
+	// auditConfig is the configuration snapshot recorded at crawl start
+	auditConfig map[string]string
+	// crawlMetadata brackets the JSON output with header and summary records
+	crawlMetadata bool
+	// crawlerVersion is reported in the header record
+	crawlerVersion string
+	// auditOverrides names the settings changed from their defaults
+	auditOverrides []string
+	// budgetReached records whether the max pages cap has been hit
+	budgetReached bool
+	// downloadBudgetHit records whether the fetcher's download budget ran
+	// out, and unfetched counts the scheduled pages dropped because of it
+	// or an abort
+	downloadBudgetHit bool
+	unfetched         int
+	// maxErrors and maxErrorStreak abort the crawl after that many
+	// failed pages in total or in a row (0 = no limit)
+	maxErrors      int
+	maxErrorStreak int
+	// errorStreak counts the pages failed since the last success
+	errorStreak int
+	// abortReason says why the crawl was aborted ("" = it wasn't)
+	abortReason string
+	// pathBudgets cap the URLs scheduled under path prefixes or patterns
+	pathBudgets []*pathBudget
+	// languages restricts reported pages to these language tags (empty = all)
+	languages []string
+	// include limits scheduled URLs to those matching one of these (empty = all)
+	include []*regexp.Regexp
+	// exclude drops scheduled URLs matching any of these
+	exclude []*regexp.Regexp
+	// stripParams are query parameters removed from every sanitized URL
+	stripParams []string
+	// sortQuery orders query parameters by name in dedupe keys
+	sortQuery bool
+	// foldPaths lowercases paths in dedupe keys
+	foldPaths bool
+	// maxURLLength refuses longer discovered URLs (0 = no limit)
+	maxURLLength int
+	// maxQueryParams refuses discovered URLs with more parameters (0 = no limit)
+	maxQueryParams int
+	// guardSkipped holds the keys of URLs refused by the URL guards
+	guardSkipped map[string]bool
+	// filters is the embedder's filter chain, in order
+	filters []*filterStage
+	// detectLang guesses the language of pages without a lang attribute
+	detectLang bool
+	// slowTopN is how many of the slowest pages to report (0 = disabled)
+	slowTopN int
+	// slowThreshold reports every page slower than this (0 = disabled)
+	slowThreshold time.Duration
+	// largeTopN is how many of the largest pages to report (0 = disabled)
+	largeTopN int
+	// largeThreshold reports every page larger than this many bytes (0 = disabled)
+	largeThreshold int
+	// pageStats holds per-page measurements for the summary reports
+	pageStats []pageStat
+	// referrers maps a URL key to the pages linking to it (large-page,
+	// broken link, and JUnit reports)
+	referrers map[string][]string
+	// brokenLinksReport prints the broken link section after all pages
+	brokenLinksReport bool
+	// brokenLinks lists the pages that returned 404 or 410
+	brokenLinks []BrokenLink
+	// brokenLinkCount counts the pages that returned 404 or 410
+	brokenLinkCount int
+	// failOnBrokenLinks makes Crawl fail if any link is broken
+	failOnBrokenLinks bool
+	// maxErrorRate is the share of pages that may fail before Crawl fails
+	// (0 = no limit)
+	maxErrorRate float64
+	// noindexMinLinks reports noindexed pages with at least this many linking pages (0 = disabled)
+	noindexMinLinks int
+	// nofollowReport enables the nofollow-only-reachable report
+	nofollowReport bool
+	// inbound maps a URL key to its linking pages, and whether any link from
+	// that page is followable (SEO reports)
+	inbound map[string]map[string]bool
+	// noindexed lists pages whose robots meta asks not to be indexed
+	noindexed []string
+	// checkFragments enables the broken fragment anchor report
+	checkFragments bool
+	// anchors maps a fetched page's key to the fragment targets it defines
+	anchors map[string]map[string]bool
+	// fragmentRefs lists in-scope links that carry a fragment
+	fragmentRefs []fragmentRef
+	// index receives search-index documents as JSON lines (nil = disabled)
+	index io.Writer
+	// includeAssets prints non-anchor asset URLs alongside links
+	includeAssets bool
+	// followAssets schedules in-scope asset URLs as well as anchors
+	followAssets bool
+	// externalReport enables the external domain summary
+	externalReport bool
+	// externalDomains aggregates references to out-of-scope hosts
+	externalDomains map[string]*externalDomain
+	// validateSchema enables the structured data validation report
+	validateSchema bool
+	// schemaIssues lists structured data problems found on fetched pages
+	schemaIssues []schemaIssue
+	// dedupCanonical skips pages whose canonical URL was already claimed by
+	// another page
+	dedupCanonical bool
+	// canonicals maps a canonical URL key to the pages that share it
+	canonicals map[string]*canonicalGroup
+	// nearDuplicates enables the simhash near-duplicate content report
+	nearDuplicates bool
+	// nearDupDistance is the most simhash bits near duplicates differ in
+	nearDupDistance int
+	// fingerprints are the simhashes of fetched pages' text
+	fingerprints []pageFingerprint
+	// redirectMap receives the permanent redirect map (nil = disabled)
+	redirectMap io.Writer
+	// redirectMapFormat is the redirect map syntax: nginx, apache, or netlify
+	redirectMapFormat string
+	// graph receives the site graph in DOT format (nil = disabled)
+	graph io.Writer
+	// graphEdges maps each fetched page key to the in-scope keys it links to
+	graphEdges map[string]map[string]bool
+	// linkStatsTopN sizes the link statistics report lists (0 = disabled)
+	linkStatsTopN int
+	// pageRankOut receives the PageRank of every fetched page (nil = disabled)
+	pageRankOut io.Writer
+	// pageRankFormat is the PageRank export format: csv or json
+	pageRankFormat string
+	// sitemap receives the sitemap.xml of fetched pages (nil = disabled)
+	sitemap io.Writer
+	// sitemapPages maps a page key to its sitemap entry
+	sitemapPages map[string]sitemapEntry
+	// junit receives the JUnit XML report (nil = disabled)
+	junit io.Writer
+	// junitPages lists the printed pages, in order, for the JUnit report
+	junitPages []junitPage
+	// redirectRules maps a source path to its observed permanent redirect
+	redirectRules map[string]redirectRule
+	// redirectsSkipped lists permanent redirects that cannot be exported
+	redirectsSkipped map[string]bool
+	// respectRobots stops expanding nofollow pages and marks noindex pages
+	respectRobots bool
+	// hostReport enables the host consistency summary
+	hostReport bool
+	// certMismatches maps a failed URL to its certificate hostname mismatch
+	certMismatches map[string]certMismatch
+	// hostHops counts redirects between www and apex variants of a host
+	hostHops map[hostHop]int
+	// hostBounces lists redirect chains that return to a host they left
+	hostBounces []string
+	// budget tallies fetched pages for the budget breakdown (nil = disabled)
+	budget *budgetBreakdown
+	// linkDetails adds anchor text and rel to JSON output records
+	linkDetails bool
+	// captureHeaders are the canonical names of response headers added to
+	// JSON output records
+	captureHeaders []string
+	// extractText adds visible page text to JSON output records
+	extractText bool
+	// harvestMetadata adds OpenGraph and JSON-LD to JSON output records
+	harvestMetadata bool
+	// includeHTML embeds raw HTML in JSON output records
+	includeHTML bool
+	// htmlMaxBytes truncates embedded HTML (0 = no limit)
+	htmlMaxBytes int
+	// htmlBase64 base64-encodes embedded HTML
+	htmlBase64 bool
+	// hintAudit enables the resource hint audit
+	hintAudit bool
+	// hintRefs lists every resource hint found, in crawl order
+	hintRefs []hintRef
+	// hintCounts counts resource hints by rel
+	hintCounts map[string]int
+	// hintFailed maps the key of each failed fetch to its error, so hints
+	// pointing at it can be reported missing
+	hintFailed map[string]string
 }
 
 // Config contains configuration for the Coordinator.
 type Config struct {
 	// StartURL is the starting URL to crawl
 	StartURL string
+	// Frontier orders the scheduled work (nil = breadth-first, see
+	// NewFrontier). A Frontier holds one crawl's queue; don't share it.
+	Frontier Frontier
+	// Scope decides which discovered URLs are crawled (nil = the start
+	// URL's host, see HostScope). The start URL is always fetched.
+	Scope ScopePolicy
 	// MaxPages is the maximum number of pages to visit (0 = unlimited)
 	MaxPages int
 	// NumWorkers is the number of concurrent workers
 	NumWorkers int
-	// Fetcher is the HTTP client interface
+	// AutoScale makes NumWorkers a ceiling: work goes to between MinWorkers
+	// and NumWorkers workers, one more each round while pages are waiting,
+	// a quarter fewer when fetches slow down or timeouts, 429s, and 5xx
+	// responses appear, so NumWorkers needn't be tuned per site
+	AutoScale bool
+	// MinWorkers is the fewest workers AutoScale keeps busy (default 1)
+	MinWorkers int
+	// MaxPagesPerSec caps how many pages per second the scheduler hands to
+	// workers across the whole crawl, however many workers and hosts
+	// (0 = no cap). Unlike a request rate limit, robots.txt fetches, HEAD
+	// prechecks, redirects, and external link checks don't count.
+	MaxPagesPerSec float64
+	// MaxErrors aborts the crawl once this many pages have failed, and
+	// MaxConsecutiveErrors once this many failed in a row (0 = no limit).
+	// Pages in flight finish, nothing queued is fetched, and Crawl returns
+	// ErrAborted after writing its output.
+	MaxErrors            int
+	MaxConsecutiveErrors int
+	// Fetcher is the HTTP client interface. One Fetcher may be shared by
+	// several Coordinators crawling concurrently, to share its transport.
 	Fetcher Fetcher
+	// Pool, if set, limits the fetches in flight across every Coordinator
+	// sharing it; NumWorkers still sets this crawl's own concurrency
+	Pool *WorkerPool
 	// Parser is the HTML parser interface
 	Parser Parser
-	// Output is where to write results (default: os.Stdout)
+	// Output is where to write results (default: os.Stdout). If it implements
+	// Flusher it is flushed after every record, so buffered output still streams.
 	Output io.Writer
-	// OutputFormat is the output format: "text" or "json" (default: "text")
+	// OutputBuffer writes Output on a dedicated goroutine, queueing up to
+	// this many records, so a slow Output (a pager, a network pipe) doesn't
+	// stall scheduling until the queue fills. Everything queued is written
+	// before Crawl returns; a Flusher Output is flushed whenever the queue
+	// empties rather than after every record. 0 writes synchronously.
+	OutputBuffer int
+	// Pages receives every printed page as a PageResult, in output order
+	// (nil = disabled). The coordinator blocks until each is received, so
+	// the channel must be drained until Crawl returns.
+	Pages chan<- PageResult
+	// PathBudgets cap how many URLs under a path prefix or matching a
+	// pattern are scheduled; the usage of each is reported in the summary
+	PathBudgets []PathBudget
+	// CheckExternal checks that out-of-scope links resolve, with HEAD when
+	// the Fetcher is a LinkChecker, without crawling them, and prints the
+	// dead ones after all pages (text section or a JSON record). Checks
+	// don't count toward MaxPages.
+	CheckExternal bool
+	// BreakerThreshold stops scheduling new URLs on a host after this many
+	// consecutive network errors, timeouts, 5xx, or 429 responses from it,
+	// for BreakerCooldown. URLs skipped meanwhile are listed in the summary
+	// (0 = disabled).
+	BreakerThreshold int
+	// BreakerCooldown is how long a host is paused once its breaker opens
+	// (default: DefaultBreakerCooldown)
+	BreakerCooldown time.Duration
+	// PreviousCrawl is the state written by an earlier crawl of the site (see
+	// ReadCrawlState). Pages in it are fetched conditionally; pages the
+	// server reports unchanged are printed as not modified, and their links
+	// from the earlier crawl are followed without refetching their content.
+	PreviousCrawl map[string]PageState
+	// CrawlState receives each fetched page's validators and links as JSON
+	// lines when the crawl ends, for PreviousCrawl in the next crawl (nil = disabled)
+	CrawlState io.Writer
+	// CheckpointOut receives the visited set and the pages still to fetch
+	// when the crawl ends, so an interrupted crawl can be resumed (nil = disabled)
+	CheckpointOut io.Writer
+	// Logger receives every log record of the crawl: failures, skipped
+	// pages, and the summary reports (nil = slog.Default())
+	Logger *slog.Logger
+	// TracerProvider traces the crawl with OpenTelemetry spans: one for the
+	// crawl and one per page, with fetch, parse, and process children
+	// (nil = no tracing)
+	TracerProvider trace.TracerProvider
+	// Resume continues the crawl saved in a checkpoint (see ReadCheckpoint)
+	// instead of starting from StartURL. Saved pages that the current scope
+	// and filters exclude are dropped rather than fetched
+	Resume *Checkpoint
+	// OnResult is called with every result as it arrives from a worker,
+	// before the coordinator acts on it, so it can enrich or rewrite the
+	// result (nil = disabled).
+	//
+	// All hooks run on the coordinator goroutine, one call at a time, and
+	// block the crawl while they run.
+	OnResult func(result *Result)
+	// OnLinkDiscovered is called for every link, in scope or not, found on a
+	// page whose links are being followed, with the page's final URL and the
+	// link's normalized URL (nil = disabled)
+	OnLinkDiscovered func(from, link string)
+	// OnBeforeFetch is called with each in-scope, unvisited URL just before
+	// it is scheduled; returning false skips it without counting it against
+	// MaxPages. It is not called for the start URL and may be called again
+	// for a URL it rejected (nil = disabled).
+	OnBeforeFetch func(url string) bool
+	// OutputFormat is the output format: "text", "json", or "ndjson", an alias
+	// for "json" naming its one-record-per-line framing (default: "text")
 	OutputFormat string
+	// Seed enables reproducible scheduling when non-zero: a single worker is
+	// used and newly discovered links are shuffled with a source seeded by Seed,
+	// so the same seed against the same site yields the same traversal order.
+	Seed int64
+	// Events receives structured lifecycle events as JSON lines (nil = disabled)
+	Events io.Writer
+	// AuditLog receives every crawl decision as JSON lines: the start with a
+	// configuration snapshot, the seed, pages skipped by filters, robots
+	// directives honoured, budgets hit, error-limit aborts, and how the crawl
+	// ended (nil = disabled)
+	AuditLog io.Writer
+	// ErrorReport receives every page that failed to fetch as JSON lines
+	// (see FailedURL), separate from the main output so failures can be
+	// re-queued later (nil = disabled)
+	ErrorReport io.Writer
+	// AuditConfig is the configuration snapshot recorded at crawl start,
+	// such as the command-line flag values, in the audit log and the
+	// CrawlMetadata header
+	AuditConfig map[string]string
+	// AuditOverrides names the settings in AuditConfig that were changed
+	// from their defaults
+	AuditOverrides []string
+	// Index receives one search-index document per HTML page as JSON lines
+	// (nil = disabled). Requires a MetadataParser for title and content.
+	Index io.Writer
+	// RedirectMap receives every permanent (301/308) redirect observed on the
+	// crawled host, written as webserver rules when the crawl ends (nil = disabled)
+	RedirectMap io.Writer
+	// RedirectMapFormat is the RedirectMap syntax: "nginx", "apache", or
+	// "netlify" (_redirects file) (default: "nginx")
+	RedirectMapFormat string
+	// CrawlMetadata prints a CrawlHeader record before the first page and a
+	// CrawlSummary record after everything else, so consumers can check the
+	// OutputSchemaVersion. Requires JSON output.
+	CrawlMetadata bool
+	// CrawlerVersion is the crawler build reported in the CrawlHeader
+	CrawlerVersion string
+	// FailOnBrokenLinks makes Crawl return ErrThresholdExceeded if any page
+	// returned 404 or 410, or with CheckExternal, any external link is dead
+	FailOnBrokenLinks bool
+	// MaxErrorRate makes Crawl return ErrThresholdExceeded if more than
+	// this share of pages failed, between 0 and 1 (0 = no limit)
+	MaxErrorRate float64
+	// BrokenLinksReport prints a broken link section to Output after all
+	// pages: every URL that returned 404 or 410 with the pages linking to it
+	BrokenLinksReport bool
+	// Graph receives the site graph of fetched pages and the in-scope links
+	// between them, written in Graphviz DOT format when the crawl ends
+	// (nil = disabled)
+	Graph io.Writer
+	// Sitemap receives a sitemaps.org sitemap.xml of the fetched in-scope HTML
+	// pages, with lastmod from their Last-Modified headers, written when the
+	// crawl ends (nil = disabled). Pages marked noindex are left out.
+	Sitemap io.Writer
+	// JUnit receives a JUnit XML report when the crawl ends, so CI systems
+	// can show crawl failures as failed tests: one test case per page,
+	// failed if it could not be fetched, and one per external link checked
+	// (nil = disabled)
+	JUnit io.Writer
+	// LinkStatsTopN reports in/out link counts in the summary: the N most
+	// linked pages and up to N pages linked from only one page (0 = disabled)
+	LinkStatsTopN int
+	// PageRank receives the PageRank of every fetched page over the in-scope
+	// link graph, with its link counts, highest first, written when the
+	// crawl ends (nil = disabled)
+	PageRank io.Writer
+	// PageRankFormat is the PageRank syntax: "csv" or "json" (JSON lines)
+	// (default: "csv")
+	PageRankFormat string
+	// IncludeAssets prints each page's img, script, link, and iframe URLs,
+	// tagged by type. Requires a MetadataParser.
+	IncludeAssets bool
+	// FollowAssets also schedules in-scope asset URLs for crawling; by
+	// default only anchors are followed. Implies IncludeAssets.
+	FollowAssets bool
+	// ExternalDomainsReport summarizes every external domain the site links
+	// to, with reference counts and example referring pages
+	ExternalDomainsReport bool
+	// Languages restricts the crawl to pages whose <html lang> matches one of
+	// these tags (e.g. "en" matches "en-GB"). Pages that declare another
+	// language are neither printed nor expanded. Pages without a lang
+	// attribute always match, unless DetectLanguage guesses one. Requires a
+	// Parser implementing MetadataParser.
+	Languages []string
+	// Include restricts the crawl to discovered URLs matching at least one
+	// of these regular expressions (empty = all in-scope URLs). The start
+	// URL is always fetched.
+	Include []string
+	// Exclude skips discovered URLs matching any of these regular
+	// expressions, e.g. `\.pdf$` or `/calendar/`
+	Exclude []string
+	// StripParams removes these query parameters from the start URL and
+	// every discovered link before it is printed, deduplicated, or
+	// scheduled, so tracking-tagged links don't multiply the frontier. A
+	// trailing * matches a prefix; see StripQueryParams and
+	// DefaultTrackingParams.
+	StripParams []string
+	// SortQuery orders query parameters by name when deduplicating, so
+	// ?a=1&b=2 and ?b=2&a=1 are one page. URLs are still printed and
+	// fetched as first found.
+	SortQuery bool
+	// CaseInsensitivePaths lowercases paths when deduplicating, for sites
+	// on servers that ignore path case (IIS), so /About and /about are one
+	// page. The query string keeps its case.
+	CaseInsensitivePaths bool
+	// MaxURLLength refuses to schedule discovered URLs longer than this
+	// many characters (0 = no limit)
+	MaxURLLength int
+	// MaxQueryParams refuses to schedule discovered URLs with more query
+	// parameters than this (0 = no limit). With MaxURLLength, a cheap
+	// defense against traps that keep appending parameters.
+	MaxQueryParams int
+	// Filters is an ordered chain applied to every discovered URL before
+	// it is scheduled, see Filter. The number of URLs each one rejected is
+	// reported in the summary.
+	Filters []Filter
+	// DetectLanguage guesses the language of pages that declare none from
+	// the stopwords in their text (English, French, German, Spanish,
+	// Italian, Portuguese, and Dutch). Guessed languages appear in output
+	// and are subject to Languages. Requires a MetadataParser.
+	DetectLanguage bool
+	// SlowPagesTopN reports the N slowest pages in the summary (0 = disabled)
+	SlowPagesTopN int
+	// SlowPageThreshold reports every page whose fetch took longer than this (0 = disabled)
+	SlowPageThreshold time.Duration
+	// LargePagesTopN reports the N largest pages by HTML size (0 = disabled)
+	LargePagesTopN int
+	// LargePageThreshold reports every page whose HTML exceeds this many bytes (0 = disabled)
+	LargePageThreshold int
+	// NoindexMinLinks reports noindexed pages linked from at least this many
+	// internal pages (0 = disabled). Requires a MetadataParser.
+	NoindexMinLinks int
+	// NofollowReport reports pages reachable only through rel="nofollow"
+	// links. Requires a MetadataParser.
+	NofollowReport bool
+	// CheckFragments reports links whose #fragment does not match an id or
+	// <a name> in the target page. Targets that were never fetched are not
+	// checked. Requires a MetadataParser.
+	CheckFragments bool
+	// ValidateStructuredData checks each page's JSON-LD for the fields
+	// required by Article, Product, and BreadcrumbList and reports what is
+	// missing. Requires a MetadataParser.
+	ValidateStructuredData bool
+	// RespectRobotsMeta honours page-level robots directives from
+	// <meta name="robots"> and X-Robots-Tag: links on nofollow pages are
+	// printed but not followed, and noindex pages are marked in the output.
+	// The meta tag requires a MetadataParser.
+	RespectRobotsMeta bool
+	// DedupCanonical treats pages sharing a rel="canonical" URL as one page:
+	// the canonical page itself, or the first variant to arrive before it, is
+	// printed and expanded; other variants are skipped and listed with it in
+	// the summary. Requires a MetadataParser.
+	DedupCanonical bool
+	// NearDuplicates reports groups of pages whose visible text is nearly
+	// identical, by comparing 64-bit simhashes of their word shingles. Unlike
+	// DedupCanonical it doesn't skip anything. Requires a MetadataParser.
+	NearDuplicates bool
+	// NearDuplicateDistance is the most simhash bits two pages may differ in
+	// to count as near duplicates (0 = DefaultNearDuplicateDistance)
+	NearDuplicateDistance int
+	// HostConsistencyReport reports fetches that failed because the TLS
+	// certificate does not cover the hostname, redirects between www and
+	// apex host variants (flagging pairs redirected both ways), and redirect
+	// chains that return to a host they already left
+	HostConsistencyReport bool
+	// BudgetReport breaks down where the page budget went: every fetched
+	// page counted by first path segment, by link depth, and by content type
+	BudgetReport bool
+	// LinkDetails adds a "link_details" array to JSON output records, giving
+	// each link's absolute URL, anchor text, rel, and tag so consumers can
+	// filter links without re-parsing. Requires OutputFormat "json" and a
+	// MetadataParser.
+	LinkDetails bool
+	// CaptureHeaders adds the listed response headers, e.g. Cache-Control,
+	// Server, or X-Frame-Options, to the "headers" object of JSON output
+	// records, keyed by canonical name. Repeated headers are joined with
+	// ", "; headers the response lacks are left out. Requires OutputFormat
+	// "json".
+	CaptureHeaders []string
+	// ExtractText adds each page's visible body text, with scripts, styles,
+	// and other invisible elements stripped and whitespace collapsed, to the
+	// "text" field of JSON output records. Requires OutputFormat "json" and
+	// a MetadataParser.
+	ExtractText bool
+	// HarvestMetadata adds each page's OpenGraph properties ("opengraph",
+	// e.g. og:title and og:image) and JSON-LD blocks ("structured_data",
+	// embedded as JSON; blocks that don't parse are left out) to JSON output
+	// records. Requires OutputFormat "json" and a MetadataParser.
+	HarvestMetadata bool
+	// IncludeHTML embeds each page's raw HTML in the "html" field of JSON
+	// output records. Requires OutputFormat "json".
+	IncludeHTML bool
+	// HTMLMaxBytes truncates embedded HTML to this many bytes, marking the
+	// record "html_truncated" (0 = no limit)
+	HTMLMaxBytes int
+	// HTMLBase64 base64-encodes embedded HTML, marking the record
+	// "html_base64", so the body round-trips byte for byte
+	HTMLBase64 bool
+	// ResourceHintsReport audits each page's <link> preload, modulepreload,
+	// prefetch, preconnect, and dns-prefetch hints: in-scope resources that
+	// hints download are crawled to check they exist, and preloads the page
+	// doesn't reference or connection hints to origins it loads no assets
+	// from are reported as unused. Requires a MetadataParser.
+	ResourceHintsReport bool
 }
 
 // NewCoordinator creates a new Coordinator with the given configuration.
@@ -85,6 +650,7 @@ func NewCoordinator(cfg Config) (*Coordinator, error) {
 	if !ok {
 		return nil, fmt.Errorf("failed to normalize start URL")
 	}
+	normalizedStart = StripQueryParams(normalizedStart, cfg.StripParams)
 
 	// Re-parse the normalized URL
 	startURL, err = url.Parse(normalizedStart)
@@ -97,55 +663,320 @@ func NewCoordinator(cfg Config) (*Coordinator, error) {
 		output = os.Stdout
 	}
 
+	include, err := compilePatterns(cfg.Include)
+	if err != nil {
+		return nil, fmt.Errorf("Include: %w", err)
+	}
+	exclude, err := compilePatterns(cfg.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("Exclude: %w", err)
+	}
+
+	filters, err := newFilterStages(cfg.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("Filters: %w", err)
+	}
+
+	pathBudgets, err := compilePathBudgets(cfg.PathBudgets)
+	if err != nil {
+		return nil, err
+	}
+
+	var languages []string
+	for _, lang := range cfg.Languages {
+		if lang = strings.ToLower(strings.TrimSpace(lang)); lang != "" {
+			languages = append(languages, lang)
+		}
+	}
+
+	redirectMapFormat := cfg.RedirectMapFormat
+	if redirectMapFormat == "" {
+		redirectMapFormat = RedirectMapNginx
+	}
+	if redirectMapFormat != RedirectMapNginx && redirectMapFormat != RedirectMapApache && redirectMapFormat != RedirectMapNetlify {
+		return nil, fmt.Errorf("unknown redirect map format %q", redirectMapFormat)
+	}
+
+	if cfg.BreakerThreshold < 0 {
+		return nil, fmt.Errorf("BreakerThreshold cannot be negative, got %d", cfg.BreakerThreshold)
+	}
+	breakerCooldown := cfg.BreakerCooldown
+	if breakerCooldown <= 0 {
+		breakerCooldown = DefaultBreakerCooldown
+	}
+
+	if cfg.MaxURLLength < 0 || cfg.MaxQueryParams < 0 {
+		return nil, fmt.Errorf("MaxURLLength and MaxQueryParams cannot be negative")
+	}
+
+	scope := cfg.Scope
+	if scope == nil {
+		scope = HostScope(startURL.Hostname())
+	}
+
+	nearDupDistance := cfg.NearDuplicateDistance
+	if nearDupDistance < 0 || nearDupDistance > 64 {
+		return nil, fmt.Errorf("NearDuplicateDistance must be between 0 and 64, got %d", nearDupDistance)
+	}
+	if nearDupDistance == 0 {
+		nearDupDistance = DefaultNearDuplicateDistance
+	}
+
+	tracerProvider := cfg.TracerProvider
+	if tracerProvider == nil {
+		tracerProvider = noop.NewTracerProvider()
+	}
+
+	pageRankFormat := cfg.PageRankFormat
+	if pageRankFormat == "" {
+		pageRankFormat = PageRankCSV
+	}
+	if pageRankFormat != PageRankCSV && pageRankFormat != PageRankJSON {
+		return nil, fmt.Errorf("unknown PageRank format %q", pageRankFormat)
+	}
+
 	outputFormat := cfg.OutputFormat
 	if outputFormat == "" {
 		outputFormat = "text"
 	}
+	if outputFormat == "ndjson" {
+		outputFormat = "json"
+	}
+	// Fields that only exist in PageResult need a consumer of PageResults
+	structured := outputFormat == "json" || cfg.Pages != nil
+	if cfg.IncludeHTML && !structured {
+		return nil, fmt.Errorf("IncludeHTML requires JSON output or Pages")
+	}
+	if cfg.LinkDetails && !structured {
+		return nil, fmt.Errorf("LinkDetails requires JSON output or Pages")
+	}
+	if len(cfg.CaptureHeaders) > 0 && !structured {
+		return nil, fmt.Errorf("CaptureHeaders requires JSON output or Pages")
+	}
+	if cfg.CrawlMetadata && outputFormat != "json" {
+		return nil, fmt.Errorf("CrawlMetadata requires JSON output")
+	}
+	if cfg.MaxErrors < 0 || cfg.MaxConsecutiveErrors < 0 {
+		return nil, fmt.Errorf("MaxErrors and MaxConsecutiveErrors cannot be negative")
+	}
+	if cfg.MaxErrorRate < 0 || cfg.MaxErrorRate > 1 {
+		return nil, fmt.Errorf("MaxErrorRate must be between 0 and 1, got %v", cfg.MaxErrorRate)
+	}
+	captureHeaders := make([]string, len(cfg.CaptureHeaders))
+	for i, name := range cfg.CaptureHeaders {
+		captureHeaders[i] = http.CanonicalHeaderKey(strings.TrimSpace(name))
+	}
+	if cfg.ExtractText && !structured {
+		return nil, fmt.Errorf("ExtractText requires JSON output or Pages")
+	}
+	if cfg.HarvestMetadata && !structured {
+		return nil, fmt.Errorf("HarvestMetadata requires JSON output or Pages")
+	}
+	if cfg.HTMLMaxBytes < 0 {
+		return nil, fmt.Errorf("HTMLMaxBytes cannot be negative, got %d", cfg.HTMLMaxBytes)
+	}
+	if cfg.MaxPagesPerSec < 0 {
+		return nil, fmt.Errorf("MaxPagesPerSec cannot be negative, got %v", cfg.MaxPagesPerSec)
+	}
+	var pageInterval time.Duration
+	if cfg.MaxPagesPerSec > 0 {
+		pageInterval = time.Duration(float64(time.Second) / cfg.MaxPagesPerSec)
+	}
+	if cfg.OutputBuffer < 0 {
+		return nil, fmt.Errorf("OutputBuffer cannot be negative, got %d", cfg.OutputBuffer)
+	}
 
-	// Buffer workCh to avoid deadlock when coordinator enqueues multiple URLs
-	// before workers can pick them up. Buffer size is generous to handle
-	// pages with many links.
-	bufferSize := cfg.NumWorkers * 100
-	if bufferSize < 100 {
-		bufferSize = 100
+	// Reproducible mode: a single worker keeps fetch order equal to enqueue
+	// order, and the seeded source makes the enqueue order itself repeatable.
+	numWorkers := cfg.NumWorkers
+	var rng *rand.Rand
+	if cfg.Seed != 0 {
+		numWorkers = 1
+		rng = rand.New(rand.NewSource(cfg.Seed))
+	}
+
+	var autoscale *autoscaler
+	if cfg.MinWorkers < 0 || cfg.MinWorkers > max(cfg.NumWorkers, 1) {
+		return nil, fmt.Errorf("MinWorkers must be between 0 and NumWorkers (%d), got %d", cfg.NumWorkers, cfg.MinWorkers)
+	}
+	if cfg.MinWorkers > 0 && !cfg.AutoScale {
+		return nil, fmt.Errorf("MinWorkers requires AutoScale")
+	}
+	if cfg.AutoScale {
+		autoscale = newAutoscaler(min(max(cfg.MinWorkers, 1), max(numWorkers, 1)), max(numWorkers, 1))
+	}
+
+	pending := cfg.Frontier
+	if pending == nil {
+		pending = &queueFrontier{}
+	}
+
+	var budget *budgetBreakdown
+	if cfg.BudgetReport {
+		budget = newBudgetBreakdown()
+	}
+
+	fetcher := cfg.Fetcher
+	if cfg.Pool != nil {
+		if cfg.Pool.Size() <= 0 {
+			return nil, fmt.Errorf("worker pool size must be positive, got %d", cfg.Pool.Size())
+		}
+		fetcher = &pooledFetcher{pool: cfg.Pool, base: fetcher}
 	}
 
 	return &Coordinator{
-		visited:      make(map[string]bool),
-		workCh:       make(chan WorkItem, bufferSize),
-		resultsCh:    make(chan Result),
-		fetcher:      cfg.Fetcher,
-		parser:       cfg.Parser,
-		startURL:     startURL,
-		startHost:    startURL.Hostname(),
-		maxPages:     cfg.MaxPages,
-		numWorkers:   cfg.NumWorkers,
-		output:       output,
-		outputFormat: outputFormat,
+		visited:           make(map[string]bool),
+		workCh:            make(chan WorkItem),
+		resultsCh:         make(chan Result),
+		fetcher:           fetcher,
+		parser:            cfg.Parser,
+		startURL:          startURL,
+		startHost:         startURL.Hostname(),
+		scope:             scope,
+		maxPages:          cfg.MaxPages,
+		numWorkers:        numWorkers,
+		autoscale:         autoscale,
+		pageInterval:      pageInterval,
+		output:            output,
+		outputBuffer:      cfg.OutputBuffer,
+		outputFormat:      outputFormat,
+		pages:             cfg.Pages,
+		pathBudgets:       pathBudgets,
+		checkExternal:     cfg.CheckExternal,
+		externalChecks:    make(map[string]*externalCheck),
+		breakerThreshold:  cfg.BreakerThreshold,
+		breakerCooldown:   breakerCooldown,
+		breakers:          make(map[string]*hostBreaker),
+		breakerSkipped:    make(map[string]bool),
+		now:               time.Now,
+		previous:          cfg.PreviousCrawl,
+		stateOut:          cfg.CrawlState,
+		checkpointOut:     cfg.CheckpointOut,
+		frontier:          make(map[string]FrontierItem),
+		resume:            cfg.Resume,
+		progressCh:        make(chan struct{}, 1),
+		pauseCh:           make(chan struct{}, 1),
+		tracer:            tracerProvider.Tracer(tracerName),
+		logger:            cfg.Logger,
+		state:             make(map[string]PageState),
+		onResult:          cfg.OnResult,
+		onLinkDiscovered:  cfg.OnLinkDiscovered,
+		onBeforeFetch:     cfg.OnBeforeFetch,
+		rng:               rng,
+		pending:           pending,
+		events:            cfg.Events,
+		auditLog:          cfg.AuditLog,
+		errorReport:       cfg.ErrorReport,
+		auditConfig:       cfg.AuditConfig,
+		auditOverrides:    cfg.AuditOverrides,
+		crawlMetadata:     cfg.CrawlMetadata,
+		crawlerVersion:    cfg.CrawlerVersion,
+		languages:         languages,
+		include:           include,
+		exclude:           exclude,
+		stripParams:       cfg.StripParams,
+		sortQuery:         cfg.SortQuery,
+		foldPaths:         cfg.CaseInsensitivePaths,
+		maxURLLength:      cfg.MaxURLLength,
+		maxQueryParams:    cfg.MaxQueryParams,
+		guardSkipped:      make(map[string]bool),
+		filters:           filters,
+		detectLang:        cfg.DetectLanguage,
+		slowTopN:          cfg.SlowPagesTopN,
+		slowThreshold:     cfg.SlowPageThreshold,
+		largeTopN:         cfg.LargePagesTopN,
+		largeThreshold:    cfg.LargePageThreshold,
+		referrers:         make(map[string][]string),
+		brokenLinksReport: cfg.BrokenLinksReport,
+		failOnBrokenLinks: cfg.FailOnBrokenLinks,
+		maxErrorRate:      cfg.MaxErrorRate,
+		maxErrors:         cfg.MaxErrors,
+		maxErrorStreak:    cfg.MaxConsecutiveErrors,
+		noindexMinLinks:   cfg.NoindexMinLinks,
+		nofollowReport:    cfg.NofollowReport,
+		inbound:           make(map[string]map[string]bool),
+		checkFragments:    cfg.CheckFragments,
+		anchors:           make(map[string]map[string]bool),
+		index:             cfg.Index,
+		includeAssets:     cfg.IncludeAssets || cfg.FollowAssets,
+		followAssets:      cfg.FollowAssets,
+		externalReport:    cfg.ExternalDomainsReport,
+		externalDomains:   make(map[string]*externalDomain),
+		validateSchema:    cfg.ValidateStructuredData,
+		dedupCanonical:    cfg.DedupCanonical,
+		canonicals:        make(map[string]*canonicalGroup),
+		nearDuplicates:    cfg.NearDuplicates,
+		nearDupDistance:   nearDupDistance,
+		redirectMap:       cfg.RedirectMap,
+		redirectMapFormat: redirectMapFormat,
+		redirectRules:     make(map[string]redirectRule),
+		graph:             cfg.Graph,
+		graphEdges:        make(map[string]map[string]bool),
+		linkStatsTopN:     cfg.LinkStatsTopN,
+		pageRankOut:       cfg.PageRank,
+		pageRankFormat:    pageRankFormat,
+		sitemap:           cfg.Sitemap,
+		sitemapPages:      make(map[string]sitemapEntry),
+		junit:             cfg.JUnit,
+		redirectsSkipped:  make(map[string]bool),
+		respectRobots:     cfg.RespectRobotsMeta,
+		hostReport:        cfg.HostConsistencyReport,
+		certMismatches:    make(map[string]certMismatch),
+		hostHops:          make(map[hostHop]int),
+		budget:            budget,
+		linkDetails:       cfg.LinkDetails,
+		captureHeaders:    captureHeaders,
+		extractText:       cfg.ExtractText,
+		harvestMetadata:   cfg.HarvestMetadata,
+		includeHTML:       cfg.IncludeHTML,
+		htmlMaxBytes:      cfg.HTMLMaxBytes,
+		htmlBase64:        cfg.HTMLBase64,
+		hintAudit:         cfg.ResourceHintsReport,
+		hintCounts:        make(map[string]int),
+		hintFailed:        make(map[string]string),
 	}, nil
 }
 
 // Crawl starts the crawl and blocks until completion.
 // Respects context cancellation for graceful shutdown.
+// A crawl that breaks a quality threshold returns ErrThresholdExceeded
+// after all output and reports are written.
 func (c *Coordinator) Crawl(ctx context.Context) error {
-	startTime := time.Now()
+	c.startTime = time.Now()
+	c.live.start.Store(c.startTime.UnixNano())
+	ctx, c.crawlSpan = c.tracer.Start(ctx, "crawl", trace.WithAttributes(attribute.String("url.full", c.startURL.String())))
+	defer c.crawlSpan.End()
+	c.emit(Event{Type: EventCrawlStarted, URL: c.startURL.String()})
+	c.audit(AuditEntry{Decision: AuditCrawlStarted, Config: c.auditConfig, Overrides: c.auditOverrides})
+	if c.outputBuffer > 0 {
+		c.output = newAsyncOutput(c.output, c.outputBuffer)
+		defer c.closeOutput()
+	}
+	c.printCrawlHeader()
 
 	// Track when workers exit so we can close resultsCh
 	var workerWg sync.WaitGroup
 
-	// Seed the first URL BEFORE starting closer
+	// Seed the first URL (or the resumed frontier) BEFORE starting closer
 	// Mark as visited and add to WaitGroup
-	startKey := Key(c.startURL.String())
-	c.visited[startKey] = true
-	c.visitCount++
-	c.wg.Add(1) // MUST happen before starting closer goroutine
+	seed := WorkItem{URL: c.startURL.String(), Validators: c.validatorsFor(c.startURL.String())}
+	if c.resume != nil {
+		c.restoreCheckpoint()
+	} else {
+		c.startPageSpan(&seed)
+		c.visited[c.key(seed.URL)] = true
+		c.visitCount++
+		c.wg.Add(1) // MUST happen before starting closer goroutine
+		c.track(seed)
+		c.audit(AuditEntry{Decision: AuditSeed, URL: seed.URL})
+	}
 
 	// Start workers
 	for i := 0; i < c.numWorkers; i++ {
 		workerWg.Add(1)
 		go func() {
 			defer workerWg.Done()
-			worker(ctx, c.workCh, c.resultsCh, c.fetcher, c.parser)
+			worker(ctx, c.workCh, c.resultsCh, c.fetcher, c.parser, c.prepare)
 		}()
 	}
 
@@ -165,30 +996,83 @@ func (c *Coordinator) Crawl(ctx context.Context) error {
 
 	// Enqueue the first work item
 	// wg.Add(1) was already called above
-	select {
-	case c.workCh <- WorkItem{URL: c.startURL.String()}:
-		// Successfully enqueued
-	case <-ctx.Done():
-		// Context cancelled before we could start
-		c.wg.Done()
-		return ctx.Err()
+	if c.resume == nil {
+		select {
+		case c.workCh <- seed:
+			c.inFlight++
+			c.pageDispatched(seed)
+			// Successfully enqueued
+		case <-ctx.Done():
+			// Context cancelled before we could start
+			c.wg.Done()
+			c.audit(AuditEntry{Decision: AuditCrawlFinished, Reason: "cancelled"})
+			c.printCrawlSummary("cancelled", time.Since(c.startTime))
+			c.writeCheckpoint()
+			return ctx.Err()
+		}
 	}
 
 	// Process results until all workers are done
 	c.processResults(ctx)
+	c.publishProgress()
+
+	c.printBrokenLinks()
+	c.printDeadExternalLinks()
+
+	duration := time.Since(c.startTime)
+	finish := "completed"
+	if c.abortReason != "" {
+		finish = "aborted"
+	} else if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		finish = "deadline exceeded"
+	} else if ctx.Err() != nil {
+		finish = "cancelled"
+	}
+	c.printCrawlSummary(finish, duration)
+	c.closeOutput()
 
 	// Print summary to stderr
-	duration := time.Since(startTime)
-	log.Printf("\n=== Crawl Summary ===")
-	log.Printf("Total pages visited: %d", c.visitCount)
-	log.Printf("Total errors: %d", c.errorCount)
-	log.Printf("Duration: %v", duration)
+	c.emit(Event{Type: EventCrawlFinished, DurationMs: duration.Milliseconds()})
+	c.audit(AuditEntry{Decision: AuditCrawlFinished, Reason: finish})
+	rate := 0.0
 	if duration.Seconds() > 0 {
-		rate := float64(c.visitCount) / duration.Seconds()
-		log.Printf("Rate: %.2f pages/sec", rate)
+		rate = math.Round(float64(c.visitCount)/duration.Seconds()*100) / 100
 	}
+	c.log().Info("Crawl summary", "reason", finish, "pages", c.visitCount, "errors", c.errorCount,
+		"duration", duration, "pages_per_sec", rate)
+	c.logSlowPages()
+	c.logLargePages()
+	c.logNoindexLinked()
+	c.logNofollowOnly()
+	c.logBrokenFragments()
+	c.logExternalDomains()
+	c.logSchemaIssues()
+	c.logCanonicalDuplicates()
+	c.logNearDuplicates()
+	c.logHostConsistency()
+	c.logBudget()
+	c.logPathBudgets()
+	c.logResourceHints()
+	c.logLinkStats()
+	c.logUnchanged()
+	c.logBreakerSkips()
+	c.logGuardSkips()
+	c.logFilterSkips()
+	c.logAutoscale()
+	c.logDownloadBudget()
+	c.logAbort()
+	c.writeRedirectMap()
+	c.writeGraph()
+	c.writeSitemap()
+	c.writeJUnit(duration)
+	c.writePageRank()
+	c.writeCrawlState(ctx.Err() == nil && !c.budgetReached && !c.downloadBudgetHit && c.abortReason == "" && !c.pathBudgetsExhausted())
+	c.writeCheckpoint()
 
-	return nil
+	if err := c.abortError(); err != nil {
+		return err
+	}
+	return c.checkThresholds()
 }
 
 // processResults is the main loop that processes results from workers.
@@ -198,11 +1082,58 @@ func (c *Coordinator) Crawl(ctx context.Context) error {
 // 3. Enqueues new in-scope, unvisited URLs
 // 4. Calls wg.Done()
 //
+// Progress requests (see RequestProgress) are answered between results.
+//
 // This blocks until resultsCh is closed (which happens after all workers exit).
 // Respects context cancellation and stops scheduling new work when cancelled.
 func (c *Coordinator) processResults(ctx context.Context) {
-	for result := range c.resultsCh {
-		c.processResult(ctx, result)
+	for {
+		c.publishProgress()
+
+		// Without pending work or a free worker, or while paused, just wait
+		// for the next result; under a page rate, wait for the next slot as
+		// well
+		c.dropUnfetched()
+		next, ok := c.pending.Peek()
+		c.releaseLostWork()
+		paused := c.dispatchPaused()
+		var slot <-chan time.Time
+		if ok && !paused && c.workerAvailable() {
+			if delay := c.pageDelay(next); delay > 0 {
+				slot = time.After(delay)
+			}
+		}
+		if !ok || paused || !c.workerAvailable() || slot != nil {
+			select {
+			case result, ok := <-c.resultsCh:
+				if !ok {
+					return
+				}
+				c.handleResult(ctx, result)
+			case <-c.progressCh:
+				c.logProgress()
+			case <-c.pauseCh:
+			case <-slot:
+			}
+			continue
+		}
+
+		// Hand pending work to the worker while still accepting results, so
+		// the worker is never blocked sending a result we are not reading
+		select {
+		case c.workCh <- next:
+			c.pending.Pop()
+			c.inFlight++
+			c.pageDispatched(next)
+		case result, ok := <-c.resultsCh:
+			if !ok {
+				return
+			}
+			c.handleResult(ctx, result)
+		case <-c.progressCh:
+			c.logProgress()
+		case <-c.pauseCh:
+		}
 	}
 }
 
@@ -210,10 +1141,45 @@ func (c *Coordinator) processResults(ctx context.Context) {
 // This is where the termination invariant is enforced.
 // Stops scheduling new work if context is cancelled.
 func (c *Coordinator) processResult(ctx context.Context, result Result) {
+	c.inFlight--
+	c.scaleWorkers(result)
+	if c.downloadBudgetSpent(result) {
+		return
+	}
+
+	// External link checks only have an outcome to record
+	if result.CheckOnly {
+		c.recordExternalCheck(result)
+		c.wg.Done()
+		return
+	}
+
+	// The body's buffer is reused once the result is fully processed
+	defer result.releaseBody()
+
+	c.log().Debug("Result received", "url", result.URL, "depth", result.Depth, "status", result.StatusCode, "duration", result.FetchDuration)
+
+	// The page leaves the frontier; interrupted pages are put back below
+	c.settle(result)
+
+	// Unchanged pages continue the crawl with their previous links
+	c.restoreUnchanged(&result)
+
+	// Let the embedder enrich or rewrite the result first
+	if c.onResult != nil {
+		c.onResult(&result)
+		result.prepared = false
+	}
+
+	// Links restored or rewritten above weren't prepared by the worker
+	if !result.prepared && result.Err == nil {
+		c.prepareLinks(&result)
+	}
+
 	// Handle redirects: if FinalURL differs from URL and FinalURL was already
 	// visited (via a direct link), skip printing to avoid duplicates.
 	// We still process the result and call wg.Done() to maintain invariant.
-	finalKey := Key(result.FinalURL)
+	finalKey := c.key(result.FinalURL)
 	alreadyPrinted := result.URL != result.FinalURL && c.visited[finalKey]
 
 	// Mark the final URL as visited to prevent duplicate fetches
@@ -222,57 +1188,189 @@ func (c *Coordinator) processResult(ctx context.Context, result Result) {
 		c.visited[finalKey] = true
 	}
 
+	// Record permanent redirects before any of the skips below, so redirects
+	// landing on filtered, duplicate, or failed pages still reach the map
+	c.recordRedirects(result)
+	c.recordBreaker(result)
+	c.recordHostIssues(result)
+	c.recordBudget(result)
+	c.recordHintOutcome(result)
+
+	// Guess the language of pages that don't declare one
+	if c.detectLang && result.Err == nil && result.Lang == "" {
+		if lang := detectLanguage(result.Text); lang != "" {
+			result.Lang = lang
+			result.LangDetected = true
+		}
+	}
+
+	// Skip pages in languages outside the filter: not printed, not expanded
+	if result.Err == nil && !c.langAllowed(result.Lang) {
+		c.log().Info("Skipping page: language not in filter", "url", result.FinalURL, "lang", result.Lang)
+		c.audit(AuditEntry{Decision: AuditSkipped, URL: result.FinalURL, Reason: fmt.Sprintf("language %q not in filter", result.Lang)})
+		c.wg.Done()
+		return
+	}
+
+	// Skip pages whose canonical URL another page already claimed
+	if result.Err == nil && c.isCanonicalDuplicate(result) {
+		c.wg.Done()
+		return
+	}
+
 	// Print the page (even on error), unless it's a redirect to an already-visited page
 	if !alreadyPrinted {
 		c.printResult(result)
+		c.recordJUnit(result)
 	}
 
 	// If there was an error, log it and don't enqueue new work
 	if result.Err != nil {
-		c.logError(result.URL, result.Err)
+		c.logError(result.URL, result.Referrer, result.Err)
 		c.errorCount++
+		c.countFailure(ctx, result)
+		c.recordBrokenLink(result)
+		c.recordErrorReport(result)
+		if interrupted(ctx, result.Err) {
+			c.unsettle(result)
+		}
+		c.emit(Event{Type: EventPageFailed, URL: result.URL, Referrer: result.Referrer, Error: result.Err.Error()})
 		c.wg.Done()
 		return
 	}
 
+	c.emit(Event{Type: EventPageFetched, URL: result.FinalURL, Referrer: result.Referrer})
+	c.errorStreak = 0
+	if c.respectRobots && result.NoIndex {
+		c.audit(AuditEntry{Decision: AuditMarkedNoindex, URL: result.FinalURL, Reason: "robots noindex"})
+	}
+	c.recordStats(result)
+	c.recordNoindex(result)
+	c.recordFragments(result)
+	c.writeIndexDoc(result)
+	c.recordFingerprint(result)
+	c.recordExternals(result)
+	c.recordGraph(result)
+	c.recordSitemap(result)
+	c.recordState(result)
+	c.recordSchemaIssues(result)
+	c.recordResourceHints(result)
+
 	// Check if context is cancelled - don't schedule new work
 	select {
 	case <-ctx.Done():
 		// Context cancelled - stop scheduling new work
+		c.unsettle(result)
 		c.wg.Done()
 		return
 	default:
 		// Continue processing
 	}
 
-	// Sanitize all links (use FinalURL for base URL resolution after redirects)
-	sanitized := c.sanitizeLinks(result.Links, result.FinalURL)
+	// Pages that ask not to be followed are printed but not expanded
+	if c.respectRobots && result.NoFollow {
+		c.log().Info("Not following links: robots nofollow", "url", result.FinalURL)
+		c.audit(AuditEntry{Decision: AuditNotFollowed, URL: result.FinalURL, Reason: "robots nofollow"})
+		c.wg.Done()
+		return
+	}
+
+	// Sanitized links (use FinalURL for base URL resolution after
+	// redirects), copied since they are appended to and shuffled below
+	links, keys := c.pageLinks(result)
+	sanitized := slices.Clone(links)
+	linkKeys := slices.Clone(keys)
+	var extra []string
+	if c.followAssets {
+		for _, asset := range c.sanitizeAssets(result.Assets, c.linkBase(result)) {
+			extra = append(extra, asset.URL)
+		}
+	}
+	extra = append(extra, c.hintTargets(result)...)
+	for _, link := range extra {
+		sanitized = append(sanitized, link)
+		linkKeys = append(linkKeys, c.key(link))
+	}
+
+	// In reproducible mode, shuffle the scheduling order with the seeded source
+	if c.rng != nil {
+		c.rng.Shuffle(len(sanitized), func(i, j int) {
+			sanitized[i], sanitized[j] = sanitized[j], sanitized[i]
+			linkKeys[i], linkKeys[j] = linkKeys[j], linkKeys[i]
+		})
+	}
+
+	nofollow := c.nofollowSet(result)
 
 	// For each sanitized link, check scope and visited
-	for _, link := range sanitized {
+	for i, link := range sanitized {
 		// Check if context is cancelled before enqueueing each link
 		select {
 		case <-ctx.Done():
 			// Context cancelled - stop scheduling new work
+			c.unsettle(result)
 			c.wg.Done()
 			return
 		default:
 			// Continue
 		}
 
-		// Check if in scope
-		if !InScope(link, c.startHost) {
+		if c.onLinkDiscovered != nil {
+			c.onLinkDiscovered(result.FinalURL, link)
+		}
+
+		// Check if in scope; out-of-scope links may still be checked
+		if !c.scope.InScope(link) {
+			c.scheduleExternalCheck(link, result.FinalURL, result.Depth+1)
 			continue
 		}
 
 		// Check if already visited
-		linkKey := Key(link)
+		linkKey := linkKeys[i]
+		c.recordReferrer(linkKey, result.FinalURL)
+		c.recordInbound(linkKey, result.FinalURL, nofollow[linkKey])
 		if c.visited[linkKey] {
 			continue
 		}
 
 		// Check max pages cap
 		if c.maxPages > 0 && c.visitCount >= c.maxPages {
+			if !c.budgetReached {
+				c.budgetReached = true
+				c.emit(Event{Type: EventBudgetReached})
+				c.audit(AuditEntry{Decision: AuditBudgetReached, Reason: "max pages", Limit: c.maxPages})
+			}
+			continue
+		}
+
+		// Apply the include and exclude filters
+		if !c.patternsAllow(link) {
+			continue
+		}
+
+		// Refuse trap-shaped URLs
+		if !c.guardAllows(link) {
+			continue
+		}
+
+		// Pause hosts that keep failing
+		if c.breakerOpen(link) {
+			continue
+		}
+
+		// Let the embedder veto the URL
+		if c.onBeforeFetch != nil && !c.onBeforeFetch(link) {
+			c.audit(AuditEntry{Decision: AuditSkipped, URL: link, Reason: "rejected by OnBeforeFetch"})
+			continue
+		}
+
+		// Run the embedder's filter chain
+		if !c.filtersAllow(link, result.Depth+1) {
+			continue
+		}
+
+		// Keep unbounded URL spaces within their path budgets
+		if !c.pathBudgetAllows(link) {
 			continue
 		}
 
@@ -282,13 +1380,57 @@ func (c *Coordinator) processResult(ctx context.Context, result Result) {
 
 		// CRITICAL: wg.Add(1) BEFORE enqueuing
 		c.wg.Add(1)
-		c.workCh <- WorkItem{URL: link}
+		c.enqueue(WorkItem{URL: link, Depth: result.Depth + 1, Referrer: result.FinalURL, Validators: c.validatorsFor(link)})
 	}
 
 	// CRITICAL: wg.Done() AFTER processing result and enqueuing all derived work
 	c.wg.Done()
 }
 
+// enqueue schedules a work item. It is queued in pending, never sent to
+// workCh directly: the workers may all be blocked sending results, so a
+// blocking send would deadlock the coordinator.
+func (c *Coordinator) enqueue(item WorkItem) {
+	c.startPageSpan(&item)
+	c.track(item)
+	c.pending.Push(item)
+}
+
+// langAllowed reports whether a page language passes the language filter.
+// A configured tag matches itself and any subtag of it ("en" matches "en-gb").
+func (c *Coordinator) langAllowed(lang string) bool {
+	if len(c.languages) == 0 || lang == "" {
+		return true
+	}
+	for _, want := range c.languages {
+		if lang == want || strings.HasPrefix(lang, want+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// linkBase returns the URL that relative links on the page resolve against:
+// the page's <base href> if it declares a valid http(s) one, else FinalURL.
+func (c *Coordinator) linkBase(result Result) string {
+	if result.BaseHref == "" {
+		return result.FinalURL
+	}
+	pageURL, err := url.Parse(result.FinalURL)
+	if err != nil {
+		return result.FinalURL
+	}
+	ref, err := url.Parse(result.BaseHref)
+	if err != nil {
+		return result.FinalURL
+	}
+	base := pageURL.ResolveReference(ref)
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return result.FinalURL
+	}
+	return base.String()
+}
+
 // sanitizeLinks sanitizes raw hrefs against the page URL.
 // Returns only valid http(s) URLs.
 func (c *Coordinator) sanitizeLinks(rawHrefs []string, pageURL string) []string {
@@ -301,50 +1443,250 @@ func (c *Coordinator) sanitizeLinks(rawHrefs []string, pageURL string) []string
 
 	var sanitized []string
 	for _, href := range rawHrefs {
-		if abs, ok := Sanitize(href, base); ok {
+		if abs, ok := c.sanitize(href, base); ok {
 			sanitized = append(sanitized, abs)
 		}
 	}
 	return sanitized
 }
 
-// PageResult represents the JSON output for a single page.
+// prepare is the workers' hook: it prepares a fetched page's links off
+// the coordinator goroutine, so the URL parsing runs in parallel, and
+// hands back bodies nothing will read. Unchanged
+// pages are skipped, since their links are restored from the previous
+// crawl later.
+func (c *Coordinator) prepare(result *Result) {
+	// Only the HTML output and the result hook read the body
+	if !c.includeHTML && c.onResult == nil {
+		result.releaseBody()
+	}
+	if result.Err != nil || result.CheckOnly || result.NotModified {
+		return
+	}
+	c.prepareLinks(result)
+}
+
+// prepareLinks sanitizes a page's links and computes their dedupe keys.
+// Workers call it concurrently, so it must only read configuration fixed
+// by NewCoordinator.
+func (c *Coordinator) prepareLinks(result *Result) {
+	result.links = c.sanitizeLinks(result.Links, c.linkBase(*result))
+	result.linkKeys = make([]string, len(result.links))
+	for i, link := range result.links {
+		result.linkKeys[i] = c.key(link)
+	}
+	result.prepared = true
+}
+
+// pageLinks returns a page's sanitized links and their dedupe keys. The
+// slices are shared between every report; don't modify them.
+func (c *Coordinator) pageLinks(result Result) (links, keys []string) {
+	if !result.prepared {
+		c.prepareLinks(&result)
+	}
+	return result.links, result.linkKeys
+}
+
+// key is Key, with query parameters sorted when SortQuery is set and the
+// path lowercased when CaseInsensitivePaths is.
+func (c *Coordinator) key(rawURL string) string {
+	if c.sortQuery {
+		rawURL = SortQuery(rawURL)
+	}
+	if c.foldPaths {
+		rawURL = LowercasePath(rawURL)
+	}
+	return Key(rawURL)
+}
+
+// sanitize is Sanitize plus the configured query parameter removal.
+func (c *Coordinator) sanitize(href string, base *url.URL) (string, bool) {
+	abs, ok := Sanitize(href, base)
+	if !ok {
+		return "", false
+	}
+	return StripQueryParams(abs, c.stripParams), true
+}
+
+// sanitizeAssets sanitizes raw asset URLs against the page URL.
+// Returns only assets with valid http(s) URLs.
+func (c *Coordinator) sanitizeAssets(raw []Asset, pageURL string) []Asset {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	var sanitized []Asset
+	for _, asset := range raw {
+		if abs, ok := c.sanitize(asset.URL, base); ok {
+			sanitized = append(sanitized, Asset{Type: asset.Type, URL: abs})
+		}
+	}
+	return sanitized
+}
+
+// sanitizeLinkDetails resolves each link's href against the page URL, as
+// sanitizeLinks does, keeping the link's text, rel, and tag.
+func (c *Coordinator) sanitizeLinkDetails(raw []Link, pageURL string) []Link {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	var sanitized []Link
+	for _, link := range raw {
+		if abs, ok := c.sanitize(link.Href, base); ok {
+			link.Href = abs
+			sanitized = append(sanitized, link)
+		}
+	}
+	return sanitized
+}
+
+// PageResult represents the JSON output for a single page, also delivered
+// on Config.Pages.
 type PageResult struct {
-	URL   string   `json:"url"`
-	Links []string `json:"links"`
-	Error string   `json:"error,omitempty"`
+	URL            string            `json:"url"`
+	Referrer       string            `json:"referrer,omitempty"`
+	NotModified    bool              `json:"not_modified,omitempty"`
+	Title          string            `json:"title,omitempty"`
+	Description    string            `json:"description,omitempty"`
+	Lang           string            `json:"lang,omitempty"`
+	LangDetected   bool              `json:"lang_detected,omitempty"`
+	NoIndex        bool              `json:"noindex,omitempty"`
+	Status         int               `json:"status,omitempty"`
+	TTFBMs         float64           `json:"ttfb_ms,omitempty"`
+	DurationMs     float64           `json:"duration_ms,omitempty"`
+	Bytes          int               `json:"bytes,omitempty"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	Links          []string          `json:"links"`
+	LinkDetails    []Link            `json:"link_details,omitempty"`
+	Assets         []Asset           `json:"assets,omitempty"`
+	Redirects      []Redirect        `json:"redirects,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	Text           string            `json:"text,omitempty"`
+	OpenGraph      map[string]string `json:"opengraph,omitempty"`
+	StructuredData []json.RawMessage `json:"structured_data,omitempty"`
+	HTML           string            `json:"html,omitempty"`
+	HTMLBase64     bool              `json:"html_base64,omitempty"`
+	HTMLTruncated  bool              `json:"html_truncated,omitempty"`
+}
+
+// pageResult builds the JSON record of a page from its result and its
+// sanitized links and assets.
+func (c *Coordinator) pageResult(result Result, sanitized []string, assets []Asset) PageResult {
+	pageResult := PageResult{
+		URL:          result.FinalURL,
+		Referrer:     result.Referrer,
+		NotModified:  result.NotModified,
+		Title:        result.Title,
+		Description:  result.Description,
+		Lang:         result.Lang,
+		LangDetected: result.LangDetected,
+		NoIndex:      c.respectRobots && result.NoIndex,
+		Links:        sanitized,
+		Assets:       assets,
+		Redirects:    result.Redirects,
+	}
+	if result.Err != nil {
+		pageResult.Error = result.Err.Error()
+	}
+	// Response statistics, when a response was received
+	if result.StatusCode != 0 {
+		pageResult.Status = result.StatusCode
+		pageResult.TTFBMs = milliseconds(result.TTFB)
+		pageResult.DurationMs = milliseconds(result.FetchDuration)
+		pageResult.Bytes = result.BodySize
+	}
+	for _, name := range c.captureHeaders {
+		if values := result.Header.Values(name); len(values) > 0 {
+			if pageResult.Headers == nil {
+				pageResult.Headers = make(map[string]string)
+			}
+			pageResult.Headers[name] = strings.Join(values, ", ")
+		}
+	}
+	if c.linkDetails && result.Err == nil {
+		pageResult.LinkDetails = c.sanitizeLinkDetails(result.LinkDetails, c.linkBase(result))
+	}
+	if c.extractText {
+		pageResult.Text = result.Text
+	}
+	if c.harvestMetadata && result.Err == nil {
+		pageResult.OpenGraph = result.OpenGraph
+		for _, block := range result.StructuredData {
+			if json.Valid([]byte(block)) {
+				pageResult.StructuredData = append(pageResult.StructuredData, json.RawMessage(block))
+			}
+		}
+	}
+	if c.includeHTML {
+		pageResult.HTML, pageResult.HTMLTruncated = c.rawHTML(result.Body)
+		pageResult.HTMLBase64 = c.htmlBase64 && pageResult.HTML != ""
+	}
+	if sanitized == nil {
+		pageResult.Links = []string{} // Ensure empty array, not null
+	}
+	return pageResult
 }
 
 // printResult prints the result to stdout in the configured format (text or json).
 func (c *Coordinator) printResult(result Result) {
+	defer c.flushOutput()
+
 	// Sanitize all links (not just in-scope ones)
 	var sanitized []string
+	var assets []Asset
 	if result.Err == nil {
-		sanitized = c.sanitizeLinks(result.Links, result.FinalURL)
+		sanitized, _ = c.pageLinks(result)
+		if c.includeAssets {
+			assets = c.sanitizeAssets(result.Assets, c.linkBase(result))
+		}
+	}
+
+	var pageResult PageResult
+	if c.outputFormat == "json" || c.pages != nil {
+		pageResult = c.pageResult(result, sanitized, assets)
+	}
+	if c.pages != nil {
+		c.pages <- pageResult
 	}
 
 	if c.outputFormat == "json" {
 		// JSON output
-		pageResult := PageResult{
-			URL:   result.FinalURL,
-			Links: sanitized,
-		}
-		if result.Err != nil {
-			pageResult.Error = result.Err.Error()
-		}
-		if sanitized == nil {
-			pageResult.Links = []string{} // Ensure empty array, not null
-		}
-
 		jsonBytes, err := json.Marshal(pageResult)
 		if err != nil {
-			log.Printf("Error marshaling JSON: %v", err)
+			c.log().Error("Error marshaling JSON", "error", err)
 			return
 		}
 		fmt.Fprintf(c.output, "%s\n", jsonBytes)
 	} else {
 		// Text output (default)
 		fmt.Fprintf(c.output, "Visited: %s\n", result.FinalURL)
+		if result.StatusCode != 0 && result.Err == nil {
+			fmt.Fprintf(c.output, "Status: %d (first byte %s, total %s, %d bytes)\n",
+				result.StatusCode, result.TTFB.Round(time.Millisecond), result.FetchDuration.Round(time.Millisecond), result.BodySize)
+		} else if result.StatusCode != 0 {
+			fmt.Fprintf(c.output, "Status: %d\n", result.StatusCode)
+		}
+		if result.Title != "" {
+			fmt.Fprintf(c.output, "Title: %s\n", result.Title)
+		}
+		if result.Description != "" {
+			fmt.Fprintf(c.output, "Description: %s\n", result.Description)
+		}
+		if c.respectRobots && result.NoIndex {
+			fmt.Fprintf(c.output, "Robots: noindex\n")
+		}
+		if result.NotModified {
+			fmt.Fprintf(c.output, "Not modified\n")
+		}
+		if len(result.Redirects) > 0 {
+			fmt.Fprintf(c.output, "Redirected from:\n")
+			for _, hop := range result.Redirects {
+				fmt.Fprintf(c.output, "%d %s\n", hop.StatusCode, hop.URL)
+			}
+		}
 		fmt.Fprintf(c.output, "Links found:\n")
 
 		if result.Err != nil {
@@ -355,15 +1697,78 @@ func (c *Coordinator) printResult(result Result) {
 		for _, link := range sanitized {
 			fmt.Fprintf(c.output, "%s\n", link)
 		}
+
+		if c.includeAssets {
+			fmt.Fprintf(c.output, "Assets found:\n")
+			for _, asset := range assets {
+				fmt.Fprintf(c.output, "%s %s\n", asset.Type, asset.URL)
+			}
+		}
+	}
+}
+
+// milliseconds converts a duration to fractional milliseconds, rounded to
+// microseconds, for JSON output.
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Round(time.Microsecond)) / float64(time.Millisecond)
+}
+
+// rawHTML renders a page body for the "html" JSON field, truncated to
+// htmlMaxBytes and base64-encoded if configured. Plain text is cut at a
+// UTF-8 boundary so the truncation doesn't leave a broken character.
+func (c *Coordinator) rawHTML(body []byte) (string, bool) {
+	truncated := false
+	if c.htmlMaxBytes > 0 && len(body) > c.htmlMaxBytes {
+		cut := c.htmlMaxBytes
+		if !c.htmlBase64 {
+			for cut > 0 && !utf8.RuneStart(body[cut]) {
+				cut--
+			}
+		}
+		body = body[:cut]
+		truncated = true
+	}
+	if c.htmlBase64 {
+		return base64.StdEncoding.EncodeToString(body), truncated
 	}
+	return string(body), truncated
 }
 
-// logError logs an error to stderr with appropriate categorization.
+// logError logs a failed page with its error category.
 // All logging is done by the coordinator, not by workers.
-func (c *Coordinator) logError(url string, err error) {
-	if httpErr, ok := err.(*HTTPError); ok {
-		log.Printf("Failed to fetch %s: %s [%s]", url, httpErr.Error(), httpErr.Category())
-	} else {
-		log.Printf("Failed to fetch %s: %v", url, err)
+func (c *Coordinator) logError(url, referrer string, err error) {
+	attrs := []any{"url", url}
+	if referrer != "" {
+		attrs = append(attrs, "referrer", referrer)
+	}
+	attrs = append(attrs, "error", err, "category", errorCategory(err))
+	c.log().Warn("Failed to fetch", attrs...)
+}
+
+// Flusher is implemented by buffered outputs such as *bufio.Writer.
+type Flusher interface {
+	Flush() error
+}
+
+// closeOutput waits for an asynchronous output to write everything queued,
+// so the records are out before the summary. It is safe to call twice.
+func (c *Coordinator) closeOutput() {
+	a, ok := c.output.(*asyncOutput)
+	if !ok {
+		return
+	}
+	c.output = a.out
+	if err := a.Close(); err != nil {
+		c.log().Error("Error writing output", "error", err)
+	}
+}
+
+// flushOutput pushes a just-printed record through a buffered output so
+// consumers reading a pipe see it immediately.
+func (c *Coordinator) flushOutput() {
+	if f, ok := c.output.(Flusher); ok {
+		if err := f.Flush(); err != nil {
+			c.log().Error("Error flushing output", "error", err)
+		}
 	}
 }
@@ -1,14 +1,20 @@
 package crawler
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"net/url"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewCoordinator_ValidatesStartURL(t *testing.T) {
@@ -315,6 +321,66 @@ func TestCoordinator_RespectsScope(t *testing.T) {
 	// Should NOT visit external or subdomain (verify by checking visitCount is 2, not 3 or 4)
 }
 
+func TestCoordinator_ScopePolicy(t *testing.T) {
+	output := &bytes.Buffer{}
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":        []byte("root"),
+			"https://example.com/about":   []byte("about"),
+			"https://example.com/admin/x": []byte("admin"),
+			"https://docs.example.org/":   []byte("docs"),
+			"https://unrelated.example/":  []byte("unrelated"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/about", "/admin/x", "https://docs.example.org/", "https://unrelated.example/"},
+		},
+	}
+
+	// Allow two hosts, but not the admin section of the first
+	scope := ScopeFunc(func(link string) bool {
+		u, err := url.Parse(link)
+		if err != nil {
+			return false
+		}
+		switch u.Hostname() {
+		case "example.com":
+			return !strings.HasPrefix(u.Path, "/admin/")
+		case "docs.example.org":
+			return true
+		}
+		return false
+	})
+
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 2,
+		Fetcher:    fetcher,
+		Parser:     parser,
+		Output:     output,
+		Scope:      scope,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	out := output.String()
+	for _, want := range []string{"https://example.com/", "https://example.com/about", "https://docs.example.org/"} {
+		if !strings.Contains(out, "Visited: "+want+"\n") {
+			t.Errorf("output missing visit of %s:\n%s", want, out)
+		}
+	}
+	for _, skipped := range []string{"https://example.com/admin/x", "https://unrelated.example/"} {
+		if strings.Contains(out, "Visited: "+skipped) {
+			t.Errorf("%s is out of scope but was visited", skipped)
+		}
+	}
+}
+
 func TestCoordinator_RespectsMaxPages(t *testing.T) {
 	output := &bytes.Buffer{}
 	fetcher := &mockFetcher{
@@ -565,6 +631,303 @@ func TestCoordinator_JSONOutput(t *testing.T) {
 	}
 }
 
+// writeRecorder records each write it receives from a buffered writer.
+type writeRecorder struct {
+	writes []string
+}
+
+func (w *writeRecorder) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func TestCoordinator_NDJSONFlushesEachRecord(t *testing.T) {
+	rec := &writeRecorder{}
+	output := bufio.NewWriter(rec)
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":      []byte("<html>page1</html>"),
+			"https://example.com/page2": []byte("<html>page2</html>"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"<html>page1</html>": {"/page2"},
+		},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:     "https://example.com/",
+		NumWorkers:   1,
+		Fetcher:      fetcher,
+		Parser:       parser,
+		Output:       output,
+		OutputFormat: "ndjson",
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	// Each record must reach the underlying writer on its own, without
+	// waiting for the buffer to fill or for the caller to flush
+	if len(rec.writes) != 2 {
+		t.Fatalf("got %d writes, want 2 (one per record): %q", len(rec.writes), rec.writes)
+	}
+	for i, w := range rec.writes {
+		var page PageResult
+		if err := json.Unmarshal([]byte(w), &page); err != nil {
+			t.Errorf("write %d is not a JSON record: %v (%q)", i, err, w)
+		}
+		if !strings.HasSuffix(w, "\n") {
+			t.Errorf("write %d = %q, want newline-terminated", i, w)
+		}
+	}
+}
+
+func TestCoordinator_JSONOutputReferrer(t *testing.T) {
+	output := &bytes.Buffer{}
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":  []byte("root"),
+			"https://example.com/a": []byte("a"),
+			"https://example.com/b": []byte("b"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/a"},
+			"a":    {"/", "/b"},
+			"b":    {"/a"},
+		},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:     "https://example.com/",
+		NumWorkers:   1,
+		Fetcher:      fetcher,
+		Parser:       parser,
+		Output:       output,
+		OutputFormat: "json",
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	// Each page records the page that first linked to it
+	want := map[string]string{
+		"https://example.com/":  "",
+		"https://example.com/a": "https://example.com/",
+		"https://example.com/b": "https://example.com/a",
+	}
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d JSON lines, got %d: %s", len(want), len(lines), output.String())
+	}
+	for _, line := range lines {
+		var page PageResult
+		if err := json.Unmarshal([]byte(line), &page); err != nil {
+			t.Fatalf("failed to parse JSON line: %v", err)
+		}
+		if page.Referrer != want[page.URL] {
+			t.Errorf("%s: Referrer = %q, want %q", page.URL, page.Referrer, want[page.URL])
+		}
+	}
+}
+
+func TestCoordinator_JSONOutputIncludeHTML(t *testing.T) {
+	body := "<html>café</html>"
+	tests := []struct {
+		name          string
+		maxBytes      int
+		base64        bool
+		wantHTML      string
+		wantTruncated bool
+	}{
+		{"full body", 0, false, body, false},
+		{"truncated at rune boundary", 10, false, "<html>caf", true},
+		{"base64", 0, true, "PGh0bWw+Y2Fmw6k8L2h0bWw+", false},
+		{"base64 truncated", 6, true, "PGh0bWw+", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			fetcher := &mockFetcher{
+				responses: map[string][]byte{"https://example.com/": []byte(body)},
+			}
+			coord, err := NewCoordinator(Config{
+				StartURL:     "https://example.com/",
+				NumWorkers:   1,
+				Fetcher:      fetcher,
+				Parser:       &mockMetadataParser{},
+				Output:       output,
+				OutputFormat: "json",
+				IncludeHTML:  true,
+				HTMLMaxBytes: tt.maxBytes,
+				HTMLBase64:   tt.base64,
+			})
+			if err != nil {
+				t.Fatalf("NewCoordinator() error = %v", err)
+			}
+			if err := coord.Crawl(context.Background()); err != nil {
+				t.Fatalf("Crawl() error = %v", err)
+			}
+
+			var page PageResult
+			if err := json.Unmarshal(output.Bytes(), &page); err != nil {
+				t.Fatalf("failed to parse JSON: %v\n%s", err, output.String())
+			}
+			if page.HTML != tt.wantHTML {
+				t.Errorf("HTML = %q, want %q", page.HTML, tt.wantHTML)
+			}
+			if page.HTMLTruncated != tt.wantTruncated {
+				t.Errorf("HTMLTruncated = %v, want %v", page.HTMLTruncated, tt.wantTruncated)
+			}
+			if page.HTMLBase64 != tt.base64 {
+				t.Errorf("HTMLBase64 = %v, want %v", page.HTMLBase64, tt.base64)
+			}
+		})
+	}
+}
+
+func TestCoordinator_JSONOutputLinkDetails(t *testing.T) {
+	output := &bytes.Buffer{}
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{"https://example.com/": []byte("root")},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{"root": {"/a", "javascript:void(0)", "https://other.com/b"}},
+	}
+	coord, err := NewCoordinator(Config{
+		StartURL:     "https://example.com/",
+		NumWorkers:   1,
+		Fetcher:      fetcher,
+		Parser:       parser,
+		Output:       output,
+		OutputFormat: "json",
+		MaxPages:     1,
+		LinkDetails:  true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	var page PageResult
+	if err := json.Unmarshal(output.Bytes(), &page); err != nil {
+		t.Fatalf("failed to parse JSON: %v\n%s", err, output.String())
+	}
+	want := []Link{
+		{Href: "https://example.com/a", Tag: "a"},
+		{Href: "https://other.com/b", Tag: "a"},
+	}
+	if !reflect.DeepEqual(page.LinkDetails, want) {
+		t.Errorf("LinkDetails = %+v, want %+v", page.LinkDetails, want)
+	}
+}
+
+func TestCoordinator_JSONOutputHarvestMetadata(t *testing.T) {
+	output := &bytes.Buffer{}
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{"https://example.com/": []byte("root")},
+	}
+	parser := &mockMetadataParser{
+		meta: map[string]*PageMetadata{
+			"root": {
+				OpenGraph:      map[string]string{"og:title": "Home", "og:image": "/cover.png"},
+				StructuredData: []string{`{"@type": "Article"}`, `{broken`},
+			},
+		},
+	}
+	coord, err := NewCoordinator(Config{
+		StartURL:        "https://example.com/",
+		NumWorkers:      1,
+		Fetcher:         fetcher,
+		Parser:          parser,
+		Output:          output,
+		OutputFormat:    "json",
+		HarvestMetadata: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	var page struct {
+		OpenGraph      map[string]string `json:"opengraph"`
+		StructuredData []map[string]any  `json:"structured_data"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &page); err != nil {
+		t.Fatalf("failed to parse JSON: %v\n%s", err, output.String())
+	}
+	if page.OpenGraph["og:title"] != "Home" || page.OpenGraph["og:image"] != "/cover.png" {
+		t.Errorf("opengraph = %v, want og:title and og:image", page.OpenGraph)
+	}
+	if len(page.StructuredData) != 1 || page.StructuredData[0]["@type"] != "Article" {
+		t.Errorf("structured_data = %v, want only the valid Article block", page.StructuredData)
+	}
+}
+
+func TestCoordinator_JSONOutputExtractText(t *testing.T) {
+	for _, extract := range []bool{true, false} {
+		output := &bytes.Buffer{}
+		fetcher := &mockFetcher{
+			responses: map[string][]byte{"https://example.com/": []byte("root")},
+		}
+		parser := &mockMetadataParser{
+			meta: map[string]*PageMetadata{"root": {Text: "Hello visible world"}},
+		}
+		coord, err := NewCoordinator(Config{
+			StartURL:     "https://example.com/",
+			NumWorkers:   1,
+			Fetcher:      fetcher,
+			Parser:       parser,
+			Output:       output,
+			OutputFormat: "json",
+			ExtractText:  extract,
+		})
+		if err != nil {
+			t.Fatalf("NewCoordinator() error = %v", err)
+		}
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+
+		var page PageResult
+		if err := json.Unmarshal(output.Bytes(), &page); err != nil {
+			t.Fatalf("failed to parse JSON: %v\n%s", err, output.String())
+		}
+		want := ""
+		if extract {
+			want = "Hello visible world"
+		}
+		if page.Text != want {
+			t.Errorf("ExtractText=%v: text = %q, want %q", extract, page.Text, want)
+		}
+	}
+}
+
+func TestNewCoordinator_IncludeHTMLRequiresJSON(t *testing.T) {
+	_, err := NewCoordinator(Config{
+		StartURL:    "https://example.com/",
+		NumWorkers:  1,
+		IncludeHTML: true,
+	})
+	if err == nil {
+		t.Error("NewCoordinator() with IncludeHTML and text output: expected error, got nil")
+	}
+}
+
 func TestCoordinator_JSONOutputWithError(t *testing.T) {
 	output := &bytes.Buffer{}
 	fetcher := &mockFetcher{
@@ -623,3 +986,566 @@ func TestCoordinator_JSONOutputWithError(t *testing.T) {
 		t.Errorf("errorPage.Error = %q, want to contain 'fetch failed'", errorPage.Error)
 	}
 }
+
+func TestCoordinator_SeedReproducesTraversalOrder(t *testing.T) {
+	crawlWithSeed := func(seed int64) string {
+		output := &bytes.Buffer{}
+		fetcher := &mockFetcher{
+			responses: map[string][]byte{
+				"https://example.com/":  []byte("root"),
+				"https://example.com/a": []byte("leaf"),
+				"https://example.com/b": []byte("leaf"),
+				"https://example.com/c": []byte("leaf"),
+				"https://example.com/d": []byte("leaf"),
+				"https://example.com/e": []byte("leaf"),
+			},
+		}
+		parser := &mockParser{
+			fn: func(r io.Reader) ([]string, error) {
+				body, err := io.ReadAll(r)
+				if err != nil {
+					return nil, err
+				}
+				if string(body) == "root" {
+					return []string{"/a", "/b", "/c", "/d", "/e"}, nil
+				}
+				return []string{}, nil
+			},
+		}
+
+		coord, err := NewCoordinator(Config{
+			StartURL:   "https://example.com/",
+			NumWorkers: 4,
+			Fetcher:    fetcher,
+			Parser:     parser,
+			Output:     output,
+			Seed:       seed,
+		})
+		if err != nil {
+			t.Fatalf("NewCoordinator() error = %v", err)
+		}
+		if coord.numWorkers != 1 {
+			t.Errorf("numWorkers = %d, want 1 in reproducible mode", coord.numWorkers)
+		}
+
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+		return output.String()
+	}
+
+	first := crawlWithSeed(42)
+	for i := 0; i < 5; i++ {
+		if got := crawlWithSeed(42); got != first {
+			t.Fatalf("crawl %d with same seed produced different output:\n%s\nwant:\n%s", i, got, first)
+		}
+	}
+}
+
+func TestCoordinator_ProcessResultNeverBlocks(t *testing.T) {
+	// No workers are running, so any send to workCh would block forever:
+	// scheduling must only push onto the frontier
+	const numLinks = 10000
+	var links []string
+	for i := 0; i < numLinks; i++ {
+		links = append(links, fmt.Sprintf("https://example.com/p%d", i))
+	}
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    &mockFetcher{},
+		Parser:     &mockParser{},
+		Output:     io.Discard,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	coord.wg.Add(1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		coord.processResult(context.Background(), Result{
+			URL:        "https://example.com/",
+			FinalURL:   "https://example.com/",
+			StatusCode: http.StatusOK,
+			Links:      links,
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("processResult blocked scheduling %d links", numLinks)
+	}
+	if got := coord.pending.Len(); got != numLinks {
+		t.Errorf("frontier holds %d items, want %d", got, numLinks)
+	}
+}
+
+func TestCoordinator_SingleWorkerManyLinks(t *testing.T) {
+	// Far more links than workers: the single worker is often blocked
+	// sending a result while the coordinator is scheduling
+	const numLinks = 3000
+	fetcher := &mockFetcher{responses: map[string][]byte{"https://example.com/": []byte("root")}}
+	var links []string
+	for i := 0; i < numLinks; i++ {
+		link := fmt.Sprintf("https://example.com/p%d", i)
+		links = append(links, link)
+		fetcher.responses[link] = []byte("leaf")
+	}
+	parser := &mockMetadataParser{links: map[string][]string{"root": links}}
+
+	output := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     parser,
+		Output:     output,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- coord.Crawl(context.Background()) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("crawl stalled")
+	}
+	if got := strings.Count(output.String(), "Visited:"); got != numLinks+1 {
+		t.Errorf("visited %d pages, want %d", got, numLinks+1)
+	}
+}
+
+func TestCoordinator_SeedHandlesManyLinks(t *testing.T) {
+	// Many links with the single reproducible-mode worker: the coordinator
+	// must keep accepting results while it hands out work
+	const numLinks = 300
+	fetcher := &mockFetcher{responses: map[string][]byte{"https://example.com/": []byte("root")}}
+	var links []string
+	for i := 0; i < numLinks; i++ {
+		link := fmt.Sprintf("https://example.com/p%d", i)
+		links = append(links, link)
+		fetcher.responses[link] = []byte("leaf")
+	}
+	parser := &mockParser{
+		fn: func(r io.Reader) ([]string, error) {
+			body, err := io.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+			if string(body) == "root" {
+				return links, nil
+			}
+			return []string{}, nil
+		},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 8,
+		Fetcher:    fetcher,
+		Parser:     parser,
+		Output:     io.Discard,
+		Seed:       7,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- coord.Crawl(context.Background()) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("crawl deadlocked after %d pages", coord.visitCount)
+	}
+
+	if coord.visitCount != numLinks+1 {
+		t.Errorf("visitCount = %d, want %d", coord.visitCount, numLinks+1)
+	}
+}
+
+func TestCoordinator_LanguageFilter(t *testing.T) {
+	output := &bytes.Buffer{}
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":        []byte("root"),
+			"https://example.com/en":      []byte("en-gb"),
+			"https://example.com/fr":      []byte("fr"),
+			"https://example.com/unknown": []byte(""),
+			"https://example.com/fr/deep": []byte("fr"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/en", "/fr", "/unknown"},
+			"fr":   {"/fr/deep"},
+		},
+		meta: map[string]*PageMetadata{
+			"root":  {Lang: "en"},
+			"en-gb": {Lang: "en-gb"},
+			"fr":    {Lang: "fr"},
+		},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     parser,
+		Output:     output,
+		Languages:  []string{" EN "},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	out := output.String()
+	for _, want := range []string{"https://example.com/", "https://example.com/en", "https://example.com/unknown"} {
+		if !strings.Contains(out, "Visited: "+want+"\n") {
+			t.Errorf("output missing %s", want)
+		}
+	}
+	if strings.Contains(out, "Visited: https://example.com/fr") {
+		t.Errorf("output contains page outside language filter:\n%s", out)
+	}
+}
+
+func TestCoordinator_PrintsRedirectChain(t *testing.T) {
+	redirectFetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/": []byte("<html></html>"),
+		},
+		finalURLs: map[string]string{
+			"https://example.com/": "https://example.com/home",
+		},
+		redirects: map[string][]Redirect{
+			"https://example.com/": {
+				{URL: "https://example.com/", StatusCode: 301},
+				{URL: "https://example.com/index", StatusCode: 302},
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		check  func(t *testing.T, out string)
+	}{
+		{
+			name:   "text",
+			format: "text",
+			check: func(t *testing.T, out string) {
+				want := "Visited: https://example.com/home\n" +
+					"Redirected from:\n" +
+					"301 https://example.com/\n" +
+					"302 https://example.com/index\n" +
+					"Links found:\n"
+				if out != want {
+					t.Errorf("output = %q, want %q", out, want)
+				}
+			},
+		},
+		{
+			name:   "json",
+			format: "json",
+			check: func(t *testing.T, out string) {
+				var page PageResult
+				if err := json.Unmarshal([]byte(strings.TrimSpace(out)), &page); err != nil {
+					t.Fatalf("failed to parse JSON: %v", err)
+				}
+				if len(page.Redirects) != 2 {
+					t.Fatalf("got %d redirects, want 2", len(page.Redirects))
+				}
+				if page.Redirects[1].URL != "https://example.com/index" || page.Redirects[1].StatusCode != 302 {
+					t.Errorf("Redirects[1] = %+v, want {https://example.com/index 302}", page.Redirects[1])
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			coord, err := NewCoordinator(Config{
+				StartURL:     "https://example.com/",
+				NumWorkers:   1,
+				Fetcher:      redirectFetcher,
+				Parser:       &mockParser{},
+				Output:       output,
+				OutputFormat: tt.format,
+			})
+			if err != nil {
+				t.Fatalf("NewCoordinator() error = %v", err)
+			}
+			if err := coord.Crawl(context.Background()); err != nil {
+				t.Fatalf("Crawl() error = %v", err)
+			}
+			tt.check(t, output.String())
+		})
+	}
+}
+
+func TestCoordinator_Assets(t *testing.T) {
+	newFetcher := func() *mockFetcher {
+		return &mockFetcher{
+			responses: map[string][]byte{
+				"https://example.com/":       []byte("root"),
+				"https://example.com/page":   []byte("page"),
+				"https://example.com/app.js": []byte("js"),
+			},
+			contentTypes: map[string]string{
+				"https://example.com/app.js": "application/javascript",
+			},
+		}
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{"root": {"/page"}},
+		meta: map[string]*PageMetadata{
+			"root": {Assets: []Asset{
+				{Type: "script", URL: "/app.js#v2"},
+				{Type: "img", URL: "https://CDN.example.com/logo.png"},
+			}},
+		},
+	}
+
+	t.Run("printed but not followed", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		coord, err := NewCoordinator(Config{
+			StartURL:      "https://example.com/",
+			NumWorkers:    1,
+			Fetcher:       newFetcher(),
+			Parser:        parser,
+			Output:        output,
+			IncludeAssets: true,
+		})
+		if err != nil {
+			t.Fatalf("NewCoordinator() error = %v", err)
+		}
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+
+		out := output.String()
+		wantRoot := "Visited: https://example.com/\n" +
+			"Links found:\n" +
+			"https://example.com/page\n" +
+			"Assets found:\n" +
+			"script https://example.com/app.js\n" +
+			"img https://cdn.example.com/logo.png\n"
+		if !strings.HasPrefix(out, wantRoot) {
+			t.Errorf("output = %q, want prefix %q", out, wantRoot)
+		}
+		if strings.Contains(out, "Visited: https://example.com/app.js") {
+			t.Errorf("asset was crawled without FollowAssets")
+		}
+	})
+
+	t.Run("followed", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		coord, err := NewCoordinator(Config{
+			StartURL:     "https://example.com/",
+			NumWorkers:   1,
+			Fetcher:      newFetcher(),
+			Parser:       parser,
+			Output:       output,
+			FollowAssets: true,
+		})
+		if err != nil {
+			t.Fatalf("NewCoordinator() error = %v", err)
+		}
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+
+		out := output.String()
+		if !strings.Contains(out, "Visited: https://example.com/app.js") {
+			t.Errorf("in-scope asset not crawled with FollowAssets:\n%s", out)
+		}
+		if strings.Contains(out, "Visited: https://cdn.example.com") {
+			t.Errorf("out-of-scope asset crawled:\n%s", out)
+		}
+	})
+}
+
+func TestCoordinator_LinkBase(t *testing.T) {
+	tests := []struct {
+		name     string
+		finalURL string
+		baseHref string
+		want     string
+	}{
+		{"no base", "https://example.com/a/page", "", "https://example.com/a/page"},
+		{"absolute base", "https://example.com/a/page", "https://example.com/docs/", "https://example.com/docs/"},
+		{"relative base", "https://example.com/a/page", "/docs/", "https://example.com/docs/"},
+		{"non-http base ignored", "https://example.com/a/page", "javascript:void(0)", "https://example.com/a/page"},
+	}
+
+	c := &Coordinator{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.linkBase(Result{FinalURL: tt.finalURL, BaseHref: tt.baseHref})
+			if got != tt.want {
+				t.Errorf("linkBase() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoordinator_ResolvesAgainstBaseHref(t *testing.T) {
+	output := &bytes.Buffer{}
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":           []byte("root"),
+			"https://example.com/docs/guide": []byte("guide"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{"root": {"guide"}},
+		meta:  map[string]*PageMetadata{"root": {BaseHref: "/docs/"}},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     parser,
+		Output:     output,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	out := output.String()
+	if !strings.Contains(out, "Visited: https://example.com/docs/guide") {
+		t.Errorf("relative link not resolved against <base href>:\n%s", out)
+	}
+}
+
+func TestCoordinator_PrintsTitleAndDescription(t *testing.T) {
+	titled := &mockMetadataParser{
+		meta: map[string]*PageMetadata{"root": {Title: "Home", Description: "Welcome"}},
+	}
+	tests := []struct {
+		name   string
+		format string
+		parser Parser
+		want   string
+	}{
+		{
+			name:   "text",
+			format: "text",
+			parser: titled,
+			want:   "Visited: https://example.com/\nTitle: Home\nDescription: Welcome\nLinks found:\n",
+		},
+		{
+			name:   "text without metadata",
+			format: "text",
+			parser: &mockParser{},
+			want:   "Visited: https://example.com/\nLinks found:\n",
+		},
+		{
+			name:   "json",
+			format: "json",
+			parser: titled,
+			want:   `{"url":"https://example.com/","title":"Home","description":"Welcome","links":[]}` + "\n",
+		},
+		{
+			name:   "json without metadata",
+			format: "json",
+			parser: &mockParser{},
+			want:   `{"url":"https://example.com/","links":[]}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			coord, err := NewCoordinator(Config{
+				StartURL:     "https://example.com/",
+				NumWorkers:   1,
+				Fetcher:      &mockFetcher{responses: map[string][]byte{"https://example.com/": []byte("root")}},
+				Parser:       tt.parser,
+				Output:       &output,
+				OutputFormat: tt.format,
+			})
+			if err != nil {
+				t.Fatalf("NewCoordinator() error = %v", err)
+			}
+			if err := coord.Crawl(context.Background()); err != nil {
+				t.Fatalf("Crawl() error = %v", err)
+			}
+			if output.String() != tt.want {
+				t.Errorf("output = %q, want %q", output.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCoordinator_CaptureHeaders(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{"https://example.com/": []byte("root")},
+		headers: map[string]http.Header{
+			"https://example.com/": {
+				"Cache-Control": {"no-cache"},
+				"Server":        {"nginx"},
+				"Vary":          {"Accept", "Cookie"},
+			},
+		},
+	}
+	output := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:       "https://example.com/",
+		NumWorkers:     1,
+		Fetcher:        fetcher,
+		Parser:         &mockMetadataParser{},
+		Output:         output,
+		OutputFormat:   "json",
+		CaptureHeaders: []string{"cache-control", "Vary", "X-Frame-Options"},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	var page PageResult
+	if err := json.Unmarshal(output.Bytes(), &page); err != nil {
+		t.Fatalf("invalid JSON %q: %v", output.String(), err)
+	}
+	want := map[string]string{"Cache-Control": "no-cache", "Vary": "Accept, Cookie"}
+	if !reflect.DeepEqual(page.Headers, want) {
+		t.Errorf("headers = %v, want %v", page.Headers, want)
+	}
+}
+
+func TestNewCoordinator_CaptureHeadersRequiresJSON(t *testing.T) {
+	_, err := NewCoordinator(Config{
+		StartURL:       "https://example.com/",
+		NumWorkers:     1,
+		Fetcher:        &mockFetcher{},
+		Parser:         &mockMetadataParser{},
+		Output:         &bytes.Buffer{},
+		CaptureHeaders: []string{"Server"},
+	})
+	if err == nil {
+		t.Error("NewCoordinator() error = nil, want CaptureHeaders rejected for text output")
+	}
+}
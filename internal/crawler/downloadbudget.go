@@ -0,0 +1,55 @@
+package crawler
+
+import "errors"
+
+// downloadBudgetSpent handles a result whose fetch the fetcher refused
+// because its download budget ran out (see ErrDownloadBudget), reporting
+// whether it did. The page is dropped without counting as visited or
+// failed; with a checkpoint it stays in the saved frontier.
+func (c *Coordinator) downloadBudgetSpent(result Result) bool {
+	if !errors.Is(result.Err, ErrDownloadBudget) {
+		return false
+	}
+	if !c.downloadBudgetHit {
+		c.downloadBudgetHit = true
+		c.log().Warn("Download budget reached, stopping the crawl", "error", result.Err)
+		c.emit(Event{Type: EventBudgetReached})
+		c.audit(AuditEntry{Decision: AuditBudgetReached, Reason: "max total bytes"})
+	}
+	if !result.CheckOnly {
+		c.unfetched++
+		c.visitCount--
+	}
+	c.wg.Done()
+	return true
+}
+
+// dropUnfetched empties the frontier once the download budget is spent or
+// the crawl is aborted, since nothing queued will be fetched any more.
+func (c *Coordinator) dropUnfetched() {
+	if !c.downloadBudgetHit && c.abortReason == "" {
+		return
+	}
+	for {
+		item, ok := c.pending.Pop()
+		if !ok {
+			return
+		}
+		if item.Span != nil {
+			item.Span.End()
+		}
+		if !item.CheckOnly {
+			c.unfetched++
+			c.visitCount--
+		}
+		c.wg.Done()
+	}
+}
+
+// logDownloadBudget reports the pages left unfetched by the download budget.
+func (c *Coordinator) logDownloadBudget() {
+	if !c.downloadBudgetHit {
+		return
+	}
+	c.log().Info("Stopped by download budget", "unfetched", c.unfetched)
+}
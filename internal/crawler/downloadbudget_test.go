@@ -0,0 +1,56 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_DownloadBudget(t *testing.T) {
+	const numLinks = 10
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{"https://example.com/": []byte("root")},
+		errors:    map[string]error{},
+	}
+	var links []string
+	for i := 0; i < numLinks; i++ {
+		link := fmt.Sprintf("https://example.com/p%d", i)
+		links = append(links, link)
+		fetcher.errors[link] = fmt.Errorf("%w: 5000 of 4096 bytes downloaded", ErrDownloadBudget)
+	}
+
+	output := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 2,
+		Fetcher:    fetcher,
+		Parser:     &mockMetadataParser{links: map[string][]string{"root": links}},
+		Output:     output,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	logs := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Errorf("Crawl() error = %v", err)
+		}
+	})
+
+	if got := strings.Count(output.String(), "Visited:"); got != 1 {
+		t.Errorf("printed %d pages, want only the start URL", got)
+	}
+	if coord.errorCount != 0 || coord.visitCount != 1 {
+		t.Errorf("errors = %d, pages = %d, want 0 and 1", coord.errorCount, coord.visitCount)
+	}
+	if !strings.Contains(logs, "Download budget reached, stopping the crawl") {
+		t.Errorf("missing budget log:\n%s", logs)
+	}
+	if !strings.Contains(logs, fmt.Sprintf("Stopped by download budget unfetched=%d", numLinks)) {
+		t.Errorf("missing unfetched count:\n%s", logs)
+	}
+	if strings.Contains(logs, "Failed to fetch") {
+		t.Errorf("unfetched pages reported as failures:\n%s", logs)
+	}
+}
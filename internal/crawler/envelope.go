@@ -0,0 +1,79 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// OutputSchemaVersion identifies the layout of JSON output records. It is
+// bumped whenever a record changes in a way consumers could trip over:
+// a field removed, renamed, or given a different type.
+const OutputSchemaVersion = 1
+
+// CrawlHeader is the first JSON output record with CrawlMetadata set.
+type CrawlHeader struct {
+	// Record is always "crawl_header"
+	Record         string            `json:"record"`
+	SchemaVersion  int               `json:"schema_version"`
+	StartURL       string            `json:"start_url"`
+	StartedAt      time.Time         `json:"started_at"`
+	CrawlerVersion string            `json:"crawler_version,omitempty"`
+	Config         map[string]string `json:"config,omitempty"`
+}
+
+// CrawlSummary is the last JSON output record with CrawlMetadata set.
+type CrawlSummary struct {
+	// Record is always "crawl_summary"
+	Record        string    `json:"record"`
+	SchemaVersion int       `json:"schema_version"`
+	FinishedAt    time.Time `json:"finished_at"`
+	DurationMs    int64     `json:"duration_ms"`
+	// Reason is how the crawl ended: "completed", "aborted", "deadline
+	// exceeded", or "cancelled"
+	Reason string `json:"reason"`
+	Pages  int    `json:"pages"`
+	Errors int    `json:"errors"`
+}
+
+// printCrawlHeader prints the header record, if enabled.
+func (c *Coordinator) printCrawlHeader() {
+	if !c.crawlMetadata {
+		return
+	}
+	c.printRecord(CrawlHeader{
+		Record:         "crawl_header",
+		SchemaVersion:  OutputSchemaVersion,
+		StartURL:       c.startURL.String(),
+		StartedAt:      c.startTime.UTC(),
+		CrawlerVersion: c.crawlerVersion,
+		Config:         c.auditConfig,
+	})
+}
+
+// printCrawlSummary prints the summary record, if enabled.
+func (c *Coordinator) printCrawlSummary(reason string, duration time.Duration) {
+	if !c.crawlMetadata {
+		return
+	}
+	c.printRecord(CrawlSummary{
+		Record:        "crawl_summary",
+		SchemaVersion: OutputSchemaVersion,
+		FinishedAt:    c.startTime.Add(duration).UTC(),
+		DurationMs:    duration.Milliseconds(),
+		Reason:        reason,
+		Pages:         c.visitCount,
+		Errors:        c.errorCount,
+	})
+}
+
+// printRecord prints v to the output as one JSON line.
+func (c *Coordinator) printRecord(v any) {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		c.log().Error("Error marshaling JSON", "error", err)
+		return
+	}
+	fmt.Fprintf(c.output, "%s\n", jsonBytes)
+	c.flushOutput()
+}
@@ -0,0 +1,60 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_CrawlMetadata(t *testing.T) {
+	var out strings.Builder
+	coord, err := NewCoordinator(Config{
+		StartURL:          "https://example.com/",
+		NumWorkers:        2,
+		Fetcher:           &mockFetcher{responses: map[string][]byte{"https://example.com/": []byte("root"), "https://example.com/a": []byte("leaf")}},
+		Parser:            &mockMetadataParser{links: map[string][]string{"root": {"/a", "/missing"}}},
+		Output:            &out,
+		OutputFormat:      "json",
+		BrokenLinksReport: true,
+		CrawlMetadata:     true,
+		CrawlerVersion:    "v1.2.3",
+		AuditConfig:       map[string]string{"workers": "2"},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("got %d records, want header, 3 pages, broken links, summary:\n%s", len(lines), out.String())
+	}
+	var header CrawlHeader
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatalf("header record: %v", err)
+	}
+	if header.Record != "crawl_header" || header.SchemaVersion != OutputSchemaVersion ||
+		header.StartURL != "https://example.com/" || header.CrawlerVersion != "v1.2.3" ||
+		header.Config["workers"] != "2" || header.StartedAt.IsZero() {
+		t.Errorf("header = %+v", header)
+	}
+	if !strings.Contains(lines[4], `"broken_links"`) {
+		t.Errorf("summary does not follow the broken link record: %s", lines[4])
+	}
+	var summary CrawlSummary
+	if err := json.Unmarshal([]byte(lines[5]), &summary); err != nil {
+		t.Fatalf("summary record: %v", err)
+	}
+	if summary.Record != "crawl_summary" || summary.SchemaVersion != OutputSchemaVersion ||
+		summary.Reason != "completed" || summary.Pages != 3 || summary.Errors != 1 ||
+		summary.FinishedAt.Before(header.StartedAt) {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	if _, err := NewCoordinator(Config{StartURL: "https://example.com/", Fetcher: &mockFetcher{}, Parser: &mockParser{}, CrawlMetadata: true}); err == nil {
+		t.Errorf("NewCoordinator() should reject CrawlMetadata with text output")
+	}
+}
@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+)
+
+// FailedURL is one line of the error report: a page that could not be
+// fetched, with enough context to re-queue it in a later crawl.
+type FailedURL struct {
+	// URL is the page that failed, as it was requested
+	URL string `json:"url"`
+	// Referrer is the page that first linked to URL (empty for seeds)
+	Referrer string `json:"referrer,omitempty"`
+	// Depth is the link depth URL was discovered at
+	Depth int `json:"depth"`
+	// Status is the HTTP status, when the server responded
+	Status int `json:"status,omitempty"`
+	// Category classifies the failure (see errorCategory)
+	Category string `json:"category"`
+	// Error is the failure message
+	Error string `json:"error"`
+	// Attempts is how many times URL was fetched. The crawler does not
+	// retry, so this is currently always 1.
+	Attempts int `json:"attempts"`
+}
+
+// recordErrorReport writes a failed page to the error report, if one is
+// configured. Only the coordinator goroutine calls this, so lines never
+// interleave.
+func (c *Coordinator) recordErrorReport(result Result) {
+	if c.errorReport == nil {
+		return
+	}
+
+	status := result.StatusCode
+	var httpErr *HTTPError
+	if errors.As(result.Err, &httpErr) {
+		status = httpErr.StatusCode
+	}
+	jsonBytes, err := json.Marshal(FailedURL{
+		URL:      result.URL,
+		Referrer: result.Referrer,
+		Depth:    result.Depth,
+		Status:   status,
+		Category: errorCategory(result.Err),
+		Error:    result.Err.Error(),
+		Attempts: 1,
+	})
+	if err != nil {
+		c.log().Error("Error marshaling error report", "error", err)
+		return
+	}
+	if _, err := c.errorReport.Write(append(jsonBytes, '\n')); err != nil {
+		c.log().Error("Error writing error report", "error", err)
+	}
+}
+
+// errorCategory classifies a fetch error. HTTP and streaming errors use
+// their own categories; timeouts and other transport failures are told
+// apart so a re-queue can treat them differently.
+func errorCategory(err error) string {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Category()
+	}
+	var streamErr *StreamError
+	if errors.As(err, &streamErr) {
+		return streamErr.Category()
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return "timeout"
+	}
+	return "network error"
+}
@@ -0,0 +1,73 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_ErrorReport(t *testing.T) {
+	report := &bytes.Buffer{}
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/": []byte("<html>root</html>"),
+		},
+		errors: map[string]error{
+			"https://example.com/missing": &HTTPError{URL: "https://example.com/missing", StatusCode: 404},
+			"https://example.com/slow":    fmt.Errorf("fetch: %w", context.DeadlineExceeded),
+			"https://example.com/reset":   errors.New("connection reset by peer"),
+		},
+	}
+	parser := &mockParser{links: []string{"/missing", "/slow", "/reset"}}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:    "https://example.com/",
+		MaxPages:    10,
+		NumWorkers:  1,
+		Fetcher:     fetcher,
+		Parser:      parser,
+		Output:      &bytes.Buffer{},
+		ErrorReport: report,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	got := make(map[string]FailedURL)
+	for _, line := range strings.Split(strings.TrimSpace(report.String()), "\n") {
+		var f FailedURL
+		if err := json.Unmarshal([]byte(line), &f); err != nil {
+			t.Fatalf("failed to parse report line %q: %v", line, err)
+		}
+		got[f.URL] = f
+	}
+
+	want := map[string]FailedURL{
+		"https://example.com/missing": {Status: 404, Category: "dead link"},
+		"https://example.com/slow":    {Category: "timeout"},
+		"https://example.com/reset":   {Category: "network error"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("report has %d entries, want %d:\n%s", len(got), len(want), report.String())
+	}
+	for url, w := range want {
+		f, ok := got[url]
+		if !ok {
+			t.Errorf("report missing %s", url)
+			continue
+		}
+		if f.Status != w.Status || f.Category != w.Category {
+			t.Errorf("%s: status %d category %q, want %d %q", url, f.Status, f.Category, w.Status, w.Category)
+		}
+		if f.Referrer != "https://example.com/" || f.Depth != 1 || f.Attempts != 1 || f.Error == "" {
+			t.Errorf("%s: unexpected entry %+v", url, f)
+		}
+	}
+}
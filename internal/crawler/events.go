@@ -0,0 +1,58 @@
+package crawler
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types emitted on the structured events stream.
+const (
+	EventCrawlStarted  = "crawl_started"
+	EventPageFetched   = "page_fetched"
+	EventPageFailed    = "page_failed"
+	EventBudgetReached = "budget_reached"
+	EventCrawlPaused   = "crawl_paused"
+	EventCrawlResumed  = "crawl_resumed"
+	EventCrawlFinished = "crawl_finished"
+)
+
+// Event is a single machine-readable lifecycle event.
+// Events are written as JSON lines, separate from the human-readable logs on stderr.
+type Event struct {
+	// Type is one of the Event* constants
+	Type string `json:"type"`
+	// Time is when the event occurred
+	Time time.Time `json:"time"`
+	// URL is the page the event refers to (page and start events only)
+	URL string `json:"url,omitempty"`
+	// Referrer is the page that first linked to URL (page events only)
+	Referrer string `json:"referrer,omitempty"`
+	// Error is the failure message (page_failed only)
+	Error string `json:"error,omitempty"`
+	// Pages is the number of pages visited so far
+	Pages int `json:"pages"`
+	// Errors is the number of failed pages so far
+	Errors int `json:"errors"`
+	// DurationMs is the total crawl duration in milliseconds (crawl_finished only)
+	DurationMs int64 `json:"duration_ms,omitempty"`
+}
+
+// emit writes an event to the events stream, if one is configured.
+// Only the coordinator goroutine calls this, so writes never interleave.
+func (c *Coordinator) emit(ev Event) {
+	if c.events == nil {
+		return
+	}
+	ev.Time = time.Now()
+	ev.Pages = c.visitCount
+	ev.Errors = c.errorCount
+
+	jsonBytes, err := json.Marshal(ev)
+	if err != nil {
+		c.log().Error("Error marshaling event", "error", err)
+		return
+	}
+	if _, err := c.events.Write(append(jsonBytes, '\n')); err != nil {
+		c.log().Error("Error writing event", "error", err)
+	}
+}
@@ -0,0 +1,99 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_EmitsLifecycleEvents(t *testing.T) {
+	events := &bytes.Buffer{}
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":  []byte("<html>root</html>"),
+			"https://example.com/a": []byte("<html>a</html>"),
+		},
+		errors: map[string]error{
+			"https://example.com/broken": errors.New("fetch failed"),
+		},
+	}
+	parser := &mockParser{links: []string{"/a", "/broken", "/c"}}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		MaxPages:   3,
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     parser,
+		Output:     &bytes.Buffer{},
+		Events:     events,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	counts := make(map[string]int)
+	var last Event
+	for _, line := range strings.Split(strings.TrimSpace(events.String()), "\n") {
+		var ev Event
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("failed to parse event %q: %v", line, err)
+		}
+		if ev.Time.IsZero() {
+			t.Errorf("event %q has zero time", ev.Type)
+		}
+		counts[ev.Type]++
+		last = ev
+	}
+
+	want := map[string]int{
+		EventCrawlStarted:  1,
+		EventPageFetched:   2,
+		EventPageFailed:    1,
+		EventBudgetReached: 1,
+		EventCrawlFinished: 1,
+	}
+	for typ, n := range want {
+		if counts[typ] != n {
+			t.Errorf("%s events = %d, want %d", typ, counts[typ], n)
+		}
+	}
+
+	if last.Type != EventCrawlFinished {
+		t.Errorf("last event = %q, want %q", last.Type, EventCrawlFinished)
+	}
+	if last.Pages != 3 || last.Errors != 1 {
+		t.Errorf("final counts = %d pages, %d errors, want 3 pages, 1 error", last.Pages, last.Errors)
+	}
+}
+
+func TestCoordinator_NoEventsWhenDisabled(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/": []byte("<html></html>"),
+		},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     &mockParser{},
+		Output:     &bytes.Buffer{},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	// Must not panic with a nil events writer
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package crawler
+
+import (
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// maxExternalExamples is how many referring pages are kept per external domain.
+const maxExternalExamples = 3
+
+// externalDomain aggregates references to a single external host.
+type externalDomain struct {
+	// count is the number of links (and assets, if reported) to the host
+	count int
+	// examples holds up to maxExternalExamples distinct referring pages
+	examples []string
+}
+
+// recordExternals tallies the out-of-scope hosts a page references, for the
+// external domain summary. Assets are included when they are being reported.
+func (c *Coordinator) recordExternals(result Result) {
+	if !c.externalReport {
+		return
+	}
+
+	base := c.linkBase(result)
+	links, _ := c.pageLinks(result)
+	refs := slices.Clone(links)
+	if c.includeAssets {
+		for _, asset := range c.sanitizeAssets(result.Assets, base) {
+			refs = append(refs, asset.URL)
+		}
+	}
+
+	for _, ref := range refs {
+		if c.scope.InScope(ref) {
+			continue
+		}
+		u, err := url.Parse(ref)
+		if err != nil {
+			continue
+		}
+		host := strings.ToLower(u.Hostname())
+
+		dom, ok := c.externalDomains[host]
+		if !ok {
+			dom = &externalDomain{}
+			c.externalDomains[host] = dom
+		}
+		dom.count++
+		if len(dom.examples) < maxExternalExamples && !containsString(dom.examples, result.FinalURL) {
+			dom.examples = append(dom.examples, result.FinalURL)
+		}
+	}
+}
+
+// logExternalDomains prints every external domain referenced during the
+// crawl, most-referenced first, with example referring pages.
+func (c *Coordinator) logExternalDomains() {
+	if !c.externalReport {
+		return
+	}
+
+	hosts := make([]string, 0, len(c.externalDomains))
+	for host := range c.externalDomains {
+		hosts = append(hosts, host)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		ci, cj := c.externalDomains[hosts[i]].count, c.externalDomains[hosts[j]].count
+		if ci != cj {
+			return ci > cj
+		}
+		return hosts[i] < hosts[j]
+	})
+
+	c.log().Info("External domains", "count", len(hosts))
+	for _, host := range hosts {
+		dom := c.externalDomains[host]
+		c.log().Info("External domain", "host", host, "references", dom.count, "examples", dom.examples)
+	}
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_ExternalDomainsReport(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":  []byte("root"),
+			"https://example.com/a": []byte("a"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {
+				"/a",
+				"https://cdn.other.com/x",
+				"https://CDN.other.com/y",
+				"https://tracker.io/",
+			},
+			"a": {"https://cdn.other.com/z", "https://sub.example.com/"},
+		},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:              "https://example.com/",
+		NumWorkers:            1,
+		Fetcher:               fetcher,
+		Parser:                parser,
+		Output:                &bytes.Buffer{},
+		ExternalDomainsReport: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	out := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "External domains count=3") {
+		t.Errorf("wrong domain count:\n%s", out)
+	}
+	cdn := strings.Index(out, "host=cdn.other.com references=3")
+	tracker := strings.Index(out, "host=tracker.io references=1")
+	if cdn < 0 || tracker < 0 || cdn > tracker {
+		t.Errorf("domains missing or not sorted by count:\n%s", out)
+	}
+	if !strings.Contains(out, "host=sub.example.com references=1") {
+		t.Errorf("other subdomain not reported as external:\n%s", out)
+	}
+	if !strings.Contains(out, `host=cdn.other.com references=3 examples="[https://example.com/ https://example.com/a]"`) {
+		t.Errorf("cdn.other.com examples should list each referring page once:\n%s", out)
+	}
+}
@@ -0,0 +1,105 @@
+package crawler
+
+import "fmt"
+
+// Filter decides whether a discovered URL is scheduled. Filters run in
+// order after the built-in checks (scope, visited, caps, patterns, guards,
+// OnBeforeFetch) and before path budgets; the first to reject a URL stops
+// the chain, and its name is credited with the skip. Filters are only
+// called from the coordinator goroutine, so they may keep state.
+type Filter interface {
+	// Name labels the filter in the summary and the audit log
+	Name() string
+	// Allow reports whether link, found at depth (the start URL is 0),
+	// may be crawled
+	Allow(link string, depth int) bool
+}
+
+// funcFilter is a named function used as a Filter.
+type funcFilter struct {
+	name string
+	fn   func(link string, depth int) bool
+}
+
+func (f funcFilter) Name() string                      { return f.name }
+func (f funcFilter) Allow(link string, depth int) bool { return f.fn(link, depth) }
+
+// FilterFunc returns a Filter named name that calls fn.
+func FilterFunc(name string, fn func(link string, depth int) bool) Filter {
+	return funcFilter{name: name, fn: fn}
+}
+
+// DepthFilter rejects URLs more than max links away from the start URL.
+func DepthFilter(max int) Filter {
+	return FilterFunc("depth", func(_ string, depth int) bool {
+		return depth <= max
+	})
+}
+
+// RegexFilter rejects URLs matching any exclude pattern, and, when include
+// patterns are given, URLs matching none of them.
+func RegexFilter(include, exclude []string) (Filter, error) {
+	inc, err := compilePatterns(include)
+	if err != nil {
+		return nil, fmt.Errorf("include: %w", err)
+	}
+	exc, err := compilePatterns(exclude)
+	if err != nil {
+		return nil, fmt.Errorf("exclude: %w", err)
+	}
+	return FilterFunc("regex", func(link string, _ int) bool {
+		return matchesPatterns(link, inc, exc)
+	}), nil
+}
+
+// filterStage is a configured Filter with the URLs it has rejected.
+type filterStage struct {
+	filter Filter
+	// skipped holds the keys of URLs the filter rejected
+	skipped map[string]bool
+}
+
+// newFilterStages validates the filter chain.
+func newFilterStages(filters []Filter) ([]*filterStage, error) {
+	stages := make([]*filterStage, 0, len(filters))
+	for i, f := range filters {
+		if f == nil {
+			return nil, fmt.Errorf("filter %d is nil", i)
+		}
+		stages = append(stages, &filterStage{filter: f, skipped: make(map[string]bool)})
+	}
+	return stages, nil
+}
+
+// filterRejects runs the filter chain and returns the stage that rejected
+// link, or nil if every filter allows it.
+func (c *Coordinator) filterRejects(link string, depth int) *filterStage {
+	for _, stage := range c.filters {
+		if !stage.filter.Allow(link, depth) {
+			return stage
+		}
+	}
+	return nil
+}
+
+// filtersAllow applies the filter chain to a discovered URL, counting and
+// auditing each rejected URL once per filter.
+func (c *Coordinator) filtersAllow(link string, depth int) bool {
+	stage := c.filterRejects(link, depth)
+	if stage == nil {
+		return true
+	}
+	key := c.key(link)
+	if !stage.skipped[key] {
+		stage.skipped[key] = true
+		c.audit(AuditEntry{Decision: AuditSkipped, URL: link, Reason: "rejected by filter " + stage.filter.Name()})
+	}
+	return false
+}
+
+// logFilterSkips reports how many distinct URLs each filter rejected.
+func (c *Coordinator) logFilterSkips() {
+	for _, stage := range c.filters {
+		c.log().Info("Skipped by filter", "filter", stage.filter.Name(), "count", len(stage.skipped))
+	}
+}
@@ -0,0 +1,93 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_FilterChain(t *testing.T) {
+	output := &bytes.Buffer{}
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":          []byte("root"),
+			"https://example.com/a":         []byte("a"),
+			"https://example.com/a/deep":    []byte("deep"),
+			"https://example.com/doc.pdf":   []byte("pdf"),
+			"https://example.com/private/x": []byte("private"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/a", "/doc.pdf", "/private/x"},
+			"a":    {"/a/deep", "/private/x"},
+		},
+	}
+
+	regex, err := RegexFilter(nil, []string{`\.pdf$`})
+	if err != nil {
+		t.Fatalf("RegexFilter() error = %v", err)
+	}
+	var calls []string
+	private := FilterFunc("private", func(link string, _ int) bool {
+		calls = append(calls, link)
+		return !strings.Contains(link, "/private/")
+	})
+
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 2,
+		Fetcher:    fetcher,
+		Parser:     parser,
+		Output:     output,
+		Filters:    []Filter{regex, DepthFilter(1), private},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	logs := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	out := output.String()
+	if got := strings.Count(out, "Visited:"); got != 2 {
+		t.Errorf("visited %d pages, want 2 (/ and /a):\n%s", got, out)
+	}
+	for _, want := range []string{
+		"Skipped by filter filter=regex count=1",
+		// /a/deep, and /private/x when linked again from /a
+		"Skipped by filter filter=depth count=2",
+		"Skipped by filter filter=private count=1",
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("missing %q in logs:\n%s", want, logs)
+		}
+	}
+	// The chain stops at the first rejection: /a/deep never reaches "private"
+	for _, link := range calls {
+		if strings.HasSuffix(link, "/a/deep") || strings.HasSuffix(link, ".pdf") {
+			t.Errorf("filter after a rejecting one was called with %s", link)
+		}
+	}
+}
+
+func TestNewCoordinator_InvalidFilters(t *testing.T) {
+	_, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    &mockFetcher{},
+		Parser:     &mockParser{},
+		Filters:    []Filter{nil},
+	})
+	if err == nil {
+		t.Errorf("a nil filter should be rejected")
+	}
+
+	if _, err := RegexFilter([]string{"("}, nil); err == nil {
+		t.Errorf("RegexFilter() should reject an invalid pattern")
+	}
+}
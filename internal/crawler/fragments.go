@@ -0,0 +1,75 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+)
+
+// fragmentRef is an in-scope link that points at a fragment of a page.
+type fragmentRef struct {
+	// source is the page containing the link
+	source string
+	// target is the Key of the linked page
+	target string
+	// fragment is the decoded fragment identifier, without '#'
+	fragment string
+}
+
+// recordFragments stores the anchors a page defines and the fragment links
+// it contains, for cross-referencing once the crawl completes.
+func (c *Coordinator) recordFragments(result Result) {
+	if !c.checkFragments {
+		return
+	}
+
+	// Unchanged pages were not parsed, so their anchors are unknown
+	if !result.NotModified {
+		defined := make(map[string]bool, len(result.Anchors))
+		for _, anchor := range result.Anchors {
+			defined[anchor] = true
+		}
+		c.anchors[c.key(result.FinalURL)] = defined
+	}
+
+	base, err := url.Parse(c.linkBase(result))
+	if err != nil {
+		return
+	}
+	for _, href := range result.Links {
+		ref, err := url.Parse(href)
+		if err != nil || ref.Fragment == "" || strings.EqualFold(ref.Fragment, "top") {
+			// "#top" scrolls to the top of the page without a matching element
+			continue
+		}
+		abs, ok := c.sanitize(href, base)
+		if !ok || !c.scope.InScope(abs) {
+			continue
+		}
+		c.fragmentRefs = append(c.fragmentRefs, fragmentRef{
+			source:   result.FinalURL,
+			target:   c.key(abs),
+			fragment: ref.Fragment,
+		})
+	}
+}
+
+// logBrokenFragments reports fragment links whose target page was fetched
+// but defines no matching id or name.
+func (c *Coordinator) logBrokenFragments() {
+	if !c.checkFragments {
+		return
+	}
+
+	var broken []fragmentRef
+	for _, ref := range c.fragmentRefs {
+		defined, fetched := c.anchors[ref.target]
+		if fetched && !defined[ref.fragment] {
+			broken = append(broken, ref)
+		}
+	}
+
+	c.log().Info("Broken fragment anchors", "count", len(broken))
+	for _, ref := range broken {
+		c.log().Info("Broken fragment anchor", "page", ref.source, "target", ref.target+"#"+ref.fragment)
+	}
+}
@@ -0,0 +1,69 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_BrokenFragments(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":     []byte("root"),
+			"https://example.com/docs": []byte("docs"),
+		},
+		errors: map[string]error{
+			"https://example.com/gone": io.ErrUnexpectedEOF,
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {
+				"#main",                      // valid, same page
+				"#missing",                   // broken, same page
+				"#top",                       // always valid
+				"/docs#install",              // valid
+				"/docs#uninstall",            // broken
+				"/gone#anything",             // target failed, not checked
+				"https://other.com/#nowhere", // out of scope, not checked
+				"/docs#caf%C3%A9",            // valid once decoded
+			},
+		},
+		meta: map[string]*PageMetadata{
+			"root": {Anchors: []string{"main"}},
+			"docs": {Anchors: []string{"install", "café"}},
+		},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:       "https://example.com/",
+		NumWorkers:     1,
+		Fetcher:        fetcher,
+		Parser:         parser,
+		Output:         &bytes.Buffer{},
+		CheckFragments: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	out := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "Broken fragment anchors count=2") {
+		t.Errorf("wrong broken anchor count:\n%s", out)
+	}
+	for _, want := range []string{
+		"page=https://example.com/ target=https://example.com/#missing",
+		"page=https://example.com/ target=https://example.com/docs#uninstall",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package crawler
+
+import (
+	"container/heap"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Frontier orders names accepted by NewFrontier
+const (
+	// BreadthFirst crawls pages in the order they were discovered, so every
+	// page at one depth is fetched before the next depth (the default)
+	BreadthFirst = "bfs"
+	// DepthFirst crawls the most recently discovered page first, following
+	// one branch of the site to its end before backtracking
+	DepthFirst = "dfs"
+	// Priority crawls pages close to the start URL and with short paths
+	// first, see NewPriorityFrontier
+	Priority = "priority"
+)
+
+// NewFrontier returns an empty frontier for the named order.
+func NewFrontier(order string) (Frontier, error) {
+	switch order {
+	case "", BreadthFirst:
+		return &queueFrontier{}, nil
+	case DepthFirst:
+		return &stackFrontier{}, nil
+	case Priority:
+		return NewPriorityFrontier(nil), nil
+	}
+	return nil, fmt.Errorf("unknown frontier order %q (want %s, %s, or %s)", order, BreadthFirst, DepthFirst, Priority)
+}
+
+// queueFrontier is a FIFO frontier: breadth-first order.
+type queueFrontier struct {
+	items []WorkItem
+	head  int
+}
+
+func (q *queueFrontier) Push(item WorkItem) {
+	q.items = append(q.items, item)
+}
+
+func (q *queueFrontier) Peek() (WorkItem, bool) {
+	if q.head == len(q.items) {
+		return WorkItem{}, false
+	}
+	return q.items[q.head], true
+}
+
+func (q *queueFrontier) Pop() (WorkItem, bool) {
+	if q.head == len(q.items) {
+		return WorkItem{}, false
+	}
+	item := q.items[q.head]
+	q.items[q.head] = WorkItem{}
+	q.head++
+	// Reuse the backing array once everything queued has been handed out
+	if q.head == len(q.items) {
+		q.items, q.head = q.items[:0], 0
+	}
+	return item, true
+}
+
+func (q *queueFrontier) Len() int {
+	return len(q.items) - q.head
+}
+
+// stackFrontier is a LIFO frontier: depth-first order.
+type stackFrontier struct {
+	items []WorkItem
+}
+
+func (s *stackFrontier) Push(item WorkItem) {
+	s.items = append(s.items, item)
+}
+
+func (s *stackFrontier) Peek() (WorkItem, bool) {
+	if len(s.items) == 0 {
+		return WorkItem{}, false
+	}
+	return s.items[len(s.items)-1], true
+}
+
+func (s *stackFrontier) Pop() (WorkItem, bool) {
+	if len(s.items) == 0 {
+		return WorkItem{}, false
+	}
+	last := len(s.items) - 1
+	item := s.items[last]
+	s.items[last] = WorkItem{}
+	s.items = s.items[:last]
+	return item, true
+}
+
+func (s *stackFrontier) Len() int {
+	return len(s.items)
+}
+
+// ScoreFunc rates a URL for the priority frontier: higher scores are
+// crawled sooner. depth is the number of links from the start URL.
+type ScoreFunc func(url string, depth int) float64
+
+// pathSegmentWeight is how much each path segment lowers a URL's priority,
+// so among pages at the same depth, shallower paths come first.
+const pathSegmentWeight = 0.1
+
+// NewPriorityFrontier returns a frontier that crawls the highest priority
+// URL first. A URL's priority is score(url, depth) minus its depth minus a
+// tenth of its number of path segments: without a score (nil), pages close
+// to the start URL and with short paths go first. A score of, say, 10 for
+// URLs under /product/ crawls those before anything else, so they fit in
+// a page budget. Ties are crawled in discovery order.
+func NewPriorityFrontier(score ScoreFunc) Frontier {
+	return &priorityFrontier{score: score}
+}
+
+// prioritized is a queued item with its priority and push sequence number.
+type prioritized struct {
+	item     WorkItem
+	priority float64
+	seq      uint64
+}
+
+// priorityHeap is a max-heap of prioritized items, earliest pushed first
+// among equal priorities.
+type priorityHeap []prioritized
+
+func (h priorityHeap) Len() int { return len(h) }
+func (h priorityHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h priorityHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *priorityHeap) Push(x any)   { *h = append(*h, x.(prioritized)) }
+func (h *priorityHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	old[len(old)-1] = prioritized{}
+	*h = old[:len(old)-1]
+	return last
+}
+
+// priorityFrontier crawls the highest priority item first.
+type priorityFrontier struct {
+	score ScoreFunc
+	items priorityHeap
+	seq   uint64
+}
+
+// priority computes the priority of a URL at depth.
+func (p *priorityFrontier) priority(link string, depth int) float64 {
+	priority := -float64(depth)
+	if u, err := url.Parse(link); err == nil {
+		segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+		priority -= pathSegmentWeight * float64(len(segments))
+	}
+	if p.score != nil {
+		priority += p.score(link, depth)
+	}
+	return priority
+}
+
+func (p *priorityFrontier) Push(item WorkItem) {
+	p.seq++
+	heap.Push(&p.items, prioritized{item: item, priority: p.priority(item.URL, item.Depth), seq: p.seq})
+}
+
+func (p *priorityFrontier) Peek() (WorkItem, bool) {
+	if len(p.items) == 0 {
+		return WorkItem{}, false
+	}
+	return p.items[0].item, true
+}
+
+func (p *priorityFrontier) Pop() (WorkItem, bool) {
+	if len(p.items) == 0 {
+		return WorkItem{}, false
+	}
+	return heap.Pop(&p.items).(prioritized).item, true
+}
+
+func (p *priorityFrontier) Len() int {
+	return len(p.items)
+}
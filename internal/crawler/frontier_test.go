@@ -0,0 +1,222 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// popAll drains f and returns the URLs in pop order.
+func popAll(f Frontier) []string {
+	var urls []string
+	for {
+		item, ok := f.Pop()
+		if !ok {
+			return urls
+		}
+		urls = append(urls, item.URL)
+	}
+}
+
+func TestNewFrontier(t *testing.T) {
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{"", []string{"a", "b", "c", "d"}},
+		{BreadthFirst, []string{"a", "b", "c", "d"}},
+		{DepthFirst, []string{"d", "c", "b", "a"}},
+	}
+	for _, tt := range tests {
+		f, err := NewFrontier(tt.order)
+		if err != nil {
+			t.Fatalf("NewFrontier(%q) error = %v", tt.order, err)
+		}
+		for _, u := range []string{"a", "b", "c", "d"} {
+			f.Push(WorkItem{URL: u})
+		}
+		if f.Len() != 4 {
+			t.Errorf("%q: Len() = %d, want 4", tt.order, f.Len())
+		}
+		if got := popAll(f); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: pop order = %v, want %v", tt.order, got, tt.want)
+		}
+		if f.Len() != 0 {
+			t.Errorf("%q: Len() = %d after draining, want 0", tt.order, f.Len())
+		}
+	}
+
+	if _, err := NewFrontier("random"); err == nil {
+		t.Errorf("NewFrontier() should reject an unknown order")
+	}
+}
+
+func TestQueueFrontier_InterleavedPushPop(t *testing.T) {
+	f := &queueFrontier{}
+	f.Push(WorkItem{URL: "a"})
+	f.Push(WorkItem{URL: "b"})
+	if item, _ := f.Pop(); item.URL != "a" {
+		t.Fatalf("Pop() = %q, want a", item.URL)
+	}
+	f.Push(WorkItem{URL: "c"})
+	if got := popAll(f); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("pop order = %v, want [b c]", got)
+	}
+	f.Push(WorkItem{URL: "d"})
+	if got := popAll(f); !reflect.DeepEqual(got, []string{"d"}) {
+		t.Errorf("pop order after reuse = %v, want [d]", got)
+	}
+}
+
+func TestCoordinator_FrontierOrder(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":    []byte("root"),
+			"https://example.com/a":   []byte("a"),
+			"https://example.com/b":   []byte("b"),
+			"https://example.com/a/1": []byte("leaf"),
+			"https://example.com/b/1": []byte("leaf"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/a", "/b"},
+			"a":    {"/a/1"},
+			"b":    {"/b/1"},
+		},
+	}
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{BreadthFirst, []string{"/", "/a", "/b", "/a/1", "/b/1"}},
+		{DepthFirst, []string{"/", "/b", "/b/1", "/a", "/a/1"}},
+	}
+	for _, tt := range tests {
+		frontier, err := NewFrontier(tt.order)
+		if err != nil {
+			t.Fatalf("NewFrontier(%q) error = %v", tt.order, err)
+		}
+		output := &bytes.Buffer{}
+		coord, err := NewCoordinator(Config{
+			StartURL:   "https://example.com/",
+			NumWorkers: 1,
+			Fetcher:    fetcher,
+			Parser:     parser,
+			Output:     output,
+			Frontier:   frontier,
+		})
+		if err != nil {
+			t.Fatalf("NewCoordinator() error = %v", err)
+		}
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+
+		var got []string
+		for _, line := range strings.Split(output.String(), "\n") {
+			if u, ok := strings.CutPrefix(line, "Visited: https://example.com"); ok {
+				got = append(got, u)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: visit order = %v, want %v", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestPriorityFrontier(t *testing.T) {
+	f := NewPriorityFrontier(nil)
+	f.Push(WorkItem{URL: "https://example.com/a/b/c", Depth: 1})
+	f.Push(WorkItem{URL: "https://example.com/deep", Depth: 2})
+	f.Push(WorkItem{URL: "https://example.com/a", Depth: 1})
+	f.Push(WorkItem{URL: "https://example.com/x", Depth: 1})
+	want := []string{
+		"https://example.com/a", // depth 1, one segment, pushed before /x
+		"https://example.com/x",
+		"https://example.com/a/b/c",
+		"https://example.com/deep",
+	}
+	if item, _ := f.Peek(); item.URL != want[0] {
+		t.Errorf("Peek() = %q, want %q", item.URL, want[0])
+	}
+	if got := popAll(f); !reflect.DeepEqual(got, want) {
+		t.Errorf("pop order = %v, want %v", got, want)
+	}
+
+	scored := NewPriorityFrontier(func(url string, depth int) float64 {
+		if strings.Contains(url, "/product/") {
+			return 10
+		}
+		return 0
+	})
+	scored.Push(WorkItem{URL: "https://example.com/about", Depth: 1})
+	scored.Push(WorkItem{URL: "https://example.com/product/shoes/red", Depth: 3})
+	if got := popAll(scored); got[0] != "https://example.com/product/shoes/red" {
+		t.Errorf("pop order = %v, want the product page first", got)
+	}
+}
+
+func TestCoordinator_PriorityFrontierUnderBudget(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":            []byte("root"),
+			"https://example.com/blog":        []byte("blog"),
+			"https://example.com/about":       []byte("about"),
+			"https://example.com/product/":    []byte("products"),
+			"https://example.com/product/1":   []byte("leaf"),
+			"https://example.com/product/2":   []byte("leaf"),
+			"https://example.com/blog/post-1": []byte("leaf"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root":     {"/blog", "/about", "/product/"},
+			"blog":     {"/blog/post-1"},
+			"products": {"/product/1", "/product/2"},
+		},
+	}
+	score := func(url string, depth int) float64 {
+		if strings.Contains(url, "/product/") {
+			return 10
+		}
+		return 0
+	}
+
+	// The cap counts scheduled pages: / and its three links leave room for
+	// one more, found on whichever section page is fetched first
+	for _, tt := range []struct {
+		frontier Frontier
+		want     string
+	}{
+		{&queueFrontier{}, "/blog/post-1"},
+		{NewPriorityFrontier(score), "/product/1"},
+	} {
+		output := &bytes.Buffer{}
+		coord, err := NewCoordinator(Config{
+			StartURL:   "https://example.com/",
+			NumWorkers: 1,
+			MaxPages:   5,
+			Fetcher:    fetcher,
+			Parser:     parser,
+			Output:     output,
+			Frontier:   tt.frontier,
+		})
+		if err != nil {
+			t.Fatalf("NewCoordinator() error = %v", err)
+		}
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+
+		if got := strings.Count(output.String(), "Visited:"); got != 5 {
+			t.Errorf("visited %d pages, want 5", got)
+		}
+		if !strings.Contains(output.String(), "Visited: https://example.com"+tt.want+"\n") {
+			t.Errorf("%T: want %s visited within the budget:\n%s", tt.frontier, tt.want, output.String())
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// recordGraph stores the in-scope links of a fetched page as edges of the
+// site graph, for the DOT output, link statistics, and PageRank. Nodes are
+// URL keys, so a link and the page it reaches share a node even when they
+// are spelled differently.
+func (c *Coordinator) recordGraph(result Result) {
+	if c.graph == nil && c.linkStatsTopN == 0 && c.pageRankOut == nil {
+		return
+	}
+
+	from := c.key(result.FinalURL)
+	targets, ok := c.graphEdges[from]
+	if !ok {
+		targets = make(map[string]bool)
+		c.graphEdges[from] = targets
+	}
+	links, keys := c.pageLinks(result)
+	for i, link := range links {
+		if c.scope.InScope(link) {
+			targets[keys[i]] = true
+		}
+	}
+}
+
+// writeGraph writes the site graph in Graphviz DOT format, for rendering
+// with e.g. `dot -Tsvg`. Every fetched page is a node, including pages with
+// no outgoing links; nodes and edges are sorted for stable output.
+func (c *Coordinator) writeGraph() {
+	if c.graph == nil {
+		return
+	}
+
+	pages := make([]string, 0, len(c.graphEdges))
+	for page := range c.graphEdges {
+		pages = append(pages, page)
+	}
+	sort.Strings(pages)
+
+	w := bufio.NewWriter(c.graph)
+	fmt.Fprintln(w, "digraph site {")
+	for _, page := range pages {
+		fmt.Fprintf(w, "  %s;\n", dotID(page))
+	}
+	edges := 0
+	for _, page := range pages {
+		targets := make([]string, 0, len(c.graphEdges[page]))
+		for target := range c.graphEdges[page] {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+		for _, target := range targets {
+			fmt.Fprintf(w, "  %s -> %s;\n", dotID(page), dotID(target))
+			edges++
+		}
+	}
+	fmt.Fprintln(w, "}")
+	if err := w.Flush(); err != nil {
+		c.log().Error("Error writing site graph", "error", err)
+		return
+	}
+
+	c.log().Info("Site graph", "pages", len(pages), "links", edges)
+}
+
+// dotID quotes s as a DOT identifier.
+func dotID(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
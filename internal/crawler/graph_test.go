@@ -0,0 +1,65 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCoordinator_Graph(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":  []byte("root"),
+			"https://example.com/a": []byte("a"),
+			"https://example.com/b": []byte("b"),
+		},
+		errors: map[string]error{
+			"https://example.com/broken": errors.New("connection refused"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			// Duplicate and fragment links collapse into one edge; external
+			// links are not part of the site graph
+			"root": {"/a", "/a#top", "/b", "https://other.com/x"},
+			"a":    {"/", "/b", "/broken"},
+			"b":    {`/q?name="x"`},
+		},
+	}
+
+	graph := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     parser,
+		Output:     &bytes.Buffer{},
+		Graph:      graph,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	// Pages that fail to fetch only appear as link targets
+	want := `digraph site {
+  "https://example.com/";
+  "https://example.com/a";
+  "https://example.com/b";
+  "https://example.com/" -> "https://example.com/a";
+  "https://example.com/" -> "https://example.com/b";
+  "https://example.com/a" -> "https://example.com/";
+  "https://example.com/a" -> "https://example.com/b";
+  "https://example.com/a" -> "https://example.com/broken";
+  "https://example.com/b" -> "https://example.com/q?name=\"x\"";
+}
+`
+	if got := graph.String(); got != want {
+		t.Errorf("graph =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,137 @@
+package crawler
+
+import (
+	"net/url"
+)
+
+// hintRef is a resource hint found on a crawled page, with its URL resolved.
+type hintRef struct {
+	page string
+	rel  string
+	url  string
+	// used reports whether the page references the hinted resource (preload,
+	// modulepreload) or an asset on the hinted origin (preconnect,
+	// dns-prefetch); prefetches are for later navigations and always count
+	used bool
+}
+
+// fetchesHint reports whether a hint rel asks the browser to download the
+// URL, so the hinted resource must exist.
+func fetchesHint(rel string) bool {
+	return rel == "preload" || rel == "modulepreload" || rel == "prefetch"
+}
+
+// recordResourceHints resolves a page's resource hints and checks whether
+// the page actually uses what they point at.
+func (c *Coordinator) recordResourceHints(result Result) {
+	if !c.hintAudit || len(result.ResourceHints) == 0 {
+		return
+	}
+
+	base, err := url.Parse(c.linkBase(result))
+	if err != nil {
+		return
+	}
+
+	// <link> hints are themselves "link" assets, so a hinted URL counts as
+	// used only if it is referenced more often than it is hinted
+	assetRefs := make(map[string]int)
+	originRefs := make(map[string]int)
+	for _, asset := range c.sanitizeAssets(result.Assets, base.String()) {
+		assetRefs[c.key(asset.URL)]++
+		originRefs[hostOf(asset.URL)]++
+	}
+	for _, hint := range result.ResourceHints {
+		if abs, ok := c.sanitize(hint.URL, base); ok {
+			assetRefs[c.key(abs)]--
+			originRefs[hostOf(abs)]--
+		}
+	}
+
+	for _, hint := range result.ResourceHints {
+		abs, ok := c.sanitize(hint.URL, base)
+		if !ok {
+			continue
+		}
+		ref := hintRef{page: result.FinalURL, rel: hint.Rel, url: abs, used: true}
+		switch hint.Rel {
+		case "preload", "modulepreload":
+			ref.used = assetRefs[c.key(abs)] > 0
+		case "preconnect", "dns-prefetch":
+			ref.used = originRefs[hostOf(abs)] > 0
+		}
+		c.hintRefs = append(c.hintRefs, ref)
+		c.hintCounts[hint.Rel]++
+	}
+}
+
+// recordHintOutcome remembers failed fetches, so hints pointing at them can
+// be reported as missing once the crawl ends.
+func (c *Coordinator) recordHintOutcome(result Result) {
+	if !c.hintAudit || result.Err == nil {
+		return
+	}
+	c.hintFailed[c.key(result.URL)] = result.Err.Error()
+}
+
+// hintTargets returns the in-scope URLs a page's hints download, so the
+// audit can check that they exist.
+func (c *Coordinator) hintTargets(result Result) []string {
+	if !c.hintAudit {
+		return nil
+	}
+	base, err := url.Parse(c.linkBase(result))
+	if err != nil {
+		return nil
+	}
+	var targets []string
+	for _, hint := range result.ResourceHints {
+		if !fetchesHint(hint.Rel) {
+			continue
+		}
+		if abs, ok := c.sanitize(hint.URL, base); ok {
+			targets = append(targets, abs)
+		}
+	}
+	return targets
+}
+
+// logResourceHints prints the resource hint audit: how many hints of each
+// kind were found, then every hint whose target is missing or unused.
+func (c *Coordinator) logResourceHints() {
+	if !c.hintAudit {
+		return
+	}
+
+	attrs := []any{"count", len(c.hintRefs)}
+	for _, rel := range []string{"preload", "modulepreload", "prefetch", "preconnect", "dns-prefetch"} {
+		attrs = append(attrs, rel, c.hintCounts[rel])
+	}
+	c.log().Info("Resource hints", attrs...)
+
+	for _, ref := range c.hintRefs {
+		if fetchesHint(ref.rel) && c.scope.InScope(ref.url) {
+			key := c.key(ref.url)
+			if reason, failed := c.hintFailed[key]; failed {
+				c.logHintProblem(ref, "missing", "reason", reason)
+				continue
+			}
+			if !c.visited[key] {
+				c.logHintProblem(ref, "not checked (never fetched)")
+			}
+		}
+		if !ref.used {
+			if ref.rel == "preconnect" || ref.rel == "dns-prefetch" {
+				c.logHintProblem(ref, "no assets from this origin")
+			} else {
+				c.logHintProblem(ref, "not used by the page")
+			}
+		}
+	}
+}
+
+// logHintProblem logs one resource hint whose target is missing or unused.
+func (c *Coordinator) logHintProblem(ref hintRef, problem string, attrs ...any) {
+	attrs = append([]any{"page", ref.page, "rel", ref.rel, "url", ref.url, "problem", problem}, attrs...)
+	c.log().Info("Resource hint problem", attrs...)
+}
@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_ResourceHintsReport(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":          []byte("root"),
+			"https://example.com/font.woff": []byte("font"),
+			"https://example.com/app.js":    []byte("js"),
+		},
+		contentTypes: map[string]string{
+			"https://example.com/font.woff": "font/woff2",
+			"https://example.com/app.js":    "text/javascript",
+		},
+	}
+	parser := &mockMetadataParser{
+		meta: map[string]*PageMetadata{
+			"root": {
+				Assets: []Asset{
+					{Type: "link", URL: "/font.woff"},
+					{Type: "link", URL: "/app.js"},
+					{Type: "link", URL: "/gone.css"},
+					{Type: "link", URL: "https://cdn.example.net/"},
+					{Type: "link", URL: "https://fonts.example.org/"},
+					{Type: "script", URL: "/app.js"},
+					{Type: "img", URL: "https://cdn.example.net/logo.png"},
+				},
+				ResourceHints: []ResourceHint{
+					{Rel: "preload", URL: "/font.woff", As: "font"},
+					{Rel: "preload", URL: "/app.js", As: "script"},
+					{Rel: "preload", URL: "/gone.css", As: "style"},
+					{Rel: "preconnect", URL: "https://cdn.example.net/"},
+					{Rel: "dns-prefetch", URL: "https://fonts.example.org/"},
+				},
+			},
+		},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:            "https://example.com/",
+		NumWorkers:          1,
+		Fetcher:             fetcher,
+		Parser:              parser,
+		Output:              &bytes.Buffer{},
+		ResourceHintsReport: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	out := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	for _, want := range []string{
+		"Resource hints count=5 preload=3 modulepreload=0 prefetch=0 preconnect=1 dns-prefetch=1",
+		`page=https://example.com/ rel=preload url=https://example.com/font.woff problem="not used by the page"`,
+		`page=https://example.com/ rel=preload url=https://example.com/gone.css problem=missing reason="url not found in mock"`,
+		`page=https://example.com/ rel=dns-prefetch url=https://fonts.example.org/ problem="no assets from this origin"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"app.js", "rel=preconnect url=https://cdn.example.net/"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("report flags used hint %q:\n%s", unwanted, out)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_Hooks(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":        []byte("root"),
+			"https://example.com/a":       []byte("a"),
+			"https://example.com/private": []byte("private"),
+			"https://example.com/extra":   []byte("extra"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/a", "/private", "https://other.com/"},
+		},
+	}
+
+	var discovered []string
+	var vetoed []string
+	output := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     parser,
+		Output:     output,
+		MaxPages:   3,
+		OnResult: func(result *Result) {
+			// Enrich /a with a title and a link the parser did not see
+			if result.FinalURL == "https://example.com/a" {
+				result.Title = "Enriched"
+				result.Links = append(result.Links, "/extra")
+			}
+		},
+		OnLinkDiscovered: func(from, link string) {
+			discovered = append(discovered, from+" -> "+link)
+		},
+		OnBeforeFetch: func(url string) bool {
+			if strings.Contains(url, "/private") {
+				vetoed = append(vetoed, url)
+				return false
+			}
+			return true
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	out := output.String()
+	if strings.Contains(out, "Visited: https://example.com/private") {
+		t.Errorf("vetoed URL was fetched:\n%s", out)
+	}
+	if len(vetoed) != 1 {
+		t.Errorf("OnBeforeFetch vetoed %v, want only /private", vetoed)
+	}
+	if !strings.Contains(out, "Title: Enriched") {
+		t.Errorf("OnResult changes not printed:\n%s", out)
+	}
+	// The vetoed page does not use up the page budget, so the link added
+	// by OnResult is still crawled
+	if !strings.Contains(out, "Visited: https://example.com/extra") {
+		t.Errorf("link added by OnResult was not crawled:\n%s", out)
+	}
+	want := []string{
+		"https://example.com/ -> https://example.com/a",
+		"https://example.com/ -> https://example.com/private",
+		"https://example.com/ -> https://other.com/",
+		"https://example.com/a -> https://example.com/extra",
+	}
+	if strings.Join(discovered, "\n") != strings.Join(want, "\n") {
+		t.Errorf("OnLinkDiscovered saw:\n%s\nwant:\n%s", strings.Join(discovered, "\n"), strings.Join(want, "\n"))
+	}
+}
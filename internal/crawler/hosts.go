@@ -0,0 +1,133 @@
+package crawler
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// hostHop is a redirect between two variants of the same host, such as
+// www.example.com to example.com.
+type hostHop struct {
+	from, to string
+}
+
+// certMismatch is a fetch that failed because the server's certificate does
+// not cover the requested hostname.
+type certMismatch struct {
+	// host is the hostname that was requested
+	host string
+	// names are the DNS SANs (or IP SANs) the certificate does cover
+	names []string
+}
+
+// recordHostIssues notes certificate hostname mismatches and redirects
+// between www and apex host variants, for the host consistency summary.
+func (c *Coordinator) recordHostIssues(result Result) {
+	if !c.hostReport {
+		return
+	}
+
+	var hostErr x509.HostnameError
+	if result.Err != nil && errors.As(result.Err, &hostErr) && hostErr.Certificate != nil {
+		names := hostErr.Certificate.DNSNames
+		for _, ip := range hostErr.Certificate.IPAddresses {
+			names = append(names, ip.String())
+		}
+		c.certMismatches[result.URL] = certMismatch{host: hostErr.Host, names: names}
+	}
+
+	// The chain of hosts the request passed through, ending at the final URL
+	var hosts []string
+	for _, hop := range result.Redirects {
+		hosts = append(hosts, hostOf(hop.URL))
+	}
+	if len(hosts) == 0 {
+		return
+	}
+	hosts = append(hosts, hostOf(result.FinalURL))
+
+	seen := map[string]bool{hosts[0]: true}
+	bounced := false
+	for i := 1; i < len(hosts); i++ {
+		from, to := hosts[i-1], hosts[i]
+		if from == to || from == "" || to == "" {
+			continue
+		}
+		if stripWWW(from) == stripWWW(to) {
+			c.hostHops[hostHop{from: from, to: to}]++
+		}
+		if seen[to] {
+			bounced = true
+		}
+		seen[to] = true
+	}
+	if bounced && len(c.hostBounces) < maxHostBounces {
+		c.hostBounces = append(c.hostBounces, result.URL+": "+strings.Join(hosts, " -> "))
+	}
+}
+
+// maxHostBounces is how many redirect chains that revisit a host are listed.
+const maxHostBounces = 20
+
+// hostOf returns the lowercased hostname of a URL, or "" if it doesn't parse.
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// stripWWW returns host without a leading "www." label.
+func stripWWW(host string) string {
+	return strings.TrimPrefix(host, "www.")
+}
+
+// logHostConsistency prints certificate hostname mismatches, the www/apex
+// redirect directions seen, and redirect chains that bounce back to a host
+// they already left.
+func (c *Coordinator) logHostConsistency() {
+	if !c.hostReport {
+		return
+	}
+
+	urls := make([]string, 0, len(c.certMismatches))
+	for u := range c.certMismatches {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	c.log().Info("Certificate hostname mismatches", "count", len(urls))
+	for _, u := range urls {
+		m := c.certMismatches[u]
+		c.log().Info("Certificate hostname mismatch", "url", u, "host", m.host, "certificate_names", m.names)
+	}
+
+	hops := make([]hostHop, 0, len(c.hostHops))
+	for hop := range c.hostHops {
+		hops = append(hops, hop)
+	}
+	sort.Slice(hops, func(i, j int) bool {
+		if hops[i].from != hops[j].from {
+			return hops[i].from < hops[j].from
+		}
+		return hops[i].to < hops[j].to
+	})
+	c.log().Info("Host variant redirects", "count", len(hops))
+	for _, hop := range hops {
+		attrs := []any{"from", hop.from, "to", hop.to, "redirects", c.hostHops[hop]}
+		if reverse, ok := c.hostHops[hostHop{from: hop.to, to: hop.from}]; ok {
+			attrs = append(attrs, "inconsistent", true, "reverse_redirects", reverse)
+		}
+		c.log().Info("Host variant redirect", attrs...)
+	}
+
+	if len(c.hostBounces) > 0 {
+		c.log().Info("Redirect chains revisiting a host", "count", len(c.hostBounces))
+		for _, bounce := range c.hostBounces {
+			c.log().Info("Redirect chain revisiting a host", "chain", bounce)
+		}
+	}
+}
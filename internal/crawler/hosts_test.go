@@ -0,0 +1,95 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_HostConsistencyReport(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":  []byte("root"),
+			"https://example.com/a": []byte("a"),
+			"https://example.com/b": []byte("b"),
+		},
+		finalURLs: map[string]string{
+			"https://example.com/a": "https://www.example.com/a",
+			"https://example.com/b": "https://example.com/b/",
+		},
+		errors: map[string]error{
+			"https://example.com/tls": fmt.Errorf("executing request: %w", x509.HostnameError{
+				Certificate: &x509.Certificate{DNSNames: []string{"shop.example.net", "*.example.net"}},
+				Host:        "example.com",
+			}),
+		},
+		redirects: map[string][]Redirect{
+			"https://example.com/a": {{URL: "https://example.com/a", StatusCode: 301}},
+			"https://example.com/b": {
+				{URL: "https://example.com/b", StatusCode: 301},
+				{URL: "https://www.example.com/b", StatusCode: 301},
+			},
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{"root": {"/a", "/b", "/tls"}},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:              "https://example.com/",
+		NumWorkers:            1,
+		Fetcher:               fetcher,
+		Parser:                parser,
+		Output:                &bytes.Buffer{},
+		HostConsistencyReport: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	out := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	for _, want := range []string{
+		"Certificate hostname mismatches count=1",
+		`url=https://example.com/tls host=example.com certificate_names="[shop.example.net *.example.net]"`,
+		"Host variant redirects count=2",
+		"from=example.com to=www.example.com redirects=2 inconsistent=true reverse_redirects=1",
+		"from=www.example.com to=example.com redirects=1 inconsistent=true reverse_redirects=2",
+		"Redirect chains revisiting a host count=1",
+		`chain="https://example.com/b: example.com -> www.example.com -> example.com"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCoordinator_HostConsistencyReportDisabled(t *testing.T) {
+	fetcher := &mockFetcher{responses: map[string][]byte{"https://example.com/": []byte("root")}}
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     &mockMetadataParser{},
+		Output:     &bytes.Buffer{},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	out := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+	if strings.Contains(out, "Host variant redirects") {
+		t.Errorf("report printed without HostConsistencyReport:\n%s", out)
+	}
+}
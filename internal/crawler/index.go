@@ -0,0 +1,51 @@
+package crawler
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// IndexDoc is a single page in the search-index export.
+// The schema is flat with a string primary key so it can be bulk-imported
+// as JSON lines into Meilisearch, Typesense, or Bleve without reshaping.
+type IndexDoc struct {
+	// ID is a stable identifier derived from the page URL (hex SHA-1 of its Key)
+	ID string `json:"id"`
+	// URL is the normalized page URL
+	URL string `json:"url"`
+	// Title is the page <title>
+	Title string `json:"title"`
+	// Lang is the declared page language ("" if unknown)
+	Lang string `json:"lang,omitempty"`
+	// Content is the visible body text
+	Content string `json:"content"`
+}
+
+// writeIndexDoc writes a search-index document for a successfully parsed
+// page. Pages with neither title nor text (non-HTML, or a parser without
+// metadata support) are skipped.
+func (c *Coordinator) writeIndexDoc(result Result) {
+	if c.index == nil || (result.Title == "" && result.Text == "") {
+		return
+	}
+
+	key := c.key(result.FinalURL)
+	sum := sha1.Sum([]byte(key))
+	doc := IndexDoc{
+		ID:      hex.EncodeToString(sum[:]),
+		URL:     key,
+		Title:   result.Title,
+		Lang:    result.Lang,
+		Content: result.Text,
+	}
+
+	jsonBytes, err := json.Marshal(doc)
+	if err != nil {
+		c.log().Error("Error marshaling index document", "error", err)
+		return
+	}
+	if _, err := c.index.Write(append(jsonBytes, '\n')); err != nil {
+		c.log().Error("Error writing index document", "error", err)
+	}
+}
@@ -0,0 +1,61 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_IndexExport(t *testing.T) {
+	index := &bytes.Buffer{}
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":         []byte("home text"),
+			"https://example.com/file.pdf": []byte("%PDF"),
+		},
+		contentTypes: map[string]string{
+			"https://example.com/file.pdf": "application/pdf",
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{"home text": {"/file.pdf"}},
+		meta:  map[string]*PageMetadata{"home text": {Title: "Title", Text: "home text", Lang: "en"}},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://EXAMPLE.com",
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     parser,
+		Output:     &bytes.Buffer{},
+		Index:      index,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	// Only the HTML page is exported; the PDF has no title or text
+	lines := strings.Split(strings.TrimSpace(index.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d index documents, want 1:\n%s", len(lines), index.String())
+	}
+
+	var doc IndexDoc
+	if err := json.Unmarshal([]byte(lines[0]), &doc); err != nil {
+		t.Fatalf("failed to parse index document: %v", err)
+	}
+	if doc.URL != "https://example.com/" {
+		t.Errorf("URL = %q, want %q", doc.URL, "https://example.com/")
+	}
+	if doc.Title != "Title" || doc.Content != "home text" || doc.Lang != "en" {
+		t.Errorf("doc = %+v, want title, content, and lang from metadata", doc)
+	}
+	if len(doc.ID) != 40 {
+		t.Errorf("ID = %q, want 40-char hex SHA-1", doc.ID)
+	}
+}
@@ -2,14 +2,32 @@ package crawler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // WorkItem represents a single URL to be fetched and parsed by a worker.
 type WorkItem struct {
 	// URL is the absolute URL to fetch
 	URL string
+	// Depth is the number of links followed from the start URL (0 for the start URL)
+	Depth int
+	// Referrer is the page that first linked to URL ("" for the start URL)
+	Referrer string
+	// Validators are the ETag and Last-Modified from a previous crawl, for a
+	// conditional fetch (zero = fetch unconditionally)
+	Validators Validators
+	// CheckOnly marks an external link to verify rather than a page to
+	// crawl: the worker only checks that it resolves
+	CheckOnly bool
+	// Span is the page's trace span, started when it was scheduled; the
+	// worker's fetch and parse spans are its children (nil = untraced)
+	Span trace.Span
 }
 
 // Result represents the outcome of processing a single WorkItem.
@@ -19,20 +37,146 @@ type Result struct {
 	URL string
 	// FinalURL is the URL after following redirects (use this for base URL resolution)
 	FinalURL string
+	// Depth is the depth of the WorkItem (same as WorkItem.Depth)
+	Depth int
+	// Referrer is the page that first linked to URL (same as WorkItem.Referrer)
+	Referrer string
+	// CheckOnly is true for an external link check (same as
+	// WorkItem.CheckOnly); only Err is meaningful
+	CheckOnly bool
+	// Span is the page's trace span (same as WorkItem.Span); the
+	// coordinator ends it once the result is processed
+	Span trace.Span
+	// ContentType is the response Content-Type header ("" on fetch error)
+	ContentType string
 	// Links contains the raw href strings extracted from the HTML
 	Links []string
+	// LinkDetails holds the anchor text, rel, and tag of each entry in
+	// Links, in the same order (parser metadata)
+	LinkDetails []Link
+	// Redirects is the chain of redirects followed to reach FinalURL (empty if none)
+	Redirects []Redirect
+	// StatusCode is the HTTP status of the response, including error
+	// responses (0 if none was received)
+	StatusCode int
+	// TTFB is the time from sending the request to receiving the response
+	// headers (zero on fetch error)
+	TTFB time.Duration
+	// Header holds the response headers (nil on fetch error)
+	Header http.Header
+	// FetchDuration is how long the HTTP fetch took (zero on fetch error)
+	FetchDuration time.Duration
+	// BodySize is the size in bytes of the fetched body (zero on fetch error)
+	BodySize int
+	// LastModified is the Last-Modified response header (zero if absent or on fetch error)
+	LastModified time.Time
+	// ETag is the ETag response header ("" if absent or on fetch error)
+	ETag string
+	// NotModified is true if the page is unchanged since the previous crawl;
+	// it was not parsed, so Links and metadata are empty
+	NotModified bool
+	// Body is the fetched HTML (nil for non-HTML content, on fetch error,
+	// and when neither IncludeHTML nor an OnResult hook needs it). Its
+	// memory is reused once the result is processed; copy it to keep it.
+	Body []byte
+	// Lang is the page's declared language, if the parser reports metadata
+	Lang string
+	// LangDetected is true if Lang was guessed from the page text because
+	// the page declares no language
+	LangDetected bool
+	// NoIndex is true if the page asks not to be indexed, via robots meta or
+	// the X-Robots-Tag header
+	NoIndex bool
+	// NoFollow is true if the page asks that its links not be followed, via
+	// robots meta or the X-Robots-Tag header
+	NoFollow bool
+	// NofollowLinks are the raw hrefs from Links marked rel="nofollow" (parser metadata)
+	NofollowLinks []string
+	// Anchors are the fragment targets defined by the page (parser metadata)
+	Anchors []string
+	// Title is the page's <title> text (parser metadata)
+	Title string
+	// Description is the page's meta description (parser metadata)
+	Description string
+	// Text is the page's visible body text (parser metadata)
+	Text string
+	// Assets are the page's non-anchor dependencies, raw (parser metadata)
+	Assets []Asset
+	// BaseHref is the page's raw <base href>, which overrides FinalURL for
+	// resolving relative links (parser metadata)
+	BaseHref string
+	// Canonical is the page's raw rel="canonical" href (parser metadata)
+	Canonical string
+	// StructuredData are the page's raw JSON-LD blocks (parser metadata)
+	StructuredData []string
+	// ResourceHints are the page's preload, prefetch, and connection hints,
+	// raw (parser metadata)
+	ResourceHints []ResourceHint
+	// OpenGraph maps the page's og: properties to their content (parser metadata)
+	OpenGraph map[string]string
 	// Err is any error that occurred during fetch or parse (nil on success)
 	Err error
+
+	// links and linkKeys are Links sanitized and their dedupe keys,
+	// prepared by the worker so the URL parsing runs in parallel (valid
+	// only when prepared is set)
+	links    []string
+	linkKeys []string
+	prepared bool
+	// release hands Body's buffer back to the fetcher (nil = not pooled)
+	release func()
+}
+
+// releaseBody drops Body and hands its buffer back to the fetcher.
+func (r *Result) releaseBody() {
+	if r.release != nil {
+		r.release()
+		r.release = nil
+	}
+	r.Body = nil
 }
 
 // FetchResult contains the result of an HTTP fetch operation.
 type FetchResult struct {
-	// Body is the response body content
+	// Body is the response body content, transcoded to UTF-8 for HTML and CSS
 	Body []byte
+	// Release, if set, hands Body's buffer back to the fetcher for reuse.
+	// The crawler calls it once it is done with the page, after which Body
+	// must not be touched.
+	Release func()
 	// FinalURL is the URL after following redirects
 	FinalURL string
 	// ContentType is the Content-Type header value
 	ContentType string
+	// Redirects is the chain of redirects followed to reach FinalURL, in order
+	Redirects []Redirect
+	// RobotsTags are the values of the X-Robots-Tag response headers
+	RobotsTags []string
+	// LastModified is the Last-Modified response header (zero if absent or invalid)
+	LastModified time.Time
+	// ETag is the ETag response header ("" if absent)
+	ETag string
+	// NotModified is true if a conditional fetch got 304 Not Modified; Body
+	// is then empty
+	NotModified bool
+	// StatusCode is the HTTP status of the final response
+	StatusCode int
+	// Header holds the final response's headers
+	Header http.Header
+	// TTFB is the time from sending the request to receiving the response
+	// headers, excluding any rate-limit wait
+	TTFB time.Duration
+	// Duration is the time spent on the request and body read, excluding
+	// any rate-limit wait
+	Duration time.Duration
+}
+
+// Redirect is a single hop in a redirect chain.
+type Redirect struct {
+	// URL is the URL that responded with a redirect
+	URL string `json:"url"`
+	// StatusCode is the redirect status (301, 302, 307, ...)
+	StatusCode int `json:"status"`
 }
 
 // Fetcher is the interface for fetching HTTP content.
@@ -44,6 +188,70 @@ type Fetcher interface {
 	Fetch(ctx context.Context, url string) (*FetchResult, error)
 }
 
+// Validators identify a version of a page for conditional requests.
+type Validators struct {
+	// ETag is the entity tag from the ETag response header
+	ETag string
+	// LastModified is the time from the Last-Modified response header
+	LastModified time.Time
+}
+
+// IsZero reports whether there is nothing to validate against.
+func (v Validators) IsZero() bool {
+	return v.ETag == "" && v.LastModified.IsZero()
+}
+
+// ConditionalFetcher is a Fetcher that can revalidate a page from an earlier
+// crawl. Workers use it for WorkItems carrying Validators.
+type ConditionalFetcher interface {
+	Fetcher
+	// FetchIfModified fetches url unless it still matches v, in which case
+	// it returns a result with NotModified set and an empty body.
+	FetchIfModified(ctx context.Context, url string, v Validators) (*FetchResult, error)
+}
+
+// LinkChecker is a Fetcher that can verify a URL resolves without
+// downloading it. Workers use it for external link checks; other Fetchers
+// fall back to Fetch.
+type LinkChecker interface {
+	Fetcher
+	// Check returns nil if url answers with a success status, following
+	// redirects, and an error (an *HTTPError for error statuses) otherwise.
+	Check(ctx context.Context, url string) error
+}
+
+// Frontier holds the work scheduled but not yet handed to a worker, and
+// decides the order it is crawled in. The coordinator offers the Peek item
+// to the workers and pops it once one takes it, so items pushed meanwhile
+// can still go first. It is only called from the coordinator goroutine and
+// needs no locking.
+type Frontier interface {
+	// Push adds a scheduled item
+	Push(item WorkItem)
+	// Peek returns the next item to crawl without removing it (false = empty)
+	Peek() (WorkItem, bool)
+	// Pop removes and returns the next item to crawl (false = empty)
+	Pop() (WorkItem, bool)
+	// Len returns the number of items waiting
+	Len() int
+}
+
+// ScopePolicy decides which URLs belong to the crawl. In-scope links are
+// crawled; out-of-scope links are printed but not followed (and checked
+// with CheckExternal). It is only called from the coordinator goroutine.
+type ScopePolicy interface {
+	// InScope reports whether a sanitized absolute URL should be crawled.
+	InScope(url string) bool
+}
+
+// ScopeFunc adapts a function to a ScopePolicy.
+type ScopeFunc func(url string) bool
+
+// InScope calls f(url).
+func (f ScopeFunc) InScope(url string) bool {
+	return f(url)
+}
+
 // Parser is the interface for parsing HTML and extracting links.
 // This abstraction allows for testing with mock implementations.
 type Parser interface {
@@ -52,10 +260,109 @@ type Parser interface {
 	ExtractLinks(r io.Reader) ([]string, error)
 }
 
+// PageMetadata holds document-level information extracted from a page.
+type PageMetadata struct {
+	// Lang is the lowercased <html lang> value ("" if not declared)
+	Lang string
+	// NoIndex is true if the page's robots meta tag asks not to be indexed
+	NoIndex bool
+	// NoFollow is true if the page's robots meta tag asks that its links not be followed
+	NoFollow bool
+	// NofollowLinks contains the raw hrefs of links marked rel="nofollow"
+	NofollowLinks []string
+	// Anchors contains the ids and <a name> values that fragments can target
+	Anchors []string
+	// Title is the text of the page's <title> element
+	Title string
+	// Description is the content of the page's <meta name="description">
+	Description string
+	// Text is the page's visible body text, whitespace-collapsed
+	Text string
+	// Assets contains the raw URLs of img (including srcset candidates),
+	// script, link, and iframe tags
+	Assets []Asset
+	// BaseHref is the raw href of the page's <base> element ("" if absent)
+	BaseHref string
+	// Canonical is the raw href of the page's rel="canonical" link ("" if absent)
+	Canonical string
+	// StructuredData contains the raw bodies of the page's JSON-LD scripts
+	StructuredData []string
+	// ResourceHints contains the page's <link> preload, modulepreload,
+	// prefetch, preconnect, and dns-prefetch hints
+	ResourceHints []ResourceHint
+	// OpenGraph maps the page's og: meta properties (e.g. "og:title") to
+	// their content
+	OpenGraph map[string]string
+}
+
+// ResourceHint is a <link> asking the browser to fetch or connect early.
+type ResourceHint struct {
+	// Rel is the hint type: "preload", "modulepreload", "prefetch",
+	// "preconnect", or "dns-prefetch"
+	Rel string
+	// URL is the raw href value
+	URL string
+	// As is the destination of a preload, such as "font" ("" if absent)
+	As string
+}
+
+// Asset is a page dependency referenced from a non-anchor tag.
+type Asset struct {
+	// Type is the tag the URL came from: "img", "script", "link", or
+	// "iframe", or "css" for url() and @import references in CSS
+	Type string `json:"type"`
+	// URL is the src/href value (raw from the parser, sanitized in output)
+	URL string `json:"url"`
+}
+
+// MetadataParser is an optional extension of Parser.
+// Workers use it, when the configured Parser implements it, to report
+// document-level metadata alongside the extracted links.
+type MetadataParser interface {
+	Parser
+	// ExtractPage parses HTML once and returns the links, whose hrefs are
+	// the same raw hrefs ExtractLinks returns, together with document-level
+	// metadata.
+	ExtractPage(r io.Reader) ([]Link, *PageMetadata, error)
+}
+
+// Link is a hyperlink with the context it appears in.
+type Link struct {
+	// Href is the raw href value (sanitized to an absolute URL in output)
+	Href string `json:"href"`
+	// Text is the link's visible text, or the alt text of an image link
+	Text string `json:"text,omitempty"`
+	// Rel is the lowercased, space-separated rel attribute
+	Rel string `json:"rel,omitempty"`
+	// Tag is the element the link came from, such as "a"
+	Tag string `json:"tag"`
+}
+
+// StylesheetParser is an optional extension of Parser.
+// Workers use it, when the configured Parser implements it, to report the
+// url() and @import references of fetched stylesheets as "css" assets.
+type StylesheetParser interface {
+	// ExtractStylesheet parses CSS and returns the raw URLs it references.
+	ExtractStylesheet(r io.Reader) ([]string, error)
+}
+
+// PDFParser is an optional extension of Parser.
+// Workers use it, when the configured Parser implements it, to extract the
+// link annotations of fetched PDF documents, which are then followed like
+// page links.
+type PDFParser interface {
+	// ExtractPDFLinks parses a PDF and returns the raw URIs it links to.
+	ExtractPDFLinks(r io.Reader) ([]string, error)
+}
+
 // HTTPError represents an HTTP error with status code information.
 type HTTPError struct {
 	StatusCode int
 	URL        string
+	// FinalURL is the URL that returned the error, after following redirects
+	FinalURL string
+	// Redirects is the chain of redirects followed to reach FinalURL
+	Redirects []Redirect
 }
 
 func (e *HTTPError) Error() string {
@@ -86,3 +393,24 @@ func (e *HTTPError) Category() string {
 		return "http error"
 	}
 }
+
+// StreamError reports an endpoint that streams indefinitely (event streams,
+// long-polling) and was abandoned rather than read to completion.
+type StreamError struct {
+	URL    string
+	Reason string
+}
+
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("streaming endpoint: %s", e.Reason)
+}
+
+// Category returns a human-readable error category.
+func (e *StreamError) Category() string {
+	return "streaming endpoint"
+}
+
+// ErrDownloadBudget is returned by a Fetcher whose total download budget is
+// spent. The coordinator then stops the crawl cleanly: the page and those
+// still queued are dropped rather than reported as failed.
+var ErrDownloadBudget = errors.New("download budget exhausted")
@@ -62,3 +62,14 @@ func TestHTTPError_Category(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamError(t *testing.T) {
+	err := &StreamError{URL: "https://example.com/events", Reason: "streaming content type text/event-stream"}
+
+	if got, want := err.Error(), "streaming endpoint: streaming content type text/event-stream"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if got, want := err.Category(), "streaming endpoint"; got != want {
+		t.Errorf("Category() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,178 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// junitPage is one fetched page in the JUnit report.
+type junitPage struct {
+	url      string
+	duration time.Duration
+	// err is the fetch failure (nil = passed)
+	err error
+}
+
+// recordJUnit adds a printed page to the JUnit report, if one is configured.
+func (c *Coordinator) recordJUnit(result Result) {
+	if c.junit == nil {
+		return
+	}
+	c.junitPages = append(c.junitPages, junitPage{url: result.URL, duration: result.FetchDuration, err: result.Err})
+}
+
+// The JUnit XML schema, as read by Jenkins, GitLab, GitHub Actions, and
+// most other CI systems.
+type (
+	junitTestSuites struct {
+		XMLName  xml.Name         `xml:"testsuites"`
+		Name     string           `xml:"name,attr"`
+		Tests    int              `xml:"tests,attr"`
+		Failures int              `xml:"failures,attr"`
+		Skipped  int              `xml:"skipped,attr"`
+		Time     string           `xml:"time,attr"`
+		Suites   []junitTestSuite `xml:"testsuite"`
+	}
+	junitTestSuite struct {
+		Name      string          `xml:"name,attr"`
+		Tests     int             `xml:"tests,attr"`
+		Failures  int             `xml:"failures,attr"`
+		Skipped   int             `xml:"skipped,attr"`
+		Timestamp string          `xml:"timestamp,attr"`
+		Cases     []junitTestCase `xml:"testcase"`
+	}
+	junitTestCase struct {
+		Name      string        `xml:"name,attr"`
+		Classname string        `xml:"classname,attr"`
+		Time      string        `xml:"time,attr,omitempty"`
+		Failure   *junitFailure `xml:"failure"`
+		Skipped   *junitSkipped `xml:"skipped"`
+	}
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	}
+	junitSkipped struct {
+		Message string `xml:"message,attr"`
+	}
+)
+
+// writeJUnit writes the JUnit XML report: a "pages" suite with one test
+// case per printed page, failed if the page could not be fetched, and with
+// CheckExternal an "external links" suite with one per link checked. A
+// failure lists the pages linking to the URL. Pages interrupted by
+// cancellation are skipped rather than failed.
+func (c *Coordinator) writeJUnit(duration time.Duration) {
+	if c.junit == nil {
+		return
+	}
+	timestamp := c.startTime.UTC().Format("2006-01-02T15:04:05")
+
+	pages := junitTestSuite{Name: "pages", Timestamp: timestamp}
+	for _, page := range c.junitPages {
+		tc := junitTestCase{Name: page.url, Classname: junitClassname(page.url), Time: junitSeconds(page.duration)}
+		switch {
+		case errors.Is(page.err, context.Canceled):
+			tc.Skipped = &junitSkipped{Message: "crawl cancelled"}
+		case page.err != nil:
+			tc.Failure = &junitFailure{
+				Message: page.err.Error(),
+				Type:    errorCategory(page.err),
+				Text:    junitReferrers(c.referrers[c.key(page.url)]),
+			}
+		}
+		pages.add(tc)
+	}
+	report := junitTestSuites{Name: "crawl " + c.startURL.String(), Time: junitSeconds(duration)}
+	report.add(pages)
+
+	if c.checkExternal {
+		external := junitTestSuite{Name: "external links", Timestamp: timestamp}
+		checks := make([]*externalCheck, 0, len(c.externalChecks))
+		for _, check := range c.externalChecks {
+			checks = append(checks, check)
+		}
+		sort.Slice(checks, func(i, j int) bool {
+			return checks[i].url < checks[j].url
+		})
+		for _, check := range checks {
+			tc := junitTestCase{Name: check.url, Classname: junitClassname(check.url)}
+			if check.err != nil {
+				tc.Failure = &junitFailure{
+					Message: check.err.Error(),
+					Type:    errorCategory(check.err),
+					Text:    junitReferrers(check.referrers),
+				}
+			}
+			external.add(tc)
+		}
+		report.add(external)
+	}
+
+	w := bufio.NewWriter(c.junit)
+	fmt.Fprint(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		c.log().Error("Error writing JUnit report", "error", err)
+		return
+	}
+	fmt.Fprintln(w)
+	if err := w.Flush(); err != nil {
+		c.log().Error("Error writing JUnit report", "error", err)
+		return
+	}
+	c.log().Info("JUnit report", "tests", report.Tests, "failures", report.Failures, "skipped", report.Skipped)
+}
+
+// add appends a test case to the suite and counts it.
+func (s *junitTestSuite) add(tc junitTestCase) {
+	s.Cases = append(s.Cases, tc)
+	s.Tests++
+	if tc.Failure != nil {
+		s.Failures++
+	}
+	if tc.Skipped != nil {
+		s.Skipped++
+	}
+}
+
+// add appends a suite to the report and adds up its counts.
+func (r *junitTestSuites) add(s junitTestSuite) {
+	r.Suites = append(r.Suites, s)
+	r.Tests += s.Tests
+	r.Failures += s.Failures
+	r.Skipped += s.Skipped
+}
+
+// junitClassname groups test cases by host, as CI systems group them by
+// class.
+func junitClassname(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Host
+}
+
+// junitSeconds formats d the way JUnit reports durations.
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// junitReferrers is the failure body: the pages linking to the URL.
+func junitReferrers(refs []string) string {
+	var b strings.Builder
+	for _, ref := range refs {
+		fmt.Fprintf(&b, "linked from %s\n", ref)
+	}
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_JUnit(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":  []byte("root"),
+			"https://example.com/a": []byte("a"),
+			"https://other.com/ok":  []byte("ok"),
+		},
+		errors: map[string]error{
+			"https://example.com/missing": &HTTPError{StatusCode: 404, URL: "https://example.com/missing"},
+			"https://other.com/gone":      &HTTPError{StatusCode: 410, URL: "https://other.com/gone"},
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/a", "/missing", "https://other.com/ok"},
+			"a":    {"/missing", "https://other.com/gone"},
+		},
+	}
+	var junit bytes.Buffer
+	coord, err := NewCoordinator(Config{
+		StartURL:      "https://example.com/",
+		NumWorkers:    1,
+		Fetcher:       fetcher,
+		Parser:        parser,
+		Output:        &strings.Builder{},
+		CheckExternal: true,
+		JUnit:         &junit,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	logs := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	if !strings.HasPrefix(junit.String(), xml.Header) {
+		t.Errorf("report does not start with an XML declaration:\n%s", junit.String())
+	}
+	var report junitTestSuites
+	if err := xml.Unmarshal(junit.Bytes(), &report); err != nil {
+		t.Fatalf("report is not valid XML: %v\n%s", err, junit.String())
+	}
+	if report.Tests != 5 || report.Failures != 2 || len(report.Suites) != 2 {
+		t.Fatalf("report has %d tests, %d failures, %d suites, want 5, 2, 2:\n%s", report.Tests, report.Failures, len(report.Suites), junit.String())
+	}
+
+	pages := report.Suites[0]
+	if pages.Name != "pages" || pages.Tests != 3 || pages.Failures != 1 {
+		t.Errorf("pages suite = %s: %d tests, %d failures, want 3, 1", pages.Name, pages.Tests, pages.Failures)
+	}
+	for _, tc := range pages.Cases {
+		if tc.Classname != "example.com" {
+			t.Errorf("%s: classname = %q, want example.com", tc.Name, tc.Classname)
+		}
+		if tc.Name != "https://example.com/missing" {
+			if tc.Failure != nil {
+				t.Errorf("%s: unexpected failure %+v", tc.Name, tc.Failure)
+			}
+			continue
+		}
+		if tc.Failure == nil {
+			t.Fatalf("%s did not fail", tc.Name)
+		}
+		want := "linked from https://example.com/\nlinked from https://example.com/a\n"
+		if tc.Failure.Type != "dead link" || tc.Failure.Text != want {
+			t.Errorf("failure = %+v, want type dead link with referrers %q", tc.Failure, want)
+		}
+	}
+
+	external := report.Suites[1]
+	if external.Name != "external links" || external.Tests != 2 || external.Failures != 1 {
+		t.Errorf("external suite = %s: %d tests, %d failures, want 2, 1", external.Name, external.Tests, external.Failures)
+	}
+	if tc := external.Cases[0]; tc.Name != "https://other.com/gone" || tc.Failure == nil ||
+		tc.Failure.Text != "linked from https://example.com/a\n" {
+		t.Errorf("first external case = %+v, want the failed https://other.com/gone", tc)
+	}
+	if !strings.Contains(logs, "JUnit report tests=5 failures=2") {
+		t.Errorf("missing JUnit report summary:\n%s", logs)
+	}
+}
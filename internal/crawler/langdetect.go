@@ -0,0 +1,82 @@
+package crawler
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Thresholds for guessing a page's language from its text.
+const (
+	// langMinWords is how many words a text needs before it is classified
+	langMinWords = 20
+	// langMinShare is the fraction of words that must be stopwords of the
+	// winning language
+	langMinShare = 0.08
+)
+
+// langStopwords holds very common words of each language the heuristic
+// recognises. The lists avoid words shared between the languages where
+// possible, since only relative counts matter.
+var langStopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "with", "for", "was", "on", "are", "this", "be", "by", "have", "from", "or", "which"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "dans", "que", "pour", "qui", "sur", "pas", "au", "avec", "sont", "ce", "du", "mais", "nous"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "den", "ein", "eine", "auf", "sich", "auch", "dem", "wird", "von", "zu", "sie", "wir", "oder"},
+	"es": {"el", "los", "las", "y", "es", "por", "una", "con", "para", "del", "se", "lo", "como", "más", "pero", "sus", "al", "fue", "este", "está"},
+	"it": {"il", "di", "che", "è", "della", "per", "gli", "sono", "non", "con", "una", "del", "nel", "alla", "anche", "come", "più", "questo", "ma", "ha"},
+	"pt": {"o", "os", "e", "do", "da", "em", "um", "uma", "para", "com", "não", "que", "se", "na", "no", "dos", "as", "mais", "foi", "pelo"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "te", "zijn", "voor", "met", "ook", "aan", "er", "maar", "om", "wordt", "bij"},
+}
+
+// stopwordLangs maps each stopword to the languages it belongs to.
+var stopwordLangs = func() map[string][]string {
+	m := make(map[string][]string)
+	for lang, words := range langStopwords {
+		for _, w := range words {
+			m[w] = append(m[w], lang)
+		}
+	}
+	return m
+}()
+
+// detectLanguage guesses the language of a text from its stopwords.
+// It returns "" when the text is too short or no language clearly wins.
+func detectLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) < langMinWords {
+		return ""
+	}
+
+	scores := make(map[string]int)
+	for _, w := range words {
+		for _, lang := range stopwordLangs[w] {
+			scores[lang]++
+		}
+	}
+
+	langs := make([]string, 0, len(scores))
+	for lang := range scores {
+		langs = append(langs, lang)
+	}
+	if len(langs) == 0 {
+		return ""
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if scores[langs[i]] != scores[langs[j]] {
+			return scores[langs[i]] > scores[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+	best, bestScore, runnerUp := langs[0], scores[langs[0]], 0
+	if len(langs) > 1 {
+		runnerUp = scores[langs[1]]
+	}
+
+	// Require a clear margin so mixed or borderline texts stay unlabelled
+	if float64(bestScore) < langMinShare*float64(len(words)) || bestScore < runnerUp*3/2 {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,116 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "english",
+			text: "The quick brown fox jumps over the lazy dog and it is known that this sentence was written for testing, with every letter of the alphabet in it.",
+			want: "en",
+		},
+		{
+			name: "french",
+			text: "Le chat est sur la table et les enfants sont dans le jardin avec des amis qui ne sont pas au courant de ce que nous faisons pour la fête.",
+			want: "fr",
+		},
+		{
+			name: "german",
+			text: "Der Hund und die Katze sind nicht im Haus, das ist auch gut so, denn sie wird mit dem Auto von der Stadt auf das Land gebracht und wir warten.",
+			want: "de",
+		},
+		{
+			name: "spanish",
+			text: "El perro y los gatos están en la casa, pero el niño se fue con su madre para comprar pan del mercado como todos los días por la mañana.",
+			want: "es",
+		},
+		{
+			name: "too short",
+			text: "The cat and the dog",
+			want: "",
+		},
+		{
+			name: "no stopwords",
+			text: strings.Repeat("lorem ipsum dolor sit amet ", 10),
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectLanguage(tt.text); got != tt.want {
+				t.Errorf("detectLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoordinator_DetectLanguage(t *testing.T) {
+	french := "Le chat est sur la table et les enfants sont dans le jardin avec des amis qui ne sont pas au courant de ce que nous faisons pour la fête."
+	english := "The quick brown fox jumps over the lazy dog and it is known that this sentence was written for testing, with every letter of the alphabet in it."
+
+	output := &bytes.Buffer{}
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":   []byte("root"),
+			"https://example.com/fr": []byte("fr"),
+			"https://example.com/de": []byte("de"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{"root": {"/fr", "/de"}},
+		meta: map[string]*PageMetadata{
+			"root": {Text: english},
+			"fr":   {Text: french},
+			"de":   {Lang: "de", Text: english},
+		},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:       "https://example.com/",
+		NumWorkers:     1,
+		Fetcher:        fetcher,
+		Parser:         parser,
+		Output:         output,
+		OutputFormat:   "json",
+		Languages:      []string{"en", "de"},
+		DetectLanguage: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	pages := make(map[string]PageResult)
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var page PageResult
+		if err := json.Unmarshal([]byte(line), &page); err != nil {
+			t.Fatalf("failed to parse JSON line %q: %v", line, err)
+		}
+		pages[page.URL] = page
+	}
+
+	if root := pages["https://example.com/"]; root.Lang != "en" || !root.LangDetected {
+		t.Errorf("root lang = %q (detected %v), want detected en", root.Lang, root.LangDetected)
+	}
+	if _, ok := pages["https://example.com/fr"]; ok {
+		t.Error("page detected as French was not filtered out")
+	}
+	if de := pages["https://example.com/de"]; de.Lang != "de" || de.LangDetected {
+		t.Errorf("declared lang = %q (detected %v), want declared de", de.Lang, de.LangDetected)
+	}
+}
@@ -0,0 +1,110 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// DeadExternalLink is an out-of-scope link target that did not resolve.
+type DeadExternalLink struct {
+	URL string `json:"url"`
+	// Status is the HTTP error status (0 if the request itself failed)
+	Status int `json:"status,omitempty"`
+	// Error describes the failure
+	Error     string   `json:"error"`
+	Referrers []string `json:"referrers"`
+}
+
+// DeadExternalLinksReport is the JSON record printed after all pages when
+// external links are checked.
+type DeadExternalLinksReport struct {
+	DeadExternalLinks []DeadExternalLink `json:"dead_external_links"`
+}
+
+// externalCheck tracks one out-of-scope URL queued for checking.
+type externalCheck struct {
+	// url is the link as first discovered
+	url string
+	// referrers are the pages linking to the URL, in discovery order
+	referrers []string
+	// err is the check's outcome (nil = resolved)
+	err error
+}
+
+// scheduleExternalCheck queues a check of an out-of-scope link the first
+// time it is seen, and notes the linking page every time. Checks are not
+// pages: they don't count toward MaxPages and their targets are never
+// parsed or followed.
+func (c *Coordinator) scheduleExternalCheck(link, from string, depth int) {
+	if !c.checkExternal {
+		return
+	}
+	key := c.key(link)
+	if check, ok := c.externalChecks[key]; ok {
+		if !containsString(check.referrers, from) {
+			check.referrers = append(check.referrers, from)
+		}
+		return
+	}
+	c.externalChecks[key] = &externalCheck{url: link, referrers: []string{from}}
+
+	// CRITICAL: wg.Add(1) BEFORE enqueuing
+	c.wg.Add(1)
+	c.enqueue(WorkItem{URL: link, Depth: depth, Referrer: from, CheckOnly: true})
+}
+
+// recordExternalCheck stores the outcome of an external link check.
+func (c *Coordinator) recordExternalCheck(result Result) {
+	if check, ok := c.externalChecks[c.key(result.URL)]; ok {
+		check.err = result.Err
+	}
+}
+
+// printDeadExternalLinks prints the external links that failed their
+// check after all pages, in the configured format, sorted by URL.
+func (c *Coordinator) printDeadExternalLinks() {
+	if !c.checkExternal {
+		return
+	}
+	defer c.flushOutput()
+
+	dead := []DeadExternalLink{}
+	for _, check := range c.externalChecks {
+		if check.err == nil {
+			continue
+		}
+		link := DeadExternalLink{URL: check.url, Error: check.err.Error(), Referrers: check.referrers}
+		var httpErr *HTTPError
+		if errors.As(check.err, &httpErr) {
+			link.Status = httpErr.StatusCode
+		}
+		dead = append(dead, link)
+	}
+	sort.Slice(dead, func(i, j int) bool {
+		return dead[i].URL < dead[j].URL
+	})
+
+	if c.outputFormat == "json" {
+		jsonBytes, err := json.Marshal(DeadExternalLinksReport{DeadExternalLinks: dead})
+		if err != nil {
+			c.log().Error("Error marshaling JSON", "error", err)
+			return
+		}
+		fmt.Fprintf(c.output, "%s\n", jsonBytes)
+		return
+	}
+
+	fmt.Fprintf(c.output, "Dead external links:\n")
+	for _, link := range dead {
+		if link.Status != 0 {
+			fmt.Fprintf(c.output, "%d %s\n", link.Status, link.URL)
+		} else {
+			fmt.Fprintf(c.output, "failed %s (%s)\n", link.URL, link.Error)
+		}
+		for _, ref := range link.Referrers {
+			fmt.Fprintf(c.output, "  linked from %s\n", ref)
+		}
+	}
+}
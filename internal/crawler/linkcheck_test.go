@@ -0,0 +1,107 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func externalCheckFixture() (*mockFetcher, *mockMetadataParser) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":  []byte("root"),
+			"https://example.com/a": []byte("a"),
+			"https://other.com/ok":  []byte("ok"),
+		},
+		errors: map[string]error{
+			"https://other.com/gone": &HTTPError{StatusCode: 410},
+			"https://down.test/":     errors.New("no such host"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/a", "https://other.com/ok", "https://other.com/gone"},
+			"a":    {"https://other.com/gone", "https://down.test/"},
+			// Never parsed: external targets are only checked
+			"ok": {"https://other.com/deeper"},
+		},
+	}
+	return fetcher, parser
+}
+
+func TestCoordinator_CheckExternal(t *testing.T) {
+	fetcher, parser := externalCheckFixture()
+	output := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:      "https://example.com/",
+		NumWorkers:    2,
+		Fetcher:       fetcher,
+		Parser:        parser,
+		Output:        output,
+		MaxPages:      2,
+		CheckExternal: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	out := output.String()
+	want := "Dead external links:\n" +
+		"failed https://down.test/ (no such host)\n" +
+		"  linked from https://example.com/a\n" +
+		"410 https://other.com/gone\n" +
+		"  linked from https://example.com/\n" +
+		"  linked from https://example.com/a\n"
+	if !strings.HasSuffix(out, want) {
+		t.Errorf("output does not end with the dead link section:\n%s", out)
+	}
+	if strings.Contains(out, "Visited: https://other.com") || strings.Contains(out, "deeper") {
+		t.Errorf("external link was crawled:\n%s", out)
+	}
+	// Checks don't use up the page budget
+	if !strings.Contains(out, "Visited: https://example.com/a") {
+		t.Errorf("in-scope page missing, checks counted toward MaxPages:\n%s", out)
+	}
+}
+
+func TestCoordinator_CheckExternalJSON(t *testing.T) {
+	fetcher, parser := externalCheckFixture()
+	output := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:      "https://example.com/",
+		NumWorkers:    1,
+		Fetcher:       fetcher,
+		Parser:        parser,
+		Output:        output,
+		OutputFormat:  "json",
+		CheckExternal: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	var report DeadExternalLinksReport
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &report); err != nil {
+		t.Fatalf("last record is not JSON: %v", err)
+	}
+	if len(report.DeadExternalLinks) != 2 {
+		t.Fatalf("dead_external_links = %+v, want 2 entries", report.DeadExternalLinks)
+	}
+	gone := report.DeadExternalLinks[1]
+	if gone.URL != "https://other.com/gone" || gone.Status != 410 || len(gone.Referrers) != 2 {
+		t.Errorf("dead_external_links[1] = %+v, want other.com/gone, 410, 2 referrers", gone)
+	}
+	if down := report.DeadExternalLinks[0]; down.Status != 0 || down.Error != "no such host" {
+		t.Errorf("dead_external_links[0] = %+v, want no status and the network error", down)
+	}
+}
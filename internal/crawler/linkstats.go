@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"math"
+	"sort"
+)
+
+// linkDegree is the number of distinct fetched pages linking to a page and
+// the number of distinct in-scope pages it links to. Self-links are ignored.
+type linkDegree struct {
+	url      string
+	inbound  int
+	outbound int
+}
+
+// linkDegrees computes the in and out degree of every fetched page from the
+// collected site graph edges.
+func (c *Coordinator) linkDegrees() []linkDegree {
+	degrees := make(map[string]*linkDegree, len(c.graphEdges))
+	for page := range c.graphEdges {
+		degrees[page] = &linkDegree{url: page}
+	}
+	for page, targets := range c.graphEdges {
+		for target := range targets {
+			if target == page {
+				continue
+			}
+			degrees[page].outbound++
+			if d, ok := degrees[target]; ok {
+				d.inbound++
+			}
+		}
+	}
+
+	out := make([]linkDegree, 0, len(degrees))
+	for _, d := range degrees {
+		out = append(out, *d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].url < out[j].url })
+	return out
+}
+
+// logLinkStats prints the link statistics report to stderr: graph totals,
+// the N most-linked pages, and up to N pages reachable through a single
+// linking page, which become orphans if that one link is removed.
+func (c *Coordinator) logLinkStats() {
+	if c.linkStatsTopN == 0 || len(c.graphEdges) == 0 {
+		return
+	}
+
+	degrees := c.linkDegrees()
+	links := 0
+	for _, d := range degrees {
+		links += d.outbound
+	}
+	c.log().Info("Link statistics", "pages", len(degrees), "internal_links", links,
+		"links_per_page", math.Round(float64(links)/float64(len(degrees))*10)/10)
+
+	byInbound := make([]linkDegree, len(degrees))
+	copy(byInbound, degrees)
+	sort.SliceStable(byInbound, func(i, j int) bool { return byInbound[i].inbound > byInbound[j].inbound })
+	n := min(c.linkStatsTopN, len(byInbound))
+	for _, d := range byInbound[:n] {
+		c.log().Info("Most linked page", "url", d.url, "inbound", d.inbound, "outbound", d.outbound)
+	}
+
+	start := c.key(c.startURL.String())
+	var weak []linkDegree
+	for _, d := range degrees {
+		if d.inbound == 1 && d.url != start {
+			weak = append(weak, d)
+		}
+	}
+	c.log().Info("Pages linked from a single page", "count", len(weak))
+	for _, d := range weak[:min(c.linkStatsTopN, len(weak))] {
+		c.log().Info("Page linked from a single page", "url", d.url, "inbound", d.inbound, "outbound", d.outbound)
+	}
+}
@@ -0,0 +1,61 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_LinkStats(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":      []byte("root"),
+			"https://example.com/a":     []byte("a"),
+			"https://example.com/b":     []byte("b"),
+			"https://example.com/leaf":  []byte("leaf"),
+			"https://example.com/about": []byte("about"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/a", "/b", "/about", "/", "https://other.com/"},
+			"a":    {"/", "/b", "/about", "/leaf"},
+			"b":    {"/", "/a", "/about", "/about#team"},
+		},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:      "https://example.com/",
+		NumWorkers:    1,
+		Fetcher:       fetcher,
+		Parser:        parser,
+		Output:        &bytes.Buffer{},
+		LinkStatsTopN: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	out := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	// Self-links, external links, and repeated links don't count
+	if !strings.Contains(out, "Link statistics pages=5 internal_links=10 links_per_page=2") {
+		t.Errorf("wrong totals:\n%s", out)
+	}
+	most := strings.Index(out, "Most linked page ")
+	about := strings.Index(out, "Most linked page url=https://example.com/about inbound=3 outbound=0")
+	if most < 0 || about != most {
+		t.Errorf("/about should be listed as most linked:\n%s", out)
+	}
+	if strings.Count(out, "Most linked page ") < 2 {
+		t.Errorf("want 2 most linked pages:\n%s", out)
+	}
+	if !strings.Contains(out, "Pages linked from a single page count=1\n") ||
+		!strings.Contains(out, "Page linked from a single page url=https://example.com/leaf inbound=1 outbound=0") {
+		t.Errorf("/leaf should be reported as linked from a single page:\n%s", out)
+	}
+}
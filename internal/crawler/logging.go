@@ -0,0 +1,12 @@
+package crawler
+
+import "log/slog"
+
+// log returns the logger the coordinator reports through: Config.Logger,
+// or slog.Default() for a coordinator built without NewCoordinator.
+func (c *Coordinator) log() *slog.Logger {
+	if c.logger == nil {
+		return slog.Default()
+	}
+	return c.logger
+}
@@ -0,0 +1,72 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_Logger(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/": []byte("<html>root</html>"),
+		},
+		errors: map[string]error{
+			"https://example.com/broken": errors.New("connection refused"),
+		},
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     &mockParser{links: []string{"/broken"}},
+		Output:     &bytes.Buffer{},
+		Logger:     logger,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	// Nothing may reach the default logger
+	if logs := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	}); logs != "" {
+		t.Errorf("default logger received:\n%s", logs)
+	}
+
+	levels := make(map[string]string)
+	var failed map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("failed to parse log line %q: %v", line, err)
+		}
+		msg, _ := record["msg"].(string)
+		levels[msg], _ = record["level"].(string)
+		if msg == "Failed to fetch" {
+			failed = record
+		}
+	}
+
+	for msg, level := range map[string]string{
+		"Result received": "DEBUG",
+		"Failed to fetch": "WARN",
+		"Crawl summary":   "INFO",
+	} {
+		if levels[msg] != level {
+			t.Errorf("%q logged at %q, want %q", msg, levels[msg], level)
+		}
+	}
+	if failed["url"] != "https://example.com/broken" || failed["referrer"] != "https://example.com/" || failed["category"] != "network error" {
+		t.Errorf("unexpected failure record: %v", failed)
+	}
+}
@@ -0,0 +1,132 @@
+package crawler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// PageRank export formats accepted by Config.PageRankFormat.
+const (
+	PageRankCSV  = "csv"
+	PageRankJSON = "json"
+)
+
+const (
+	// pageRankDamping is the probability of following a link rather than
+	// jumping to a random page
+	pageRankDamping = 0.85
+	// pageRankTolerance stops iterating once scores move less than this in total
+	pageRankTolerance = 1e-9
+	// pageRankMaxIterations bounds the computation on graphs that converge slowly
+	pageRankMaxIterations = 100
+)
+
+// PageRankScore is one page's row in the PageRank export.
+type PageRankScore struct {
+	URL      string  `json:"url"`
+	PageRank float64 `json:"pagerank"`
+	Inbound  int     `json:"inbound"`
+	Outbound int     `json:"outbound"`
+}
+
+// pageRank computes PageRank over the collected site graph. Only links
+// between fetched pages count, self-links are ignored, and pages without
+// outgoing links spread their score evenly over all pages. Scores sum to 1.
+func (c *Coordinator) pageRank() []PageRankScore {
+	degrees := c.linkDegrees()
+	n := len(degrees)
+	index := make(map[string]int, n)
+	for i, d := range degrees {
+		index[d.url] = i
+	}
+
+	// out[i] lists the fetched pages that page i links to
+	out := make([][]int, n)
+	for i, d := range degrees {
+		for target := range c.graphEdges[d.url] {
+			if j, ok := index[target]; ok && j != i {
+				out[i] = append(out[i], j)
+			}
+		}
+	}
+
+	rank := make([]float64, n)
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+	next := make([]float64, n)
+	for iter := 0; iter < pageRankMaxIterations; iter++ {
+		dangling := 0.0
+		for i := range out {
+			if len(out[i]) == 0 {
+				dangling += rank[i]
+			}
+		}
+		base := (1-pageRankDamping)/float64(n) + pageRankDamping*dangling/float64(n)
+		for i := range next {
+			next[i] = base
+		}
+		for i, targets := range out {
+			share := pageRankDamping * rank[i] / float64(len(targets))
+			for _, j := range targets {
+				next[j] += share
+			}
+		}
+
+		delta := 0.0
+		for i := range rank {
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if delta < pageRankTolerance {
+			break
+		}
+	}
+
+	scores := make([]PageRankScore, n)
+	for i, d := range degrees {
+		scores[i] = PageRankScore{URL: d.url, PageRank: rank[i], Inbound: d.inbound, Outbound: len(out[i])}
+	}
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].PageRank > scores[j].PageRank })
+	return scores
+}
+
+// writePageRank writes every fetched page's PageRank, highest first, with
+// its inbound and outbound link counts. The lowest-ranked pages at the end
+// are the ones internal linking neglects.
+func (c *Coordinator) writePageRank() {
+	if c.pageRankOut == nil || len(c.graphEdges) == 0 {
+		return
+	}
+
+	scores := c.pageRank()
+	var err error
+	if c.pageRankFormat == PageRankJSON {
+		enc := json.NewEncoder(c.pageRankOut)
+		for _, s := range scores {
+			if err = enc.Encode(s); err != nil {
+				break
+			}
+		}
+	} else {
+		w := csv.NewWriter(c.pageRankOut)
+		w.Write([]string{"url", "pagerank", "inbound", "outbound"})
+		for _, s := range scores {
+			w.Write([]string{s.URL, strconv.FormatFloat(s.PageRank, 'f', 6, 64), strconv.Itoa(s.Inbound), strconv.Itoa(s.Outbound)})
+		}
+		w.Flush()
+		err = w.Error()
+	}
+	if err != nil {
+		c.log().Error("Error writing PageRank", "error", err)
+		return
+	}
+
+	c.log().Info("PageRank", "pages", len(scores))
+	for _, s := range scores[max(0, len(scores)-3):] {
+		c.log().Info("Lowest PageRank", "url", s.URL, "pagerank", strconv.FormatFloat(s.PageRank, 'f', 6, 64))
+	}
+}
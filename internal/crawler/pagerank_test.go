@@ -0,0 +1,111 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func pageRankCrawl(t *testing.T, format string) (string, string) {
+	t.Helper()
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":     []byte("root"),
+			"https://example.com/a":    []byte("a"),
+			"https://example.com/b":    []byte("b"),
+			"https://example.com/lone": []byte("lone"),
+		},
+	}
+	// Every page links home; /lone hangs off a single link and has no
+	// outgoing links of its own
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/a", "/b", "/", "https://other.com/"},
+			"a":    {"/"},
+			"b":    {"/", "/a", "/lone"},
+		},
+	}
+
+	pageRank := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:       "https://example.com/",
+		NumWorkers:     1,
+		Fetcher:        fetcher,
+		Parser:         parser,
+		Output:         &bytes.Buffer{},
+		PageRank:       pageRank,
+		PageRankFormat: format,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	out := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+	return pageRank.String(), out
+}
+
+func TestCoordinator_PageRankCSV(t *testing.T) {
+	got, out := pageRankCrawl(t, "")
+
+	rows, err := csv.NewReader(strings.NewReader(got)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v\n%s", err, got)
+	}
+	if len(rows) != 5 || strings.Join(rows[0], ",") != "url,pagerank,inbound,outbound" {
+		t.Fatalf("want header and 4 rows, got:\n%s", got)
+	}
+	if rows[1][0] != "https://example.com/" || rows[1][2] != "2" || rows[1][3] != "2" {
+		t.Errorf("home page should rank first with 2 in, 2 out: %q", rows[1])
+	}
+	if rows[4][0] != "https://example.com/lone" || rows[4][2] != "1" || rows[4][3] != "0" {
+		t.Errorf("/lone should rank last with 1 in, 0 out: %q", rows[4])
+	}
+	if !strings.Contains(out, "PageRank pages=4") || !strings.Contains(out, "Lowest PageRank url=") {
+		t.Errorf("missing PageRank summary:\n%s", out)
+	}
+}
+
+func TestCoordinator_PageRankJSON(t *testing.T) {
+	got, _ := pageRankCrawl(t, PageRankJSON)
+
+	var sum float64
+	var prev = math.Inf(1)
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("want 4 JSON lines, got:\n%s", got)
+	}
+	for _, line := range lines {
+		var s PageRankScore
+		if err := json.Unmarshal([]byte(line), &s); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		if s.PageRank > prev {
+			t.Errorf("scores not sorted highest first:\n%s", got)
+		}
+		prev = s.PageRank
+		sum += s.PageRank
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		t.Errorf("scores sum to %v, want 1", sum)
+	}
+}
+
+func TestNewCoordinator_PageRankFormat(t *testing.T) {
+	_, err := NewCoordinator(Config{
+		StartURL:       "https://example.com/",
+		NumWorkers:     1,
+		Fetcher:        &mockFetcher{},
+		Parser:         &mockMetadataParser{},
+		PageRankFormat: "xml",
+	})
+	if err == nil {
+		t.Error("NewCoordinator() accepted an unknown PageRank format")
+	}
+}
@@ -0,0 +1,21 @@
+package crawler
+
+import "time"
+
+// pageDelay returns how long the page rate holds item back (0 = it may be
+// handed to a worker now). External link checks aren't paced.
+func (c *Coordinator) pageDelay(item WorkItem) time.Duration {
+	if c.pageInterval == 0 || item.CheckOnly {
+		return 0
+	}
+	return time.Until(c.nextPage)
+}
+
+// pageDispatched books the page rate slot item was handed out in. A late
+// dispatch doesn't earn credit, so pages never come in bursts.
+func (c *Coordinator) pageDispatched(item WorkItem) {
+	if c.pageInterval == 0 || item.CheckOnly {
+		return
+	}
+	c.nextPage = time.Now().Add(c.pageInterval)
+}
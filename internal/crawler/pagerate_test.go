@@ -0,0 +1,73 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCoordinator_MaxPagesPerSec(t *testing.T) {
+	const numLinks = 5
+	fetcher := &mockFetcher{responses: map[string][]byte{"https://example.com/": []byte("root")}}
+	var links []string
+	for i := 0; i < numLinks; i++ {
+		link := fmt.Sprintf("https://example.com/p%d", i)
+		links = append(links, link)
+		fetcher.responses[link] = []byte("leaf")
+	}
+
+	output := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:       "https://example.com/",
+		NumWorkers:     4,
+		MaxPagesPerSec: 50,
+		Fetcher:        fetcher,
+		Parser:         &mockMetadataParser{links: map[string][]string{"root": links}},
+		Output:         output,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	start := time.Now()
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	// Six pages at 50/sec: the last is handed out at least 100ms after the first
+	if elapsed := time.Since(start); elapsed < numLinks*20*time.Millisecond {
+		t.Errorf("crawled %d pages in %v, faster than 50 pages/sec", numLinks+1, elapsed)
+	}
+	if got := strings.Count(output.String(), "Visited:"); got != numLinks+1 {
+		t.Errorf("visited %d pages, want %d", got, numLinks+1)
+	}
+
+	if _, err := NewCoordinator(Config{StartURL: "https://example.com/", Fetcher: fetcher, Parser: &mockParser{}, MaxPagesPerSec: -1}); err == nil {
+		t.Errorf("NewCoordinator() should reject a negative MaxPagesPerSec")
+	}
+}
+
+func TestCoordinator_PageDelay(t *testing.T) {
+	coord := &Coordinator{pageInterval: time.Hour}
+	page := WorkItem{URL: "https://example.com/"}
+	if d := coord.pageDelay(page); d > 0 {
+		t.Errorf("first page delayed %v", d)
+	}
+	coord.pageDispatched(page)
+	if d := coord.pageDelay(page); d < 59*time.Minute {
+		t.Errorf("next page delayed %v, want about an hour", d)
+	}
+	check := WorkItem{URL: "https://other.example/", CheckOnly: true}
+	if d := coord.pageDelay(check); d != 0 {
+		t.Errorf("external check delayed %v, want no pacing", d)
+	}
+	coord.pageDispatched(check)
+
+	unpaced := &Coordinator{}
+	unpaced.pageDispatched(page)
+	if d := unpaced.pageDelay(page); d != 0 {
+		t.Errorf("page delayed %v without a page rate", d)
+	}
+}
@@ -0,0 +1,119 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// PathBudget caps how many URLs matching a path prefix or a pattern are
+// scheduled, so unbounded spaces such as tag pages or faceted search
+// can't take over the crawl. Exactly one of Prefix and Pattern is set.
+type PathBudget struct {
+	// Prefix matches URLs whose path starts with it, e.g. "/tag/"
+	Prefix string
+	// Pattern is a regular expression matched against the full URL, e.g.
+	// `[?&]sort=`
+	Pattern string
+	// Limit is the number of matching URLs scheduled before the rest are skipped
+	Limit int
+}
+
+// pathBudget is a PathBudget with its counters.
+type pathBudget struct {
+	// label is the prefix, or the pattern with a "~" in front
+	label   string
+	prefix  string
+	pattern *regexp.Regexp
+	limit   int
+	// scheduled counts matching URLs sent to workers
+	scheduled int
+	// skipped holds the keys of matching URLs dropped once the limit was hit
+	skipped map[string]bool
+}
+
+// compilePathBudgets validates budgets and prepares their counters.
+func compilePathBudgets(budgets []PathBudget) ([]*pathBudget, error) {
+	compiled := make([]*pathBudget, 0, len(budgets))
+	for _, b := range budgets {
+		if (b.Prefix == "") == (b.Pattern == "") {
+			return nil, fmt.Errorf("path budget needs exactly one of Prefix and Pattern, got %+v", b)
+		}
+		if b.Limit < 0 {
+			return nil, fmt.Errorf("path budget limit cannot be negative, got %d", b.Limit)
+		}
+		pb := &pathBudget{label: b.Prefix, prefix: b.Prefix, limit: b.Limit, skipped: make(map[string]bool)}
+		if b.Pattern != "" {
+			re, err := regexp.Compile(b.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path budget pattern %q: %w", b.Pattern, err)
+			}
+			pb.label = "~" + b.Pattern
+			pb.pattern = re
+		}
+		compiled = append(compiled, pb)
+	}
+	return compiled, nil
+}
+
+// matches reports whether link falls under the budget.
+func (b *pathBudget) matches(link string) bool {
+	if b.pattern != nil {
+		return b.pattern.MatchString(link)
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(u.Path, b.prefix)
+}
+
+// pathBudgetAllows reports whether link may be scheduled under every path
+// budget it matches, and charges it to them if so. A link over any budget
+// is noted as skipped by the exhausted ones and charged to none.
+func (c *Coordinator) pathBudgetAllows(link string) bool {
+	var matched []*pathBudget
+	allowed := true
+	for _, b := range c.pathBudgets {
+		if !b.matches(link) {
+			continue
+		}
+		matched = append(matched, b)
+		if b.scheduled >= b.limit {
+			allowed = false
+			if len(b.skipped) == 0 {
+				c.audit(AuditEntry{Decision: AuditBudgetReached, Reason: "path budget " + b.label, Limit: b.limit})
+			}
+			b.skipped[c.key(link)] = true
+		}
+	}
+	if !allowed {
+		return false
+	}
+	for _, b := range matched {
+		b.scheduled++
+	}
+	return true
+}
+
+// pathBudgetsExhausted reports whether any path budget skipped a URL.
+func (c *Coordinator) pathBudgetsExhausted() bool {
+	for _, b := range c.pathBudgets {
+		if len(b.skipped) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// logPathBudgets prints how much of each path budget was used and how many
+// distinct URLs it kept out of the crawl.
+func (c *Coordinator) logPathBudgets() {
+	if len(c.pathBudgets) == 0 {
+		return
+	}
+	for _, b := range c.pathBudgets {
+		c.log().Info("Path budget", "budget", b.label, "scheduled", b.scheduled, "limit", b.limit, "skipped", len(b.skipped))
+	}
+}
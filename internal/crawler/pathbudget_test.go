@@ -0,0 +1,98 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_PathBudgets(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":             []byte("root"),
+			"https://example.com/tag/a":        []byte("tag-a"),
+			"https://example.com/tag/b":        []byte("tag-b"),
+			"https://example.com/tag/c":        []byte("tag-c"),
+			"https://example.com/list?sort=up": []byte("sorted"),
+			"https://example.com/list?sort=dn": []byte("sorted"),
+			"https://example.com/about":        []byte("about"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root":  {"/tag/a", "/tag/b", "/tag/c", "/list?sort=up", "/list?sort=dn", "/about"},
+			"tag-a": {"/tag/c"},
+		},
+	}
+
+	output := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     parser,
+		Output:     output,
+		PathBudgets: []PathBudget{
+			{Prefix: "/tag/", Limit: 2},
+			{Pattern: `[?&]sort=`, Limit: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	logs := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	out := output.String()
+	for _, want := range []string{"/tag/a", "/tag/b", "/list?sort=up", "/about"} {
+		if !strings.Contains(out, "Visited: https://example.com"+want+"\n") {
+			t.Errorf("output missing %s:\n%s", want, out)
+		}
+	}
+	for _, skipped := range []string{"/tag/c", "/list?sort=dn"} {
+		if strings.Contains(out, "Visited: https://example.com"+skipped+"\n") {
+			t.Errorf("%s fetched beyond its budget:\n%s", skipped, out)
+		}
+	}
+
+	// /tag/c is skipped twice (from the root and from /tag/a) but counted once
+	for _, want := range []string{
+		"Path budget budget=/tag/ scheduled=2 limit=2 skipped=1",
+		`Path budget budget="~[?&]sort=" scheduled=1 limit=1 skipped=1`,
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("logs missing %q:\n%s", want, logs)
+		}
+	}
+}
+
+func TestNewCoordinator_InvalidPathBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		budget PathBudget
+	}{
+		{"neither", PathBudget{Limit: 1}},
+		{"both", PathBudget{Prefix: "/a/", Pattern: "b", Limit: 1}},
+		{"negative", PathBudget{Prefix: "/a/", Limit: -1}},
+		{"bad regex", PathBudget{Pattern: "(", Limit: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCoordinator(Config{
+				StartURL:    "https://example.com/",
+				NumWorkers:  1,
+				Fetcher:     &mockFetcher{},
+				Parser:      &mockMetadataParser{},
+				Output:      &bytes.Buffer{},
+				PathBudgets: []PathBudget{tt.budget},
+			})
+			if err == nil {
+				t.Errorf("NewCoordinator() error = nil, want an error for %+v", tt.budget)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package crawler
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// compilePatterns compiles URL filter regular expressions, naming the
+// offending pattern on error.
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL pattern %q: %w", p, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// patternsAllow reports whether a discovered URL passes the configured
+// include and exclude filters.
+func (c *Coordinator) patternsAllow(link string) bool {
+	return matchesPatterns(link, c.include, c.exclude)
+}
+
+// matchesPatterns reports whether link matches an include pattern, if any
+// are set, and no exclude pattern.
+func matchesPatterns(link string, include, exclude []*regexp.Regexp) bool {
+	for _, re := range exclude {
+		if re.MatchString(link) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, re := range include {
+		if re.MatchString(link) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_IncludeExclude(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":               []byte("root"),
+			"https://example.com/docs/a":         []byte("a"),
+			"https://example.com/docs/guide.pdf": []byte("pdf"),
+			"https://example.com/blog/post":      []byte("post"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/docs/a", "/docs/guide.pdf", "/blog/post"},
+		},
+	}
+
+	output := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     parser,
+		Output:     output,
+		Include:    []string{"/docs/"},
+		Exclude:    []string{`\.pdf$`},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	out := output.String()
+	if !strings.Contains(out, "Visited: https://example.com/\n") {
+		t.Errorf("start URL not fetched despite not matching Include:\n%s", out)
+	}
+	if !strings.Contains(out, "Visited: https://example.com/docs/a") {
+		t.Errorf("included URL not fetched:\n%s", out)
+	}
+	for _, skipped := range []string{"/docs/guide.pdf", "/blog/post"} {
+		if strings.Contains(out, "Visited: https://example.com"+skipped) {
+			t.Errorf("%s fetched, want it filtered out:\n%s", skipped, out)
+		}
+	}
+}
+
+func TestNewCoordinator_InvalidPattern(t *testing.T) {
+	_, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    &mockFetcher{},
+		Parser:     &mockMetadataParser{},
+		Output:     &bytes.Buffer{},
+		Exclude:    []string{"("},
+	})
+	if err == nil || !strings.Contains(err.Error(), `"("`) {
+		t.Errorf("NewCoordinator() error = %v, want one naming the bad pattern", err)
+	}
+}
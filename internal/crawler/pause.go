@@ -0,0 +1,48 @@
+package crawler
+
+// Pause stops handing pages to workers. Fetches already in flight finish
+// and their results are processed as usual, so their links join the
+// frontier, but nothing new starts until Resume. The crawl keeps all its
+// state, and deadlines and cancellation still apply while paused. Like
+// RequestProgress, it is safe to call from any goroutine and never blocks.
+func (c *Coordinator) Pause() {
+	c.setPaused(true)
+}
+
+// Resume lets a paused crawl hand out pages again.
+func (c *Coordinator) Resume() {
+	c.setPaused(false)
+}
+
+// Paused reports whether Pause was called without a Resume since.
+func (c *Coordinator) Paused() bool {
+	return c.pauseRequested.Load()
+}
+
+// setPaused records the requested state and wakes the coordinator if it is
+// waiting, so the change takes effect without waiting for a result.
+func (c *Coordinator) setPaused(paused bool) {
+	c.pauseRequested.Store(paused)
+	select {
+	case c.pauseCh <- struct{}{}:
+	default:
+	}
+}
+
+// dispatchPaused reports whether pages may not be handed out, logging each
+// pause and resume as the coordinator picks it up.
+func (c *Coordinator) dispatchPaused() bool {
+	requested := c.pauseRequested.Load()
+	if requested == c.paused {
+		return c.paused
+	}
+	c.paused = requested
+	if c.paused {
+		c.log().Info("Crawl paused", "in_flight", c.inFlight, "queued", c.pending.Len())
+		c.emit(Event{Type: EventCrawlPaused})
+	} else {
+		c.log().Info("Crawl resumed", "queued", c.pending.Len())
+		c.emit(Event{Type: EventCrawlResumed})
+	}
+	return c.paused
+}
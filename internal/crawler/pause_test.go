@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCoordinator_PauseResume(t *testing.T) {
+	const numLinks = 10
+	fetcher := &countingFetcher{mockFetcher: &mockFetcher{responses: map[string][]byte{"https://example.com/": []byte("root")}}}
+	var links []string
+	for i := 0; i < numLinks; i++ {
+		link := fmt.Sprintf("https://example.com/p%d", i)
+		links = append(links, link)
+		fetcher.responses[link] = []byte("leaf")
+	}
+	var events bytes.Buffer
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 4,
+		Fetcher:    fetcher,
+		Parser:     &mockMetadataParser{links: map[string][]string{"root": links}},
+		Output:     &strings.Builder{},
+		Events:     &events,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	logs := captureLog(t, func() {
+		// The start page is already being fetched when the pause lands
+		coord.Pause()
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- coord.Crawl(context.Background())
+		}()
+
+		deadline := time.After(2 * time.Second)
+		for coord.Progress().PagesVisited < numLinks+1 {
+			select {
+			case <-deadline:
+				t.Fatalf("links of the in-flight page were not scheduled: %+v", coord.Progress())
+			case <-time.After(time.Millisecond):
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		if n := fetcher.fetches.Load(); n != 1 {
+			t.Errorf("fetched %d pages while paused, want only the start page", n)
+		}
+		if p := coord.Progress(); !p.Paused || p.Queued != numLinks {
+			t.Errorf("Progress() = %+v, want paused with %d queued", p, numLinks)
+		}
+		select {
+		case err := <-errCh:
+			t.Fatalf("Crawl() returned (%v) while paused", err)
+		default:
+		}
+
+		coord.Resume()
+		select {
+		case err := <-errCh:
+			if err != nil {
+				t.Errorf("Crawl() error = %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("crawl did not finish after Resume")
+		}
+	})
+
+	if n := fetcher.fetches.Load(); n != numLinks+1 {
+		t.Errorf("fetched %d pages, want %d", n, numLinks+1)
+	}
+	if coord.Paused() {
+		t.Errorf("Paused() = true after Resume")
+	}
+	for _, want := range []string{"Crawl paused in_flight=", "Crawl resumed queued="} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("missing %q log:\n%s", want, logs)
+		}
+	}
+	for _, want := range []string{`"type":"crawl_paused"`, `"type":"crawl_resumed"`} {
+		if !strings.Contains(events.String(), want) {
+			t.Errorf("missing %s event:\n%s", want, events.String())
+		}
+	}
+}
+
+func TestCoordinator_CancelWhilePaused(t *testing.T) {
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 2,
+		Fetcher:    &mockFetcher{responses: map[string][]byte{"https://example.com/": []byte("root")}},
+		Parser:     &mockMetadataParser{links: map[string][]string{"root": {"/a", "/b"}}},
+		Output:     &strings.Builder{},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	coord.Pause()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	errCh := make(chan error, 1)
+	captureLog(t, func() {
+		go func() {
+			errCh <- coord.Crawl(ctx)
+		}()
+		select {
+		case <-errCh:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("paused crawl did not stop at its deadline")
+		}
+	})
+}
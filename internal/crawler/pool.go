@@ -0,0 +1,81 @@
+package crawler
+
+import "context"
+
+// WorkerPool bounds the number of fetches in flight across every
+// Coordinator that shares it, so many crawls in one process (a server or a
+// batch job) can each keep their own workers and state while together
+// staying within one concurrency budget.
+type WorkerPool struct {
+	slots chan struct{}
+}
+
+// NewWorkerPool returns a pool allowing size concurrent fetches.
+func NewWorkerPool(size int) *WorkerPool {
+	return &WorkerPool{slots: make(chan struct{}, size)}
+}
+
+// Size returns the number of concurrent fetches the pool allows.
+func (p *WorkerPool) Size() int {
+	return cap(p.slots)
+}
+
+// pooledFetcher holds a pool slot for the duration of each fetch.
+type pooledFetcher struct {
+	pool *WorkerPool
+	base Fetcher
+}
+
+// FetchIfModified is Fetch for conditional requests; if the wrapped
+// Fetcher cannot revalidate, it fetches unconditionally.
+func (f *pooledFetcher) FetchIfModified(ctx context.Context, url string, v Validators) (*FetchResult, error) {
+	cf, ok := f.base.(ConditionalFetcher)
+	if !ok {
+		return f.Fetch(ctx, url)
+	}
+	if err := f.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer f.release()
+	return cf.FetchIfModified(ctx, url, v)
+}
+
+// Check verifies url with a slot held; if the wrapped Fetcher cannot
+// check links, it fetches instead.
+func (f *pooledFetcher) Check(ctx context.Context, url string) error {
+	if err := f.acquire(ctx); err != nil {
+		return err
+	}
+	defer f.release()
+	if lc, ok := f.base.(LinkChecker); ok {
+		return lc.Check(ctx, url)
+	}
+	_, err := f.base.Fetch(ctx, url)
+	return err
+}
+
+// Fetch waits for a free slot, returning the context's error if it is
+// cancelled first, and fetches with the slot held.
+func (f *pooledFetcher) Fetch(ctx context.Context, url string) (*FetchResult, error) {
+	if err := f.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer f.release()
+	return f.base.Fetch(ctx, url)
+}
+
+// acquire takes a pool slot, or returns the context's error if it is
+// cancelled first.
+func (f *pooledFetcher) acquire(ctx context.Context) error {
+	select {
+	case f.pool.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release returns a slot taken by acquire.
+func (f *pooledFetcher) release() {
+	<-f.pool.slots
+}
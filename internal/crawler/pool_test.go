@@ -0,0 +1,93 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrencyFetcher serves each URL as its own body and records the most
+// fetches it saw in flight at once.
+type concurrencyFetcher struct {
+	errors   map[string]error // Optional fetch errors
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (f *concurrencyFetcher) Fetch(ctx context.Context, url string) (*FetchResult, error) {
+	n := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		peak := f.peak.Load()
+		if n <= peak || f.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err, ok := f.errors[url]; ok {
+		return nil, err
+	}
+	return &FetchResult{Body: []byte(url), FinalURL: url, ContentType: "text/html"}, nil
+}
+
+func TestWorkerPool_SharedAcrossCoordinators(t *testing.T) {
+	fetcher := &concurrencyFetcher{}
+	pool := NewWorkerPool(3)
+
+	var wg sync.WaitGroup
+	outputs := make([]*bytes.Buffer, 2)
+	for i := range outputs {
+		// Each site's root page links to ten children
+		host := fmt.Sprintf("https://site%d.example.com", i)
+		links := map[string][]string{}
+		for j := 0; j < 10; j++ {
+			links[host+"/"] = append(links[host+"/"], fmt.Sprintf("/p%d", j))
+		}
+		outputs[i] = &bytes.Buffer{}
+		coord, err := NewCoordinator(Config{
+			StartURL:   host + "/",
+			NumWorkers: 4,
+			Fetcher:    fetcher,
+			Parser:     &mockMetadataParser{links: links},
+			Output:     outputs[i],
+			Pool:       pool,
+		})
+		if err != nil {
+			t.Fatalf("NewCoordinator() error = %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := coord.Crawl(context.Background()); err != nil {
+				t.Errorf("Crawl() error = %v", err)
+			}
+		}()
+	}
+	captureLog(t, wg.Wait)
+
+	if peak := fetcher.peak.Load(); peak > 3 {
+		t.Errorf("peak concurrent fetches = %d, want <= 3 (pool size)", peak)
+	}
+	// Each crawl keeps its own state: every page of its own host, once
+	for i, out := range outputs {
+		if got := bytes.Count(out.Bytes(), []byte("Visited: ")); got != 11 {
+			t.Errorf("crawl %d visited %d pages, want 11:\n%s", i, got, out)
+		}
+	}
+}
+
+func TestWorkerPool_CancelledWhileWaiting(t *testing.T) {
+	pool := NewWorkerPool(1)
+	pool.slots <- struct{}{} // Pool exhausted
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f := &pooledFetcher{pool: pool, base: &mockFetcher{}}
+	if _, err := f.Fetch(ctx, "https://example.com/"); err != context.Canceled {
+		t.Errorf("Fetch() error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Progress is a snapshot of a running crawl.
+type Progress struct {
+	// PagesVisited is the number of pages scheduled so far
+	PagesVisited int64 `json:"pages_visited"`
+	// Queued is the work scheduled but not yet picked up by a worker
+	Queued int64 `json:"queued"`
+	// Errors is the number of pages that failed so far
+	Errors int64 `json:"errors"`
+	// PagesPerSec is PagesVisited over the time elapsed
+	PagesPerSec float64 `json:"pages_per_sec"`
+	// ElapsedMs is the time since the crawl started, in milliseconds
+	ElapsedMs int64 `json:"elapsed_ms"`
+	// Paused reports whether the crawl is paused (see Pause)
+	Paused bool `json:"paused"`
+}
+
+// liveProgress mirrors the coordinator's counters for readers on other
+// goroutines; only the coordinator goroutine writes it.
+type liveProgress struct {
+	start   atomic.Int64 // Unix nanoseconds, 0 before Crawl starts
+	pages   atomic.Int64
+	pending atomic.Int64
+	errors  atomic.Int64
+}
+
+// publishProgress copies the coordinator's counters to live.
+func (c *Coordinator) publishProgress() {
+	c.live.pages.Store(int64(c.visitCount))
+	c.live.pending.Store(int64(c.pending.Len()))
+	c.live.errors.Store(int64(c.errorCount))
+}
+
+// Progress returns the crawl's progress so far. Unlike the rest of the
+// Coordinator it is safe to call from any goroutine while Crawl runs,
+// e.g. to publish the counters with expvar.
+func (c *Coordinator) Progress() Progress {
+	p := Progress{
+		PagesVisited: c.live.pages.Load(),
+		Queued:       c.live.pending.Load(),
+		Errors:       c.live.errors.Load(),
+		Paused:       c.Paused(),
+	}
+	if start := c.live.start.Load(); start != 0 {
+		elapsed := time.Since(time.Unix(0, start))
+		p.ElapsedMs = elapsed.Milliseconds()
+		if elapsed.Seconds() > 0 {
+			p.PagesPerSec = float64(p.PagesVisited) / elapsed.Seconds()
+		}
+	}
+	return p
+}
+
+// RequestProgress asks the coordinator to log the crawl's progress to
+// stderr without interrupting it, e.g. when the operator sends SIGUSR1.
+// It is safe to call from any goroutine and never blocks; requests made
+// while one is still pending are merged.
+func (c *Coordinator) RequestProgress() {
+	select {
+	case c.progressCh <- struct{}{}:
+	default:
+	}
+}
+
+// logProgress prints a one-line progress report.
+func (c *Coordinator) logProgress() {
+	c.publishProgress()
+	p := c.Progress()
+	c.log().Info("Progress", "pages", p.PagesVisited, "queued", p.Queued, "errors", p.Errors,
+		"pages_per_sec", math.Round(p.PagesPerSec*100)/100, "elapsed", (time.Duration(p.ElapsedMs) * time.Millisecond).Round(time.Second))
+}
@@ -0,0 +1,93 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_RequestProgress(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":  []byte("<html>root</html>"),
+			"https://example.com/a": []byte("<html>a</html>"),
+		},
+	}
+
+	var coord *Coordinator
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     &mockParser{links: []string{"/a"}},
+		Output:     &bytes.Buffer{},
+		OnResult: func(r *Result) {
+			if r.URL == "https://example.com/" {
+				// Repeated requests before the first is answered are merged
+				coord.RequestProgress()
+				coord.RequestProgress()
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	logs := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	if n := strings.Count(logs, "Progress "); n != 1 {
+		t.Fatalf("got %d progress reports, want 1:\n%s", n, logs)
+	}
+	if !strings.Contains(logs, "Progress pages=2 ") || !strings.Contains(logs, " errors=0 ") {
+		t.Errorf("unexpected progress report:\n%s", logs)
+	}
+}
+
+func TestCoordinator_ProgressConcurrentReads(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":  []byte("<html>root</html>"),
+			"https://example.com/a": []byte("<html>a</html>"),
+		},
+		errors: map[string]error{
+			"https://example.com/broken": errors.New("fetch failed"),
+		},
+	}
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 2,
+		Fetcher:    fetcher,
+		Parser:     &mockParser{links: []string{"/a", "/broken"}},
+		Output:     &bytes.Buffer{},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if p := coord.Progress(); p != (Progress{}) {
+		t.Errorf("Progress() before Crawl = %+v, want zero", p)
+	}
+
+	// Readers on other goroutines must not race with the coordinator
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			coord.Progress()
+		}
+	}()
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+	<-done
+
+	p := coord.Progress()
+	if p.PagesVisited != 3 || p.Errors != 1 || p.Queued != 0 {
+		t.Errorf("Progress() after Crawl = %+v, want 3 pages, 1 error, nothing queued", p)
+	}
+}
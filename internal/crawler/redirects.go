@@ -0,0 +1,126 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Redirect map formats accepted by Config.RedirectMapFormat.
+const (
+	RedirectMapNginx   = "nginx"
+	RedirectMapApache  = "apache"
+	RedirectMapNetlify = "netlify"
+)
+
+// redirectRule is a permanent redirect observed during the crawl.
+type redirectRule struct {
+	// from is the decoded in-scope source path, as nginx and Apache match it
+	from string
+	// fromEscaped is the source path as sent on the wire, as _redirects matches it
+	fromEscaped string
+	// to is the target path, or an absolute URL for off-site targets
+	to string
+	// status is the permanent redirect status (301 or 308)
+	status int
+}
+
+// recordRedirects stores each permanent hop in a page's redirect chain
+// whose source is on the crawled host.
+func (c *Coordinator) recordRedirects(result Result) {
+	if c.redirectMap == nil {
+		return
+	}
+
+	for i, hop := range result.Redirects {
+		if hop.StatusCode != http.StatusMovedPermanently && hop.StatusCode != http.StatusPermanentRedirect {
+			continue
+		}
+		target := result.FinalURL
+		if i+1 < len(result.Redirects) {
+			target = result.Redirects[i+1].URL
+		}
+
+		src, err := url.Parse(hop.URL)
+		if err != nil || !c.scope.InScope(hop.URL) {
+			continue
+		}
+		if src.RawQuery != "" {
+			// None of the supported formats can match on the query string
+			// with a plain rule, so these are left out rather than emitted
+			// as rules that would over-match
+			c.redirectsSkipped[c.key(hop.URL)] = true
+			continue
+		}
+		dst, err := url.Parse(target)
+		if err != nil {
+			continue
+		}
+		to := dst.String()
+		if c.scope.InScope(target) {
+			to = dst.RequestURI()
+		}
+		c.redirectRules[src.Path] = redirectRule{
+			from:        src.Path,
+			fromEscaped: src.EscapedPath(),
+			to:          to,
+			status:      hop.StatusCode,
+		}
+	}
+}
+
+// writeRedirectMap writes the collected permanent redirects in the
+// configured webserver format, sorted by source path.
+func (c *Coordinator) writeRedirectMap() {
+	if c.redirectMap == nil {
+		return
+	}
+
+	sources := make([]string, 0, len(c.redirectRules))
+	for from := range c.redirectRules {
+		sources = append(sources, from)
+	}
+	sort.Strings(sources)
+
+	for _, from := range sources {
+		rule := c.redirectRules[from]
+		var line string
+		switch c.redirectMapFormat {
+		case RedirectMapApache:
+			pattern := "^" + regexp.QuoteMeta(rule.from) + "$"
+			line = fmt.Sprintf("RedirectMatch %d %s %s", rule.status, apacheArg(pattern), rule.to)
+		case RedirectMapNetlify:
+			line = fmt.Sprintf("%s %s %d", rule.fromEscaped, rule.to, rule.status)
+		default:
+			line = fmt.Sprintf("location = %s { return %d %s; }", nginxArg(rule.from), rule.status, rule.to)
+		}
+		if _, err := fmt.Fprintln(c.redirectMap, line); err != nil {
+			c.log().Error("Error writing redirect map", "error", err)
+			return
+		}
+	}
+
+	c.log().Info("Redirect map", "redirects", len(sources), "skipped_with_query", len(c.redirectsSkipped))
+}
+
+// nginxArg double-quotes an nginx config argument that contains whitespace
+// or syntax characters. Inside quotes nginx unescapes \" and \\.
+func nginxArg(arg string) string {
+	if !strings.ContainsAny(arg, " \t\"'{};#\\") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+// apacheArg double-quotes an Apache config argument that contains whitespace
+// or quotes. Apache unescapes only \" inside quotes, so the regex escapes
+// in the argument are left alone.
+func apacheArg(arg string) string {
+	if !strings.ContainsAny(arg, " \t\"") {
+		return arg
+	}
+	return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+}
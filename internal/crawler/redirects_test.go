@@ -0,0 +1,136 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_RedirectMap(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "",
+			want: "location = /away { return 301 https://other.com/landing; }\n" +
+				"location = \"/café menu\" { return 301 /cafe; }\n" +
+				"location = /fr { return 301 /fr/; }\n" +
+				"location = /gone { return 301 /missing; }\n" +
+				"location = /mid { return 308 /new?v=2; }\n" +
+				"location = /old { return 301 /mid; }\n",
+		},
+		{
+			format: RedirectMapApache,
+			want: "RedirectMatch 301 ^/away$ https://other.com/landing\n" +
+				"RedirectMatch 301 \"^/café menu$\" /cafe\n" +
+				"RedirectMatch 301 ^/fr$ /fr/\n" +
+				"RedirectMatch 301 ^/gone$ /missing\n" +
+				"RedirectMatch 308 ^/mid$ /new?v=2\n" +
+				"RedirectMatch 301 ^/old$ /mid\n",
+		},
+		{
+			format: RedirectMapNetlify,
+			want: "/away https://other.com/landing 301\n" +
+				"/caf%C3%A9%20menu /cafe 301\n" +
+				"/fr /fr/ 301\n" +
+				"/gone /missing 301\n" +
+				"/mid /new?v=2 308\n" +
+				"/old /mid 301\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			fetcher := &mockFetcher{
+				responses: map[string][]byte{
+					"https://example.com/":                 []byte("root"),
+					"https://example.com/old":              []byte("new"),
+					"https://example.com/tmp":              []byte("x"),
+					"https://example.com/q?x=1":            []byte("q"),
+					"https://example.com/away":             []byte("away"),
+					"https://example.com/caf%C3%A9%20menu": []byte("cafe"),
+					"https://example.com/fr":               []byte("fr"),
+				},
+				finalURLs: map[string]string{
+					"https://example.com/old":              "https://example.com/new?v=2",
+					"https://example.com/tmp":              "https://example.com/x",
+					"https://example.com/q?x=1":            "https://example.com/q",
+					"https://example.com/away":             "https://other.com/landing",
+					"https://example.com/caf%C3%A9%20menu": "https://example.com/cafe",
+					"https://example.com/fr":               "https://example.com/fr/",
+				},
+				errors: map[string]error{
+					// A redirect to a failing page is still recorded
+					"https://example.com/gone": &HTTPError{
+						StatusCode: 404,
+						URL:        "https://example.com/gone",
+						FinalURL:   "https://example.com/missing",
+						Redirects:  []Redirect{{URL: "https://example.com/gone", StatusCode: 301}},
+					},
+				},
+				redirects: map[string][]Redirect{
+					"https://example.com/old": {
+						{URL: "https://example.com/old", StatusCode: 301},
+						{URL: "https://example.com/mid", StatusCode: 308},
+					},
+					"https://example.com/tmp":   {{URL: "https://example.com/tmp", StatusCode: 302}},
+					"https://example.com/q?x=1": {{URL: "https://example.com/q?x=1", StatusCode: 301}},
+					"https://example.com/away":  {{URL: "https://example.com/away", StatusCode: 301}},
+					"https://example.com/caf%C3%A9%20menu": {
+						{URL: "https://example.com/caf%C3%A9%20menu", StatusCode: 301},
+					},
+					// A redirect to a page dropped by the language filter is still recorded
+					"https://example.com/fr": {{URL: "https://example.com/fr", StatusCode: 301}},
+				},
+			}
+			parser := &mockMetadataParser{
+				links: map[string][]string{
+					"root": {"/old", "/tmp", "/q?x=1", "/away", "/caf%C3%A9%20menu", "/fr", "/gone"},
+				},
+				meta: map[string]*PageMetadata{"fr": {Lang: "fr"}},
+			}
+			var redirectMap bytes.Buffer
+			coord, err := NewCoordinator(Config{
+				StartURL:          "https://example.com/",
+				NumWorkers:        1,
+				Fetcher:           fetcher,
+				Parser:            parser,
+				Output:            &bytes.Buffer{},
+				RedirectMap:       &redirectMap,
+				RedirectMapFormat: tt.format,
+				Languages:         []string{"en"},
+			})
+			if err != nil {
+				t.Fatalf("NewCoordinator() error = %v", err)
+			}
+
+			logs := captureLog(t, func() {
+				if err := coord.Crawl(context.Background()); err != nil {
+					t.Fatalf("Crawl() error = %v", err)
+				}
+			})
+
+			if got := redirectMap.String(); got != tt.want {
+				t.Errorf("redirect map =\n%s\nwant\n%s", got, tt.want)
+			}
+			if !strings.Contains(logs, "skipped_with_query=1") {
+				t.Errorf("missing skipped redirect note:\n%s", logs)
+			}
+		})
+	}
+}
+
+func TestNewCoordinator_RedirectMapFormat(t *testing.T) {
+	_, err := NewCoordinator(Config{
+		StartURL:          "https://example.com/",
+		NumWorkers:        1,
+		Fetcher:           &mockFetcher{},
+		Parser:            &mockParser{},
+		RedirectMapFormat: "caddy",
+	})
+	if err == nil {
+		t.Fatal("expected error for unknown redirect map format")
+	}
+}
@@ -0,0 +1,123 @@
+package crawler
+
+import (
+	"sort"
+	"time"
+)
+
+// pageStat records per-page measurements for the summary reports.
+type pageStat struct {
+	url      string
+	duration time.Duration
+	size     int
+}
+
+// reportsEnabled reports whether any per-page summary report is configured.
+func (c *Coordinator) reportsEnabled() bool {
+	return c.slowTopN > 0 || c.slowThreshold > 0 || c.largeTopN > 0 || c.largeThreshold > 0
+}
+
+// recordStats stores the measurements of a successful page for the summary
+// reports. It is a no-op when no report is enabled.
+func (c *Coordinator) recordStats(result Result) {
+	if !c.reportsEnabled() {
+		return
+	}
+	c.pageStats = append(c.pageStats, pageStat{
+		url:      result.FinalURL,
+		duration: result.FetchDuration,
+		size:     result.BodySize,
+	})
+}
+
+// recordReferrer notes that page links to the URL with the given key, for
+// the large-page, broken link, and JUnit reports. Repeated links from the
+// same page are recorded once.
+func (c *Coordinator) recordReferrer(key, page string) {
+	if c.largeTopN == 0 && c.largeThreshold == 0 && !c.brokenLinksReport && c.junit == nil {
+		return
+	}
+	refs := c.referrers[key]
+	if len(refs) > 0 && refs[len(refs)-1] == page {
+		return
+	}
+	c.referrers[key] = append(refs, page)
+}
+
+// logSlowPages prints the slow-page report to stderr: the slowest N pages,
+// followed by every page whose fetch exceeded the threshold.
+func (c *Coordinator) logSlowPages() {
+	if c.slowTopN == 0 && c.slowThreshold == 0 || len(c.pageStats) == 0 {
+		return
+	}
+
+	sorted := c.sortedStats(func(a, b pageStat) bool { return a.duration > b.duration })
+
+	if c.slowTopN > 0 {
+		n := min(c.slowTopN, len(sorted))
+		c.log().Info("Slowest pages", "count", n)
+		for _, ps := range sorted[:n] {
+			c.log().Info("Slowest page", "url", ps.url, "duration", ps.duration.Round(time.Millisecond))
+		}
+	}
+
+	if c.slowThreshold > 0 {
+		var over []pageStat
+		for _, ps := range sorted {
+			if ps.duration > c.slowThreshold {
+				over = append(over, ps)
+			}
+		}
+		c.log().Info("Pages slower than threshold", "threshold", c.slowThreshold, "count", len(over))
+		for _, ps := range over {
+			c.log().Info("Page slower than threshold", "url", ps.url, "duration", ps.duration.Round(time.Millisecond))
+		}
+	}
+}
+
+// logLargePages prints the large-page report to stderr: the largest N pages
+// by HTML size, followed by every page over the size threshold. Each entry
+// lists the pages that link to it.
+func (c *Coordinator) logLargePages() {
+	if c.largeTopN == 0 && c.largeThreshold == 0 || len(c.pageStats) == 0 {
+		return
+	}
+
+	sorted := c.sortedStats(func(a, b pageStat) bool { return a.size > b.size })
+
+	if c.largeTopN > 0 {
+		n := min(c.largeTopN, len(sorted))
+		c.log().Info("Largest pages", "count", n)
+		for _, ps := range sorted[:n] {
+			c.logLargePage("Largest page", ps)
+		}
+	}
+
+	if c.largeThreshold > 0 {
+		var over []pageStat
+		for _, ps := range sorted {
+			if ps.size > c.largeThreshold {
+				over = append(over, ps)
+			}
+		}
+		c.log().Info("Pages larger than threshold", "threshold_bytes", c.largeThreshold, "count", len(over))
+		for _, ps := range over {
+			c.logLargePage("Page larger than threshold", ps)
+		}
+	}
+}
+
+// logLargePage logs one large-page entry and its referrers.
+func (c *Coordinator) logLargePage(msg string, ps pageStat) {
+	c.log().Info(msg, "url", ps.url, "bytes", ps.size, "linked_from", c.referrers[c.key(ps.url)])
+}
+
+// sortedStats returns a copy of the recorded page stats ordered by less.
+func (c *Coordinator) sortedStats(less func(a, b pageStat) bool) []pageStat {
+	sorted := make([]pageStat, len(c.pageStats))
+	copy(sorted, c.pageStats)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return less(sorted[i], sorted[j])
+	})
+	return sorted
+}
@@ -0,0 +1,116 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureLog redirects the standard logger for the duration of fn and
+// returns what was written.
+func captureLog(t testing.TB, fn func()) string {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	log.SetOutput(buf)
+	defer log.SetOutput(os.Stderr)
+	fn()
+	return buf.String()
+}
+
+func TestLogSlowPages(t *testing.T) {
+	c := &Coordinator{slowTopN: 2, slowThreshold: 150 * time.Millisecond}
+	for _, r := range []Result{
+		{FinalURL: "https://example.com/fast", FetchDuration: 10 * time.Millisecond},
+		{FinalURL: "https://example.com/slowest", FetchDuration: 300 * time.Millisecond},
+		{FinalURL: "https://example.com/medium", FetchDuration: 100 * time.Millisecond},
+		{FinalURL: "https://example.com/slow", FetchDuration: 200 * time.Millisecond},
+	} {
+		c.recordStats(r)
+	}
+
+	out := captureLog(t, c.logSlowPages)
+
+	top := out[strings.Index(out, "Slowest pages count=2"):strings.Index(out, "Pages slower than")]
+	if !strings.Contains(top, "url=https://example.com/slowest duration=300ms") || !strings.Contains(top, "url=https://example.com/slow duration=200ms") {
+		t.Errorf("top-N section missing expected pages:\n%s", top)
+	}
+	if strings.Contains(top, "/medium") || strings.Contains(top, "/fast") {
+		t.Errorf("top-N section contains pages outside the top 2:\n%s", top)
+	}
+
+	over := out[strings.Index(out, "Pages slower than"):]
+	if !strings.Contains(over, "Pages slower than threshold threshold=150ms count=2") {
+		t.Errorf("threshold section has wrong count:\n%s", over)
+	}
+	if strings.Contains(over, "/medium") {
+		t.Errorf("threshold section contains page under threshold:\n%s", over)
+	}
+}
+
+func TestLogSlowPages_Disabled(t *testing.T) {
+	c := &Coordinator{}
+	c.recordStats(Result{FinalURL: "https://example.com/", FetchDuration: time.Second})
+
+	if len(c.pageStats) != 0 {
+		t.Errorf("recorded %d page stats with report disabled, want 0", len(c.pageStats))
+	}
+	if out := captureLog(t, c.logSlowPages); out != "" {
+		t.Errorf("logSlowPages() wrote %q with report disabled", out)
+	}
+}
+
+func TestCoordinator_LargePageReport(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":      []byte("root"),
+			"https://example.com/big":   []byte(strings.Repeat("x", 5000)),
+			"https://example.com/small": []byte("s"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/big", "/big#again", "/small"},
+			"s":    {"/big"},
+		},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:           "https://example.com/",
+		NumWorkers:         1,
+		Fetcher:            fetcher,
+		Parser:             parser,
+		Output:             &bytes.Buffer{},
+		LargePagesTopN:     1,
+		LargePageThreshold: 1000,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	out := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "Largest pages count=1") {
+		t.Errorf("missing top-N header:\n%s", out)
+	}
+	if !strings.Contains(out, "Pages larger than threshold threshold_bytes=1000 count=1") {
+		t.Errorf("missing or wrong threshold section:\n%s", out)
+	}
+	if got := strings.Count(out, "url=https://example.com/big bytes=5000"); got != 2 {
+		t.Errorf("big page listed %d times, want 2 (top-N and threshold)", got)
+	}
+	// Referrers are listed once per linking page, even if linked twice
+	if got := strings.Count(out, `linked_from="[https://example.com/ https://example.com/small]"`); got != 2 {
+		t.Errorf("referrers listed %d times, want 2:\n%s", got, out)
+	}
+	if strings.Contains(out, "url=https://example.com/small ") {
+		t.Errorf("small page listed in large-page report:\n%s", out)
+	}
+}
@@ -0,0 +1,37 @@
+package crawler
+
+import "strings"
+
+// robotsDirectiveNames are the X-Robots-Tag directives that may be followed
+// by a colon, used to tell "unavailable_after: ..." apart from a value
+// scoped to a named crawler such as "googlebot: noindex".
+var robotsDirectiveNames = map[string]bool{
+	"unavailable_after": true,
+	"max-snippet":       true,
+	"max-image-preview": true,
+	"max-video-preview": true,
+}
+
+// robotsTagDirectives reports whether X-Robots-Tag header values ask that a
+// page not be indexed or that its links not be followed. Values scoped to a
+// named crawler are addressed to someone else and ignored.
+func robotsTagDirectives(values []string) (noindex, nofollow bool) {
+	for _, value := range values {
+		if scope, _, ok := strings.Cut(value, ":"); ok {
+			if name := strings.ToLower(strings.TrimSpace(scope)); !robotsDirectiveNames[name] {
+				continue
+			}
+		}
+		for _, directive := range strings.Split(value, ",") {
+			switch strings.ToLower(strings.TrimSpace(directive)) {
+			case "noindex":
+				noindex = true
+			case "nofollow":
+				nofollow = true
+			case "none":
+				noindex, nofollow = true, true
+			}
+		}
+	}
+	return noindex, nofollow
+}
@@ -0,0 +1,118 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRobotsTagDirectives(t *testing.T) {
+	tests := []struct {
+		name         string
+		values       []string
+		wantNoIndex  bool
+		wantNoFollow bool
+	}{
+		{"no header", nil, false, false},
+		{"noindex", []string{"noindex"}, true, false},
+		{"nofollow mixed case", []string{"NoFollow, noarchive"}, false, true},
+		{"none implies both", []string{"none"}, true, true},
+		{"separate headers combine", []string{"noindex", "nofollow"}, true, true},
+		{"scoped to another crawler", []string{"googlebot: noindex, nofollow"}, false, false},
+		{"unavailable_after is not a scope", []string{"unavailable_after: 25 Jun 2030 15:00:00 PST, noindex"}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noindex, nofollow := robotsTagDirectives(tt.values)
+			if noindex != tt.wantNoIndex || nofollow != tt.wantNoFollow {
+				t.Errorf("robotsTagDirectives(%q) = (%v, %v), want (%v, %v)",
+					tt.values, noindex, nofollow, tt.wantNoIndex, tt.wantNoFollow)
+			}
+		})
+	}
+}
+
+func TestCoordinator_RespectRobotsMeta(t *testing.T) {
+	newFetcher := func() *mockFetcher {
+		return &mockFetcher{
+			responses: map[string][]byte{
+				"https://example.com/":        []byte("root"),
+				"https://example.com/meta":    []byte("meta"),
+				"https://example.com/header":  []byte("header"),
+				"https://example.com/private": []byte("private"),
+				"https://example.com/a":       []byte("a"),
+				"https://example.com/b":       []byte("b"),
+			},
+			robotsTags: map[string][]string{
+				"https://example.com/header": {"nofollow"},
+			},
+		}
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root":   {"/meta", "/header", "/private"},
+			"meta":   {"/a"},
+			"header": {"/b"},
+		},
+		meta: map[string]*PageMetadata{
+			"meta":    {NoFollow: true},
+			"private": {NoIndex: true},
+		},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		var out bytes.Buffer
+		coord, err := NewCoordinator(Config{
+			StartURL:   "https://example.com/",
+			NumWorkers: 1,
+			Fetcher:    newFetcher(),
+			Parser:     parser,
+			Output:     &out,
+		})
+		if err != nil {
+			t.Fatalf("NewCoordinator() error = %v", err)
+		}
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+		if coord.visitCount != 6 {
+			t.Errorf("visitCount = %d, want 6", coord.visitCount)
+		}
+		if strings.Contains(out.String(), "Robots:") {
+			t.Errorf("noindex marked without the option:\n%s", out.String())
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		var out bytes.Buffer
+		coord, err := NewCoordinator(Config{
+			StartURL:          "https://example.com/",
+			NumWorkers:        1,
+			Fetcher:           newFetcher(),
+			Parser:            parser,
+			Output:            &out,
+			RespectRobotsMeta: true,
+		})
+		if err != nil {
+			t.Fatalf("NewCoordinator() error = %v", err)
+		}
+		captureLog(t, func() {
+			if err := coord.Crawl(context.Background()); err != nil {
+				t.Fatalf("Crawl() error = %v", err)
+			}
+		})
+
+		got := out.String()
+		if strings.Contains(got, "Visited: https://example.com/a") || strings.Contains(got, "Visited: https://example.com/b") {
+			t.Errorf("links on nofollow pages were followed:\n%s", got)
+		}
+		if !strings.Contains(got, "Visited: https://example.com/meta\nLinks found:\nhttps://example.com/a\n") {
+			t.Errorf("links on nofollow pages should still be printed:\n%s", got)
+		}
+		if !strings.Contains(got, "Visited: https://example.com/private\nRobots: noindex\n") {
+			t.Errorf("noindex page not marked:\n%s", got)
+		}
+	})
+}
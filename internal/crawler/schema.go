@@ -0,0 +1,131 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// schemaRequirements lists the fields each validated schema.org type must
+// carry. Each entry is a set of alternatives, any one of which satisfies it.
+var schemaRequirements = map[string][][]string{
+	"Article":        {{"headline"}, {"author"}, {"datePublished"}},
+	"NewsArticle":    {{"headline"}, {"author"}, {"datePublished"}},
+	"BlogPosting":    {{"headline"}, {"author"}, {"datePublished"}},
+	"Product":        {{"name"}, {"offers", "review", "aggregateRating"}},
+	"BreadcrumbList": {{"itemListElement"}},
+}
+
+// schemaIssue is a structured data problem found on a page.
+type schemaIssue struct {
+	// page is the page carrying the JSON-LD block
+	page string
+	// problem describes what is wrong, e.g. "Article missing author"
+	problem string
+}
+
+// recordSchemaIssues validates each of a page's JSON-LD blocks and stores
+// any problems for the summary.
+func (c *Coordinator) recordSchemaIssues(result Result) {
+	if !c.validateSchema {
+		return
+	}
+
+	for _, block := range result.StructuredData {
+		var data any
+		if err := json.Unmarshal([]byte(block), &data); err != nil {
+			c.schemaIssues = append(c.schemaIssues, schemaIssue{
+				page:    result.FinalURL,
+				problem: fmt.Sprintf("invalid JSON-LD (%v)", err),
+			})
+			continue
+		}
+		for _, problem := range validateSchema(data) {
+			c.schemaIssues = append(c.schemaIssues, schemaIssue{page: result.FinalURL, problem: problem})
+		}
+	}
+}
+
+// validateSchema walks a decoded JSON-LD value, descending into arrays and
+// @graph containers, and returns a description of every item of a known
+// type that lacks a required field.
+func validateSchema(data any) []string {
+	var problems []string
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			problems = append(problems, validateSchema(item)...)
+		}
+	case map[string]any:
+		if graph, ok := v["@graph"]; ok {
+			problems = append(problems, validateSchema(graph)...)
+		}
+		for _, typ := range schemaTypes(v["@type"]) {
+			reqs, ok := schemaRequirements[typ]
+			if !ok {
+				continue
+			}
+			var missing []string
+			for _, alternatives := range reqs {
+				if !hasAnyField(v, alternatives) {
+					missing = append(missing, strings.Join(alternatives, " or "))
+				}
+			}
+			if len(missing) > 0 {
+				problems = append(problems, fmt.Sprintf("%s missing %s", typ, strings.Join(missing, ", ")))
+			}
+		}
+	}
+	return problems
+}
+
+// schemaTypes returns the type names from an @type value, which may be a
+// single string or an array of strings.
+func schemaTypes(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var types []string
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// hasAnyField reports whether obj has a non-empty value for any of fields.
+func hasAnyField(obj map[string]any, fields []string) bool {
+	for _, field := range fields {
+		switch v := obj[field].(type) {
+		case nil:
+			continue
+		case string:
+			if strings.TrimSpace(v) != "" {
+				return true
+			}
+		case []any:
+			if len(v) > 0 {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// logSchemaIssues reports structured data problems found during the crawl.
+func (c *Coordinator) logSchemaIssues() {
+	if !c.validateSchema {
+		return
+	}
+
+	c.log().Info("Structured data issues", "count", len(c.schemaIssues))
+	for _, issue := range c.schemaIssues {
+		c.log().Info("Structured data issue", "url", issue.page, "problem", issue.problem)
+	}
+}
@@ -0,0 +1,110 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want []string
+	}{
+		{
+			name: "complete article",
+			json: `{"@type": "Article", "headline": "Hi", "author": {"name": "A"}, "datePublished": "2024-01-01"}`,
+			want: nil,
+		},
+		{
+			name: "article missing fields",
+			json: `{"@type": "Article", "headline": "Hi", "author": ""}`,
+			want: []string{"Article missing author, datePublished"},
+		},
+		{
+			name: "product satisfied by any offer alternative",
+			json: `{"@type": "Product", "name": "Card", "aggregateRating": {"ratingValue": 5}}`,
+			want: nil,
+		},
+		{
+			name: "product missing offers",
+			json: `{"@type": "Product", "name": "Card", "offers": []}`,
+			want: []string{"Product missing offers or review or aggregateRating"},
+		},
+		{
+			name: "array of items",
+			json: `[{"@type": "BreadcrumbList"}, {"@type": "Organization"}]`,
+			want: []string{"BreadcrumbList missing itemListElement"},
+		},
+		{
+			name: "graph container",
+			json: `{"@context": "https://schema.org", "@graph": [{"@type": ["Product"], "offers": {}}]}`,
+			want: []string{"Product missing name"},
+		},
+		{
+			name: "unknown type ignored",
+			json: `{"@type": "WebSite"}`,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data any
+			if err := json.Unmarshal([]byte(tt.json), &data); err != nil {
+				t.Fatalf("invalid test JSON: %v", err)
+			}
+			if got := validateSchema(data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateSchema() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoordinator_StructuredDataReport(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":     []byte("root"),
+			"https://example.com/shop": []byte("shop"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{"root": {"/shop"}},
+		meta: map[string]*PageMetadata{
+			"root": {StructuredData: []string{`{"@type": "BreadcrumbList", "itemListElement": [{}]}`}},
+			"shop": {StructuredData: []string{`{"@type": "Product"}`, `{"@type": `}},
+		},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:               "https://example.com/",
+		NumWorkers:             1,
+		Fetcher:                fetcher,
+		Parser:                 parser,
+		Output:                 &bytes.Buffer{},
+		ValidateStructuredData: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	out := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "Structured data issues count=2") {
+		t.Errorf("wrong issue count:\n%s", out)
+	}
+	if !strings.Contains(out, `url=https://example.com/shop problem="Product missing name, offers or review or aggregateRating"`) {
+		t.Errorf("missing Product issue:\n%s", out)
+	}
+	if !strings.Contains(out, `url=https://example.com/shop problem="invalid JSON-LD`) {
+		t.Errorf("missing invalid JSON-LD issue:\n%s", out)
+	}
+}
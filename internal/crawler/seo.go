@@ -0,0 +1,102 @@
+package crawler
+
+import (
+	"sort"
+)
+
+// seoReportsEnabled reports whether either SEO report needs link data.
+func (c *Coordinator) seoReportsEnabled() bool {
+	return c.noindexMinLinks > 0 || c.nofollowReport
+}
+
+// nofollowSet returns the keys of the page's links marked rel="nofollow".
+// Returns nil when no SEO report is enabled.
+func (c *Coordinator) nofollowSet(result Result) map[string]bool {
+	if !c.seoReportsEnabled() || len(result.NofollowLinks) == 0 {
+		return nil
+	}
+	set := make(map[string]bool)
+	for _, link := range c.sanitizeLinks(result.NofollowLinks, c.linkBase(result)) {
+		set[c.key(link)] = true
+	}
+	return set
+}
+
+// recordInbound notes that page links to the URL with the given key.
+// A target counts as followable from a page if any of that page's links to
+// it is not nofollow.
+func (c *Coordinator) recordInbound(key, page string, nofollow bool) {
+	if !c.seoReportsEnabled() {
+		return
+	}
+	from, ok := c.inbound[key]
+	if !ok {
+		from = make(map[string]bool)
+		c.inbound[key] = from
+	}
+	from[page] = from[page] || !nofollow
+}
+
+// recordNoindex remembers pages whose robots meta asks not to be indexed.
+func (c *Coordinator) recordNoindex(result Result) {
+	if c.noindexMinLinks > 0 && result.NoIndex {
+		c.noindexed = append(c.noindexed, result.FinalURL)
+	}
+}
+
+// logNoindexLinked reports noindexed pages that are still linked from at
+// least noindexMinLinks internal pages, most-linked first.
+func (c *Coordinator) logNoindexLinked() {
+	if c.noindexMinLinks == 0 {
+		return
+	}
+
+	type linked struct {
+		url   string
+		count int
+	}
+	var found []linked
+	for _, page := range c.noindexed {
+		if n := len(c.inbound[c.key(page)]); n >= c.noindexMinLinks {
+			found = append(found, linked{url: page, count: n})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].count > found[j].count })
+
+	c.log().Info("Noindexed pages linked from many pages", "min_links", c.noindexMinLinks, "count", len(found))
+	for _, l := range found {
+		c.log().Info("Noindexed page linked from many pages", "url", l.url, "links", l.count)
+	}
+}
+
+// logNofollowOnly reports in-scope pages whose every inbound link is
+// rel="nofollow". The start page is reachable by definition and excluded.
+func (c *Coordinator) logNofollowOnly() {
+	if !c.nofollowReport {
+		return
+	}
+
+	startKey := c.key(c.startURL.String())
+	var found []string
+	for key, from := range c.inbound {
+		if key == startKey || !c.visited[key] {
+			continue
+		}
+		followed := false
+		for _, f := range from {
+			if f {
+				followed = true
+				break
+			}
+		}
+		if !followed {
+			found = append(found, key)
+		}
+	}
+	sort.Strings(found)
+
+	c.log().Info("Pages reachable only via nofollow links", "count", len(found))
+	for _, url := range found {
+		c.log().Info("Page reachable only via nofollow links", "url", url)
+	}
+}
@@ -0,0 +1,69 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_SEOReports(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":        []byte("root"),
+			"https://example.com/a":       []byte("a"),
+			"https://example.com/hidden":  []byte("hidden"),
+			"https://example.com/private": []byte("private"),
+			"https://example.com/mixed":   []byte("mixed"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/a", "/hidden", "/private", "/mixed"},
+			"a":    {"/private", "/mixed", "/hidden"},
+		},
+		meta: map[string]*PageMetadata{
+			"root":    {NofollowLinks: []string{"/hidden", "/mixed"}},
+			"a":       {NofollowLinks: []string{"/hidden"}},
+			"private": {NoIndex: true},
+		},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:        "https://example.com/",
+		NumWorkers:      1,
+		Fetcher:         fetcher,
+		Parser:          parser,
+		Output:          &bytes.Buffer{},
+		NoindexMinLinks: 2,
+		NofollowReport:  true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	out := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "Noindexed pages linked from many pages min_links=2 count=1") {
+		t.Errorf("missing noindex report header:\n%s", out)
+	}
+	if !strings.Contains(out, "url=https://example.com/private links=2") {
+		t.Errorf("noindex report missing /private:\n%s", out)
+	}
+
+	nofollowSection := out[strings.Index(out, "Pages reachable only via nofollow links"):]
+	if !strings.Contains(nofollowSection, "Pages reachable only via nofollow links count=1") {
+		t.Errorf("wrong nofollow report count:\n%s", nofollowSection)
+	}
+	if !strings.Contains(nofollowSection, "url=https://example.com/hidden") {
+		t.Errorf("nofollow report missing /hidden:\n%s", nofollowSection)
+	}
+	// /mixed is nofollow from root but followed from /a
+	if strings.Contains(nofollowSection, "/mixed") {
+		t.Errorf("nofollow report lists /mixed despite a followed link:\n%s", nofollowSection)
+	}
+}
@@ -0,0 +1,133 @@
+package crawler
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultNearDuplicateDistance is the default maximum number of differing
+// simhash bits for two pages to count as near duplicates
+const DefaultNearDuplicateDistance = 3
+
+// shingleSize is the number of consecutive words hashed together. Shingles
+// make the fingerprint sensitive to word order, so pages sharing a
+// vocabulary but not their sentences stay apart.
+const shingleSize = 3
+
+// pageFingerprint is the simhash of a fetched page's text.
+type pageFingerprint struct {
+	url  string
+	hash uint64
+}
+
+// simhash returns the 64-bit simhash of text: every shingle votes on each
+// bit with its FNV-1a hash, and a bit is set when most shingles set it.
+// Similar texts get fingerprints that differ in only a few bits. The second
+// result is false when text has no words.
+func simhash(text string) (uint64, bool) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return 0, false
+	}
+
+	n := shingleSize
+	if len(words) < n {
+		n = len(words)
+	}
+	var votes [64]int
+	for i := 0; i+n <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+n], " ")))
+		sum := h.Sum64()
+		for bit := range votes {
+			if sum&(1<<bit) != 0 {
+				votes[bit]++
+			} else {
+				votes[bit]--
+			}
+		}
+	}
+
+	var hash uint64
+	for bit, v := range votes {
+		if v > 0 {
+			hash |= 1 << bit
+		}
+	}
+	return hash, true
+}
+
+// recordFingerprint stores the simhash of a fetched page's text for the
+// near-duplicate report.
+func (c *Coordinator) recordFingerprint(result Result) {
+	if !c.nearDuplicates {
+		return
+	}
+	if hash, ok := simhash(result.Text); ok {
+		c.fingerprints = append(c.fingerprints, pageFingerprint{url: result.FinalURL, hash: hash})
+	}
+}
+
+// nearDuplicateGroups clusters pages whose fingerprints are within the
+// configured distance, transitively: if A is near B and B is near C, all
+// three form one group. Pages are compared pairwise, which is fine for the
+// thousands of pages an audit crawl covers. Groups are sorted by size, then
+// by their first URL; pages within a group are sorted.
+func (c *Coordinator) nearDuplicateGroups() [][]string {
+	parent := make([]int, len(c.fingerprints))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range c.fingerprints {
+		for j := i + 1; j < len(c.fingerprints); j++ {
+			if bits.OnesCount64(c.fingerprints[i].hash^c.fingerprints[j].hash) <= c.nearDupDistance {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	members := make(map[int][]string)
+	for i, fp := range c.fingerprints {
+		root := find(i)
+		members[root] = append(members[root], fp.url)
+	}
+	var groups [][]string
+	for _, urls := range members {
+		if len(urls) > 1 {
+			sort.Strings(urls)
+			groups = append(groups, urls)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i]) != len(groups[j]) {
+			return len(groups[i]) > len(groups[j])
+		}
+		return groups[i][0] < groups[j][0]
+	})
+	return groups
+}
+
+// logNearDuplicates reports groups of pages with nearly identical text.
+func (c *Coordinator) logNearDuplicates() {
+	if !c.nearDuplicates {
+		return
+	}
+
+	groups := c.nearDuplicateGroups()
+	c.log().Info("Near-duplicate groups", "count", len(groups), "max_distance", c.nearDupDistance)
+	for _, urls := range groups {
+		c.log().Info("Near-duplicate group", "pages", len(urls), "urls", urls)
+	}
+}
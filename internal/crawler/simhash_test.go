@@ -0,0 +1,94 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"math/bits"
+	"strings"
+	"testing"
+)
+
+func TestSimhash(t *testing.T) {
+	base := "Our store sells hand made leather boots in many sizes and colors, shipped worldwide within three days of your order"
+	near := "Our store sells hand made leather boots in many sizes and colours, shipped worldwide within three days of your order"
+	other := "The quarterly report covers revenue growth, hiring plans, and the new office opening in the spring next year"
+
+	h1, _ := simhash(base)
+	h2, _ := simhash(near)
+	h3, _ := simhash(other)
+	if d := bits.OnesCount64(h1 ^ h2); d > 12 {
+		t.Errorf("one-word edit changed %d bits", d)
+	}
+	if d := bits.OnesCount64(h1 ^ h3); d < 16 {
+		t.Errorf("unrelated texts differ in only %d bits", d)
+	}
+	if h, _ := simhash(strings.ToUpper(base)); h != h1 {
+		t.Errorf("simhash should ignore case")
+	}
+	if _, ok := simhash(" -- "); ok {
+		t.Errorf("text without words should have no simhash")
+	}
+}
+
+func TestCoordinator_NearDuplicates(t *testing.T) {
+	boilerplate := strings.Repeat("Shop our collection of shoes boots and sandals with free returns. ", 5)
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":       []byte("root"),
+			"https://example.com/red":    []byte("red"),
+			"https://example.com/blue":   []byte("blue"),
+			"https://example.com/report": []byte("report"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/red", "/blue", "/report"},
+		},
+		meta: map[string]*PageMetadata{
+			"root":   {Text: "Welcome to the shop, browse by category or search for a product by name"},
+			"red":    {Text: boilerplate + "Color red."},
+			"blue":   {Text: boilerplate + "Color blue."},
+			"report": {Text: "Annual report: revenue grew while costs fell across all regions this year"},
+		},
+	}
+
+	coord, err := NewCoordinator(Config{
+		StartURL:       "https://example.com/",
+		NumWorkers:     2,
+		Fetcher:        fetcher,
+		Parser:         parser,
+		Output:         &bytes.Buffer{},
+		NearDuplicates: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	logs := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	if !strings.Contains(logs, "Near-duplicate groups count=1 max_distance=3") {
+		t.Errorf("wrong group count:\n%s", logs)
+	}
+	if !strings.Contains(logs, `Near-duplicate group pages=2 urls="[https://example.com/blue https://example.com/red]"`) {
+		t.Errorf("missing red/blue group:\n%s", logs)
+	}
+}
+
+func TestNewCoordinator_InvalidNearDuplicateDistance(t *testing.T) {
+	for _, distance := range []int{-1, 65} {
+		_, err := NewCoordinator(Config{
+			StartURL:              "https://example.com/",
+			NumWorkers:            1,
+			Fetcher:               &mockFetcher{},
+			Parser:                &mockParser{},
+			NearDuplicateDistance: distance,
+		})
+		if err == nil {
+			t.Errorf("NearDuplicateDistance %d should be rejected", distance)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// maxSitemapURLs is the sitemaps.org limit on URLs in a single sitemap file.
+const maxSitemapURLs = 50000
+
+// recordSitemap adds a fetched HTML page to the sitemap. Pages that asked
+// not to be indexed are left out, as are pages redirected off the crawled host.
+func (c *Coordinator) recordSitemap(result Result) {
+	if c.sitemap == nil {
+		return
+	}
+	if !isHTML(result.ContentType) || result.NoIndex || !c.scope.InScope(result.FinalURL) {
+		return
+	}
+
+	key := c.key(result.FinalURL)
+	if _, ok := c.sitemapPages[key]; ok {
+		return
+	}
+	c.sitemapPages[key] = sitemapEntry{loc: result.FinalURL, lastMod: result.LastModified}
+}
+
+// sitemapEntry is one page listed in the sitemap.
+type sitemapEntry struct {
+	// loc is the page URL as fetched
+	loc string
+	// lastMod is the page's Last-Modified time (zero = omitted)
+	lastMod time.Time
+}
+
+// writeSitemap writes the collected pages as a sitemaps.org sitemap.xml,
+// sorted by URL. Pages beyond the 50,000 URL limit are dropped and logged.
+func (c *Coordinator) writeSitemap() {
+	if c.sitemap == nil {
+		return
+	}
+
+	keys := make([]string, 0, len(c.sitemapPages))
+	for key := range c.sitemapPages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	dropped := 0
+	if len(keys) > maxSitemapURLs {
+		dropped = len(keys) - maxSitemapURLs
+		keys = keys[:maxSitemapURLs]
+	}
+
+	w := bufio.NewWriter(c.sitemap)
+	fmt.Fprint(w, xml.Header)
+	fmt.Fprintln(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	for _, key := range keys {
+		entry := c.sitemapPages[key]
+		fmt.Fprint(w, "  <url>\n    <loc>")
+		xml.EscapeText(w, []byte(entry.loc))
+		fmt.Fprint(w, "</loc>\n")
+		if !entry.lastMod.IsZero() {
+			fmt.Fprintf(w, "    <lastmod>%s</lastmod>\n", entry.lastMod.UTC().Format(time.RFC3339))
+		}
+		fmt.Fprint(w, "  </url>\n")
+	}
+	fmt.Fprintln(w, "</urlset>")
+	if err := w.Flush(); err != nil {
+		c.log().Error("Error writing sitemap", "error", err)
+		return
+	}
+
+	attrs := []any{"pages", len(keys)}
+	if dropped > 0 {
+		attrs = append(attrs, "left_out", dropped, "limit", maxSitemapURLs)
+	}
+	c.log().Info("Sitemap", attrs...)
+}
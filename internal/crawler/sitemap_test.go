@@ -0,0 +1,85 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCoordinator_Sitemap(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":          []byte("root"),
+			"https://example.com/b?x=1&y=2": []byte("b"),
+			"https://example.com/a":         []byte("a"),
+			"https://example.com/old":       []byte("a"),
+			"https://example.com/hidden":    []byte("hidden"),
+			"https://example.com/file.pdf":  []byte("%PDF"),
+			"https://example.com/away":      []byte("away"),
+		},
+		contentTypes: map[string]string{
+			"https://example.com/file.pdf": "application/pdf",
+		},
+		finalURLs: map[string]string{
+			"https://example.com/old":  "https://example.com/a",
+			"https://example.com/away": "https://other.com/",
+		},
+		robotsTags: map[string][]string{
+			"https://example.com/hidden": {"noindex"},
+		},
+		lastModified: map[string]time.Time{
+			"https://example.com/a": time.Date(2026, 3, 3, 10, 15, 0, 0, time.FixedZone("CET", 3600)),
+		},
+		errors: map[string]error{
+			"https://example.com/broken": errors.New("connection refused"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/a", "/old", "/b?x=1&y=2", "/hidden", "/file.pdf", "/away", "/broken"},
+		},
+	}
+
+	sitemap := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     parser,
+		Output:     &bytes.Buffer{},
+		Sitemap:    sitemap,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	out := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	// Only successful, indexable, on-site HTML pages are listed, once each
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/</loc>
+  </url>
+  <url>
+    <loc>https://example.com/a</loc>
+    <lastmod>2026-03-03T09:15:00Z</lastmod>
+  </url>
+  <url>
+    <loc>https://example.com/b?x=1&amp;y=2</loc>
+  </url>
+</urlset>
+`
+	if got := sitemap.String(); got != want {
+		t.Errorf("sitemap =\n%s\nwant\n%s", got, want)
+	}
+	if !strings.Contains(out, "Sitemap pages=3") {
+		t.Errorf("missing sitemap summary:\n%s", out)
+	}
+}
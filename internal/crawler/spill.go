@@ -0,0 +1,268 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpillFrontier is a breadth-first Frontier with bounded memory: it keeps
+// up to a fixed number of items in memory and appends the rest to a
+// temporary file, reading them back in order as the head drains. Pushing
+// never blocks, however link-dense the site. Close removes the file.
+//
+// Write errors (e.g. a full disk) stop the spilling and keep later items
+// in memory instead. Items that can no longer be read back are dropped and
+// reported by the coordinator as errors; Err returns the first failure.
+type SpillFrontier struct {
+	// limit is the number of items held in memory before spilling
+	limit int
+	// head holds the oldest items, in memory
+	head queueFrontier
+	// tail holds the newest items in memory once spilling has failed
+	tail queueFrontier
+	// file is the spill file, appended through w and read back through r
+	file *os.File
+	w    *bufio.Writer
+	r    *bufio.Reader
+	// reader is the spill file's second handle, for reading
+	reader *os.File
+	// onDisk counts items written but not yet read back
+	onDisk int
+	// spans keeps the trace spans of spilled items, which can't be
+	// serialized, by spill sequence number
+	spans map[uint64]trace.Span
+	seq   uint64
+	// lost counts items dropped since the coordinator last asked
+	lost int
+	err  error
+}
+
+// spilledItem is a WorkItem as written to the spill file.
+type spilledItem struct {
+	URL          string    `json:"u"`
+	Depth        int       `json:"d"`
+	Referrer     string    `json:"r,omitempty"`
+	ETag         string    `json:"e,omitempty"`
+	LastModified time.Time `json:"m,omitzero"`
+	CheckOnly    bool      `json:"c,omitempty"`
+	// Seq identifies the item's span in SpillFrontier.spans (0 = untraced)
+	Seq uint64 `json:"s,omitempty"`
+}
+
+// NewSpillFrontier returns a SpillFrontier holding up to limit items in
+// memory and spilling the rest to a new file in dir (the default temporary
+// directory when empty).
+func NewSpillFrontier(limit int, dir string) (*SpillFrontier, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("spill frontier memory limit must be positive, got %d", limit)
+	}
+	file, err := os.CreateTemp(dir, "crawler-frontier-*.jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("creating frontier spill file: %w", err)
+	}
+	reader, err := os.Open(file.Name())
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("opening frontier spill file: %w", err), file.Close(), os.Remove(file.Name()))
+	}
+	return &SpillFrontier{
+		limit:  limit,
+		file:   file,
+		w:      bufio.NewWriter(file),
+		reader: reader,
+		r:      bufio.NewReader(reader),
+		spans:  make(map[uint64]trace.Span),
+	}, nil
+}
+
+func (s *SpillFrontier) Push(item WorkItem) {
+	switch {
+	case s.err != nil:
+		s.tail.Push(item)
+	case s.onDisk == 0 && s.head.Len() < s.limit:
+		s.head.Push(item)
+	default:
+		if err := s.spill(item); err != nil {
+			s.fail(err)
+			s.tail.Push(item)
+		}
+	}
+}
+
+func (s *SpillFrontier) Peek() (WorkItem, bool) {
+	s.refill()
+	if item, ok := s.head.Peek(); ok {
+		return item, true
+	}
+	return s.tail.Peek()
+}
+
+func (s *SpillFrontier) Pop() (WorkItem, bool) {
+	s.refill()
+	if item, ok := s.head.Pop(); ok {
+		return item, true
+	}
+	return s.tail.Pop()
+}
+
+func (s *SpillFrontier) Len() int {
+	return s.head.Len() + s.onDisk + s.tail.Len()
+}
+
+// Err returns the first spill file error, if any.
+func (s *SpillFrontier) Err() error {
+	return s.err
+}
+
+// Close closes and removes the spill file.
+func (s *SpillFrontier) Close() error {
+	readErr := s.reader.Close()
+	writeErr := s.file.Close()
+	if err := os.Remove(s.file.Name()); err != nil {
+		return fmt.Errorf("removing frontier spill file: %w", err)
+	}
+	if err := errors.Join(readErr, writeErr); err != nil {
+		return fmt.Errorf("closing frontier spill file: %w", err)
+	}
+	return nil
+}
+
+// spill appends item to the spill file.
+func (s *SpillFrontier) spill(item WorkItem) error {
+	rec := spilledItem{
+		URL:          item.URL,
+		Depth:        item.Depth,
+		Referrer:     item.Referrer,
+		ETag:         item.Validators.ETag,
+		LastModified: item.Validators.LastModified,
+		CheckOnly:    item.CheckOnly,
+	}
+	if item.Span != nil {
+		s.seq++
+		rec.Seq = s.seq
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encoding frontier item: %w", err)
+	}
+	if _, err := s.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("writing frontier spill file: %w", err)
+	}
+	if item.Span != nil {
+		s.spans[rec.Seq] = item.Span
+	}
+	s.onDisk++
+	return nil
+}
+
+// refill moves up to limit spilled items back into the empty head. Once
+// the file is drained it is truncated, so it only grows as large as the
+// backlog.
+func (s *SpillFrontier) refill() {
+	if s.head.Len() > 0 || s.onDisk == 0 {
+		return
+	}
+	if err := s.w.Flush(); err != nil {
+		s.drop(fmt.Errorf("writing frontier spill file: %w", err))
+		return
+	}
+	for s.onDisk > 0 && s.head.Len() < s.limit {
+		line, err := s.r.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			s.drop(fmt.Errorf("reading frontier spill file: %w", err))
+			return
+		}
+		var rec spilledItem
+		if err := json.Unmarshal(line, &rec); err != nil {
+			s.drop(fmt.Errorf("decoding frontier spill file: %w", err))
+			return
+		}
+		item := WorkItem{
+			URL:        rec.URL,
+			Depth:      rec.Depth,
+			Referrer:   rec.Referrer,
+			Validators: Validators{ETag: rec.ETag, LastModified: rec.LastModified},
+			CheckOnly:  rec.CheckOnly,
+		}
+		if rec.Seq != 0 {
+			item.Span = s.spans[rec.Seq]
+			delete(s.spans, rec.Seq)
+		}
+		s.head.Push(item)
+		s.onDisk--
+	}
+	if s.onDisk == 0 {
+		if err := s.rewind(); err != nil {
+			s.fail(err)
+		}
+	}
+}
+
+// rewind empties the drained spill file.
+func (s *SpillFrontier) rewind() error {
+	if err := s.file.Truncate(0); err != nil {
+		return fmt.Errorf("truncating frontier spill file: %w", err)
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("rewinding frontier spill file: %w", err)
+	}
+	if _, err := s.reader.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("rewinding frontier spill file: %w", err)
+	}
+	s.w.Reset(s.file)
+	s.r.Reset(s.reader)
+	return nil
+}
+
+// drop gives up on the items still in the spill file after err.
+func (s *SpillFrontier) drop(err error) {
+	s.fail(err)
+	s.lost += s.onDisk
+	s.onDisk = 0
+	for seq, span := range s.spans {
+		span.End()
+		delete(s.spans, seq)
+	}
+}
+
+// fail records the first error; spilling stops from then on.
+func (s *SpillFrontier) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// takeLost returns the number of items dropped since the last call.
+func (s *SpillFrontier) takeLost() int {
+	n := s.lost
+	s.lost = 0
+	return n
+}
+
+// releaseLostWork settles the work a SpillFrontier dropped: each lost page
+// counts as an error and releases its WaitGroup slot, so the crawl still
+// terminates. With a checkpoint, lost pages stay in its saved frontier.
+func (c *Coordinator) releaseLostWork() {
+	s, ok := c.pending.(*SpillFrontier)
+	if !ok {
+		return
+	}
+	n := s.takeLost()
+	if n == 0 {
+		return
+	}
+	c.errorCount += n
+	c.log().Error("Frontier lost scheduled pages", "count", n, "error", s.Err())
+	for ; n > 0; n-- {
+		c.wg.Done()
+	}
+}
@@ -0,0 +1,201 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func newTestSpillFrontier(t *testing.T, limit int) *SpillFrontier {
+	t.Helper()
+	s, err := NewSpillFrontier(limit, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewSpillFrontier() error = %v", err)
+	}
+	t.Cleanup(func() {
+		if err := s.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	})
+	return s
+}
+
+func TestSpillFrontier_KeepsOrder(t *testing.T) {
+	s := newTestSpillFrontier(t, 2)
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	_, span := noop.NewTracerProvider().Tracer("test").Start(context.Background(), "page")
+
+	s.Push(WorkItem{URL: "a"})
+	s.Push(WorkItem{URL: "b"})
+	s.Push(WorkItem{URL: "c", Depth: 2, Referrer: "a", Validators: Validators{ETag: `"x"`, LastModified: modified}, Span: span})
+	s.Push(WorkItem{URL: "d", CheckOnly: true})
+	if s.Len() != 4 || s.head.Len() != 2 || s.onDisk != 2 {
+		t.Fatalf("Len() = %d with %d in memory and %d on disk, want 4, 2, 2", s.Len(), s.head.Len(), s.onDisk)
+	}
+
+	if item, _ := s.Pop(); item.URL != "a" {
+		t.Fatalf("Pop() = %q, want a", item.URL)
+	}
+	// Pushed while c and d are on disk, so e must follow them
+	s.Push(WorkItem{URL: "e"})
+	if item, _ := s.Pop(); item.URL != "b" {
+		t.Fatalf("Pop() = %q, want b", item.URL)
+	}
+
+	c, ok := s.Peek()
+	if !ok || c.URL != "c" {
+		t.Fatalf("Peek() = %q, want c", c.URL)
+	}
+	want := WorkItem{URL: "c", Depth: 2, Referrer: "a", Validators: Validators{ETag: `"x"`, LastModified: modified}, Span: span}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("spilled item came back as %+v, want %+v", c, want)
+	}
+	if got := popAll(s); !reflect.DeepEqual(got, []string{"c", "d", "e"}) {
+		t.Errorf("pop order = %v, want [c d e]", got)
+	}
+	if s.Len() != 0 || s.Err() != nil {
+		t.Errorf("Len() = %d, Err() = %v after draining", s.Len(), s.Err())
+	}
+
+	info, err := s.file.Stat()
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("drained spill file is %d bytes, want it truncated", info.Size())
+	}
+}
+
+func TestSpillFrontier_CloseRemovesFile(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSpillFrontier(1, dir)
+	if err != nil {
+		t.Fatalf("NewSpillFrontier() error = %v", err)
+	}
+	s.Push(WorkItem{URL: "a"})
+	s.Push(WorkItem{URL: "b"})
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("spill file left behind: %v", entries)
+	}
+
+	if _, err := NewSpillFrontier(0, dir); err == nil {
+		t.Errorf("NewSpillFrontier() should reject a zero limit")
+	}
+	if _, err := NewSpillFrontier(1, filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("NewSpillFrontier() should fail for a missing directory")
+	}
+}
+
+func TestSpillFrontier_LostWorkIsReleased(t *testing.T) {
+	s := newTestSpillFrontier(t, 1)
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    &mockFetcher{},
+		Parser:     &mockParser{},
+		Output:     io.Discard,
+		Frontier:   s,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	for _, u := range []string{"a", "b", "c"} {
+		coord.wg.Add(1)
+		s.Push(WorkItem{URL: u})
+	}
+	s.Pop()
+	coord.wg.Done()
+
+	// The spill file is cut short, so b and c can't be read back
+	if err := s.w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if err := os.Truncate(s.file.Name(), 3); err != nil {
+		t.Fatalf("Truncate() error = %v", err)
+	}
+	logs := captureLog(t, func() {
+		if _, ok := s.Peek(); ok {
+			t.Errorf("Peek() found an item after the spill file failed")
+		}
+		coord.releaseLostWork()
+	})
+
+	if s.Err() == nil {
+		t.Errorf("Err() = nil after a failed read")
+	}
+	if coord.errorCount != 2 {
+		t.Errorf("errorCount = %d, want 2 lost pages", coord.errorCount)
+	}
+	if !strings.Contains(logs, "Frontier lost scheduled pages count=2") {
+		t.Errorf("missing lost pages log:\n%s", logs)
+	}
+	done := make(chan struct{})
+	go func() {
+		coord.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("lost pages still hold the WaitGroup")
+	}
+
+	// Later pushes stay in memory
+	s.Push(WorkItem{URL: "d"})
+	if item, ok := s.Pop(); !ok || item.URL != "d" {
+		t.Errorf("Pop() = %q, %v after failure, want d", item.URL, ok)
+	}
+}
+
+func TestCoordinator_SpillFrontier(t *testing.T) {
+	const numLinks = 300
+	fetcher := &mockFetcher{responses: map[string][]byte{"https://example.com/": []byte("root")}}
+	var links []string
+	for i := 0; i < numLinks; i++ {
+		link := fmt.Sprintf("https://example.com/p%d", i)
+		links = append(links, link)
+		fetcher.responses[link] = []byte("leaf")
+	}
+	parser := &mockMetadataParser{links: map[string][]string{"root": links}}
+
+	s := newTestSpillFrontier(t, 10)
+	out := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 4,
+		Fetcher:    fetcher,
+		Parser:     parser,
+		Output:     out,
+		Frontier:   s,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	if got := strings.Count(out.String(), "Visited:"); got != numLinks+1 {
+		t.Errorf("visited %d pages, want %d", got, numLinks+1)
+	}
+	if s.Err() != nil {
+		t.Errorf("Err() = %v", s.Err())
+	}
+}
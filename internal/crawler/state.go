@@ -0,0 +1,125 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"time"
+)
+
+// PageState is what a crawl remembers about a page for the next crawl: its
+// validators for a conditional request, and its links, which stand in for
+// the page's content when the server reports it unchanged.
+type PageState struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified time.Time `json:"last_modified,omitzero"`
+	Links        []string  `json:"links"`
+}
+
+// ReadCrawlState reads the JSON lines written through Config.CrawlState by
+// an earlier crawl, keyed by URL key, for Config.PreviousCrawl.
+func ReadCrawlState(r io.Reader) (map[string]PageState, error) {
+	states := make(map[string]PageState)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024) // Pages with many links make long lines
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var st PageState
+		if err := json.Unmarshal(scanner.Bytes(), &st); err != nil {
+			return nil, fmt.Errorf("crawl state line %d: %w", line, err)
+		}
+		states[Key(st.URL)] = st
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading crawl state: %w", err)
+	}
+	return states, nil
+}
+
+// validatorsFor returns the previous crawl's validators for a URL, if any.
+func (c *Coordinator) validatorsFor(url string) Validators {
+	st, ok := c.previous[Key(url)]
+	if !ok {
+		return Validators{}
+	}
+	return Validators{ETag: st.ETag, LastModified: st.LastModified}
+}
+
+// restoreUnchanged gives a page the server reported unchanged the links it
+// had in the previous crawl, so the crawl continues through it.
+func (c *Coordinator) restoreUnchanged(result *Result) {
+	if !result.NotModified {
+		return
+	}
+	c.unchanged++
+	if st, ok := c.previous[Key(result.URL)]; ok {
+		result.Links = st.Links
+	}
+}
+
+// recordState remembers a fetched page's validators and absolute links for
+// the next crawl.
+func (c *Coordinator) recordState(result Result) {
+	if c.stateOut == nil {
+		return
+	}
+	links, _ := c.pageLinks(result)
+	c.state[Key(result.URL)] = PageState{
+		URL:          result.URL,
+		ETag:         result.ETag,
+		LastModified: result.LastModified,
+		Links:        slices.Clone(links),
+	}
+}
+
+// writeCrawlState writes the state of every page fetched, sorted by URL.
+// If the crawl stopped early, pages it did not reach keep their previous
+// state, so the next crawl can still revalidate them.
+func (c *Coordinator) writeCrawlState(complete bool) {
+	if c.stateOut == nil {
+		return
+	}
+	if !complete {
+		for key, st := range c.previous {
+			if _, ok := c.state[key]; !ok {
+				c.state[key] = st
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(c.state))
+	for key := range c.state {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	w := bufio.NewWriter(c.stateOut)
+	enc := json.NewEncoder(w)
+	for _, key := range keys {
+		st := c.state[key]
+		if st.Links == nil {
+			st.Links = []string{}
+		}
+		if err := enc.Encode(st); err != nil {
+			c.log().Error("Error writing crawl state", "error", err)
+			return
+		}
+	}
+	if err := w.Flush(); err != nil {
+		c.log().Error("Error writing crawl state", "error", err)
+	}
+}
+
+// logUnchanged prints how many pages were unchanged since the previous crawl.
+func (c *Coordinator) logUnchanged() {
+	if c.previous == nil {
+		return
+	}
+	c.log().Info("Unchanged since previous crawl", "pages", c.unchanged)
+}
@@ -0,0 +1,141 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// conditionalFetcher is a mockFetcher whose pages carry ETags and answer
+// revalidation with 304 while the ETag still matches.
+type conditionalFetcher struct {
+	mockFetcher
+	etags map[string]string
+
+	mu         sync.Mutex
+	validators map[string]Validators // Validators received per URL
+}
+
+func (f *conditionalFetcher) Fetch(ctx context.Context, url string) (*FetchResult, error) {
+	result, err := f.mockFetcher.Fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	result.ETag = f.etags[url]
+	return result, nil
+}
+
+func (f *conditionalFetcher) FetchIfModified(ctx context.Context, url string, v Validators) (*FetchResult, error) {
+	f.mu.Lock()
+	f.validators[url] = v
+	f.mu.Unlock()
+
+	if v.ETag != "" && v.ETag == f.etags[url] {
+		return &FetchResult{Body: []byte{}, FinalURL: url, ETag: v.ETag, NotModified: true}, nil
+	}
+	return f.Fetch(ctx, url)
+}
+
+func TestCoordinator_IncrementalRecrawl(t *testing.T) {
+	fetcher := &conditionalFetcher{
+		mockFetcher: mockFetcher{
+			responses: map[string][]byte{
+				"https://example.com/":  []byte("root"),
+				"https://example.com/a": []byte("a"),
+				"https://example.com/b": []byte("b"),
+			},
+			lastModified: map[string]time.Time{
+				"https://example.com/b": time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+		},
+		etags: map[string]string{
+			"https://example.com/":  `"root-v1"`,
+			"https://example.com/a": `"a-v1"`,
+		},
+		validators: make(map[string]Validators),
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{
+			"root": {"/a", "/b"},
+			"a":    {"/"},
+		},
+	}
+	crawl := func(previous map[string]PageState) (string, string) {
+		output := &bytes.Buffer{}
+		state := &bytes.Buffer{}
+		coord, err := NewCoordinator(Config{
+			StartURL:      "https://example.com/",
+			NumWorkers:    1,
+			Fetcher:       fetcher,
+			Parser:        parser,
+			Output:        output,
+			PreviousCrawl: previous,
+			CrawlState:    state,
+		})
+		if err != nil {
+			t.Fatalf("NewCoordinator() error = %v", err)
+		}
+		captureLog(t, func() {
+			if err := coord.Crawl(context.Background()); err != nil {
+				t.Fatalf("Crawl() error = %v", err)
+			}
+		})
+		return output.String(), state.String()
+	}
+
+	// First crawl: no validators to send, everything fetched in full
+	out, state := crawl(nil)
+	if len(fetcher.validators) != 0 || strings.Contains(out, "Not modified") {
+		t.Fatalf("first crawl revalidated pages: %v\n%s", fetcher.validators, out)
+	}
+	previous, err := ReadCrawlState(strings.NewReader(state))
+	if err != nil {
+		t.Fatalf("ReadCrawlState() error = %v", err)
+	}
+	if len(previous) != 3 {
+		t.Fatalf("state has %d pages, want 3:\n%s", len(previous), state)
+	}
+
+	// The root page changes; /a does not
+	fetcher.etags["https://example.com/"] = `"root-v2"`
+	parser.links["root"] = []string{"/a", "/b", "/c"}
+	fetcher.responses["https://example.com/c"] = []byte("c")
+	fetcher.responses["https://example.com/a"] = []byte("a-v2 must not be read")
+	out, state = crawl(previous)
+
+	if v := fetcher.validators["https://example.com/b"]; !v.LastModified.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("/b revalidated with %+v, want its Last-Modified", v)
+	}
+	if !strings.Contains(out, "Visited: https://example.com/a\nNot modified\nLinks found:\nhttps://example.com/\n") {
+		t.Errorf("/a should be printed as not modified with its stored links:\n%s", out)
+	}
+	if !strings.Contains(out, "Visited: https://example.com/c\n") {
+		t.Errorf("new page /c not crawled:\n%s", out)
+	}
+	if strings.Count(out, "Not modified") != 1 {
+		t.Errorf("only /a is unchanged:\n%s", out)
+	}
+
+	// Unchanged pages keep their links and validators in the new state
+	next, err := ReadCrawlState(strings.NewReader(state))
+	if err != nil {
+		t.Fatalf("ReadCrawlState() error = %v", err)
+	}
+	a := next[Key("https://example.com/a")]
+	if a.ETag != `"a-v1"` || len(a.Links) != 1 || a.Links[0] != "https://example.com/" {
+		t.Errorf("state for /a = %+v, want previous ETag and links", a)
+	}
+	if len(next) != 4 {
+		t.Errorf("state has %d pages, want 4", len(next))
+	}
+}
+
+func TestReadCrawlState_Invalid(t *testing.T) {
+	if _, err := ReadCrawlState(strings.NewReader("{\"url\":\"https://example.com/\"}\nnot json\n")); err == nil ||
+		!strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ReadCrawlState() error = %v, want a line 2 error", err)
+	}
+}
@@ -0,0 +1,292 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Page behaviours in a generated site graph.
+const (
+	graphPageOK = iota
+	graphPageError
+	graphPagePanic
+	graphPageRedirect
+)
+
+// siteGraph is a randomly generated site: pages with outgoing links, some of
+// which fail, panic, or redirect to another page.
+type siteGraph struct {
+	urls     []string
+	kinds    []int
+	links    [][]string
+	redirect []int
+}
+
+// newSiteGraph builds a graph of up to maxNodes pages from seed. Page 0 is
+// the start URL. Links may point anywhere, including back to the linking
+// page, so cycles are common.
+func newSiteGraph(seed int64, maxNodes int) *siteGraph {
+	rng := rand.New(rand.NewSource(seed))
+	n := 1 + rng.Intn(maxNodes)
+
+	g := &siteGraph{
+		urls:     make([]string, n),
+		kinds:    make([]int, n),
+		links:    make([][]string, n),
+		redirect: make([]int, n),
+	}
+	for i := range g.urls {
+		g.urls[i] = fmt.Sprintf("https://example.com/p%d", i)
+	}
+	g.urls[0] = "https://example.com/"
+
+	for i := range g.urls {
+		switch r := rng.Intn(100); {
+		case i == 0:
+			g.kinds[i] = graphPageOK
+		case r < 10:
+			g.kinds[i] = graphPageError
+		case r < 15:
+			g.kinds[i] = graphPagePanic
+		case r < 30:
+			g.kinds[i] = graphPageRedirect
+			g.redirect[i] = rng.Intn(n)
+		default:
+			g.kinds[i] = graphPageOK
+		}
+
+		for j := rng.Intn(6); j > 0; j-- {
+			target := g.urls[rng.Intn(n)]
+			switch rng.Intn(10) {
+			case 0:
+				target = "https://external.com/"
+			case 1:
+				target += "#section"
+			case 2:
+				target = "mailto:someone@example.com"
+			}
+			g.links[i] = append(g.links[i], target)
+		}
+	}
+	return g
+}
+
+// graphFetcher serves a siteGraph and counts fetches per URL.
+// It is safe for concurrent use by workers.
+type graphFetcher struct {
+	graph *siteGraph
+	index map[string]int
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func newGraphFetcher(g *siteGraph) *graphFetcher {
+	index := make(map[string]int, len(g.urls))
+	for i, u := range g.urls {
+		index[u] = i
+	}
+	return &graphFetcher{graph: g, index: index, calls: make(map[string]int)}
+}
+
+func (f *graphFetcher) Fetch(ctx context.Context, url string) (*FetchResult, error) {
+	f.mu.Lock()
+	f.calls[url]++
+	f.mu.Unlock()
+
+	i, ok := f.index[url]
+	if !ok {
+		return nil, errors.New("url not in graph")
+	}
+	switch f.graph.kinds[i] {
+	case graphPageError:
+		return nil, &HTTPError{StatusCode: 500}
+	case graphPagePanic:
+		panic("fetcher exploded")
+	case graphPageRedirect:
+		target := f.graph.redirect[i]
+		return &FetchResult{
+			Body:        []byte(fmt.Sprint(target)),
+			FinalURL:    f.graph.urls[target],
+			ContentType: "text/html",
+			Redirects:   []Redirect{{URL: url, StatusCode: 301}},
+		}, nil
+	}
+	return &FetchResult{
+		Body:        []byte(fmt.Sprint(i)),
+		FinalURL:    url,
+		ContentType: "text/html",
+	}, nil
+}
+
+// totalCalls returns the number of fetches and the largest per-URL count.
+func (f *graphFetcher) totalCalls() (total, most int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, n := range f.calls {
+		total += n
+		most = max(most, n)
+	}
+	return total, most
+}
+
+// graphParser returns the outgoing links of the page whose index is the body.
+type graphParser struct {
+	graph *siteGraph
+}
+
+func (p *graphParser) ExtractLinks(r io.Reader) ([]string, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var i int
+	if _, err := fmt.Sscan(string(body), &i); err != nil {
+		return nil, err
+	}
+	return p.graph.links[i], nil
+}
+
+// checkTermination crawls a generated graph and asserts the coordinator
+// terminates, fetches no URL twice, and produces exactly one result for
+// every URL it scheduled.
+func checkTermination(t *testing.T, seed int64, workers, maxPages int) {
+	t.Helper()
+
+	graph := newSiteGraph(seed, 60)
+	fetcher := newGraphFetcher(graph)
+	var events bytes.Buffer
+	coord, err := NewCoordinator(Config{
+		StartURL:   graph.urls[0],
+		NumWorkers: workers,
+		MaxPages:   maxPages,
+		Fetcher:    fetcher,
+		Parser:     &graphParser{graph: graph},
+		Output:     io.Discard,
+		Events:     &events,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+
+	done := make(chan error, 1)
+	captureLog(t, func() {
+		go func() { done <- coord.Crawl(context.Background()) }()
+		select {
+		case err = <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("seed %d: crawl did not terminate", seed)
+		}
+	})
+	if err != nil {
+		t.Fatalf("seed %d: Crawl() error = %v", seed, err)
+	}
+
+	var results, scheduled int
+	scanner := bufio.NewScanner(&events)
+	for scanner.Scan() {
+		var ev Event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("invalid event %q: %v", scanner.Text(), err)
+		}
+		switch ev.Type {
+		case EventPageFetched, EventPageFailed:
+			results++
+		case EventCrawlFinished:
+			scheduled = ev.Pages
+		}
+	}
+
+	fetches, most := fetcher.totalCalls()
+	if most > 1 {
+		t.Errorf("seed %d: a URL was fetched %d times", seed, most)
+	}
+	if fetches != scheduled || results != scheduled {
+		t.Errorf("seed %d: scheduled %d URLs, fetched %d, got %d results", seed, scheduled, fetches, results)
+	}
+	if maxPages > 0 && scheduled > maxPages {
+		t.Errorf("seed %d: scheduled %d URLs, exceeding max pages %d", seed, scheduled, maxPages)
+	}
+}
+
+func TestCoordinator_TerminatesOnRandomGraphs(t *testing.T) {
+	seeds := 200
+	if testing.Short() {
+		seeds = 20
+	}
+	for seed := int64(1); seed <= int64(seeds); seed++ {
+		workers := 1 + int(seed%8)
+		maxPages := 0
+		if seed%3 == 0 {
+			maxPages = int(seed % 20)
+		}
+		checkTermination(t, seed, workers, maxPages)
+	}
+}
+
+func FuzzCoordinatorTermination(f *testing.F) {
+	f.Add(int64(1), uint8(1), uint8(0))
+	f.Add(int64(42), uint8(4), uint8(0))
+	f.Add(int64(7), uint8(16), uint8(5))
+	f.Fuzz(func(t *testing.T, seed int64, workers, maxPages uint8) {
+		checkTermination(t, seed, 1+int(workers%32), int(maxPages))
+	})
+}
+
+// BenchmarkCoordinator_Crawl measures scheduling overhead per page against
+// a fetcher that returns instantly.
+func BenchmarkCoordinator_Crawl(b *testing.B) {
+	const pages = 1000
+	graph := &siteGraph{
+		urls:     make([]string, pages),
+		kinds:    make([]int, pages),
+		links:    make([][]string, pages),
+		redirect: make([]int, pages),
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := range graph.urls {
+		graph.urls[i] = fmt.Sprintf("https://example.com/p%d", i)
+	}
+	graph.urls[0] = "https://example.com/"
+	for i := range graph.links {
+		for j := 0; j < 10; j++ {
+			graph.links[i] = append(graph.links[i], graph.urls[rng.Intn(pages)])
+		}
+	}
+
+	for _, workers := range []int{1, 8, 64} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.ReportAllocs()
+			var visited int
+			for b.Loop() {
+				fetcher := newGraphFetcher(graph)
+				coord, err := NewCoordinator(Config{
+					StartURL:   graph.urls[0],
+					NumWorkers: workers,
+					Fetcher:    fetcher,
+					Parser:     &graphParser{graph: graph},
+					Output:     io.Discard,
+				})
+				if err != nil {
+					b.Fatalf("NewCoordinator() error = %v", err)
+				}
+				captureLog(b, func() {
+					if err := coord.Crawl(context.Background()); err != nil {
+						b.Fatalf("Crawl() error = %v", err)
+					}
+				})
+				visited += coord.visitCount
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(visited), "ns/page")
+		})
+	}
+}
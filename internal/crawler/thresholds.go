@@ -0,0 +1,51 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrThresholdExceeded is returned by Crawl, once all output is written,
+// when the crawl broke a quality threshold (FailOnBrokenLinks or
+// MaxErrorRate), so a deployment gate can fail on it.
+var ErrThresholdExceeded = errors.New("quality threshold exceeded")
+
+// isBrokenLink reports whether err means the link target is gone: a 404
+// Not Found or 410 Gone response.
+func isBrokenLink(err error) bool {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	return httpErr.StatusCode == http.StatusNotFound || httpErr.StatusCode == http.StatusGone
+}
+
+// checkThresholds returns an error wrapping ErrThresholdExceeded that
+// names every threshold the crawl broke, or nil.
+func (c *Coordinator) checkThresholds() error {
+	var broken []string
+	if c.failOnBrokenLinks {
+		n := c.brokenLinkCount
+		for _, check := range c.externalChecks {
+			if check.err != nil {
+				n++
+			}
+		}
+		if n > 0 {
+			broken = append(broken, fmt.Sprintf("broken links: %d", n))
+		}
+	}
+	if c.maxErrorRate > 0 && c.visitCount > 0 {
+		rate := float64(c.errorCount) / float64(c.visitCount)
+		if rate > c.maxErrorRate {
+			broken = append(broken, fmt.Sprintf("error rate %.2f%% above %.2f%%", rate*100, c.maxErrorRate*100))
+		}
+	}
+	if len(broken) == 0 {
+		return nil
+	}
+	c.log().Error("Quality threshold exceeded", "violations", strings.Join(broken, "; "))
+	return fmt.Errorf("%w: %s", ErrThresholdExceeded, strings.Join(broken, "; "))
+}
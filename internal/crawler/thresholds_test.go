@@ -0,0 +1,93 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_Thresholds(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":  []byte("root"),
+			"https://example.com/a": []byte("a"),
+			"https://example.com/b": []byte("b"),
+		},
+		errors: map[string]error{
+			"https://example.com/missing": &HTTPError{StatusCode: 404},
+		},
+	}
+	parser := &mockMetadataParser{links: map[string][]string{"root": {"/a", "/b", "/missing"}}}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{"no thresholds", Config{}, ""},
+		{"broken link", Config{FailOnBrokenLinks: true}, "broken links: 1"},
+		{"error rate within limit", Config{MaxErrorRate: 0.25}, ""},
+		{"error rate over limit", Config{MaxErrorRate: 0.2}, "error rate 25.00% above 20.00%"},
+		{"both", Config{FailOnBrokenLinks: true, MaxErrorRate: 0.1}, "broken links: 1; error rate 25.00% above 10.00%"},
+	}
+	for _, tt := range tests {
+		cfg := tt.cfg
+		cfg.StartURL, cfg.NumWorkers, cfg.Fetcher, cfg.Parser = "https://example.com/", 2, fetcher, parser
+		var out strings.Builder
+		cfg.Output = &out
+		coord, err := NewCoordinator(cfg)
+		if err != nil {
+			t.Fatalf("%s: NewCoordinator() error = %v", tt.name, err)
+		}
+		var crawlErr error
+		logs := captureLog(t, func() {
+			crawlErr = coord.Crawl(context.Background())
+		})
+
+		if tt.wantErr == "" {
+			if crawlErr != nil {
+				t.Errorf("%s: Crawl() error = %v, want nil", tt.name, crawlErr)
+			}
+			continue
+		}
+		if !errors.Is(crawlErr, ErrThresholdExceeded) || !strings.HasSuffix(crawlErr.Error(), ": "+tt.wantErr) {
+			t.Errorf("%s: Crawl() error = %v, want ErrThresholdExceeded: %s", tt.name, crawlErr, tt.wantErr)
+		}
+		if !strings.Contains(logs, "Quality threshold exceeded") {
+			t.Errorf("%s: missing threshold log:\n%s", tt.name, logs)
+		}
+		// The output is complete even though the crawl failed
+		if n := strings.Count(out.String(), "Visited:"); n != 4 {
+			t.Errorf("%s: printed %d pages, want 4", tt.name, n)
+		}
+	}
+
+	for _, rate := range []float64{-0.1, 1.5} {
+		if _, err := NewCoordinator(Config{StartURL: "https://example.com/", Fetcher: &mockFetcher{}, Parser: &mockParser{}, MaxErrorRate: rate}); err == nil {
+			t.Errorf("NewCoordinator() should reject MaxErrorRate %v", rate)
+		}
+	}
+}
+
+func TestCoordinator_FailOnDeadExternalLinks(t *testing.T) {
+	fetcher, parser := externalCheckFixture()
+	coord, err := NewCoordinator(Config{
+		StartURL:          "https://example.com/",
+		NumWorkers:        2,
+		Fetcher:           fetcher,
+		Parser:            parser,
+		Output:            &strings.Builder{},
+		CheckExternal:     true,
+		FailOnBrokenLinks: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	captureLog(t, func() {
+		err = coord.Crawl(context.Background())
+	})
+	if !errors.Is(err, ErrThresholdExceeded) || !strings.Contains(err.Error(), "broken links: 2") {
+		t.Errorf("Crawl() error = %v, want the two dead external links", err)
+	}
+}
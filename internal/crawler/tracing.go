@@ -0,0 +1,73 @@
+package crawler
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the crawler's spans.
+const tracerName = "github.com/cametumbling/web-crawler/internal/crawler"
+
+// A crawl is traced as one "crawl" span with a child span per page. A page
+// span starts when the page is scheduled and ends once the coordinator has
+// processed its result; its "fetch", "parse", and "process" children leave
+// the time spent queued as the gap before "fetch".
+
+// startPageSpan starts the span of a page about to be scheduled.
+func (c *Coordinator) startPageSpan(item *WorkItem) {
+	name := "page"
+	if item.CheckOnly {
+		name = "check"
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("url.full", item.URL),
+		attribute.Int("crawler.depth", item.Depth),
+	}
+	if host := hostOf(item.URL); host != "" {
+		attrs = append(attrs, attribute.String("server.address", host))
+	}
+	if item.Referrer != "" {
+		attrs = append(attrs, attribute.String("crawler.referrer", item.Referrer))
+	}
+	_, item.Span = c.tracer.Start(trace.ContextWithSpan(context.Background(), c.crawlSpan), name, trace.WithAttributes(attrs...))
+}
+
+// handleResult processes a result inside a "process" span, then ends the
+// page span.
+func (c *Coordinator) handleResult(ctx context.Context, result Result) {
+	if result.Span == nil {
+		c.processResult(ctx, result)
+		return
+	}
+
+	_, span := c.tracer.Start(trace.ContextWithSpan(ctx, result.Span), "process")
+	c.processResult(ctx, result)
+	span.End()
+
+	if result.StatusCode != 0 {
+		result.Span.SetAttributes(attribute.Int("http.response.status_code", result.StatusCode))
+	}
+	if result.Err != nil {
+		result.Span.RecordError(result.Err)
+		result.Span.SetStatus(codes.Error, result.Err.Error())
+	}
+	result.Span.End()
+}
+
+// workerTracer returns the tracer for a work item's child spans: the one
+// that started its page span, so workers need no tracing configuration.
+func workerTracer(ctx context.Context) trace.Tracer {
+	return trace.SpanFromContext(ctx).TracerProvider().Tracer(tracerName)
+}
+
+// endSpan ends a worker span, marking it failed if err is non-nil.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
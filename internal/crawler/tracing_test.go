@@ -0,0 +1,89 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestCoordinator_TracesPages(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":  []byte("<html>root</html>"),
+			"https://example.com/a": []byte("<html>a</html>"),
+		},
+		errors: map[string]error{
+			"https://example.com/broken": errors.New("fetch failed"),
+		},
+	}
+	coord, err := NewCoordinator(Config{
+		StartURL:       "https://example.com/",
+		NumWorkers:     2,
+		Fetcher:        fetcher,
+		Parser:         &mockParser{links: []string{"/a", "/broken"}},
+		Output:         &bytes.Buffer{},
+		TracerProvider: tp,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	spans := recorder.Ended()
+	counts := make(map[string]int)
+	byID := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range spans {
+		counts[s.Name()]++
+		byID[s.SpanContext().SpanID().String()] = s
+	}
+	want := map[string]int{"crawl": 1, "page": 3, "fetch": 3, "parse": 2, "process": 3}
+	for name, n := range want {
+		if counts[name] != n {
+			t.Errorf("got %d %q spans, want %d (all: %v)", counts[name], name, n, counts)
+		}
+	}
+
+	for _, s := range spans {
+		parent, ok := byID[s.Parent().SpanID().String()]
+		switch s.Name() {
+		case "crawl":
+			if s.Parent().IsValid() {
+				t.Errorf("crawl span has a parent")
+			}
+		case "page":
+			if !ok || parent.Name() != "crawl" {
+				t.Errorf("page span is not a child of the crawl span")
+			}
+		default:
+			if !ok || parent.Name() != "page" {
+				t.Errorf("%s span is not a child of a page span", s.Name())
+			}
+		}
+	}
+
+	for _, s := range spans {
+		if s.Name() != "page" {
+			continue
+		}
+		var url string
+		for _, attr := range s.Attributes() {
+			if attr.Key == "url.full" {
+				url = attr.Value.AsString()
+			}
+		}
+		failed := s.Status().Code == codes.Error
+		if failed != (url == "https://example.com/broken") {
+			t.Errorf("page span %s has status %v", url, s.Status())
+		}
+	}
+}
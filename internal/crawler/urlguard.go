@@ -0,0 +1,58 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// URL guards refuse links whose shape alone marks them as crawler traps:
+// calendars and faceted search that append a parameter or path segment on
+// every click produce ever longer URLs long before a path budget or the
+// page cap would notice.
+
+// guardRejects reports why a discovered URL is refused by the length and
+// query parameter limits ("" = allowed).
+func (c *Coordinator) guardRejects(link string) string {
+	if c.maxURLLength > 0 && len(link) > c.maxURLLength {
+		return fmt.Sprintf("URL longer than %d characters", c.maxURLLength)
+	}
+	if c.maxQueryParams > 0 {
+		if u, err := url.Parse(link); err == nil && countParams(u.RawQuery) > c.maxQueryParams {
+			return fmt.Sprintf("more than %d query parameters", c.maxQueryParams)
+		}
+	}
+	return ""
+}
+
+// guardAllows applies the URL guards to a discovered URL, recording and
+// auditing each refused URL once.
+func (c *Coordinator) guardAllows(link string) bool {
+	reason := c.guardRejects(link)
+	if reason == "" {
+		return true
+	}
+	key := c.key(link)
+	if !c.guardSkipped[key] {
+		c.guardSkipped[key] = true
+		c.audit(AuditEntry{Decision: AuditSkipped, URL: link, Reason: reason})
+	}
+	return false
+}
+
+// countParams counts the parameters of a raw query string.
+func countParams(rawQuery string) int {
+	if rawQuery == "" {
+		return 0
+	}
+	return strings.Count(rawQuery, "&") + 1
+}
+
+// logGuardSkips reports how many distinct URLs the guards refused.
+func (c *Coordinator) logGuardSkips() {
+	if len(c.guardSkipped) == 0 {
+		return
+	}
+	c.log().Info("Refused by URL guards", "count", len(c.guardSkipped),
+		"max_url_length", c.maxURLLength, "max_query_params", c.maxQueryParams)
+}
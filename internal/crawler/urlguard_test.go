@@ -0,0 +1,63 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCoordinator_URLGuards(t *testing.T) {
+	long := "/calendar/" + strings.Repeat("next/", 20)
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":           []byte("<html>root</html>"),
+			"https://example.com/ok?a=1&b=2": []byte("<html>ok</html>"),
+		},
+	}
+	parser := &mockParser{links: []string{"/ok?a=1&b=2", "/search?a=1&b=2&c=3", long}}
+
+	var out bytes.Buffer
+	audit := &bytes.Buffer{}
+	coord, err := NewCoordinator(Config{
+		StartURL:       "https://example.com/",
+		NumWorkers:     1,
+		Fetcher:        fetcher,
+		Parser:         parser,
+		Output:         &out,
+		AuditLog:       audit,
+		MaxURLLength:   60,
+		MaxQueryParams: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	logs := captureLog(t, func() {
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("Crawl() error = %v", err)
+		}
+	})
+
+	want := []string{"https://example.com/", "https://example.com/ok?a=1&b=2"}
+	if got := visitedURLs(out.String()); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("visited %v, want %v", got, want)
+	}
+	if !strings.Contains(logs, "Refused by URL guards count=2") {
+		t.Errorf("wrong refused count:\n%s", logs)
+	}
+	// Each refused URL is audited once, though both pages link to it
+	for _, reason := range []string{"URL longer than 60 characters", "more than 2 query parameters"} {
+		if n := strings.Count(audit.String(), reason); n != 1 {
+			t.Errorf("audit log has %q %d times, want 1:\n%s", reason, n, audit.String())
+		}
+	}
+}
+
+func TestCountParams(t *testing.T) {
+	tests := map[string]int{"": 0, "a=1": 1, "a=1&b=2&a=3": 3, "flag": 1}
+	for query, want := range tests {
+		if got := countParams(query); got != want {
+			t.Errorf("countParams(%q) = %d, want %d", query, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package crawler
 
 import (
 	"net/url"
+	"sort"
 	"strings"
 )
 
@@ -69,6 +70,15 @@ func InScope(urlStr string, startHost string) bool {
 	return candidateHost == normalizedStartHost
 }
 
+// HostScope is the default ScopePolicy: URLs on the given host, compared
+// case-insensitively, as InScope does.
+type HostScope string
+
+// InScope reports whether url is on the host.
+func (h HostScope) InScope(url string) bool {
+	return InScope(url, string(h))
+}
+
 // Key returns the canonical string representation of a URL for deduplication.
 // The key reflects the same normalization rules as Sanitize.
 func Key(urlStr string) string {
@@ -100,3 +110,81 @@ func Key(urlStr string) string {
 
 	return u.String()
 }
+
+// DefaultTrackingParams are the query parameters stripped when tracking
+// parameter removal is enabled without a custom list: analytics campaign
+// tags and ad click identifiers, which never change the page served.
+var DefaultTrackingParams = []string{"utm_*", "gclid", "dclid", "fbclid", "msclkid", "mc_cid", "mc_eid", "_hsenc", "_hsmi"}
+
+// StripQueryParams removes the named query parameters from a URL. A name
+// ending in * matches every parameter with that prefix, so "utm_*" covers
+// utm_source and utm_campaign. Names match case-insensitively. The other
+// parameters keep their order and encoding; a query left empty is dropped
+// along with its "?".
+func StripQueryParams(rawURL string, names []string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" || len(names) == 0 {
+		return rawURL
+	}
+
+	var kept []string
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		name, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if !paramMatches(name, names) {
+			kept = append(kept, pair)
+		}
+	}
+	u.RawQuery = strings.Join(kept, "&")
+	u.ForceQuery = false
+	return u.String()
+}
+
+// paramMatches reports whether a query parameter name is in names.
+func paramMatches(name string, names []string) bool {
+	name = strings.ToLower(name)
+	for _, n := range names {
+		n = strings.ToLower(n)
+		if prefix, ok := strings.CutSuffix(n, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == n {
+			return true
+		}
+	}
+	return false
+}
+
+// SortQuery orders a URL's query parameters by name, keeping the order of
+// repeated names and the encoding of every parameter, so URLs that differ
+// only in parameter order become equal.
+func SortQuery(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || !strings.Contains(u.RawQuery, "&") {
+		return rawURL
+	}
+
+	pairs := strings.Split(u.RawQuery, "&")
+	sort.SliceStable(pairs, func(i, j int) bool {
+		a, _, _ := strings.Cut(pairs[i], "=")
+		b, _, _ := strings.Cut(pairs[j], "=")
+		return a < b
+	})
+	u.RawQuery = strings.Join(pairs, "&")
+	return u.String()
+}
+
+// LowercasePath lowercases a URL's path, leaving the host, query, and
+// fragment as they are.
+func LowercasePath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.Path = strings.ToLower(u.Path)
+	u.RawPath = ""
+	return u.String()
+}
@@ -1,7 +1,10 @@
 package crawler
 
 import (
+	"bytes"
+	"context"
 	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -515,3 +518,195 @@ func TestKey_DifferentURLs(t *testing.T) {
 		})
 	}
 }
+
+func TestStripQueryParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		params []string
+		want   string
+	}{
+		{
+			name:   "prefix wildcard",
+			url:    "https://example.com/page?utm_source=news&id=7&utm_medium=email",
+			params: DefaultTrackingParams,
+			want:   "https://example.com/page?id=7",
+		},
+		{
+			name:   "all parameters removed drops the question mark",
+			url:    "https://example.com/page?gclid=abc&fbclid=def",
+			params: DefaultTrackingParams,
+			want:   "https://example.com/page",
+		},
+		{
+			name:   "names match case-insensitively",
+			url:    "https://example.com/?UTM_Source=x&q=1",
+			params: []string{"utm_*"},
+			want:   "https://example.com/?q=1",
+		},
+		{
+			name:   "encoding and order of kept parameters preserved",
+			url:    "https://example.com/search?q=a%20b&sid=1&lang=en",
+			params: []string{"sid"},
+			want:   "https://example.com/search?q=a%20b&lang=en",
+		},
+		{
+			name:   "exact names are not prefixes",
+			url:    "https://example.com/?gclid_extra=1",
+			params: []string{"gclid"},
+			want:   "https://example.com/?gclid_extra=1",
+		},
+		{
+			name:   "no list leaves the URL alone",
+			url:    "https://example.com/?utm_source=x",
+			params: nil,
+			want:   "https://example.com/?utm_source=x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripQueryParams(tt.url, tt.params); got != tt.want {
+				t.Errorf("StripQueryParams(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoordinator_StripParams(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":      []byte("<html>root</html>"),
+			"https://example.com/a":     []byte("<html>a</html>"),
+			"https://example.com/a?p=2": []byte("<html>a2</html>"),
+		},
+	}
+	parser := &mockParser{links: []string{"/a?utm_source=x", "/a?gclid=1", "/a", "/a?p=2&utm_campaign=y"}}
+
+	var out bytes.Buffer
+	coord, err := NewCoordinator(Config{
+		StartURL:    "https://example.com/?utm_source=ad",
+		NumWorkers:  1,
+		Fetcher:     fetcher,
+		Parser:      parser,
+		Output:      &out,
+		StripParams: DefaultTrackingParams,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	want := []string{"https://example.com/", "https://example.com/a", "https://example.com/a?p=2"}
+	if got := visitedURLs(out.String()); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("visited %v, want %v", got, want)
+	}
+	if strings.Contains(out.String(), "utm_") || strings.Contains(out.String(), "gclid") {
+		t.Errorf("tracking parameters printed:\n%s", out.String())
+	}
+}
+
+func TestSortQuery(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/?b=2&a=1", "https://example.com/?a=1&b=2"},
+		{"https://example.com/?tag=z&id=1&tag=a", "https://example.com/?id=1&tag=z&tag=a"},
+		{"https://example.com/?q=a%26b&c", "https://example.com/?c&q=a%26b"},
+		{"https://example.com/?only=1", "https://example.com/?only=1"},
+		{"https://example.com/", "https://example.com/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := SortQuery(tt.url); got != tt.want {
+				t.Errorf("SortQuery(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoordinator_SortQuery(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":             []byte("<html>root</html>"),
+			"https://example.com/list?b=2&a=1": []byte("<html>list</html>"),
+		},
+	}
+	parser := &mockParser{links: []string{"/list?b=2&a=1", "/list?a=1&b=2"}}
+
+	var out bytes.Buffer
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    fetcher,
+		Parser:     parser,
+		Output:     &out,
+		SortQuery:  true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	// The first ordering found is the one fetched and printed
+	want := []string{"https://example.com/", "https://example.com/list?b=2&a=1"}
+	if got := visitedURLs(out.String()); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("visited %v, want %v", got, want)
+	}
+}
+
+func TestLowercasePath(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/About/Team.aspx", "https://example.com/about/team.aspx"},
+		{"https://example.com/Search?Q=Shoes", "https://example.com/search?Q=Shoes"},
+		{"https://example.com/%C3%84rger", "https://example.com/%C3%A4rger"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := LowercasePath(tt.url); got != tt.want {
+				t.Errorf("LowercasePath(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoordinator_CaseInsensitivePaths(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/":      []byte("<html>root</html>"),
+			"https://example.com/About": []byte("<html>about</html>"),
+		},
+	}
+	parser := &mockParser{links: []string{"/About", "/about", "/ABOUT"}}
+
+	var out bytes.Buffer
+	coord, err := NewCoordinator(Config{
+		StartURL:             "https://example.com/",
+		NumWorkers:           1,
+		Fetcher:              fetcher,
+		Parser:               parser,
+		Output:               &out,
+		CaseInsensitivePaths: true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	if err := coord.Crawl(context.Background()); err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	want := []string{"https://example.com/", "https://example.com/About"}
+	if got := visitedURLs(out.String()); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("visited %v, want %v", got, want)
+	}
+}
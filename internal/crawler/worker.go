@@ -3,16 +3,20 @@ package crawler
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // worker is a stateless goroutine that processes WorkItems from workCh.
 // For each WorkItem, it fetches the URL, parses the HTML, and sends exactly one Result.
 // Workers never mutate shared state, never print, and never touch the WaitGroup.
+// prepare (nil = none) precomputes result fields from fixed configuration.
 // CRITICAL: Even on panic, exactly one Result must be sent to maintain termination invariant.
 // Respects context cancellation for graceful shutdown.
-func worker(ctx context.Context, workCh <-chan WorkItem, resultsCh chan<- Result, fetcher Fetcher, parser Parser) {
+func worker(ctx context.Context, workCh <-chan WorkItem, resultsCh chan<- Result, fetcher Fetcher, parser Parser, prepare func(*Result)) {
 	for {
 		select {
 		case <-ctx.Done():
@@ -33,9 +37,13 @@ func worker(ctx context.Context, workCh <-chan WorkItem, resultsCh chan<- Result
 						// Panic occurred - send error Result if we haven't sent one yet
 						if !sent {
 							resultsCh <- Result{
-								URL:   item.URL,
-								Links: nil,
-								Err:   fmt.Errorf("worker panic: %v", r),
+								URL:       item.URL,
+								Depth:     item.Depth,
+								Referrer:  item.Referrer,
+								CheckOnly: item.CheckOnly,
+								Span:      item.Span,
+								Links:     nil,
+								Err:       fmt.Errorf("worker panic: %v", r),
 							}
 						}
 					}
@@ -43,6 +51,10 @@ func worker(ctx context.Context, workCh <-chan WorkItem, resultsCh chan<- Result
 
 				// Normal processing
 				result = processWorkItem(ctx, item, fetcher, parser)
+				result.Span = item.Span
+				if prepare != nil {
+					prepare(&result)
+				}
 				resultsCh <- result
 				sent = true
 			}()
@@ -54,45 +66,186 @@ func worker(ctx context.Context, workCh <-chan WorkItem, resultsCh chan<- Result
 // Always returns a Result, even on error.
 // Worker is stateless - it does NOT log. Logging is done by the coordinator.
 func processWorkItem(ctx context.Context, item WorkItem, fetcher Fetcher, parser Parser) Result {
-	// Fetch the URL
-	fetchResult, err := fetcher.Fetch(ctx, item.URL)
+	// Trace under the page span; the time before this event was spent queued
+	if item.Span != nil {
+		ctx = trace.ContextWithSpan(ctx, item.Span)
+		item.Span.AddEvent("dequeued")
+	}
+	tracer := workerTracer(ctx)
+
+	if item.CheckOnly {
+		return checkWorkItem(ctx, item, fetcher)
+	}
+
+	// Fetch the URL, revalidating it if an earlier crawl saw it
+	var fetchResult *FetchResult
+	var err error
+	fetchCtx, fetchSpan := tracer.Start(ctx, "fetch")
+	if cf, ok := fetcher.(ConditionalFetcher); ok && !item.Validators.IsZero() {
+		fetchResult, err = cf.FetchIfModified(fetchCtx, item.URL, item.Validators)
+	} else {
+		fetchResult, err = fetcher.Fetch(fetchCtx, item.URL)
+	}
+	endSpan(fetchSpan, err)
 	if err != nil {
-		return Result{
+		result := Result{
 			URL:      item.URL,
 			FinalURL: item.URL, // Use original URL as fallback
+			Depth:    item.Depth,
+			Referrer: item.Referrer,
 			Links:    nil,
 			Err:      err, // Return raw error - coordinator will wrap/log
 		}
+		// Keep the redirects that led to an error response
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) {
+			result.StatusCode = httpErr.StatusCode
+			if len(httpErr.Redirects) > 0 {
+				result.FinalURL = httpErr.FinalURL
+				result.Redirects = httpErr.Redirects
+			}
+		}
+		return result
+	}
+
+	_, parseSpan := tracer.Start(ctx, "parse")
+	defer parseSpan.End()
+
+	// The parsers read the body in place and are done with it when this
+	// returns; unless it is kept as result.Body, its buffer goes straight
+	// back to the fetcher
+	kept := false
+	if fetchResult.Release != nil {
+		defer func() {
+			if !kept {
+				fetchResult.Release()
+			}
+		}()
+	}
+
+	// Fields known from the fetch, shared by every outcome below
+	result := Result{
+		URL:           item.URL,
+		FinalURL:      fetchResult.FinalURL,
+		Depth:         item.Depth,
+		Referrer:      item.Referrer,
+		ContentType:   fetchResult.ContentType,
+		Redirects:     fetchResult.Redirects,
+		StatusCode:    fetchResult.StatusCode,
+		TTFB:          fetchResult.TTFB,
+		Header:        fetchResult.Header,
+		FetchDuration: fetchResult.Duration,
+		BodySize:      len(fetchResult.Body),
+		LastModified:  fetchResult.LastModified,
+		ETag:          fetchResult.ETag,
+	}
+	result.NoIndex, result.NoFollow = robotsTagDirectives(fetchResult.RobotsTags)
+
+	// Unchanged pages have no body; the coordinator supplies their links
+	if fetchResult.NotModified {
+		result.NotModified = true
+		result.Links = []string{}
+		return result
+	}
+
+	// Stylesheets (fetched when assets are crawled) reference further assets
+	if isCSS(fetchResult.ContentType) {
+		result.Links = []string{}
+		if sp, ok := parser.(StylesheetParser); ok {
+			urls, err := sp.ExtractStylesheet(bytes.NewReader(fetchResult.Body))
+			if err != nil {
+				result.Err = err
+				return result
+			}
+			for _, u := range urls {
+				result.Assets = append(result.Assets, Asset{Type: "css", URL: u})
+			}
+		}
+		return result
+	}
+
+	// PDFs can link back into the site
+	if isPDF(fetchResult.ContentType) {
+		result.Links = []string{}
+		if pp, ok := parser.(PDFParser); ok && len(fetchResult.Body) > 0 {
+			links, err := pp.ExtractPDFLinks(bytes.NewReader(fetchResult.Body))
+			if err != nil {
+				result.Err = err
+				return result
+			}
+			result.Links = append(result.Links, links...)
+		}
+		return result
 	}
 
 	// Check if content is HTML
 	if !isHTML(fetchResult.ContentType) {
 		// Non-HTML content: return empty links (not an error)
-		return Result{
-			URL:      item.URL,
-			FinalURL: fetchResult.FinalURL,
-			Links:    []string{}, // Empty, not nil
-			Err:      nil,
+		result.Links = []string{} // Empty, not nil
+		return result
+	}
+
+	result.Body = fetchResult.Body
+	result.release, kept = fetchResult.Release, true
+
+	// Parsers that support metadata extract it in the same pass as the links
+	mp, ok := parser.(MetadataParser)
+	if !ok {
+		links, err := parser.ExtractLinks(bytes.NewReader(fetchResult.Body))
+		if err != nil {
+			result.Err = err // Return raw error - coordinator will log
+			return result
 		}
+		result.Links = links
+		return result
 	}
 
-	// Parse the HTML to extract links
-	links, err := parser.ExtractLinks(bytes.NewReader(fetchResult.Body))
+	links, meta, err := mp.ExtractPage(bytes.NewReader(fetchResult.Body))
 	if err != nil {
-		return Result{
-			URL:      item.URL,
-			FinalURL: fetchResult.FinalURL,
-			Links:    nil,
-			Err:      err, // Return raw error - coordinator will log
-		}
+		result.Err = err // Return raw error - coordinator will log
+		return result
 	}
+	result.Lang = meta.Lang
+	result.NoIndex = result.NoIndex || meta.NoIndex
+	result.NoFollow = result.NoFollow || meta.NoFollow
+	result.NofollowLinks = meta.NofollowLinks
+	result.Anchors = meta.Anchors
+	result.Title = meta.Title
+	result.Description = meta.Description
+	result.Text = meta.Text
+	result.Assets = meta.Assets
+	result.BaseHref = meta.BaseHref
+	result.Canonical = meta.Canonical
+	result.StructuredData = meta.StructuredData
+	result.ResourceHints = meta.ResourceHints
+	result.OpenGraph = meta.OpenGraph
 
 	// Success
+	result.Links = make([]string, len(links))
+	for i, link := range links {
+		result.Links[i] = link.Href
+	}
+	result.LinkDetails = links
+	return result
+}
+
+// checkWorkItem verifies an external link resolves, with a HEAD-style
+// check when the fetcher supports one and a full fetch otherwise.
+func checkWorkItem(ctx context.Context, item WorkItem, fetcher Fetcher) Result {
+	var err error
+	if lc, ok := fetcher.(LinkChecker); ok {
+		err = lc.Check(ctx, item.URL)
+	} else {
+		_, err = fetcher.Fetch(ctx, item.URL)
+	}
 	return Result{
-		URL:      item.URL,
-		FinalURL: fetchResult.FinalURL,
-		Links:    links,
-		Err:      nil,
+		URL:       item.URL,
+		FinalURL:  item.URL,
+		Depth:     item.Depth,
+		Referrer:  item.Referrer,
+		CheckOnly: true,
+		Links:     []string{},
+		Err:       err,
 	}
 }
 
@@ -106,3 +259,15 @@ func isHTML(contentType string) bool {
 	ct := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
 	return ct == "text/html"
 }
+
+// isCSS returns true if the Content-Type header indicates a stylesheet.
+func isCSS(contentType string) bool {
+	ct := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return ct == "text/css"
+}
+
+// isPDF returns true if the Content-Type header indicates a PDF document.
+func isPDF(contentType string) bool {
+	ct := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return ct == "application/pdf"
+}
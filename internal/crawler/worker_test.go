@@ -1,18 +1,30 @@
 package crawler
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // mockFetcher is a mock implementation of the Fetcher interface for testing.
 type mockFetcher struct {
 	responses    map[string][]byte
 	errors       map[string]error
-	contentTypes map[string]string // Optional content types per URL
-	finalURLs    map[string]string // Optional redirected URLs
+	contentTypes map[string]string      // Optional content types per URL
+	finalURLs    map[string]string      // Optional redirected URLs
+	robotsTags   map[string][]string    // Optional X-Robots-Tag header values
+	lastModified map[string]time.Time   // Optional Last-Modified times
+	headers      map[string]http.Header // Optional response headers
+	redirects    map[string][]Redirect  // Optional redirect chains
+	pooled       bool                   // Optional: set Release, counting calls in released
+	released     atomic.Int64
 }
 
 func (m *mockFetcher) Fetch(ctx context.Context, url string) (*FetchResult, error) {
@@ -29,14 +41,27 @@ func (m *mockFetcher) Fetch(ctx context.Context, url string) (*FetchResult, erro
 			contentType = ct
 		}
 		return &FetchResult{
-			Body:        body,
-			FinalURL:    finalURL,
-			ContentType: contentType,
+			Body:         body,
+			FinalURL:     finalURL,
+			ContentType:  contentType,
+			RobotsTags:   m.robotsTags[url],
+			LastModified: m.lastModified[url],
+			Header:       m.headers[url],
+			Redirects:    m.redirects[url],
+			Release:      m.release(),
 		}, nil
 	}
 	return nil, errors.New("url not found in mock")
 }
 
+// release returns the Release func for a fetched body (nil unless pooled).
+func (m *mockFetcher) release() func() {
+	if !m.pooled {
+		return nil
+	}
+	return func() { m.released.Add(1) }
+}
+
 // mockParser is a mock implementation of the Parser interface for testing.
 type mockParser struct {
 	links []string
@@ -55,6 +80,57 @@ func (m *mockParser) ExtractLinks(r io.Reader) ([]string, error) {
 	return m.links, nil
 }
 
+// mockMetadataParser is a mock implementation of MetadataParser that serves
+// fixed links and metadata keyed by page body. Bodies without an entry have
+// no links and empty metadata. It also implements StylesheetParser, serving
+// stylesheet references from css keyed the same way.
+type mockMetadataParser struct {
+	links map[string][]string
+	meta  map[string]*PageMetadata
+	css   map[string][]string
+	pdf   map[string][]string
+}
+
+func (m *mockMetadataParser) ExtractPDFLinks(r io.Reader) ([]string, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return m.pdf[string(body)], nil
+}
+
+func (m *mockMetadataParser) ExtractStylesheet(r io.Reader) ([]string, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return m.css[string(body)], nil
+}
+
+func (m *mockMetadataParser) ExtractLinks(r io.Reader) ([]string, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return m.links[string(body)], nil
+}
+
+func (m *mockMetadataParser) ExtractPage(r io.Reader) ([]Link, *PageMetadata, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	meta, ok := m.meta[string(body)]
+	if !ok {
+		meta = &PageMetadata{}
+	}
+	var links []Link
+	for _, href := range m.links[string(body)] {
+		links = append(links, Link{Href: href, Tag: "a"})
+	}
+	return links, meta, nil
+}
+
 func TestProcessWorkItem_Success(t *testing.T) {
 	fetcher := &mockFetcher{
 		responses: map[string][]byte{
@@ -65,12 +141,21 @@ func TestProcessWorkItem_Success(t *testing.T) {
 		links: []string{"/link1", "/link2"},
 	}
 
-	item := WorkItem{URL: "https://example.com/page"}
+	item := WorkItem{URL: "https://example.com/page", Depth: 2, Referrer: "https://example.com/"}
 	result := processWorkItem(context.Background(), item, fetcher, parser)
 
 	if result.URL != "https://example.com/page" {
 		t.Errorf("Result.URL = %q, want %q", result.URL, "https://example.com/page")
 	}
+	if result.Depth != 2 {
+		t.Errorf("Result.Depth = %d, want 2", result.Depth)
+	}
+	if result.Referrer != "https://example.com/" {
+		t.Errorf("Result.Referrer = %q, want %q", result.Referrer, "https://example.com/")
+	}
+	if result.ContentType != "text/html" {
+		t.Errorf("Result.ContentType = %q, want %q", result.ContentType, "text/html")
+	}
 	if result.Err != nil {
 		t.Errorf("Result.Err = %v, want nil", result.Err)
 	}
@@ -92,12 +177,15 @@ func TestProcessWorkItem_FetchError(t *testing.T) {
 		links: []string{"/link1"},
 	}
 
-	item := WorkItem{URL: "https://example.com/error"}
+	item := WorkItem{URL: "https://example.com/error", Referrer: "https://example.com/"}
 	result := processWorkItem(context.Background(), item, fetcher, parser)
 
 	if result.URL != "https://example.com/error" {
 		t.Errorf("Result.URL = %q, want %q", result.URL, "https://example.com/error")
 	}
+	if result.Referrer != "https://example.com/" {
+		t.Errorf("Result.Referrer = %q, want %q", result.Referrer, "https://example.com/")
+	}
 	if result.Err == nil {
 		t.Errorf("Result.Err = nil, want error")
 	}
@@ -106,6 +194,32 @@ func TestProcessWorkItem_FetchError(t *testing.T) {
 	}
 }
 
+func TestProcessWorkItem_FetchErrorAfterRedirect(t *testing.T) {
+	redirects := []Redirect{{URL: "https://example.com/old", StatusCode: 301}}
+	fetcher := &mockFetcher{
+		errors: map[string]error{
+			"https://example.com/old": &HTTPError{
+				StatusCode: 404,
+				URL:        "https://example.com/old",
+				FinalURL:   "https://example.com/missing",
+				Redirects:  redirects,
+			},
+		},
+	}
+
+	result := processWorkItem(context.Background(), WorkItem{URL: "https://example.com/old"}, fetcher, &mockParser{})
+
+	if result.Err == nil {
+		t.Fatal("Result.Err = nil, want error")
+	}
+	if result.FinalURL != "https://example.com/missing" {
+		t.Errorf("Result.FinalURL = %q, want %q", result.FinalURL, "https://example.com/missing")
+	}
+	if len(result.Redirects) != 1 || result.Redirects[0] != redirects[0] {
+		t.Errorf("Result.Redirects = %+v, want %+v", result.Redirects, redirects)
+	}
+}
+
 func TestProcessWorkItem_ParseError(t *testing.T) {
 	fetcher := &mockFetcher{
 		responses: map[string][]byte{
@@ -170,7 +284,7 @@ func TestWorker_ProcessesMultipleItems(t *testing.T) {
 	resultsCh := make(chan Result, 3)
 
 	// Start worker
-	go worker(context.Background(), workCh, resultsCh, fetcher, parser)
+	go worker(context.Background(), workCh, resultsCh, fetcher, parser, nil)
 
 	// Send work items
 	workCh <- WorkItem{URL: "https://example.com/page1"}
@@ -209,6 +323,125 @@ func TestWorker_ProcessesMultipleItems(t *testing.T) {
 	}
 }
 
+func TestWorker_PreparesLinks(t *testing.T) {
+	coord, err := NewCoordinator(Config{
+		StartURL:   "https://example.com/",
+		NumWorkers: 1,
+		Fetcher:    &mockFetcher{},
+		Parser:     &mockParser{},
+		SortQuery:  true,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator() error = %v", err)
+	}
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{"https://example.com/page": []byte("<html>page</html>")},
+		errors:    map[string]error{"https://example.com/broken": errors.New("boom")},
+	}
+	parser := &mockParser{links: []string{"/a?y=1&x=2", "mailto:me@example.com", "HTTPS://Example.com/b#top"}}
+
+	workCh := make(chan WorkItem, 2)
+	resultsCh := make(chan Result, 2)
+	go worker(context.Background(), workCh, resultsCh, fetcher, parser, coord.prepare)
+	workCh <- WorkItem{URL: "https://example.com/page"}
+	workCh <- WorkItem{URL: "https://example.com/broken"}
+	close(workCh)
+
+	page, broken := <-resultsCh, <-resultsCh
+	if !page.prepared {
+		t.Fatalf("successful result was not prepared")
+	}
+	wantLinks := []string{"https://example.com/a?y=1&x=2", "https://example.com/b"}
+	wantKeys := []string{"https://example.com/a?x=2&y=1", "https://example.com/b"}
+	if !reflect.DeepEqual(page.links, wantLinks) {
+		t.Errorf("links = %v, want %v", page.links, wantLinks)
+	}
+	if !reflect.DeepEqual(page.linkKeys, wantKeys) {
+		t.Errorf("linkKeys = %v, want %v", page.linkKeys, wantKeys)
+	}
+	if broken.prepared {
+		t.Errorf("failed result should not be prepared")
+	}
+}
+
+func TestWorker_ReleasesBodies(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/page":  []byte("<html>page</html>"),
+			"https://example.com/image": []byte("GIF89a"),
+		},
+		contentTypes: map[string]string{"https://example.com/image": "image/gif"},
+		pooled:       true,
+	}
+	workCh := make(chan WorkItem, 2)
+	resultsCh := make(chan Result, 2)
+	go worker(context.Background(), workCh, resultsCh, fetcher, &mockParser{}, nil)
+	workCh <- WorkItem{URL: "https://example.com/image"}
+	workCh <- WorkItem{URL: "https://example.com/page"}
+	close(workCh)
+
+	// Only the HTML body outlives the parse
+	image, page := <-resultsCh, <-resultsCh
+	if image.release != nil || fetcher.released.Load() != 1 {
+		t.Errorf("non-HTML body not released by the worker (released %d)", fetcher.released.Load())
+	}
+	if string(page.Body) != "<html>page</html>" || page.release == nil {
+		t.Fatalf("HTML body = %q, want it kept for the coordinator", page.Body)
+	}
+	page.releaseBody()
+	page.releaseBody()
+	if page.Body != nil || fetcher.released.Load() != 2 {
+		t.Errorf("releaseBody() released %d bodies, want each once", fetcher.released.Load())
+	}
+}
+
+func TestCoordinator_ReleasesBodies(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		config func(*Config)
+	}{
+		{"unused", func(*Config) {}},
+		{"include html", func(cfg *Config) { cfg.OutputFormat, cfg.IncludeHTML = "json", true }},
+		{"result hook", func(cfg *Config) {
+			cfg.OnResult = func(r *Result) {
+				if r.Err == nil && len(r.Body) == 0 {
+					t.Errorf("OnResult got no body for %s", r.URL)
+				}
+			}
+		}},
+	} {
+		fetcher := &mockFetcher{
+			responses: map[string][]byte{
+				"https://example.com/":  []byte("root"),
+				"https://example.com/a": []byte("leaf"),
+			},
+			pooled: true,
+		}
+		output := &bytes.Buffer{}
+		cfg := Config{
+			StartURL:   "https://example.com/",
+			NumWorkers: 2,
+			Fetcher:    fetcher,
+			Parser:     &mockMetadataParser{links: map[string][]string{"root": {"/a"}}},
+			Output:     output,
+		}
+		tt.config(&cfg)
+		coord, err := NewCoordinator(cfg)
+		if err != nil {
+			t.Fatalf("%s: NewCoordinator() error = %v", tt.name, err)
+		}
+		if err := coord.Crawl(context.Background()); err != nil {
+			t.Fatalf("%s: Crawl() error = %v", tt.name, err)
+		}
+		if got := fetcher.released.Load(); got != 2 {
+			t.Errorf("%s: released %d bodies, want 2", tt.name, got)
+		}
+		if cfg.IncludeHTML && !strings.Contains(output.String(), `"html":"leaf"`) {
+			t.Errorf("%s: HTML missing from output:\n%s", tt.name, output.String())
+		}
+	}
+}
+
 func TestWorker_MixedSuccessAndErrors(t *testing.T) {
 	fetcher := &mockFetcher{
 		responses: map[string][]byte{
@@ -226,7 +459,7 @@ func TestWorker_MixedSuccessAndErrors(t *testing.T) {
 	resultsCh := make(chan Result, 2)
 
 	// Start worker
-	go worker(context.Background(), workCh, resultsCh, fetcher, parser)
+	go worker(context.Background(), workCh, resultsCh, fetcher, parser, nil)
 
 	// Send work items
 	workCh <- WorkItem{URL: "https://example.com/success"}
@@ -271,7 +504,7 @@ func TestWorker_AlwaysSendsOneResultPerItem(t *testing.T) {
 	resultsCh := make(chan Result, 2)
 
 	// Start worker
-	go worker(context.Background(), workCh, resultsCh, fetcher, parser)
+	go worker(context.Background(), workCh, resultsCh, fetcher, parser, nil)
 
 	// Send work items that will fail
 	workCh <- WorkItem{URL: "https://example.com/error1"}
@@ -309,7 +542,7 @@ func TestWorker_RecoverFromFetcherPanic(t *testing.T) {
 	resultsCh := make(chan Result, 1)
 
 	// Start worker
-	go worker(context.Background(), workCh, resultsCh, fetcher, parser)
+	go worker(context.Background(), workCh, resultsCh, fetcher, parser, nil)
 
 	// Send work item that will cause panic
 	workCh <- WorkItem{URL: "https://example.com/panic"}
@@ -347,7 +580,7 @@ func TestWorker_RecoverFromParserPanic(t *testing.T) {
 	resultsCh := make(chan Result, 1)
 
 	// Start worker
-	go worker(context.Background(), workCh, resultsCh, fetcher, parser)
+	go worker(context.Background(), workCh, resultsCh, fetcher, parser, nil)
 
 	// Send work item that will cause parser to panic
 	workCh <- WorkItem{URL: "https://example.com/page"}
@@ -393,7 +626,7 @@ func TestWorker_ContinuesAfterPanic(t *testing.T) {
 	resultsCh := make(chan Result, 3)
 
 	// Start worker
-	go worker(context.Background(), workCh, resultsCh, fetcher, parser)
+	go worker(context.Background(), workCh, resultsCh, fetcher, parser, nil)
 
 	// Send 3 work items (second one will panic)
 	workCh <- WorkItem{URL: "https://example.com/page1"}
@@ -506,6 +739,58 @@ func TestProcessWorkItem_NonHTMLContent(t *testing.T) {
 	}
 }
 
+func TestProcessWorkItem_LinkDetails(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{"https://example.com/": []byte("page")},
+	}
+	parser := &mockMetadataParser{links: map[string][]string{"page": {"/a", "/b"}}}
+
+	result := processWorkItem(context.Background(), WorkItem{URL: "https://example.com/"}, fetcher, parser)
+
+	if len(result.Links) != 2 || result.Links[0] != "/a" || result.Links[1] != "/b" {
+		t.Errorf("Result.Links = %v, want [/a /b]", result.Links)
+	}
+	if len(result.LinkDetails) != 2 || result.LinkDetails[1].Href != "/b" {
+		t.Errorf("Result.LinkDetails = %+v, want one entry per link", result.LinkDetails)
+	}
+}
+
+func TestProcessWorkItem_Stylesheet(t *testing.T) {
+	body := "body { background: url(/bg.png) }"
+	fetcher := &mockFetcher{
+		responses:    map[string][]byte{"https://example.com/site.css": []byte(body)},
+		contentTypes: map[string]string{"https://example.com/site.css": "text/css; charset=utf-8"},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{body: {"/should-not-be-used"}},
+		css:   map[string][]string{body: {"/bg.png", "theme.css"}},
+	}
+
+	result := processWorkItem(context.Background(), WorkItem{URL: "https://example.com/site.css"}, fetcher, parser)
+
+	if result.Err != nil {
+		t.Fatalf("Result.Err = %v, want nil", result.Err)
+	}
+	if result.Links == nil || len(result.Links) != 0 {
+		t.Errorf("Result.Links = %v, want empty slice", result.Links)
+	}
+	want := []Asset{{Type: "css", URL: "/bg.png"}, {Type: "css", URL: "theme.css"}}
+	if len(result.Assets) != len(want) {
+		t.Fatalf("Result.Assets = %v, want %v", result.Assets, want)
+	}
+	for i := range want {
+		if result.Assets[i] != want[i] {
+			t.Errorf("Result.Assets[%d] = %v, want %v", i, result.Assets[i], want[i])
+		}
+	}
+
+	// A parser without stylesheet support treats CSS as a leaf
+	result = processWorkItem(context.Background(), WorkItem{URL: "https://example.com/site.css"}, fetcher, &mockParser{links: []string{"/x"}})
+	if result.Err != nil || len(result.Links) != 0 || len(result.Assets) != 0 {
+		t.Errorf("plain parser: Links = %v, Assets = %v, Err = %v; want none", result.Links, result.Assets, result.Err)
+	}
+}
+
 func TestProcessWorkItem_HTMLContentType(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -543,3 +828,64 @@ func TestProcessWorkItem_HTMLContentType(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessWorkItem_Metadata(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/": []byte("fr page"),
+		},
+	}
+	parser := &mockMetadataParser{
+		links: map[string][]string{"fr page": {"/a"}},
+		meta:  map[string]*PageMetadata{"fr page": {Lang: "fr"}},
+	}
+
+	result := processWorkItem(context.Background(), WorkItem{URL: "https://example.com/"}, fetcher, parser)
+	if result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	if result.Lang != "fr" {
+		t.Errorf("Lang = %q, want %q", result.Lang, "fr")
+	}
+	if len(result.Links) != 1 {
+		t.Errorf("got %d links, want 1", len(result.Links))
+	}
+}
+
+func TestProcessWorkItem_PDF(t *testing.T) {
+	fetcher := &mockFetcher{
+		responses: map[string][]byte{
+			"https://example.com/doc.pdf":   []byte("%PDF doc"),
+			"https://example.com/empty.pdf": []byte("%PDF empty"),
+		},
+		contentTypes: map[string]string{
+			"https://example.com/doc.pdf":   "application/pdf",
+			"https://example.com/empty.pdf": "application/pdf",
+		},
+	}
+	parser := &mockMetadataParser{
+		pdf: map[string][]string{
+			"%PDF doc": {"/from-pdf", "https://other.com/"},
+		},
+	}
+
+	result := processWorkItem(context.Background(), WorkItem{URL: "https://example.com/doc.pdf"}, fetcher, parser)
+	if result.Err != nil {
+		t.Fatalf("processWorkItem() error = %v", result.Err)
+	}
+	if !reflect.DeepEqual(result.Links, []string{"/from-pdf", "https://other.com/"}) {
+		t.Errorf("Links = %v, want the PDF's links", result.Links)
+	}
+
+	// A PDF without links is not an error, and Links stays non-nil
+	result = processWorkItem(context.Background(), WorkItem{URL: "https://example.com/empty.pdf"}, fetcher, parser)
+	if result.Err != nil || result.Links == nil || len(result.Links) != 0 {
+		t.Errorf("empty PDF: Links = %#v, Err = %v, want empty links and no error", result.Links, result.Err)
+	}
+
+	// Parsers without PDF support leave PDFs as dead ends
+	result = processWorkItem(context.Background(), WorkItem{URL: "https://example.com/doc.pdf"}, fetcher, &mockParser{links: []string{"/ignored"}})
+	if len(result.Links) != 0 {
+		t.Errorf("Links = %v with a non-PDF parser, want none", result.Links)
+	}
+}
@@ -0,0 +1,148 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"os/exec"
+	"time"
+
+	"github.com/cametumbling/web-crawler/internal/crawler"
+)
+
+const (
+	// DefaultRenderBudget is how long scripts may run before the DOM is captured
+	DefaultRenderBudget = 5 * time.Second
+	// DefaultTimeout is the default cap on a single browser run
+	DefaultTimeout = 30 * time.Second
+)
+
+// chromeNames are the executables looked up in PATH when no browser is configured.
+var chromeNames = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "chrome"}
+
+// ErrNoBrowser is returned by New when no Chrome or Chromium executable is found.
+var ErrNoBrowser = errors.New("no Chrome or Chromium executable found")
+
+// Renderer is a Fetcher that renders HTML pages in headless Chrome, so
+// links added by JavaScript are visible to the parser. The page is first
+// fetched by the underlying Fetcher, which supplies the status, redirects,
+// and content type; HTML responses are then loaded in the browser and the
+// Body replaced with the serialized DOM. It is safe for concurrent use.
+//
+// The browser loads the page again itself, with its subresources, in a
+// new Chrome process per page. Those requests bypass the underlying
+// Fetcher: its rate limits, headers, User-Agent, and private address
+// blocking do not apply to them.
+type Renderer struct {
+	base         crawler.Fetcher
+	chromePath   string
+	renderBudget time.Duration
+	timeout      time.Duration
+}
+
+// Config contains configuration options for the Renderer.
+type Config struct {
+	// ChromePath is the Chrome or Chromium executable (default: the first of
+	// chromium, chromium-browser, google-chrome, google-chrome-stable, or
+	// chrome found in PATH)
+	ChromePath string
+	// RenderBudget is how long the page's scripts may run, in the browser's
+	// virtual time, before the DOM is captured (default: 5s)
+	RenderBudget time.Duration
+	// Timeout caps each browser run in wall-clock time (default: 30s)
+	Timeout time.Duration
+}
+
+// New creates a Renderer that fetches through base and renders HTML in the
+// configured browser. Returns ErrNoBrowser if no browser can be found.
+func New(base crawler.Fetcher, cfg Config) (*Renderer, error) {
+	if cfg.RenderBudget == 0 {
+		cfg.RenderBudget = DefaultRenderBudget
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	chromePath := cfg.ChromePath
+	if chromePath == "" {
+		for _, name := range chromeNames {
+			if p, err := exec.LookPath(name); err == nil {
+				chromePath = p
+				break
+			}
+		}
+		if chromePath == "" {
+			return nil, ErrNoBrowser
+		}
+	} else if _, err := exec.LookPath(chromePath); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoBrowser, err)
+	}
+
+	return &Renderer{
+		base:         base,
+		chromePath:   chromePath,
+		renderBudget: cfg.RenderBudget,
+		timeout:      cfg.Timeout,
+	}, nil
+}
+
+// Check verifies url through the underlying Fetcher without rendering it.
+func (r *Renderer) Check(ctx context.Context, url string) error {
+	if lc, ok := r.base.(crawler.LinkChecker); ok {
+		return lc.Check(ctx, url)
+	}
+	_, err := r.base.Fetch(ctx, url)
+	return err
+}
+
+// Fetch retrieves url through the underlying Fetcher and, for HTML
+// responses, replaces the body with the DOM as rendered by the browser.
+func (r *Renderer) Fetch(ctx context.Context, url string) (*crawler.FetchResult, error) {
+	result, err := r.base.Fetch(ctx, url)
+	if err != nil || !isHTML(result.ContentType) {
+		return result, err
+	}
+
+	start := time.Now()
+	runCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, r.chromePath,
+		"--headless=new",
+		"--disable-gpu",
+		"--mute-audio",
+		"--no-first-run",
+		fmt.Sprintf("--virtual-time-budget=%d", r.renderBudget.Milliseconds()),
+		"--dump-dom",
+		result.FinalURL,
+	)
+	// Chrome's helper processes inherit stdout; stop waiting on them soon
+	// after the browser itself is killed
+	cmd.WaitDelay = time.Second
+	dom, err := cmd.Output()
+	if err != nil {
+		if runCtx.Err() != nil && ctx.Err() == nil {
+			return nil, fmt.Errorf("rendering %s: browser timed out after %v", url, r.timeout)
+		}
+		return nil, fmt.Errorf("rendering %s: %w", url, err)
+	}
+
+	result.Body = dom
+	result.Duration += time.Since(start)
+	return result, nil
+}
+
+// isHTML reports whether a Content-Type header denotes HTML; an empty header
+// is treated as HTML, as the crawler does.
+func isHTML(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	// A malformed parameter still leaves a usable media type
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil && !errors.Is(err, mime.ErrInvalidMediaParameter) {
+		return false
+	}
+	return mediaType == "text/html"
+}
@@ -0,0 +1,119 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cametumbling/web-crawler/internal/crawler"
+)
+
+// stubFetcher returns a fixed result for every URL.
+type stubFetcher struct {
+	result *crawler.FetchResult
+	err    error
+}
+
+func (f *stubFetcher) Fetch(ctx context.Context, url string) (*crawler.FetchResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	result := *f.result
+	return &result, nil
+}
+
+// fakeChrome writes a shell script standing in for the browser: it prints
+// the given output with the last argument (the URL) substituted for {url}.
+func fakeChrome(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake browser is a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "chrome")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatalf("writing fake browser: %v", err)
+	}
+	return path
+}
+
+func TestRenderer_RendersHTML(t *testing.T) {
+	chrome := fakeChrome(t, `for last; do :; done; echo "<html><a href=\"/rendered\">$last</a></html>"`)
+	base := &stubFetcher{result: &crawler.FetchResult{
+		Body:        []byte("<html><div id=app></div></html>"),
+		FinalURL:    "https://example.com/app/",
+		ContentType: "text/html; charset=utf-8",
+		Redirects:   []crawler.Redirect{{URL: "https://example.com/app", StatusCode: 301}},
+	}}
+
+	r, err := New(base, Config{ChromePath: chrome})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	result, err := r.Fetch(context.Background(), "https://example.com/app")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	want := `<html><a href="/rendered">https://example.com/app/</a></html>` + "\n"
+	if string(result.Body) != want {
+		t.Errorf("Body = %q, want %q (DOM of the final URL)", result.Body, want)
+	}
+	if result.FinalURL != "https://example.com/app/" || len(result.Redirects) != 1 {
+		t.Errorf("FinalURL/Redirects = %q/%v, want those of the HTTP fetch", result.FinalURL, result.Redirects)
+	}
+}
+
+func TestRenderer_PassesThroughNonHTMLAndErrors(t *testing.T) {
+	chrome := fakeChrome(t, `echo "should not run"; exit 1`)
+
+	base := &stubFetcher{result: &crawler.FetchResult{Body: []byte("%PDF"), ContentType: "application/pdf"}}
+	r, err := New(base, Config{ChromePath: chrome})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	result, err := r.Fetch(context.Background(), "https://example.com/a.pdf")
+	if err != nil || string(result.Body) != "%PDF" {
+		t.Errorf("Fetch() = %q, %v; want the PDF body untouched", result.Body, err)
+	}
+
+	fetchErr := &crawler.HTTPError{StatusCode: 404, URL: "https://example.com/missing"}
+	r, err = New(&stubFetcher{err: fetchErr}, Config{ChromePath: chrome})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := r.Fetch(context.Background(), "https://example.com/missing"); !errors.Is(err, fetchErr) {
+		t.Errorf("Fetch() error = %v, want the HTTP error", err)
+	}
+}
+
+func TestRenderer_BrowserFailure(t *testing.T) {
+	base := &stubFetcher{result: &crawler.FetchResult{FinalURL: "https://example.com/", ContentType: "text/html"}}
+
+	r, err := New(base, Config{ChromePath: fakeChrome(t, "exit 3")})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := r.Fetch(context.Background(), "https://example.com/"); err == nil {
+		t.Error("Fetch() error = nil, want browser failure")
+	}
+
+	r, err = New(base, Config{ChromePath: fakeChrome(t, "sleep 5"), Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := r.Fetch(context.Background(), "https://example.com/"); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Fetch() error = %v, want timeout", err)
+	}
+}
+
+func TestNew_NoBrowser(t *testing.T) {
+	_, err := New(&stubFetcher{}, Config{ChromePath: filepath.Join(t.TempDir(), "missing-chrome")})
+	if !errors.Is(err, ErrNoBrowser) {
+		t.Errorf("New() error = %v, want ErrNoBrowser", err)
+	}
+}
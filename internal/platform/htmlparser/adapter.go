@@ -0,0 +1,63 @@
+package htmlparser
+
+import (
+	"io"
+
+	"github.com/cametumbling/web-crawler/internal/crawler"
+)
+
+// Parser implements the crawler's Parser, MetadataParser, and
+// StylesheetParser interfaces with this package's extractors.
+type Parser struct{}
+
+// PDFParser is Parser plus PDF link extraction (crawler.PDFParser).
+type PDFParser struct {
+	Parser
+}
+
+func (p *Parser) ExtractLinks(r io.Reader) ([]string, error) {
+	return ExtractLinks(r)
+}
+
+func (p *Parser) ExtractStylesheet(r io.Reader) ([]string, error) {
+	return ExtractStylesheet(r)
+}
+
+func (p *Parser) ExtractPage(r io.Reader) ([]crawler.Link, *crawler.PageMetadata, error) {
+	links, meta, err := ExtractPage(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	crawlerLinks := make([]crawler.Link, len(links))
+	for i, l := range links {
+		crawlerLinks[i] = crawler.Link{Href: l.Href, Text: l.Text, Rel: l.Rel, Tag: l.Tag}
+	}
+	assets := make([]crawler.Asset, len(meta.Assets))
+	for i, a := range meta.Assets {
+		assets[i] = crawler.Asset{Type: a.Type, URL: a.URL}
+	}
+	hints := make([]crawler.ResourceHint, len(meta.ResourceHints))
+	for i, h := range meta.ResourceHints {
+		hints[i] = crawler.ResourceHint{Rel: h.Rel, URL: h.URL, As: h.As}
+	}
+	return crawlerLinks, &crawler.PageMetadata{
+		Lang:           meta.Lang,
+		NoIndex:        meta.NoIndex,
+		NoFollow:       meta.NoFollow,
+		NofollowLinks:  meta.NofollowLinks,
+		Anchors:        meta.Anchors,
+		Title:          meta.Title,
+		Description:    meta.Description,
+		Text:           meta.Text,
+		Assets:         assets,
+		BaseHref:       meta.BaseHref,
+		Canonical:      meta.Canonical,
+		StructuredData: meta.StructuredData,
+		ResourceHints:  hints,
+		OpenGraph:      meta.OpenGraph,
+	}, nil
+}
+
+func (p *PDFParser) ExtractPDFLinks(r io.Reader) ([]string, error) {
+	return ExtractPDFLinks(r)
+}
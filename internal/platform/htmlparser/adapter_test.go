@@ -0,0 +1,39 @@
+package htmlparser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParser_ExtractPageMapsEveryField(t *testing.T) {
+	page := `<html lang="en"><head>
+		<title>Title</title>
+		<base href="/docs/">
+		<meta name="description" content="About">
+		<meta name="robots" content="none">
+		<meta property="og:title" content="OG">
+		<link rel="canonical" href="/canonical">
+		<link rel="preload" href="/font.woff2" as="font">
+		<script type="application/ld+json">{"@type": "Thing"}</script>
+	</head><body id="top">
+		<img src="/logo.png">
+		<a href="/next" rel="nofollow">Next</a>
+	</body></html>`
+
+	links, meta, err := (&Parser{}).ExtractPage(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("ExtractPage() error = %v", err)
+	}
+	if len(links) != 1 || links[0].Href != "/next" || links[0].Text != "Next" {
+		t.Errorf("links = %+v", links)
+	}
+	// A field left zero here was most likely added to Metadata without
+	// being copied into the crawler's PageMetadata
+	v := reflect.ValueOf(*meta)
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).IsZero() {
+			t.Errorf("PageMetadata.%s is not set", v.Type().Field(i).Name)
+		}
+	}
+}
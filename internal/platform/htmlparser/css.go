@@ -0,0 +1,45 @@
+package htmlparser
+
+import (
+	"io"
+	"regexp"
+)
+
+var (
+	// cssComment matches a CSS comment, which may hide url() references
+	cssComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	// cssURL matches url(...) with a double-quoted, single-quoted, or bare argument
+	cssURL = regexp.MustCompile(`(?i)\burl\(\s*(?:"([^"]*)"|'([^']*)'|([^)"'\s]*))\s*\)`)
+	// cssImport matches @import with a plain string; @import url(...) is
+	// already covered by cssURL
+	cssImport = regexp.MustCompile(`(?i)@import\s+(?:"([^"]*)"|'([^']*)')`)
+)
+
+// ExtractCSSURLs returns the raw URLs referenced by url() and @import in a
+// CSS source, in order of appearance within each kind. Empty references are
+// dropped; data: and other non-http URLs are left for the caller to filter.
+func ExtractCSSURLs(css string) []string {
+	css = cssComment.ReplaceAllString(css, "")
+
+	var urls []string
+	for _, re := range []*regexp.Regexp{cssImport, cssURL} {
+		for _, m := range re.FindAllStringSubmatch(css, -1) {
+			for _, group := range m[1:] {
+				if group != "" {
+					urls = append(urls, group)
+					break
+				}
+			}
+		}
+	}
+	return urls
+}
+
+// ExtractStylesheet reads a CSS file and returns the raw URLs it references.
+func ExtractStylesheet(r io.Reader) ([]string, error) {
+	css, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ExtractCSSURLs(string(css)), nil
+}
@@ -0,0 +1,61 @@
+package htmlparser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractCSSURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		css  string
+		want []string
+	}{
+		{"bare url", "body { background: url(/bg.png) }", []string{"/bg.png"}},
+		{"quoted urls", `a { background: url("a.png") } b { background: URL( 'b.png' ) }`, []string{"a.png", "b.png"}},
+		{"import string", `@import "theme.css"; @import 'print.css' print;`, []string{"theme.css", "print.css"}},
+		{"import url", `@import url(fonts.css);`, []string{"fonts.css"}},
+		{"commented out", "/* url(/old.png) */ div { background: url(/new.png) }", []string{"/new.png"}},
+		{"empty url dropped", "div { background: url() }", nil},
+		{"no references", "div { color: red }", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractCSSURLs(tt.css)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractCSSURLs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractStylesheet(t *testing.T) {
+	got, err := ExtractStylesheet(strings.NewReader(`@import "base.css"; h1 { background: url(h1.svg) }`))
+	if err != nil {
+		t.Fatalf("ExtractStylesheet() error = %v", err)
+	}
+	want := []string{"base.css", "h1.svg"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractStylesheet() = %v, want %v", got, want)
+	}
+}
+
+func TestExtractMetadata_InlineCSS(t *testing.T) {
+	html := `<html><head><style>@import "site.css"; body { background: url(/bg.jpg) }</style></head>
+<body><div style="background-image: url('/hero.png')"></div></body></html>`
+
+	meta, err := ExtractMetadata(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("ExtractMetadata() error = %v", err)
+	}
+	want := []Asset{
+		{Type: "css", URL: "site.css"},
+		{Type: "css", URL: "/bg.jpg"},
+		{Type: "css", URL: "/hero.png"},
+	}
+	if !reflect.DeepEqual(meta.Assets, want) {
+		t.Errorf("Assets = %v, want %v", meta.Assets, want)
+	}
+}
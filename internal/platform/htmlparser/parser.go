@@ -2,34 +2,452 @@ package htmlparser
 
 import (
 	"io"
+	"strings"
 
 	"golang.org/x/net/html"
 )
 
-// ExtractLinks parses HTML from the reader and returns all href attributes
+// ExtractLinks tokenizes HTML from the reader and returns all href attributes
 // found in <a> tags. Returns raw href strings exactly as they appear in the HTML.
+// It streams the input rather than building a document tree, so memory stays
+// flat however large the body is.
 func ExtractLinks(r io.Reader) ([]string, error) {
-	doc, err := html.Parse(r)
-	if err != nil {
-		return nil, err
-	}
-
 	var links []string
-	var walk func(*html.Node)
-	walk = func(n *html.Node) {
-		if n.Type == html.ElementNode && n.Data == "a" {
-			for _, attr := range n.Attr {
-				if attr.Key == "href" {
-					links = append(links, attr.Val)
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return nil, err
+			}
+			return links, nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "a" {
+				continue
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) == "href" {
+					links = append(links, string(val))
 					break
 				}
 			}
 		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			walk(c)
+	}
+}
+
+// Metadata holds document-level information extracted from an HTML page.
+type Metadata struct {
+	// Lang is the lowercased lang attribute of the <html> element ("" if absent)
+	Lang string
+	// NoIndex is true if a <meta name="robots"> tag contains noindex or none
+	NoIndex bool
+	// NoFollow is true if a <meta name="robots"> tag contains nofollow or none
+	NoFollow bool
+	// NofollowLinks contains the raw hrefs of <a> tags with rel="nofollow"
+	NofollowLinks []string
+	// Anchors contains the fragment targets in the document: every element
+	// id, plus the name attribute of <a> tags
+	Anchors []string
+	// Title is the whitespace-collapsed text of the first <title> element
+	Title string
+	// Description is the whitespace-collapsed content of the first
+	// <meta name="description"> tag
+	Description string
+	// Text is the page's visible body text, whitespace-collapsed
+	Text string
+	// Assets contains the raw URLs of page dependencies, in document order,
+	// including every srcset candidate of <img> and <picture> sources and
+	// the url() and @import references of inline CSS
+	Assets []Asset
+	// BaseHref is the raw href of the first <base> element ("" if absent)
+	BaseHref string
+	// Canonical is the raw href of the first <link rel="canonical"> ("" if absent)
+	Canonical string
+	// StructuredData contains the raw bodies of <script type="application/ld+json">
+	// blocks, in document order
+	StructuredData []string
+	// ResourceHints contains the page's <link> preload, modulepreload,
+	// prefetch, preconnect, and dns-prefetch hints, in document order
+	ResourceHints []ResourceHint
+	// OpenGraph maps each <meta property="og:..."> property, lowercased, to
+	// the content of its first occurrence (nil if the page has none)
+	OpenGraph map[string]string
+}
+
+// ResourceHint is a <link> asking the browser to fetch or connect early.
+type ResourceHint struct {
+	// Rel is the lowercased hint type, such as "preload" or "preconnect"
+	Rel string
+	// URL is the raw href value
+	URL string
+	// As is the lowercased as attribute of preloads ("" if absent)
+	As string
+}
+
+// resourceHintRels are the rel values extracted as resource hints.
+var resourceHintRels = map[string]bool{
+	"preload":       true,
+	"modulepreload": true,
+	"prefetch":      true,
+	"preconnect":    true,
+	"dns-prefetch":  true,
+}
+
+// Asset is a non-anchor URL referenced by a page.
+type Asset struct {
+	// Type is the tag the URL came from: "img", "script", "link", or
+	// "iframe", or "css" for references in inline styles
+	Type string
+	// URL is the raw src or href value
+	URL string
+}
+
+// assetAttrs maps asset-bearing tags to the attribute holding their URL.
+var assetAttrs = map[string]string{
+	"img":    "src",
+	"script": "src",
+	"link":   "href",
+	"iframe": "src",
+}
+
+// ExtractMetadata parses HTML from the reader and returns document-level metadata.
+func ExtractMetadata(r io.Reader) (Metadata, error) {
+	_, meta, err := ExtractPage(r)
+	return meta, err
+}
+
+// Link is an <a> element with an href.
+type Link struct {
+	// Href is the raw href value
+	Href string
+	// Text is the whitespace-collapsed visible text of the element, or the
+	// alt text of its first image if it has none
+	Text string
+	// Rel is the lowercased, space-separated rel attribute ("" if absent)
+	Rel string
+	// Tag is the element the link came from ("a")
+	Tag string
+}
+
+// ExtractPage tokenizes HTML from the reader once and returns both the <a>
+// links, whose hrefs are the ones ExtractLinks would return, and the
+// document-level metadata. Like ExtractLinks it streams the input rather
+// than building a document tree.
+func ExtractPage(r io.Reader) ([]Link, Metadata, error) {
+	var (
+		links []Link
+		meta  Metadata
+		// text collects the visible text; element starts add a space so
+		// adjacent blocks don't run together
+		text strings.Builder
+		// link is the <a> being read, until its end tag or the next <a>
+		link *openLink
+		// rawTag is the raw text element (script, style, title, ...) whose
+		// content the next text token is
+		rawTag     string
+		ldJSON     bool
+		seenHTML   bool
+		inTemplate int
+		inPicture  int
+	)
+	closeLink := func() {
+		if link == nil {
+			return
 		}
+		l := Link{Href: link.href, Text: collapse(link.text.String()), Rel: link.rel, Tag: "a"}
+		if l.Text == "" {
+			l.Text = link.alt
+		}
+		links = append(links, l)
+		link = nil
 	}
-	walk(doc)
+	// visible adds s to the open link's text and, outside templates, the
+	// page text, unless it is the content of a script, title, or the like
+	visible := func(s string) {
+		if invisibleElements[rawTag] || rawTag == "title" {
+			return
+		}
+		if inTemplate == 0 {
+			text.WriteString(s)
+		}
+		if link != nil {
+			link.text.WriteString(s)
+		}
+	}
+
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return nil, Metadata{}, err
+			}
+			closeLink()
+			meta.Text = collapse(text.String())
+			return links, meta, nil
+
+		case html.TextToken:
+			data := string(z.Text())
+			switch {
+			case rawTag == "style":
+				for _, u := range ExtractCSSURLs(data) {
+					meta.Assets = append(meta.Assets, Asset{Type: "css", URL: u})
+				}
+			case rawTag == "script" && ldJSON:
+				if body := strings.TrimSpace(data); body != "" {
+					meta.StructuredData = append(meta.StructuredData, body)
+				}
+			case rawTag == "title" && meta.Title == "":
+				meta.Title = collapse(data)
+			}
+			visible(data)
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch tag := string(name); tag {
+			case rawTag:
+				rawTag, ldJSON = "", false
+			case "a":
+				closeLink()
+			case "template":
+				inTemplate = max(inTemplate-1, 0)
+			case "picture":
+				inPicture = max(inPicture-1, 0)
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			tag, attrs := tok.Data, tok.Attr
+			visible(" ")
+			if tt == html.StartTagToken && rawTextElements[tag] {
+				rawTag = tag
+			}
+
+			if id := attrValue(attrs, "id"); id != "" {
+				meta.Anchors = append(meta.Anchors, id)
+			}
+			if name := attrValue(attrs, "name"); tag == "a" && name != "" {
+				meta.Anchors = append(meta.Anchors, name)
+			}
+			if key, ok := assetAttrs[tag]; ok {
+				if val, ok := attr(attrs, key); ok && strings.TrimSpace(val) != "" {
+					meta.Assets = append(meta.Assets, Asset{Type: tag, URL: val})
+				}
+			}
+			// Inline CSS: background images and the like in style attributes
+			if style := attrValue(attrs, "style"); style != "" {
+				for _, u := range ExtractCSSURLs(style) {
+					meta.Assets = append(meta.Assets, Asset{Type: "css", URL: u})
+				}
+			}
+			// Responsive image variants: <img srcset> and <picture><source srcset>
+			if tag == "img" || tag == "source" && inPicture > 0 {
+				for _, u := range parseSrcset(attrValue(attrs, "srcset")) {
+					meta.Assets = append(meta.Assets, Asset{Type: "img", URL: u})
+				}
+			}
 
-	return links, nil
+			switch tag {
+			case "template":
+				if tt == html.StartTagToken {
+					inTemplate++
+				}
+			case "picture":
+				if tt == html.StartTagToken {
+					inPicture++
+				}
+			case "img":
+				// Image-only links are named by their first image's alt text
+				if link != nil && !link.sawImg {
+					link.sawImg = true
+					link.alt = collapse(attrValue(attrs, "alt"))
+				}
+			case "base":
+				if href, ok := attr(attrs, "href"); ok && meta.BaseHref == "" {
+					meta.BaseHref = strings.TrimSpace(href)
+				}
+			case "script":
+				ldJSON = strings.EqualFold(strings.TrimSpace(attrValue(attrs, "type")), "application/ld+json")
+			case "link":
+				if href := strings.TrimSpace(attrValue(attrs, "href")); href != "" {
+					for _, rel := range tokens(attrValue(attrs, "rel"), " ") {
+						if resourceHintRels[rel] {
+							meta.ResourceHints = append(meta.ResourceHints, ResourceHint{
+								Rel: rel,
+								URL: href,
+								As:  strings.ToLower(strings.TrimSpace(attrValue(attrs, "as"))),
+							})
+						}
+					}
+				}
+				if href, ok := attr(attrs, "href"); ok && meta.Canonical == "" {
+					for _, rel := range tokens(attrValue(attrs, "rel"), " ") {
+						if rel == "canonical" {
+							meta.Canonical = strings.TrimSpace(href)
+							break
+						}
+					}
+				}
+			case "html":
+				if !seenHTML {
+					seenHTML = true
+					meta.Lang = strings.ToLower(strings.TrimSpace(attrValue(attrs, "lang")))
+				}
+			case "meta":
+				if strings.EqualFold(attrValue(attrs, "name"), "description") && meta.Description == "" {
+					meta.Description = collapse(attrValue(attrs, "content"))
+				}
+				if prop := strings.ToLower(strings.TrimSpace(attrValue(attrs, "property"))); strings.HasPrefix(prop, "og:") {
+					if meta.OpenGraph == nil {
+						meta.OpenGraph = make(map[string]string)
+					}
+					if _, seen := meta.OpenGraph[prop]; !seen {
+						meta.OpenGraph[prop] = strings.TrimSpace(attrValue(attrs, "content"))
+					}
+				}
+				if strings.EqualFold(attrValue(attrs, "name"), "robots") {
+					for _, directive := range tokens(attrValue(attrs, "content"), ",") {
+						if directive == "noindex" || directive == "none" {
+							meta.NoIndex = true
+						}
+						if directive == "nofollow" || directive == "none" {
+							meta.NoFollow = true
+						}
+					}
+				}
+			case "a":
+				// Links don't nest: a new <a> closes the open one, as in a browser
+				closeLink()
+				if href, ok := attr(attrs, "href"); ok {
+					rels := tokens(attrValue(attrs, "rel"), " ")
+					link = &openLink{href: href, rel: strings.Join(rels, " ")}
+					for _, rel := range rels {
+						if rel == "nofollow" {
+							meta.NofollowLinks = append(meta.NofollowLinks, href)
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// openLink is an <a> element whose text is still being read.
+type openLink struct {
+	href, rel string
+	text      strings.Builder
+	// alt is the alt text of the link's first image
+	alt    string
+	sawImg bool
+}
+
+// parseSrcset returns the candidate URLs of a srcset attribute, dropping the
+// width and density descriptors. A URL may itself contain commas, so
+// candidates are split the way browsers do: the URL runs to the next
+// whitespace, and its descriptors run to the next comma.
+func parseSrcset(srcset string) []string {
+	var urls []string
+	s := srcset
+	for {
+		s = strings.TrimLeft(s, " \t\n\r\f,")
+		if s == "" {
+			return urls
+		}
+
+		end := strings.IndexAny(s, " \t\n\r\f")
+		if end < 0 {
+			end = len(s)
+		}
+		u := s[:end]
+		s = s[end:]
+
+		if strings.HasSuffix(u, ",") {
+			// No descriptors: the comma ends the candidate
+			u = strings.TrimRight(u, ",")
+		} else {
+			// Skip descriptors up to the next comma outside parentheses
+			depth := 0
+			i := 0
+		descriptors:
+			for ; i < len(s); i++ {
+				switch s[i] {
+				case '(':
+					depth++
+				case ')':
+					if depth > 0 {
+						depth--
+					}
+				case ',':
+					if depth == 0 {
+						break descriptors
+					}
+				}
+			}
+			s = s[i:]
+		}
+
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+}
+
+// invisibleElements are elements whose text content is never rendered.
+var invisibleElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"template": true,
+}
+
+// rawTextElements are the elements whose content the tokenizer returns as
+// a single text token rather than markup.
+var rawTextElements = map[string]bool{
+	"iframe":    true,
+	"noembed":   true,
+	"noframes":  true,
+	"noscript":  true,
+	"plaintext": true,
+	"script":    true,
+	"style":     true,
+	"textarea":  true,
+	"title":     true,
+	"xmp":       true,
+}
+
+// collapse trims s and replaces each run of whitespace with a single space.
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// attr returns the value of the named attribute and whether it is present.
+func attr(attrs []html.Attribute, key string) (string, bool) {
+	for _, a := range attrs {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// attrValue returns the value of the named attribute, or "" if absent.
+func attrValue(attrs []html.Attribute, key string) string {
+	val, _ := attr(attrs, key)
+	return val
+}
+
+// tokens splits s on sep and returns the lowercased, trimmed, non-empty parts.
+func tokens(s, sep string) []string {
+	var out []string
+	for _, tok := range strings.Split(s, sep) {
+		if tok = strings.ToLower(strings.TrimSpace(tok)); tok != "" {
+			out = append(out, tok)
+		}
+	}
+	return out
 }
@@ -1,8 +1,12 @@
 package htmlparser
 
 import (
+	"errors"
+	"io"
+	"reflect"
 	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestExtractLinks(t *testing.T) {
@@ -119,10 +123,36 @@ func TestExtractLinks(t *testing.T) {
 			</body></html>`,
 			expected: []string{"/path%20with%20spaces", "/path/to/file.html?query=value&other=value"},
 		},
+		{
+			name: "markup in comments and raw text ignored",
+			html: `<html><head>
+				<script>document.write('<a href="/from-script">x</a>')</script>
+				<style>a[href="/from-style"] { color: red }</style>
+			</head><body>
+				<!-- <a href="/commented">x</a> -->
+				<textarea><a href="/from-textarea">x</a></textarea>
+				<a href="/real">Real</a>
+			</body></html>`,
+			expected: []string{"/real"},
+		},
+		{
+			name:     "entities decoded and uppercase tags",
+			html:     `<A HREF="/search?a=1&amp;b=2">Search</A><a href="/self-closing"/>`,
+			expected: []string{"/search?a=1&b=2", "/self-closing"},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			// ExtractLinks must agree with the links ExtractPage returns
+			pageLinks, _, err := ExtractPage(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatalf("ExtractPage() error = %v", err)
+			}
+			if len(pageLinks) != len(tt.expected) {
+				t.Errorf("ExtractPage() links = %+v, want hrefs %v", pageLinks, tt.expected)
+			}
+
 			r := strings.NewReader(tt.html)
 			got, err := ExtractLinks(r)
 			if err != nil {
@@ -176,3 +206,483 @@ func TestExtractLinks_InvalidHTML(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractLinks_ReadError(t *testing.T) {
+	readErr := errors.New("connection reset")
+	r := io.MultiReader(strings.NewReader(`<a href="/partial">`), iotest.ErrReader(readErr))
+	if _, err := ExtractLinks(r); !errors.Is(err, readErr) {
+		t.Errorf("ExtractLinks() error = %v, want %v", err, readErr)
+	}
+	r = io.MultiReader(strings.NewReader(`<a href="/partial">`), iotest.ErrReader(readErr))
+	if _, _, err := ExtractPage(r); !errors.Is(err, readErr) {
+		t.Errorf("ExtractPage() error = %v, want %v", err, readErr)
+	}
+}
+
+func TestExtractMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		wantLang string
+	}{
+		{
+			name:     "lang attribute",
+			html:     `<html lang="en"><body></body></html>`,
+			wantLang: "en",
+		},
+		{
+			name:     "region subtag lowercased",
+			html:     `<!DOCTYPE html><html lang=" en-GB "><body></body></html>`,
+			wantLang: "en-gb",
+		},
+		{
+			name:     "no lang attribute",
+			html:     `<html><body></body></html>`,
+			wantLang: "",
+		},
+		{
+			name:     "lang on nested element ignored",
+			html:     `<html><body><p lang="fr">Bonjour</p></body></html>`,
+			wantLang: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := ExtractMetadata(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatalf("ExtractMetadata() error = %v", err)
+			}
+			if meta.Lang != tt.wantLang {
+				t.Errorf("Lang = %q, want %q", meta.Lang, tt.wantLang)
+			}
+		})
+	}
+}
+
+func TestExtractMetadata_Robots(t *testing.T) {
+	tests := []struct {
+		name         string
+		html         string
+		wantNoIndex  bool
+		wantNoFollow bool
+		wantNofollow []string
+	}{
+		{
+			name:        "noindex directive",
+			html:        `<html><head><meta name="robots" content="noindex, follow"></head></html>`,
+			wantNoIndex: true,
+		},
+		{
+			name:         "nofollow directive",
+			html:         `<html><head><meta name="robots" content="index, NOFOLLOW"></head></html>`,
+			wantNoFollow: true,
+		},
+		{
+			name:         "none directive implies noindex and nofollow",
+			html:         `<html><head><meta name="ROBOTS" content="NONE"></head></html>`,
+			wantNoIndex:  true,
+			wantNoFollow: true,
+		},
+		{
+			name:        "index directive",
+			html:        `<html><head><meta name="robots" content="index,follow"></head></html>`,
+			wantNoIndex: false,
+		},
+		{
+			name: "nofollow links",
+			html: `<html><body>
+				<a href="/a" rel="nofollow">A</a>
+				<a href="/b" rel="noopener NOFOLLOW">B</a>
+				<a href="/c">C</a>
+				<a rel="nofollow">No href</a>
+			</body></html>`,
+			wantNofollow: []string{"/a", "/b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := ExtractMetadata(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatalf("ExtractMetadata() error = %v", err)
+			}
+			if meta.NoIndex != tt.wantNoIndex {
+				t.Errorf("NoIndex = %v, want %v", meta.NoIndex, tt.wantNoIndex)
+			}
+			if meta.NoFollow != tt.wantNoFollow {
+				t.Errorf("NoFollow = %v, want %v", meta.NoFollow, tt.wantNoFollow)
+			}
+			if len(meta.NofollowLinks) != len(tt.wantNofollow) {
+				t.Fatalf("NofollowLinks = %v, want %v", meta.NofollowLinks, tt.wantNofollow)
+			}
+			for i, want := range tt.wantNofollow {
+				if meta.NofollowLinks[i] != want {
+					t.Errorf("NofollowLinks[%d] = %q, want %q", i, meta.NofollowLinks[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractMetadata_Anchors(t *testing.T) {
+	html := `<html><body>
+		<h1 id="intro">Intro</h1>
+		<a name="legacy"></a>
+		<div name="ignored"></div>
+		<section id="details"><a href="#intro" id="back">Back</a></section>
+	</body></html>`
+
+	meta, err := ExtractMetadata(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("ExtractMetadata() error = %v", err)
+	}
+
+	want := []string{"intro", "legacy", "details", "back"}
+	if len(meta.Anchors) != len(want) {
+		t.Fatalf("Anchors = %v, want %v", meta.Anchors, want)
+	}
+	for i := range want {
+		if meta.Anchors[i] != want[i] {
+			t.Errorf("Anchors[%d] = %q, want %q", i, meta.Anchors[i], want[i])
+		}
+	}
+}
+
+func TestExtractMetadata_TitleAndText(t *testing.T) {
+	html := `<html><head>
+		<title>  Monzo
+		Help </title>
+		<style>body { color: red }</style>
+		<meta name="Description" content=" Banking  made
+		easy ">
+		<meta name="description" content="Second">
+	</head><body>
+		<h1>Welcome</h1><p>Bank   <b>better</b>.</p>
+		<script>var hidden = "no";</script>
+		<noscript>Enable JS</noscript>
+	</body></html>`
+
+	meta, err := ExtractMetadata(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("ExtractMetadata() error = %v", err)
+	}
+	if meta.Title != "Monzo Help" {
+		t.Errorf("Title = %q, want %q", meta.Title, "Monzo Help")
+	}
+	if meta.Description != "Banking made easy" {
+		t.Errorf("Description = %q, want %q", meta.Description, "Banking made easy")
+	}
+	if meta.Text != "Welcome Bank better." {
+		t.Errorf("Text = %q, want %q", meta.Text, "Welcome Bank better.")
+	}
+}
+
+func TestExtractMetadata_Assets(t *testing.T) {
+	html := `<html><head>
+		<link rel="stylesheet" href="/style.css">
+		<script src="app.js"></script>
+		<script>inline()</script>
+	</head><body>
+		<img src="https://cdn.example.com/logo.png">
+		<img src="  ">
+		<iframe src="/embed"></iframe>
+		<a href="/page">Not an asset</a>
+	</body></html>`
+
+	meta, err := ExtractMetadata(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("ExtractMetadata() error = %v", err)
+	}
+
+	want := []Asset{
+		{Type: "link", URL: "/style.css"},
+		{Type: "script", URL: "app.js"},
+		{Type: "img", URL: "https://cdn.example.com/logo.png"},
+		{Type: "iframe", URL: "/embed"},
+	}
+	if len(meta.Assets) != len(want) {
+		t.Fatalf("Assets = %v, want %v", meta.Assets, want)
+	}
+	for i := range want {
+		if meta.Assets[i] != want[i] {
+			t.Errorf("Assets[%d] = %+v, want %+v", i, meta.Assets[i], want[i])
+		}
+	}
+}
+
+func TestExtractMetadata_Srcset(t *testing.T) {
+	html := `<html><body>
+		<img src="/small.jpg" srcset="/small.jpg 480w, /large.jpg 1080w">
+		<picture>
+			<source srcset="/hero.webp 1x,/hero@2x.webp 2x" type="image/webp">
+			<img src="/hero.jpg">
+		</picture>
+		<video><source srcset="/ignored.jpg" src="/clip.mp4"></video>
+	</body></html>`
+
+	meta, err := ExtractMetadata(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("ExtractMetadata() error = %v", err)
+	}
+
+	want := []Asset{
+		{Type: "img", URL: "/small.jpg"},
+		{Type: "img", URL: "/small.jpg"},
+		{Type: "img", URL: "/large.jpg"},
+		{Type: "img", URL: "/hero.webp"},
+		{Type: "img", URL: "/hero@2x.webp"},
+		{Type: "img", URL: "/hero.jpg"},
+	}
+	if !reflect.DeepEqual(meta.Assets, want) {
+		t.Errorf("Assets = %v, want %v", meta.Assets, want)
+	}
+}
+
+func TestParseSrcset(t *testing.T) {
+	tests := []struct {
+		srcset string
+		want   []string
+	}{
+		{"", nil},
+		{"/a.jpg", []string{"/a.jpg"}},
+		{"/a.jpg 1x, /b.jpg 2x", []string{"/a.jpg", "/b.jpg"}},
+		{"/a.jpg, /b.jpg", []string{"/a.jpg", "/b.jpg"}},
+		{"  /a.jpg  100w ,\n /b.jpg   200w  ", []string{"/a.jpg", "/b.jpg"}},
+		{"/img?size=1,2 1x, /c.jpg 2x", []string{"/img?size=1,2", "/c.jpg"}},
+		{"/a.jpg (future, descriptor), /b.jpg", []string{"/a.jpg", "/b.jpg"}},
+	}
+
+	for _, tt := range tests {
+		if got := parseSrcset(tt.srcset); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSrcset(%q) = %q, want %q", tt.srcset, got, tt.want)
+		}
+	}
+}
+
+func TestExtractMetadata_BaseHref(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "base href",
+			html: `<html><head><base href=" https://cdn.example.com/docs/ "></head></html>`,
+			want: "https://cdn.example.com/docs/",
+		},
+		{
+			name: "first base wins",
+			html: `<html><head><base href="/a/"><base href="/b/"></head></html>`,
+			want: "/a/",
+		},
+		{
+			name: "base without href ignored",
+			html: `<html><head><base target="_blank"><base href="/b/"></head></html>`,
+			want: "/b/",
+		},
+		{
+			name: "no base",
+			html: `<html><head></head></html>`,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := ExtractMetadata(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatalf("ExtractMetadata() error = %v", err)
+			}
+			if meta.BaseHref != tt.want {
+				t.Errorf("BaseHref = %q, want %q", meta.BaseHref, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractMetadata_StructuredData(t *testing.T) {
+	html := `<html><head>
+		<script type="application/ld+json">
+			{"@type": "Article", "headline": "Hi"}
+		</script>
+		<script type="APPLICATION/LD+JSON">[{"@type": "Product"}]</script>
+		<script type="application/ld+json">   </script>
+		<script>{"@type": "Ignored"}</script>
+	</head><body>
+		<script type="application/ld+json">{"@type": "BreadcrumbList"}</script>
+	</body></html>`
+
+	meta, err := ExtractMetadata(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("ExtractMetadata() error = %v", err)
+	}
+
+	want := []string{
+		`{"@type": "Article", "headline": "Hi"}`,
+		`[{"@type": "Product"}]`,
+		`{"@type": "BreadcrumbList"}`,
+	}
+	if len(meta.StructuredData) != len(want) {
+		t.Fatalf("StructuredData = %q, want %q", meta.StructuredData, want)
+	}
+	for i := range want {
+		if meta.StructuredData[i] != want[i] {
+			t.Errorf("StructuredData[%d] = %q, want %q", i, meta.StructuredData[i], want[i])
+		}
+	}
+	if strings.Contains(meta.Text, "@type") {
+		t.Errorf("Text should not include JSON-LD, got %q", meta.Text)
+	}
+}
+
+func TestExtractMetadata_Canonical(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "canonical link",
+			html: `<html><head><link rel="canonical" href=" https://example.com/p "></head></html>`,
+			want: "https://example.com/p",
+		},
+		{
+			name: "rel tokens are case-insensitive",
+			html: `<html><head><link rel="stylesheet" href="/s.css"><link rel="Canonical" href="/p"></head></html>`,
+			want: "/p",
+		},
+		{
+			name: "first canonical wins",
+			html: `<html><head><link rel="canonical" href="/a"><link rel="canonical" href="/b"></head></html>`,
+			want: "/a",
+		},
+		{
+			name: "no canonical",
+			html: `<html><head><link rel="alternate" href="/fr"></head></html>`,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := ExtractMetadata(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatalf("ExtractMetadata() error = %v", err)
+			}
+			if meta.Canonical != tt.want {
+				t.Errorf("Canonical = %q, want %q", meta.Canonical, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractPage(t *testing.T) {
+	pages := []string{
+		`<html lang="en"><head><title>Home</title><base href="/docs/"></head><body><a href="a.html">A</a><a href="/b" rel="nofollow">B</a><a name="top">Top</a><a href="">Empty</a></body></html>`,
+		`<a href="/first" href="/second">dup</a><img src="/logo.png"><a href="#main">Skip</a>`,
+		``,
+	}
+
+	for _, page := range pages {
+		links, meta, err := ExtractPage(strings.NewReader(page))
+		if err != nil {
+			t.Fatalf("ExtractPage() error = %v", err)
+		}
+
+		wantLinks, err := ExtractLinks(strings.NewReader(page))
+		if err != nil {
+			t.Fatalf("ExtractLinks() error = %v", err)
+		}
+		var hrefs []string
+		for _, link := range links {
+			hrefs = append(hrefs, link.Href)
+		}
+		if !reflect.DeepEqual(hrefs, wantLinks) {
+			t.Errorf("ExtractPage() hrefs = %v, want %v (as ExtractLinks)", hrefs, wantLinks)
+		}
+
+		wantMeta, err := ExtractMetadata(strings.NewReader(page))
+		if err != nil {
+			t.Fatalf("ExtractMetadata() error = %v", err)
+		}
+		if !reflect.DeepEqual(meta, wantMeta) {
+			t.Errorf("ExtractPage() metadata = %+v, want %+v (as ExtractMetadata)", meta, wantMeta)
+		}
+	}
+}
+
+func TestExtractMetadata_ResourceHints(t *testing.T) {
+	html := `<html><head>
+<link rel="preload" href="/font.woff2" as="Font" crossorigin>
+<link rel="modulepreload" href="/app.mjs">
+<link rel="prefetch" href="/next">
+<link rel="preconnect dns-prefetch" href="https://cdn.example.net">
+<link rel="stylesheet" href="/site.css">
+<link rel="preload" href="">
+</head></html>`
+
+	meta, err := ExtractMetadata(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("ExtractMetadata() error = %v", err)
+	}
+	want := []ResourceHint{
+		{Rel: "preload", URL: "/font.woff2", As: "font"},
+		{Rel: "modulepreload", URL: "/app.mjs"},
+		{Rel: "prefetch", URL: "/next"},
+		{Rel: "preconnect", URL: "https://cdn.example.net"},
+		{Rel: "dns-prefetch", URL: "https://cdn.example.net"},
+	}
+	if !reflect.DeepEqual(meta.ResourceHints, want) {
+		t.Errorf("ResourceHints = %+v, want %+v", meta.ResourceHints, want)
+	}
+}
+
+func TestExtractPage_LinkDetails(t *testing.T) {
+	page := `<a href="/a" rel="NoFollow  Sponsored">  Buy
+  now </a><a href="/b"><img src="/b.png" alt="Logo"></a><a href="/c"><span style="display:none"></span></a><a href="/d">D<a href="/e">E</a><template><a href="/f">F</a></template>`
+
+	links, _, err := ExtractPage(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("ExtractPage() error = %v", err)
+	}
+	want := []Link{
+		{Href: "/a", Text: "Buy now", Rel: "nofollow sponsored", Tag: "a"},
+		{Href: "/b", Text: "Logo", Tag: "a"},
+		{Href: "/c", Tag: "a"},
+		// An unclosed link ends where the next one starts
+		{Href: "/d", Text: "D", Tag: "a"},
+		{Href: "/e", Text: "E", Tag: "a"},
+		{Href: "/f", Text: "F", Tag: "a"},
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("ExtractPage() links = %+v, want %+v", links, want)
+	}
+}
+
+func TestExtractMetadata_OpenGraph(t *testing.T) {
+	html := `<html><head>
+<meta property="og:title" content=" Hello ">
+<meta property="OG:Image" content="/cover.png">
+<meta property="og:image" content="/second.png">
+<meta property="twitter:card" content="summary">
+<meta name="og:ignored" content="name, not property">
+</head></html>`
+
+	meta, err := ExtractMetadata(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("ExtractMetadata() error = %v", err)
+	}
+	want := map[string]string{"og:title": "Hello", "og:image": "/cover.png"}
+	if !reflect.DeepEqual(meta.OpenGraph, want) {
+		t.Errorf("OpenGraph = %v, want %v", meta.OpenGraph, want)
+	}
+
+	meta, err = ExtractMetadata(strings.NewReader(`<html><head><title>x</title></head></html>`))
+	if err != nil {
+		t.Fatalf("ExtractMetadata() error = %v", err)
+	}
+	if meta.OpenGraph != nil {
+		t.Errorf("OpenGraph = %v, want nil for a page without og: tags", meta.OpenGraph)
+	}
+}
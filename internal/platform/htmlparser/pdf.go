@@ -0,0 +1,174 @@
+package htmlparser
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"io"
+	"regexp"
+)
+
+// maxPDFStreamBytes caps how much a single compressed PDF stream may
+// inflate to, so a hostile document can't exhaust memory.
+const maxPDFStreamBytes = 16 * 1024 * 1024
+
+var (
+	// pdfURI matches the start of a link action's /URI entry; the string
+	// that follows is parsed by hand since literal strings nest parentheses
+	pdfURI = regexp.MustCompile(`/URI\s*[(<]`)
+	// pdfStream matches the keyword opening a stream's data
+	pdfStream = regexp.MustCompile(`stream\r?\n`)
+)
+
+// ErrNotPDF is returned by ExtractPDFLinks for input without a PDF header.
+var ErrNotPDF = errors.New("not a PDF document")
+
+// ExtractPDFLinks reads a PDF and returns the raw URIs of its link
+// annotations, in order of appearance and without duplicates. Links inside
+// Flate-compressed object streams are found as well. Truncated documents
+// yield the links found before the cut.
+func ExtractPDFLinks(r io.Reader) ([]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\n\f\r "), []byte("%PDF-")) {
+		return nil, ErrNotPDF
+	}
+
+	seen := make(map[string]bool)
+	var links []string
+	collect := func(src []byte) {
+		for _, uri := range pdfURIs(src) {
+			if uri != "" && !seen[uri] {
+				seen[uri] = true
+				links = append(links, uri)
+			}
+		}
+	}
+
+	collect(data)
+	for _, stream := range pdfStreams(data) {
+		zr, err := zlib.NewReader(bytes.NewReader(stream))
+		if err != nil {
+			continue // Not Flate-encoded (images, fonts)
+		}
+		inflated, err := io.ReadAll(io.LimitReader(zr, maxPDFStreamBytes))
+		zr.Close()
+		// Truncated streams still yield what was inflated before the cut;
+		// corrupt ones are skipped
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			continue
+		}
+		collect(inflated)
+	}
+	return links, nil
+}
+
+// pdfURIs returns the /URI string values in src.
+func pdfURIs(src []byte) []string {
+	var uris []string
+	for _, loc := range pdfURI.FindAllIndex(src, -1) {
+		start := loc[1] - 1 // The opening ( or <
+		if src[start] == '(' {
+			uris = append(uris, pdfLiteralString(src[start+1:]))
+			continue
+		}
+		end := bytes.IndexByte(src[start:], '>')
+		if end < 0 {
+			continue
+		}
+		hexDigits := bytes.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+				return -1
+			}
+			return r
+		}, src[start+1:start+end])
+		if len(hexDigits)%2 == 1 {
+			hexDigits = append(hexDigits, '0')
+		}
+		decoded, err := hex.DecodeString(string(hexDigits))
+		if err == nil {
+			uris = append(uris, string(decoded))
+		}
+	}
+	return uris
+}
+
+// pdfLiteralString decodes a PDF literal string whose opening parenthesis
+// has been consumed, handling escapes and balanced nested parentheses.
+func pdfLiteralString(src []byte) string {
+	var out []byte
+	depth := 0
+	for i := 0; i < len(src); i++ {
+		ch := src[i]
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return string(out)
+			}
+			depth--
+		case '\\':
+			i++
+			if i == len(src) {
+				return string(out)
+			}
+			switch esc := src[i]; esc {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				// Line continuation
+				if i+1 < len(src) && src[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+				// Line continuation
+			default:
+				if esc >= '0' && esc <= '7' {
+					// Up to three octal digits
+					v := 0
+					for n := 0; n < 3 && i < len(src) && src[i] >= '0' && src[i] <= '7'; n++ {
+						v = v*8 + int(src[i]-'0')
+						i++
+					}
+					i--
+					out = append(out, byte(v))
+				} else {
+					out = append(out, esc)
+				}
+			}
+			continue
+		}
+		out = append(out, ch)
+	}
+	return string(out)
+}
+
+// pdfStreams returns the raw data of each stream in the document, ending
+// at the next endstream keyword or, if the file was cut short, at its end.
+func pdfStreams(data []byte) [][]byte {
+	var streams [][]byte
+	for _, loc := range pdfStream.FindAllIndex(data, -1) {
+		// Skip the "stream" inside "endstream"
+		if loc[0] >= 3 && string(data[loc[0]-3:loc[0]]) == "end" {
+			continue
+		}
+		body := data[loc[1]:]
+		if end := bytes.Index(body, []byte("endstream")); end >= 0 {
+			body = body[:end]
+		}
+		streams = append(streams, body)
+	}
+	return streams
+}
@@ -0,0 +1,126 @@
+package htmlparser
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// pdfWithStream returns a minimal PDF whose second object is a
+// Flate-compressed stream holding content.
+func pdfWithStream(t *testing.T, plain, compressed string) []byte {
+	t.Helper()
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	if _, err := zw.Write([]byte(compressed)); err != nil {
+		t.Fatalf("compressing: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("compressing: %v", err)
+	}
+
+	var doc bytes.Buffer
+	doc.WriteString("%PDF-1.7\n")
+	doc.WriteString("1 0 obj\n" + plain + "\nendobj\n")
+	doc.WriteString("2 0 obj\n<< /Type /ObjStm /Filter /FlateDecode >>\nstream\n")
+	doc.Write(z.Bytes())
+	doc.WriteString("\nendstream\nendobj\n%%EOF\n")
+	return doc.Bytes()
+}
+
+func TestExtractPDFLinks(t *testing.T) {
+	doc := pdfWithStream(t,
+		`<< /Type /Annot /Subtype /Link /A << /S /URI /URI (https://example.com/a) >> >>`+
+			` << /A << /URI (https://example.com/p\(1\)?q=\050x\051) >> >>`+
+			` << /A << /URI <68747470733A2F2F6578616D706C652E636F6D2F6878> >> >>`,
+		`<< /A << /S /URI /URI (/relative) >> >> << /A << /URI (https://example.com/a) >> >>`)
+
+	got, err := ExtractPDFLinks(bytes.NewReader(doc))
+	if err != nil {
+		t.Fatalf("ExtractPDFLinks() error = %v", err)
+	}
+	want := []string{
+		"https://example.com/a",
+		"https://example.com/p(1)?q=(x)",
+		"https://example.com/hx",
+		"/relative",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractPDFLinks() = %q, want %q", got, want)
+	}
+}
+
+func TestExtractPDFLinks_Truncated(t *testing.T) {
+	doc := pdfWithStream(t, `<< /A << /URI (https://example.com/first) >> >>`, `<< /A << /URI (https://example.com/later) >> >>`)
+	cut := doc[:bytes.Index(doc, []byte("stream\n"))+10]
+
+	got, err := ExtractPDFLinks(bytes.NewReader(cut))
+	if err != nil {
+		t.Fatalf("ExtractPDFLinks() error = %v", err)
+	}
+	if len(got) == 0 || got[0] != "https://example.com/first" {
+		t.Errorf("ExtractPDFLinks() = %q, want the link before the cut", got)
+	}
+}
+
+func TestExtractPDFLinks_TruncatedStream(t *testing.T) {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	// Repetitive content compresses, so the URI is only found by inflating
+	if _, err := zw.Write([]byte(`<< /A << /URI (https://example.com/inflated) >> >>` + strings.Repeat(" ", 4096))); err != nil {
+		t.Fatalf("compressing: %v", err)
+	}
+	if err := zw.Flush(); err != nil {
+		t.Fatalf("compressing: %v", err)
+	}
+	flushed := z.Len()
+	if _, err := zw.Write(bytes.Repeat([]byte("0123456789abcdef"), 1024)); err != nil {
+		t.Fatalf("compressing: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("compressing: %v", err)
+	}
+
+	doc := append([]byte("%PDF-1.7\n2 0 obj\n<< /Filter /FlateDecode >>\nstream\n"), z.Bytes()[:flushed+8]...)
+	got, err := ExtractPDFLinks(bytes.NewReader(doc))
+	if err != nil {
+		t.Fatalf("ExtractPDFLinks() error = %v", err)
+	}
+	if want := []string{"https://example.com/inflated"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractPDFLinks() = %q, want %q from the stream's inflated part", got, want)
+	}
+}
+
+func TestExtractPDFLinks_CorruptStream(t *testing.T) {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	if _, err := zw.Write([]byte(`<< /A << /URI (https://example.com/corrupt) >> >>` + strings.Repeat(" ", 4096))); err != nil {
+		t.Fatalf("compressing: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("compressing: %v", err)
+	}
+	stream := z.Bytes()
+	// Break the Adler-32 checksum, the last four bytes
+	stream[len(stream)-1] ^= 0xff
+
+	doc := append([]byte("%PDF-1.7\n2 0 obj\n<< /Filter /FlateDecode >>\nstream\n"), stream...)
+	doc = append(doc, "\nendstream\nendobj\n"...)
+	got, err := ExtractPDFLinks(bytes.NewReader(doc))
+	if err != nil {
+		t.Fatalf("ExtractPDFLinks() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("ExtractPDFLinks() = %q, want a corrupt stream skipped", got)
+	}
+}
+
+func TestExtractPDFLinks_NotPDF(t *testing.T) {
+	_, err := ExtractPDFLinks(strings.NewReader("<html></html>"))
+	if !errors.Is(err, ErrNotPDF) {
+		t.Errorf("ExtractPDFLinks() error = %v, want ErrNotPDF", err)
+	}
+}
@@ -0,0 +1,80 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/cametumbling/web-crawler/internal/crawler"
+	"golang.org/x/time/rate"
+)
+
+// bandwidth enforces the client's download limits: a cap on body bytes per
+// second across every request, and a total byte budget after which no new
+// requests are sent.
+type bandwidth struct {
+	// limiter paces body reads (nil = unthrottled)
+	limiter *rate.Limiter
+	// budget is the total body bytes allowed (0 = unlimited)
+	budget int64
+	// downloaded counts the body bytes read so far
+	downloaded atomic.Int64
+}
+
+// newBandwidth returns the limits for bytesPerSec and totalBytes (0 = no
+// limit), or nil if neither is set.
+func newBandwidth(bytesPerSec, totalBytes int64) *bandwidth {
+	if bytesPerSec <= 0 && totalBytes <= 0 {
+		return nil
+	}
+	b := &bandwidth{budget: totalBytes}
+	if bytesPerSec > 0 {
+		// A second's worth of bytes may be read at once
+		b.limiter = rate.NewLimiter(rate.Limit(bytesPerSec), int(bytesPerSec))
+	}
+	return b
+}
+
+// allow returns crawler.ErrDownloadBudget once the total budget is spent.
+// Bodies already being read finish, so the total can overshoot by those.
+func (b *bandwidth) allow() error {
+	if b == nil || b.budget == 0 {
+		return nil
+	}
+	if n := b.downloaded.Load(); n >= b.budget {
+		return fmt.Errorf("%w: %d of %d bytes downloaded", crawler.ErrDownloadBudget, n, b.budget)
+	}
+	return nil
+}
+
+// reader wraps body so reads are paced and counted.
+func (b *bandwidth) reader(ctx context.Context, body io.Reader) io.Reader {
+	if b == nil {
+		return body
+	}
+	return &meteredReader{ctx: ctx, r: body, bw: b}
+}
+
+// meteredReader is a response body read under the client's bandwidth.
+type meteredReader struct {
+	ctx context.Context
+	r   io.Reader
+	bw  *bandwidth
+}
+
+func (m *meteredReader) Read(p []byte) (int, error) {
+	if m.bw.limiter != nil && len(p) > m.bw.limiter.Burst() {
+		p = p[:m.bw.limiter.Burst()]
+	}
+	n, err := m.r.Read(p)
+	if n > 0 {
+		m.bw.downloaded.Add(int64(n))
+		if m.bw.limiter != nil {
+			if werr := m.bw.limiter.WaitN(m.ctx, n); werr != nil {
+				return n, werr
+			}
+		}
+	}
+	return n, err
+}
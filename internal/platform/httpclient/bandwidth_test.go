@@ -0,0 +1,69 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cametumbling/web-crawler/internal/crawler"
+)
+
+func TestFetch_MaxBytesPerSec(t *testing.T) {
+	body := strings.Repeat("x", 1500)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	// The first second's 1000 bytes are read at once, the last 500 take half a second
+	c := New(Config{MaxBytesPerSec: 1000})
+	start := time.Now()
+	result, err := c.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("read 1500 bytes in %v, faster than 1000 bytes/sec", elapsed)
+	}
+	if string(result.Body) != body {
+		t.Errorf("body has %d bytes, want %d", len(result.Body), len(body))
+	}
+}
+
+func TestFetch_MaxTotalBytes(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(strings.Repeat("x", 600)))
+	}))
+	defer server.Close()
+
+	c := New(Config{MaxTotalBytes: 1000})
+	for i := 0; i < 2; i++ {
+		if _, err := c.Fetch(context.Background(), server.URL); err != nil {
+			t.Fatalf("Fetch() %d error = %v", i, err)
+		}
+	}
+	// 1200 bytes are in: the budget is spent, so no request goes out
+	_, err := c.Fetch(context.Background(), server.URL)
+	if !errors.Is(err, crawler.ErrDownloadBudget) {
+		t.Errorf("Fetch() error = %v, want ErrDownloadBudget", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("server saw %d requests, want 2", n)
+	}
+	if n := c.bandwidth.downloaded.Load(); n != 1200 {
+		t.Errorf("downloaded = %d, want 1200", n)
+	}
+
+	if newBandwidth(0, 0) != nil {
+		t.Errorf("newBandwidth() without limits should be nil")
+	}
+}
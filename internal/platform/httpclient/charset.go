@@ -0,0 +1,39 @@
+package httpclient
+
+import (
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/transform"
+)
+
+// ToUTF8 transcodes a fetched body to UTF-8, so parsers that assume UTF-8
+// see non-ASCII hrefs and text correctly. The encoding comes from a byte
+// order mark, the Content-Type charset, or a <meta> charset declaration in
+// the first 1024 bytes, in that order; undeclared bodies that are valid
+// UTF-8 are kept as-is and the rest are read as windows-1252, as browsers do.
+// Bodies that fail to decode are returned unchanged. transcoded reports
+// whether the result is a new slice rather than body itself.
+func ToUTF8(body []byte, contentType string) (utf8Body []byte, transcoded bool) {
+	enc, name, _ := charset.DetermineEncoding(body, contentType)
+	// ASCII reads the same in windows-1252, so undeclared ASCII bodies,
+	// the common case, aren't copied through the decoder
+	if name == "utf-8" || (name == "windows-1252" && isASCII(body)) {
+		return body, false
+	}
+	decoded, _, err := transform.Bytes(enc.NewDecoder(), body)
+	if err != nil {
+		return body, false
+	}
+	return decoded, true
+}
+
+// isASCII reports whether b is 7-bit ASCII.
+func isASCII(b []byte) bool {
+	for _, c := range b {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -1,13 +1,24 @@
 package httpclient
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"net"
 	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/cametumbling/web-crawler/internal/crawler"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -17,27 +28,139 @@ const (
 	DefaultMaxBodySize = 2 * 1024 * 1024
 	// DefaultUserAgent is the default User-Agent header
 	DefaultUserAgent = "MonzoCrawler/1.0"
+	// DefaultStreamReadTimeout is the default cap on reading a body of unknown length
+	DefaultStreamReadTimeout = 5 * time.Second
+	// DefaultDialTimeout is the default cap on establishing a TCP connection
+	DefaultDialTimeout = 30 * time.Second
 )
 
+// streamingContentTypes are media types served by endpoints that stream
+// indefinitely rather than returning a finite document.
+var streamingContentTypes = map[string]bool{
+	"text/event-stream":         true,
+	"multipart/x-mixed-replace": true,
+	"application/x-ndjson":      true,
+	"application/stream+json":   true,
+}
+
 // Client is an HTTP client with timeout, rate limiting, and body size limits.
 // It is safe for concurrent use by multiple goroutines.
 type Client struct {
 	httpClient  *http.Client
 	userAgent   string
+	from        string
+	headers     map[string]string
 	maxBodySize int64
-	rateLimiter <-chan time.Time
+	// maxOtherBodySize limits bodies the crawler doesn't parse (0 = skipped)
+	maxOtherBodySize int64
+	streamRead       time.Duration
+	rateLimiter      *rate.Limiter
+	hostLimiter      *hostLimiter
+	throttle         *throttle
+	headPrecheck     bool
+	// readPDF treats PDFs as parsed content
+	readPDF bool
+	// bandwidth paces and budgets body downloads (nil = unlimited)
+	bandwidth *bandwidth
 }
 
 // Config contains configuration options for the HTTP client.
 type Config struct {
-	// Timeout is the total request timeout (default: 10s)
+	// Timeout is the total request timeout, from dialing to reading the last
+	// body byte (default: 10s)
 	Timeout time.Duration
-	// UserAgent is the User-Agent header to send (default: "MonzoCrawler/1.0")
+	// DialTimeout caps establishing the TCP connection (default: 30s)
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout caps the TLS handshake (default: net/http's 10s)
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout caps the wait for response headers after the
+	// request is written, so a server that accepts connections but never
+	// answers fails fast while slow bodies still get the full Timeout
+	// (0 = only Timeout applies)
+	ResponseHeaderTimeout time.Duration
+	// UserAgent is the User-Agent header to send (default: "MonzoCrawler/1.0").
+	// The placeholders {from} and {info} are replaced with From and
+	// CrawlInfoURL, so operators can embed contact details.
 	UserAgent string
-	// MaxBodySize is the maximum response body size in bytes (default: 2MB)
+	// From is an operator contact email sent in the From header ("" = omitted)
+	From string
+	// Headers are extra request headers sent with every request, e.g.
+	// Authorization or Cookie. User-Agent and From are set by their own
+	// options and take precedence.
+	Headers map[string]string
+	// CrawlInfoURL points site owners to a page describing the crawl. When set
+	// and the User-Agent does not reference it via {info}, it is appended to
+	// the User-Agent in the conventional "(+URL)" form.
+	CrawlInfoURL string
+	// MaxBodySize is the maximum size in bytes of HTML and CSS bodies, the
+	// content the crawler parses (default: 2MB)
 	MaxBodySize int64
-	// RateLimit is the minimum duration between requests (0 = no limit)
+	// MaxOtherBodySize is how many bytes to read of any other content type
+	// (0 = the body is skipped). The prefix is sniffed, and
+	// application/octet-stream responses that turn out to be HTML are read
+	// in full up to MaxBodySize and reported as HTML.
+	MaxOtherBodySize int64
+	// ReadPDF reads application/pdf bodies in full, up to MaxBodySize, for
+	// PDF link extraction; otherwise they are handled like other content
+	ReadPDF bool
+	// StreamReadTimeout caps how long a body without Content-Length may take
+	// to read before the endpoint is classified as streaming (default: 5s)
+	StreamReadTimeout time.Duration
+	// RateLimit is the minimum duration between requests across all hosts (0 = no limit)
 	RateLimit time.Duration
+	// RateBurst is how many requests may be sent back-to-back before
+	// RateLimit spacing applies (default: 1, i.e. strict spacing)
+	RateBurst int
+	// HostRateLimit is the minimum duration between requests to the same host (0 = no limit)
+	HostRateLimit time.Duration
+	// HostRateLimits overrides HostRateLimit for specific hosts, keyed by
+	// host or host:port
+	HostRateLimits map[string]time.Duration
+	// AdaptiveThrottle slows requests to a host when it answers 429/503 or
+	// its latency spikes, and speeds back up as responses recover
+	AdaptiveThrottle bool
+	// MaxIdleConnsPerHost is the number of idle keep-alive connections kept
+	// per host (default: net/http's 2, which causes connection churn when
+	// many workers hit one host)
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept open
+	// (default: net/http's 90s)
+	IdleConnTimeout time.Duration
+	// DisableKeepAlives opens a new connection for every request
+	DisableKeepAlives bool
+	// BlockPrivateIPs refuses connections to loopback, private (RFC 1918 and
+	// RFC 4193), link-local, and unspecified addresses after DNS resolution
+	BlockPrivateIPs bool
+	// HeadPrecheck issues a HEAD request before GETting URLs whose extension
+	// suggests binary content, skipping the body download when the response
+	// is non-HTML or larger than MaxBodySize
+	HeadPrecheck bool
+	// MaxBytesPerSec caps how fast response bodies are read, across all
+	// requests (0 = no limit)
+	MaxBytesPerSec int64
+	// MaxTotalBytes is the total response body bytes the client downloads;
+	// once spent, fetches fail with crawler.ErrDownloadBudget without
+	// sending a request, and the crawler stops cleanly (0 = no limit).
+	// Bodies already being read finish, so the total can overshoot by up
+	// to one body per worker.
+	MaxTotalBytes int64
+	// HAR records every request and response in HTTP Archive format
+	// (nil = disabled)
+	HAR *HARRecorder
+}
+
+// ErrBlockedAddress is returned when BlockPrivateIPs rejects a connection.
+var ErrBlockedAddress = errors.New("connection to private or local address blocked")
+
+// binaryExtensions lists path extensions that usually point at non-HTML assets.
+var binaryExtensions = map[string]bool{
+	".7z": true, ".avi": true, ".bin": true, ".bmp": true,
+	".dmg": true, ".doc": true, ".docx": true, ".exe": true, ".gif": true,
+	".gz": true, ".ico": true, ".iso": true, ".jpeg": true, ".jpg": true,
+	".js": true, ".mov": true, ".mp3": true, ".mp4": true, ".pdf": true,
+	".png": true, ".ppt": true, ".pptx": true, ".rar": true, ".svg": true,
+	".tar": true, ".tgz": true, ".wav": true, ".webm": true, ".webp": true,
+	".woff": true, ".woff2": true, ".xls": true, ".xlsx": true, ".zip": true,
 }
 
 // New creates a new HTTP client with the given configuration.
@@ -48,21 +171,77 @@ func New(cfg Config) *Client {
 	if cfg.UserAgent == "" {
 		cfg.UserAgent = DefaultUserAgent
 	}
+	cfg.UserAgent = buildUserAgent(cfg.UserAgent, cfg.From, cfg.CrawlInfoURL)
 	if cfg.MaxBodySize == 0 {
 		cfg.MaxBodySize = DefaultMaxBodySize
 	}
+	if cfg.StreamReadTimeout == 0 {
+		cfg.StreamReadTimeout = DefaultStreamReadTimeout
+	}
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = DefaultDialTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+		if transport.MaxIdleConns < cfg.MaxIdleConnsPerHost {
+			transport.MaxIdleConns = cfg.MaxIdleConnsPerHost
+		}
+	}
+	if cfg.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	transport.DisableKeepAlives = cfg.DisableKeepAlives
+	if cfg.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = cfg.TLSHandshakeTimeout
+	}
+	transport.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	if cfg.BlockPrivateIPs {
+		dialer.Control = blockPrivateControl
+	}
+	transport.DialContext = dialer.DialContext
+	var roundTripper http.RoundTripper = transport
+	if cfg.HAR != nil {
+		roundTripper = &harTransport{next: transport, har: cfg.HAR}
+	}
 
 	c := &Client{
 		httpClient: &http.Client{
-			Timeout: cfg.Timeout,
+			Timeout:   cfg.Timeout,
+			Transport: roundTripper,
 		},
-		userAgent:   cfg.UserAgent,
-		maxBodySize: cfg.MaxBodySize,
+		userAgent:        cfg.UserAgent,
+		from:             cfg.From,
+		headers:          cfg.Headers,
+		maxBodySize:      cfg.MaxBodySize,
+		maxOtherBodySize: cfg.MaxOtherBodySize,
+		streamRead:       cfg.StreamReadTimeout,
+		readPDF:          cfg.ReadPDF,
+		headPrecheck:     cfg.HeadPrecheck,
+		bandwidth:        newBandwidth(cfg.MaxBytesPerSec, cfg.MaxTotalBytes),
 	}
 
-	// Set up rate limiter if configured -- time.Tick intentionally used over NewTicker - this is a CLI tool with a single rate limiter for the process lifetime; the "leak" is cleaned up on process exit
+	// Set up a token-bucket rate limiter if configured
 	if cfg.RateLimit > 0 {
-		c.rateLimiter = time.Tick(cfg.RateLimit)
+		burst := cfg.RateBurst
+		if burst <= 0 {
+			burst = 1
+		}
+		c.rateLimiter = rate.NewLimiter(rate.Every(cfg.RateLimit), burst)
+	}
+	if cfg.HostRateLimit > 0 || len(cfg.HostRateLimits) > 0 {
+		c.hostLimiter = newHostLimiter(cfg.HostRateLimit)
+		for host, interval := range cfg.HostRateLimits {
+			c.hostLimiter.overrides[strings.ToLower(host)] = interval
+		}
+	}
+	if cfg.AdaptiveThrottle {
+		c.throttle = newThrottle()
 	}
 
 	return c
@@ -73,56 +252,435 @@ func New(cfg Config) *Client {
 // Applies rate limiting, sets User-Agent, and enforces body size limits.
 // Respects context cancellation.
 func (c *Client) Fetch(ctx context.Context, url string) (*crawler.FetchResult, error) {
-	// Apply rate limiting if configured
-	if c.rateLimiter != nil {
-		select {
-		case <-c.rateLimiter:
-			// Rate limit satisfied, continue
-		case <-ctx.Done():
-			return nil, ctx.Err()
+	return c.fetch(ctx, url, crawler.Validators{})
+}
+
+// FetchIfModified is Fetch with a conditional request: the validators from
+// an earlier fetch are sent as If-None-Match and If-Modified-Since, and a
+// 304 response returns a bodyless result with NotModified set.
+func (c *Client) FetchIfModified(ctx context.Context, url string, v crawler.Validators) (*crawler.FetchResult, error) {
+	return c.fetch(ctx, url, v)
+}
+
+func (c *Client) fetch(ctx context.Context, url string, v crawler.Validators) (*crawler.FetchResult, error) {
+	// Probe likely-binary URLs with HEAD first so the body can be skipped
+	if c.headPrecheck && looksBinary(url) {
+		if result, skip := c.precheck(ctx, url); skip {
+			return result, nil
 		}
 	}
 
-	// Create request with context
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	// Apply rate limiting if configured
+	if err := c.wait(ctx, url); err != nil {
+		return nil, err
+	}
+	if err := c.bandwidth.allow(); err != nil {
+		return nil, err
+	}
+
+	// Create request with a cancellable context so a streaming body can be
+	// abandoned without waiting for the global timeout
+	reqCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
 
-	// Set User-Agent header
-	req.Header.Set("User-Agent", c.userAgent)
+	// Set identification headers
+	c.setHeaders(req)
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if !v.LastModified.IsZero() {
+		req.Header.Set("If-Modified-Since", v.LastModified.UTC().Format(http.TimeFormat))
+	}
 
 	// Execute request
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("executing request: %w", err)
 	}
 	defer resp.Body.Close()
+	ttfb := time.Since(start)
+
+	// Feed the response back into the adaptive throttle
+	if c.throttle != nil {
+		c.throttle.observe(hostOf(url), resp, time.Since(start))
+	}
+
+	// Unchanged since the validators were issued: nothing to read
+	if resp.StatusCode == http.StatusNotModified && !v.IsZero() {
+		etag := resp.Header.Get("ETag")
+		if etag == "" {
+			etag = v.ETag
+		}
+		modified := lastModified(resp)
+		if modified.IsZero() {
+			modified = v.LastModified
+		}
+		return &crawler.FetchResult{
+			Body:         []byte{},
+			FinalURL:     resp.Request.URL.String(),
+			ContentType:  resp.Header.Get("Content-Type"),
+			Redirects:    redirectChain(resp),
+			LastModified: modified,
+			ETag:         etag,
+			NotModified:  true,
+			StatusCode:   resp.StatusCode,
+			Header:       resp.Header,
+			TTFB:         ttfb,
+			Duration:     time.Since(start),
+		}, nil
+	}
 
 	// Check status code
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return nil, &crawler.HTTPError{
 			StatusCode: resp.StatusCode,
 			URL:        url,
+			FinalURL:   resp.Request.URL.String(),
+			Redirects:  redirectChain(resp),
 		}
 	}
 
-	// Read body with size limit
-	limitedReader := io.LimitReader(resp.Body, c.maxBodySize)
-	body, err := io.ReadAll(limitedReader)
-	if err != nil {
-		return nil, fmt.Errorf("reading response body: %w", err)
-	}
-
 	// Get final URL after redirects
 	finalURL := resp.Request.URL.String()
 
 	// Get Content-Type header
 	contentType := resp.Header.Get("Content-Type")
 
+	redirects := redirectChain(resp)
+
+	// Event streams and similar never finish; classify them without reading
+	if isStreamingContentType(contentType) {
+		return nil, &crawler.StreamError{URL: url, Reason: "streaming content type " + contentType}
+	}
+
+	// Skip the download for content the crawler does not parse; the
+	// deferred Close drops the connection before the rest of the body arrives
+	if !c.parses(contentType) && c.maxOtherBodySize == 0 {
+		return &crawler.FetchResult{
+			Body:         []byte{},
+			FinalURL:     finalURL,
+			ContentType:  contentType,
+			Redirects:    redirects,
+			RobotsTags:   resp.Header.Values("X-Robots-Tag"),
+			LastModified: lastModified(resp),
+			ETag:         resp.Header.Get("ETag"),
+			StatusCode:   resp.StatusCode,
+			Header:       resp.Header,
+			TTFB:         ttfb,
+			Duration:     time.Since(start),
+		}, nil
+	}
+
+	// Bodies of unknown length (chunked, long-poll) get a read deadline
+	var streamTimedOut atomic.Bool
+	if resp.ContentLength < 0 {
+		timer := time.AfterFunc(c.streamRead, func() {
+			streamTimedOut.Store(true)
+			cancel()
+		})
+		defer timer.Stop()
+	}
+	readErr := func(err error) error {
+		if streamTimedOut.Load() {
+			return &crawler.StreamError{
+				URL:    url,
+				Reason: fmt.Sprintf("body still streaming after %v", c.streamRead),
+			}
+		}
+		return fmt.Errorf("reading response body: %w", err)
+	}
+
+	// Bodies are read into a pooled buffer, which the crawler hands back
+	// through Release once it is done with the page
+	stream := c.bandwidth.reader(reqCtx, resp.Body)
+	buf := bodyPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	release := func() { bodyPool.Put(buf) }
+	read := func(limit int64) error {
+		if resp.ContentLength > 0 {
+			buf.Grow(int(min(resp.ContentLength, limit)))
+		}
+		_, err := buf.ReadFrom(io.LimitReader(stream, limit))
+		return err
+	}
+
+	// Other content is read only up to its own, smaller limit. Generic
+	// binary responses whose prefix sniffs as HTML are mislabelled pages,
+	// so the rest is read under the HTML limit.
+	if !c.parses(contentType) {
+		if err := read(c.maxOtherBodySize); err != nil {
+			release()
+			return nil, readErr(err)
+		}
+		if !sniffsAsHTML(contentType, buf.Bytes()) {
+			return &crawler.FetchResult{
+				Body:         buf.Bytes(),
+				Release:      release,
+				FinalURL:     finalURL,
+				ContentType:  contentType,
+				Redirects:    redirects,
+				RobotsTags:   resp.Header.Values("X-Robots-Tag"),
+				LastModified: lastModified(resp),
+				ETag:         resp.Header.Get("ETag"),
+				StatusCode:   resp.StatusCode,
+				Header:       resp.Header,
+				TTFB:         ttfb,
+				Duration:     time.Since(start),
+			}, nil
+		}
+		// The sniffer always claims UTF-8; leave the charset to the body
+		contentType = "text/html"
+		if int64(buf.Len()) > c.maxBodySize {
+			buf.Truncate(int(c.maxBodySize))
+		}
+	}
+
+	// Read body with size limit
+	if err := read(c.maxBodySize - int64(buf.Len())); err != nil {
+		release()
+		return nil, readErr(err)
+	}
+	body := buf.Bytes()
+
+	// PDFs are binary; only text is transcoded. A transcoded body is a
+	// copy, so the buffer can go straight back to the pool.
+	if !isPDFContentType(contentType) {
+		if utf8Body, transcoded := ToUTF8(body, contentType); transcoded {
+			release()
+			body, release = utf8Body, nil
+		}
+	}
+
 	return &crawler.FetchResult{
-		Body:        body,
-		FinalURL:    finalURL,
-		ContentType: contentType,
+		Body:         body,
+		Release:      release,
+		FinalURL:     finalURL,
+		ContentType:  contentType,
+		Redirects:    redirects,
+		RobotsTags:   resp.Header.Values("X-Robots-Tag"),
+		LastModified: lastModified(resp),
+		ETag:         resp.Header.Get("ETag"),
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header,
+		TTFB:         ttfb,
+		Duration:     time.Since(start),
 	}, nil
 }
+
+// bodyPool recycles the buffers response bodies are read into; at up to
+// MaxBodySize per page they would otherwise dominate allocations.
+var bodyPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// setHeaders sets the crawler identification headers on a request.
+func (c *Client) setHeaders(req *http.Request) {
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.from != "" {
+		req.Header.Set("From", c.from)
+	}
+}
+
+// buildUserAgent expands the {from} and {info} placeholders in a User-Agent
+// template, appending "(+infoURL)" if the template does not place it itself.
+func buildUserAgent(template, from, infoURL string) string {
+	ua := strings.NewReplacer("{from}", from, "{info}", infoURL).Replace(template)
+	if infoURL != "" && !strings.Contains(template, "{info}") {
+		ua += " (+" + infoURL + ")"
+	}
+	return ua
+}
+
+// wait blocks until the global, per-host, and adaptive limiters all allow a
+// request to rawURL. Returns the context error if cancelled while waiting.
+func (c *Client) wait(ctx context.Context, rawURL string) error {
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if c.hostLimiter != nil {
+		if err := c.hostLimiter.wait(ctx, hostOf(rawURL)); err != nil {
+			return err
+		}
+	}
+	if c.throttle != nil {
+		return c.throttle.wait(ctx, hostOf(rawURL))
+	}
+	return nil
+}
+
+// hostOf returns the lowercased host[:port] of rawURL, used to key per-host
+// state. Unparseable URLs key on themselves.
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return strings.ToLower(u.Host)
+}
+
+// precheck issues a HEAD request and reports whether the GET can be skipped.
+// The body is skipped when the response is neither HTML nor CSS, or its Content-Length
+// exceeds maxBodySize. Any HEAD failure falls through to a normal GET, since
+// some servers reject HEAD outright.
+func (c *Client) precheck(ctx context.Context, url string) (*crawler.FetchResult, bool) {
+	if err := c.wait(ctx, url); err != nil {
+		return nil, false
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return nil, false
+	}
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, false
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, false
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !c.parses(contentType) || resp.ContentLength > c.maxBodySize {
+		return &crawler.FetchResult{
+			Body:         []byte{},
+			FinalURL:     resp.Request.URL.String(),
+			ContentType:  contentType,
+			Redirects:    redirectChain(resp),
+			RobotsTags:   resp.Header.Values("X-Robots-Tag"),
+			LastModified: lastModified(resp),
+			ETag:         resp.Header.Get("ETag"),
+			StatusCode:   resp.StatusCode,
+			Header:       resp.Header,
+		}, true
+	}
+	return nil, false
+}
+
+// lastModified returns the response's Last-Modified time, or the zero time
+// if the header is missing or malformed.
+func lastModified(resp *http.Response) time.Time {
+	t, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// redirectChain returns the redirects followed to produce resp, oldest first.
+// net/http links each redirected request to the response that caused it, so
+// the chain is recovered by walking those links backwards.
+func redirectChain(resp *http.Response) []crawler.Redirect {
+	var chain []crawler.Redirect
+	for req := resp.Request; req.Response != nil; req = req.Response.Request {
+		chain = append(chain, crawler.Redirect{
+			URL:        req.Response.Request.URL.String(),
+			StatusCode: req.Response.StatusCode,
+		})
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// looksBinary reports whether the URL path has an extension associated with
+// non-HTML content.
+func looksBinary(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return binaryExtensions[strings.ToLower(path.Ext(u.Path))]
+}
+
+// isStreamingContentType reports whether a Content-Type header denotes an
+// indefinitely streaming response.
+func isStreamingContentType(contentType string) bool {
+	mediaType, ok := parseMediaType(contentType)
+	return ok && streamingContentTypes[mediaType]
+}
+
+// isHTMLContentType reports whether a Content-Type header denotes HTML.
+// An empty header is treated as HTML, matching the worker's behaviour.
+func isHTMLContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, ok := parseMediaType(contentType)
+	return ok && mediaType == "text/html"
+}
+
+// sniffsAsHTML reports whether a response declared as generic binary
+// content is actually HTML, judging by its first bytes.
+func sniffsAsHTML(contentType string, prefix []byte) bool {
+	mediaType, ok := parseMediaType(contentType)
+	if !ok || mediaType != "application/octet-stream" {
+		return false
+	}
+	sniffed, _ := parseMediaType(http.DetectContentType(prefix))
+	return sniffed == "text/html"
+}
+
+// isParsedContentType reports whether a Content-Type header denotes a body
+// the crawler parses: HTML pages, and stylesheets for their url() references.
+func isParsedContentType(contentType string) bool {
+	if isHTMLContentType(contentType) {
+		return true
+	}
+	mediaType, ok := parseMediaType(contentType)
+	return ok && mediaType == "text/css"
+}
+
+// parses reports whether the client reads a body of this Content-Type in
+// full: parsed content, plus PDFs when ReadPDF is set.
+func (c *Client) parses(contentType string) bool {
+	return isParsedContentType(contentType) || (c.readPDF && isPDFContentType(contentType))
+}
+
+// isPDFContentType reports whether a Content-Type header denotes a PDF.
+func isPDFContentType(contentType string) bool {
+	mediaType, ok := parseMediaType(contentType)
+	return ok && mediaType == "application/pdf"
+}
+
+// parseMediaType returns the lowercased media type of a Content-Type header.
+// A malformed parameter (e.g. "text/html; charset") does not invalidate the
+// media type itself, which browsers and the worker both still honour.
+func parseMediaType(contentType string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil && !errors.Is(err, mime.ErrInvalidMediaParameter) {
+		return "", false
+	}
+	return mediaType, true
+}
+
+// blockPrivateControl is a net.Dialer Control hook that rejects connections
+// to non-public addresses. It runs after DNS resolution, so hostnames that
+// resolve to internal IPs are caught as well as literal IPs.
+func blockPrivateControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: unparseable address %q", ErrBlockedAddress, host)
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, ip)
+	}
+	return nil
+}
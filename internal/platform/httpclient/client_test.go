@@ -1,13 +1,17 @@
 package httpclient
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/cametumbling/web-crawler/internal/crawler"
 )
 
 func TestNew_Defaults(t *testing.T) {
@@ -56,6 +60,7 @@ func TestFetch_Success(t *testing.T) {
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		expectedUA = r.Header.Get("User-Agent")
+		w.Header().Set("Content-Type", "text/html")
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprint(w, expectedBody)
 	}))
@@ -133,6 +138,7 @@ func TestFetch_BodySizeLimit(t *testing.T) {
 	largeBody := strings.Repeat("a", 2000)
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprint(w, largeBody)
 	}))
@@ -253,3 +259,978 @@ func TestFetch_EmptyBody(t *testing.T) {
 		t.Errorf("Fetch() body length = %d, want 0", len(result.Body))
 	}
 }
+
+func TestFetch_HeadPrecheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		contentType  string
+		body         string
+		precheck     bool
+		wantHead     bool
+		wantGet      bool
+		wantBodySize int
+	}{
+		{
+			name:         "binary extension with non-HTML type skips GET",
+			path:         "/file.pdf",
+			contentType:  "application/pdf",
+			body:         "%PDF-1.4",
+			precheck:     true,
+			wantHead:     true,
+			wantGet:      false,
+			wantBodySize: 0,
+		},
+		{
+			name:         "binary extension served as HTML falls through to GET",
+			path:         "/page.pdf",
+			contentType:  "text/html; charset=utf-8",
+			body:         "<html></html>",
+			precheck:     true,
+			wantHead:     true,
+			wantGet:      true,
+			wantBodySize: 13,
+		},
+		{
+			name:         "oversized HTML skips GET",
+			path:         "/huge.zip",
+			contentType:  "text/html",
+			body:         strings.Repeat("a", 2000),
+			precheck:     true,
+			wantHead:     true,
+			wantGet:      false,
+			wantBodySize: 0,
+		},
+		{
+			name:         "HTML-looking path is not prechecked",
+			path:         "/about.html",
+			contentType:  "text/html",
+			body:         "<html></html>",
+			precheck:     true,
+			wantHead:     false,
+			wantGet:      true,
+			wantBodySize: 13,
+		},
+		{
+			name:         "precheck disabled",
+			path:         "/file.pdf",
+			contentType:  "application/pdf",
+			body:         "%PDF-1.4",
+			precheck:     false,
+			wantHead:     false,
+			wantGet:      true,
+			wantBodySize: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotHead, gotGet := false, false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodHead:
+					gotHead = true
+				case http.MethodGet:
+					gotGet = true
+				}
+				w.Header().Set("Content-Type", tt.contentType)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			c := New(Config{MaxBodySize: 1000, HeadPrecheck: tt.precheck})
+			result, err := c.Fetch(context.Background(), server.URL+tt.path)
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+
+			if gotHead != tt.wantHead {
+				t.Errorf("HEAD sent = %v, want %v", gotHead, tt.wantHead)
+			}
+			if gotGet != tt.wantGet {
+				t.Errorf("GET sent = %v, want %v", gotGet, tt.wantGet)
+			}
+			if len(result.Body) != tt.wantBodySize {
+				t.Errorf("body size = %d, want %d", len(result.Body), tt.wantBodySize)
+			}
+			if result.ContentType != tt.contentType {
+				t.Errorf("ContentType = %q, want %q", result.ContentType, tt.contentType)
+			}
+		})
+	}
+}
+
+func TestFetch_HeadPrecheckFallsBackOnHeadFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html></html>")
+	}))
+	defer server.Close()
+
+	c := New(Config{HeadPrecheck: true})
+	result, err := c.Fetch(context.Background(), server.URL+"/page.zip")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if string(result.Body) != "<html></html>" {
+		t.Errorf("body = %q, want %q", string(result.Body), "<html></html>")
+	}
+}
+
+func TestFetch_SkipsNonHTMLBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		wantBody    string
+	}{
+		{"HTML body is read", "text/html; charset=utf-8", "<html></html>"},
+		{"missing Content-Type assumed HTML", "", "<html></html>"},
+		{"parameter without value still HTML", "text/html; charset", "<html></html>"},
+		{"unterminated quoted parameter still HTML", `text/html;charset="utf-8`, "<html></html>"},
+		{"stylesheet body is read", "text/css", "<html></html>"},
+		{"PDF body skipped", "application/pdf", ""},
+		{"image body skipped", "image/png", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Setting the header to nil suppresses content sniffing
+				w.Header()["Content-Type"] = nil
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				fmt.Fprint(w, "<html></html>")
+			}))
+			defer server.Close()
+
+			c := New(Config{})
+			result, err := c.Fetch(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if string(result.Body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", string(result.Body), tt.wantBody)
+			}
+			if result.ContentType != tt.contentType {
+				t.Errorf("ContentType = %q, want %q", result.ContentType, tt.contentType)
+			}
+		})
+	}
+}
+
+func TestFetch_HostRateLimit(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	serverA := httptest.NewServer(handler)
+	defer serverA.Close()
+	serverB := httptest.NewServer(handler)
+	defer serverB.Close()
+
+	c := New(Config{HostRateLimit: 100 * time.Millisecond})
+
+	// Alternating hosts: each host gets its own schedule, so the second
+	// request to serverB is not delayed by the first request to serverA
+	start := time.Now()
+	for _, u := range []string{serverA.URL, serverB.URL} {
+		if _, err := c.Fetch(context.Background(), u); err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed >= 95*time.Millisecond {
+		t.Errorf("requests to different hosts took %v, want < 95ms", elapsed)
+	}
+
+	// Repeated requests to the same host are spaced out
+	start = time.Now()
+	for i := 0; i < 2; i++ {
+		if _, err := c.Fetch(context.Background(), serverA.URL); err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 95*time.Millisecond {
+		t.Errorf("two further requests to one host took %v, want >= 95ms", elapsed)
+	}
+}
+
+func TestFetch_HostRateLimitCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := New(Config{HostRateLimit: time.Hour})
+	if _, err := c.Fetch(context.Background(), server.URL); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.Fetch(ctx, server.URL); err == nil {
+		t.Errorf("Fetch() expected context error while waiting on host limit, got nil")
+	}
+}
+
+func TestFetch_HostRateLimitOverride(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// The override matches the bare hostname, whatever the port
+	c := New(Config{
+		HostRateLimit:  time.Hour,
+		HostRateLimits: map[string]time.Duration{"127.0.0.1": 0},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		if _, err := c.Fetch(ctx, server.URL); err != nil {
+			t.Fatalf("Fetch() #%d error = %v, want the override to lift the default limit", i, err)
+		}
+	}
+}
+
+func TestFetch_ExtraHeaders(t *testing.T) {
+	var gotAuth, gotUA string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotUA = r.Header.Get("User-Agent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := New(Config{
+		UserAgent: "TestBot/1.0",
+		Headers: map[string]string{
+			"Authorization": "Bearer token",
+			"User-Agent":    "Ignored/1.0",
+		},
+	})
+	if _, err := c.Fetch(context.Background(), server.URL); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if gotAuth != "Bearer token" {
+		t.Errorf("Authorization = %q, want %q", gotAuth, "Bearer token")
+	}
+	if gotUA != "TestBot/1.0" {
+		t.Errorf("User-Agent = %q, want the UserAgent option to win", gotUA)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var methods []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, "ok "+r.Method)
+	})
+	mux.HandleFunc("/get-only", func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, "get-only "+r.Method)
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/missing", http.StatusMovedPermanently)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := New(Config{})
+	if err := c.Check(context.Background(), server.URL+"/ok"); err != nil {
+		t.Errorf("Check(/ok) error = %v", err)
+	}
+	if err := c.Check(context.Background(), server.URL+"/get-only"); err != nil {
+		t.Errorf("Check(/get-only) error = %v, want the GET retry to succeed", err)
+	}
+	err := c.Check(context.Background(), server.URL+"/moved")
+	var httpErr *crawler.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Check(/moved) error = %v, want a 404 HTTPError after the redirect", err)
+	}
+
+	want := []string{"ok HEAD", "get-only HEAD", "get-only GET"}
+	if strings.Join(methods, ",") != strings.Join(want, ",") {
+		t.Errorf("requests = %v, want %v", methods, want)
+	}
+}
+
+func TestFetch_ReadPDF(t *testing.T) {
+	// Bytes that a charset decoder would mangle
+	pdf := []byte("%PDF-1.7\n\xe2\x80\x93\xff binary")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(pdf)
+	}))
+	defer server.Close()
+
+	result, err := New(Config{}).Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(result.Body) != 0 {
+		t.Errorf("Body = %q without ReadPDF, want it skipped", result.Body)
+	}
+
+	result, err = New(Config{ReadPDF: true}).Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if !bytes.Equal(result.Body, pdf) {
+		t.Errorf("Body = %q with ReadPDF, want the PDF bytes unchanged", result.Body)
+	}
+}
+
+func TestFetch_RedirectChain(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/b", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/c", http.StatusFound)
+	})
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html></html>")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := New(Config{})
+	result, err := c.Fetch(context.Background(), server.URL+"/a")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	if result.FinalURL != server.URL+"/c" {
+		t.Errorf("FinalURL = %q, want %q", result.FinalURL, server.URL+"/c")
+	}
+
+	want := []struct {
+		url    string
+		status int
+	}{
+		{server.URL + "/a", http.StatusMovedPermanently},
+		{server.URL + "/b", http.StatusFound},
+	}
+	if len(result.Redirects) != len(want) {
+		t.Fatalf("got %d redirects, want %d: %+v", len(result.Redirects), len(want), result.Redirects)
+	}
+	for i, w := range want {
+		if result.Redirects[i].URL != w.url || result.Redirects[i].StatusCode != w.status {
+			t.Errorf("Redirects[%d] = %+v, want {%s %d}", i, result.Redirects[i], w.url, w.status)
+		}
+	}
+}
+
+func TestFetch_NoRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html></html>")
+	}))
+	defer server.Close()
+
+	c := New(Config{})
+	result, err := c.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(result.Redirects) != 0 {
+		t.Errorf("got %d redirects, want 0", len(result.Redirects))
+	}
+}
+
+func TestFetch_RedirectToErrorPage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/missing", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := New(Config{})
+	_, err := c.Fetch(context.Background(), server.URL+"/old")
+
+	var httpErr *crawler.HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("Fetch() error = %v, want *crawler.HTTPError", err)
+	}
+	if httpErr.FinalURL != server.URL+"/missing" {
+		t.Errorf("FinalURL = %q, want %q", httpErr.FinalURL, server.URL+"/missing")
+	}
+	if len(httpErr.Redirects) != 1 || httpErr.Redirects[0].URL != server.URL+"/old" || httpErr.Redirects[0].StatusCode != 301 {
+		t.Errorf("Redirects = %+v, want [{%s/old 301}]", httpErr.Redirects, server.URL)
+	}
+}
+
+func TestFetch_RobotsTags(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Add("X-Robots-Tag", "noindex")
+		w.Header().Add("X-Robots-Tag", "googlebot: nofollow")
+		fmt.Fprint(w, "<html></html>")
+	}))
+	defer server.Close()
+
+	c := New(Config{})
+	result, err := c.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	want := []string{"noindex", "googlebot: nofollow"}
+	if len(result.RobotsTags) != len(want) || result.RobotsTags[0] != want[0] || result.RobotsTags[1] != want[1] {
+		t.Errorf("RobotsTags = %q, want %q", result.RobotsTags, want)
+	}
+}
+
+func TestFetch_LastModified(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/dated" {
+			w.Header().Set("Last-Modified", "Tue, 03 Mar 2026 10:15:00 GMT")
+		} else if r.URL.Path == "/bad" {
+			w.Header().Set("Last-Modified", "yesterday")
+		}
+		fmt.Fprint(w, "<html></html>")
+	}))
+	defer server.Close()
+
+	c := New(Config{})
+	tests := []struct {
+		path string
+		want time.Time
+	}{
+		{"/dated", time.Date(2026, 3, 3, 10, 15, 0, 0, time.UTC)},
+		{"/bad", time.Time{}},
+		{"/none", time.Time{}},
+	}
+	for _, tt := range tests {
+		result, err := c.Fetch(context.Background(), server.URL+tt.path)
+		if err != nil {
+			t.Fatalf("Fetch(%s) error = %v", tt.path, err)
+		}
+		if !result.LastModified.Equal(tt.want) {
+			t.Errorf("Fetch(%s).LastModified = %v, want %v", tt.path, result.LastModified, tt.want)
+		}
+	}
+}
+
+func TestFetchIfModified(t *testing.T) {
+	modified := time.Date(2026, 3, 3, 10, 15, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !since.Before(modified) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html></html>")
+	}))
+	defer server.Close()
+
+	c := New(Config{})
+	first, err := c.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if first.ETag != `"v1"` || first.NotModified {
+		t.Errorf("Fetch() = ETag %q, NotModified %v; want \"v1\", false", first.ETag, first.NotModified)
+	}
+
+	tests := []struct {
+		name        string
+		v           crawler.Validators
+		notModified bool
+	}{
+		{"matching etag", crawler.Validators{ETag: `"v1"`}, true},
+		{"stale etag", crawler.Validators{ETag: `"v0"`}, false},
+		{"not modified since", crawler.Validators{LastModified: modified}, true},
+		{"modified since", crawler.Validators{LastModified: modified.Add(-time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := c.FetchIfModified(context.Background(), server.URL, tt.v)
+			if err != nil {
+				t.Fatalf("FetchIfModified() error = %v", err)
+			}
+			if result.NotModified != tt.notModified {
+				t.Errorf("NotModified = %v, want %v", result.NotModified, tt.notModified)
+			}
+			if tt.notModified && len(result.Body) != 0 {
+				t.Errorf("Body = %q, want empty for 304", result.Body)
+			}
+			if result.ETag != `"v1"` {
+				t.Errorf("ETag = %q, want \"v1\"", result.ETag)
+			}
+		})
+	}
+}
+
+func TestFetch_RecordsDuration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html></html>")
+	}))
+	defer server.Close()
+
+	c := New(Config{})
+	result, err := c.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if result.Duration < 20*time.Millisecond {
+		t.Errorf("Duration = %v, want >= 20ms", result.Duration)
+	}
+}
+
+func TestFetch_BlockPrivateIPs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// httptest listens on loopback, which must be refused when blocking
+	c := New(Config{BlockPrivateIPs: true})
+	_, err := c.Fetch(context.Background(), server.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("Fetch() error = %v, want ErrBlockedAddress", err)
+	}
+
+	// Default config allows loopback
+	c = New(Config{})
+	if _, err := c.Fetch(context.Background(), server.URL); err != nil {
+		t.Errorf("Fetch() without blocking error = %v", err)
+	}
+}
+
+func TestBlockPrivateControl(t *testing.T) {
+	tests := []struct {
+		address     string
+		wantBlocked bool
+	}{
+		{"127.0.0.1:80", true},
+		{"10.1.2.3:443", true},
+		{"172.16.0.1:80", true},
+		{"192.168.1.1:80", true},
+		{"169.254.169.254:80", true},
+		{"0.0.0.0:80", true},
+		{"[::1]:80", true},
+		{"[fe80::1]:80", true},
+		{"[fd00::1]:80", true},
+		{"93.184.216.34:443", false},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := blockPrivateControl("tcp", tt.address, nil)
+			if blocked := errors.Is(err, ErrBlockedAddress); blocked != tt.wantBlocked {
+				t.Errorf("blockPrivateControl(%q) error = %v, wantBlocked %v", tt.address, err, tt.wantBlocked)
+			}
+		})
+	}
+}
+
+func TestFetch_IdentificationHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		wantUA   string
+		wantFrom string
+	}{
+		{
+			name:   "defaults",
+			cfg:    Config{},
+			wantUA: DefaultUserAgent,
+		},
+		{
+			name:     "from and info URL appended",
+			cfg:      Config{From: "ops@example.com", CrawlInfoURL: "https://example.com/bot"},
+			wantUA:   DefaultUserAgent + " (+https://example.com/bot)",
+			wantFrom: "ops@example.com",
+		},
+		{
+			name: "template placeholders",
+			cfg: Config{
+				UserAgent:    "AuditBot/2.0 ({info}; {from})",
+				From:         "ops@example.com",
+				CrawlInfoURL: "https://example.com/bot",
+			},
+			wantUA:   "AuditBot/2.0 (https://example.com/bot; ops@example.com)",
+			wantFrom: "ops@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUA, gotFrom string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUA = r.Header.Get("User-Agent")
+				gotFrom = r.Header.Get("From")
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			c := New(tt.cfg)
+			if _, err := c.Fetch(context.Background(), server.URL); err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if gotUA != tt.wantUA {
+				t.Errorf("User-Agent = %q, want %q", gotUA, tt.wantUA)
+			}
+			if gotFrom != tt.wantFrom {
+				t.Errorf("From = %q, want %q", gotFrom, tt.wantFrom)
+			}
+		})
+	}
+}
+
+func TestNew_TransportTuning(t *testing.T) {
+	c := New(Config{
+		MaxIdleConnsPerHost: 32,
+		IdleConnTimeout:     15 * time.Second,
+		DisableKeepAlives:   true,
+	})
+
+	transport, ok := c.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Transport = %T, want *http.Transport", c.httpClient.Transport)
+	}
+	if transport.MaxIdleConnsPerHost != 32 {
+		t.Errorf("MaxIdleConnsPerHost = %d, want 32", transport.MaxIdleConnsPerHost)
+	}
+	if transport.MaxIdleConns < 32 {
+		t.Errorf("MaxIdleConns = %d, want >= 32", transport.MaxIdleConns)
+	}
+	if transport.IdleConnTimeout != 15*time.Second {
+		t.Errorf("IdleConnTimeout = %v, want 15s", transport.IdleConnTimeout)
+	}
+	if !transport.DisableKeepAlives {
+		t.Errorf("DisableKeepAlives = false, want true")
+	}
+}
+
+func TestNew_PhaseTimeouts(t *testing.T) {
+	c := New(Config{
+		TLSHandshakeTimeout:   3 * time.Second,
+		ResponseHeaderTimeout: 4 * time.Second,
+	})
+
+	transport := c.httpClient.Transport.(*http.Transport)
+	if transport.TLSHandshakeTimeout != 3*time.Second {
+		t.Errorf("TLSHandshakeTimeout = %v, want 3s", transport.TLSHandshakeTimeout)
+	}
+	if transport.ResponseHeaderTimeout != 4*time.Second {
+		t.Errorf("ResponseHeaderTimeout = %v, want 4s", transport.ResponseHeaderTimeout)
+	}
+}
+
+func TestFetch_ResponseHeaderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	c := New(Config{Timeout: 5 * time.Second, ResponseHeaderTimeout: 50 * time.Millisecond})
+	start := time.Now()
+	_, err := c.Fetch(context.Background(), server.URL)
+	if err == nil {
+		t.Fatal("Fetch() error = nil, want a response header timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Fetch() took %v, want the header timeout to fire well before Timeout", elapsed)
+	}
+}
+
+func TestNew_TransportDefaults(t *testing.T) {
+	c := New(Config{})
+
+	transport := c.httpClient.Transport.(*http.Transport)
+	def := http.DefaultTransport.(*http.Transport)
+	if transport.MaxIdleConnsPerHost != def.MaxIdleConnsPerHost {
+		t.Errorf("MaxIdleConnsPerHost = %d, want default %d", transport.MaxIdleConnsPerHost, def.MaxIdleConnsPerHost)
+	}
+	if transport.IdleConnTimeout != def.IdleConnTimeout {
+		t.Errorf("IdleConnTimeout = %v, want default %v", transport.IdleConnTimeout, def.IdleConnTimeout)
+	}
+	if transport.DisableKeepAlives {
+		t.Errorf("DisableKeepAlives = true, want false")
+	}
+	if transport.TLSHandshakeTimeout != def.TLSHandshakeTimeout {
+		t.Errorf("TLSHandshakeTimeout = %v, want default %v", transport.TLSHandshakeTimeout, def.TLSHandshakeTimeout)
+	}
+	if transport.ResponseHeaderTimeout != 0 {
+		t.Errorf("ResponseHeaderTimeout = %v, want 0", transport.ResponseHeaderTimeout)
+	}
+}
+
+func TestFetch_RateBurst(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := New(Config{RateLimit: 100 * time.Millisecond, RateBurst: 3})
+
+	// The first three requests fit in the burst and are not delayed
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := c.Fetch(context.Background(), server.URL); err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed >= 95*time.Millisecond {
+		t.Errorf("burst of 3 took %v, want < 95ms", elapsed)
+	}
+
+	// The fourth waits for a token
+	start = time.Now()
+	if _, err := c.Fetch(context.Background(), server.URL); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("request after burst took %v, want to be rate limited", elapsed)
+	}
+}
+
+func TestFetch_RateLimitCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := New(Config{RateLimit: time.Hour})
+	if _, err := c.Fetch(context.Background(), server.URL); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	// Shutdown must not block on the limiter
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if _, err := c.Fetch(ctx, server.URL); err == nil {
+		t.Errorf("Fetch() expected error with cancelled context, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled Fetch() took %v, want immediate return", elapsed)
+	}
+}
+
+func TestFetch_StreamingEndpoints(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	mux.HandleFunc("/longpoll", func(w http.ResponseWriter, r *http.Request) {
+		// Chunked HTML that never finishes
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "<html>")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	mux.HandleFunc("/chunked", func(w http.ResponseWriter, r *http.Request) {
+		// Chunked HTML that completes within the cap
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html>")
+		w.(http.Flusher).Flush()
+		fmt.Fprint(w, "</html>")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := New(Config{StreamReadTimeout: 100 * time.Millisecond, Timeout: 5 * time.Second})
+
+	for _, path := range []string{"/events", "/longpoll"} {
+		t.Run(path, func(t *testing.T) {
+			start := time.Now()
+			_, err := c.Fetch(context.Background(), server.URL+path)
+			var streamErr *crawler.StreamError
+			if !errors.As(err, &streamErr) {
+				t.Fatalf("Fetch() error = %v, want *crawler.StreamError", err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("Fetch() took %v, want well under the global timeout", elapsed)
+			}
+		})
+	}
+
+	t.Run("/chunked", func(t *testing.T) {
+		result, err := c.Fetch(context.Background(), server.URL+"/chunked")
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		if string(result.Body) != "<html></html>" {
+			t.Errorf("body = %q, want %q", string(result.Body), "<html></html>")
+		}
+	})
+}
+
+func TestFetch_TranscodesToUTF8(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        string
+	}{
+		{
+			name:        "charset from Content-Type",
+			contentType: "text/html; charset=ISO-8859-1",
+			body:        []byte("<a href=\"/caf\xe9\">caf\xe9</a>"),
+			want:        `<a href="/café">café</a>`,
+		},
+		{
+			name:        "Shift_JIS from Content-Type",
+			contentType: "text/html; charset=Shift_JIS",
+			body:        []byte("<a href=\"/\x93\xfa\x96\x7b\">x</a>"),
+			want:        `<a href="/日本">x</a>`,
+		},
+		{
+			name:        "charset from meta tag",
+			contentType: "text/html",
+			body:        []byte("<meta charset=\"iso-8859-1\"><a href=\"/na\xefve\">x</a>"),
+			want:        `<meta charset="iso-8859-1"><a href="/naïve">x</a>`,
+		},
+		{
+			name:        "undeclared UTF-8 kept",
+			contentType: "text/html",
+			body:        []byte(`<a href="/café">x</a>`),
+			want:        `<a href="/café">x</a>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write(tt.body)
+			}))
+			defer server.Close()
+
+			c := New(Config{})
+			result, err := c.Fetch(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if string(result.Body) != tt.want {
+				t.Errorf("body = %q, want %q", string(result.Body), tt.want)
+			}
+		})
+	}
+}
+
+func TestFetch_MaxOtherBodySize(t *testing.T) {
+	page := "<!DOCTYPE html><html><body>" + strings.Repeat("x", 100) + "</body></html>"
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		maxOther    int64
+		wantBody    string
+		wantType    string
+	}{
+		{"other content skipped by default", "application/pdf", "%PDF-1.7 data", 0, "", "application/pdf"},
+		{"other content read up to its limit", "application/pdf", "%PDF-1.7 data", 4, "%PDF", "application/pdf"},
+		{"HTML not held to the other limit", "text/html", page, 4, page, "text/html"},
+		{"octet-stream sniffed as HTML", "application/octet-stream", page, 16, page, "text/html"},
+		{"octet-stream binary kept short", "application/octet-stream", "\x00\x01\x02\x03\x04\x05", 4, "\x00\x01\x02\x03", "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			c := New(Config{MaxOtherBodySize: tt.maxOther})
+			result, err := c.Fetch(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if string(result.Body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", string(result.Body), tt.wantBody)
+			}
+			if result.ContentType != tt.wantType {
+				t.Errorf("ContentType = %q, want %q", result.ContentType, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestFetch_SniffedHTMLHeldToMaxBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		fmt.Fprint(w, "<html><body>0123456789</body></html>")
+	}))
+	defer server.Close()
+
+	c := New(Config{MaxBodySize: 10, MaxOtherBodySize: 20})
+	result, err := c.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if string(result.Body) != "<html><bod" {
+		t.Errorf("body = %q, want the first 10 bytes", string(result.Body))
+	}
+}
+
+func TestFetch_PooledBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latin1" {
+			w.Header().Set("Content-Type", "text/html; charset=ISO-8859-1")
+			fmt.Fprint(w, "<p>na\xefve</p>")
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<p>"+strings.TrimPrefix(r.URL.Path, "/")+"</p>")
+	}))
+	defer server.Close()
+
+	c := New(Config{})
+	for _, page := range []string{"first", "second", "third"} {
+		result, err := c.Fetch(context.Background(), server.URL+"/"+page)
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		if want := "<p>" + page + "</p>"; string(result.Body) != want {
+			t.Errorf("body = %q, want %q", string(result.Body), want)
+		}
+		if result.Release == nil {
+			t.Fatalf("Release = nil for a body read into a pooled buffer")
+		}
+		result.Release()
+	}
+
+	// A transcoded body is a copy; its buffer is already back in the pool
+	result, err := c.Fetch(context.Background(), server.URL+"/latin1")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if string(result.Body) != "<p>naïve</p>" {
+		t.Errorf("body = %q, want it transcoded", string(result.Body))
+	}
+	if result.Release != nil {
+		t.Errorf("Release set for a transcoded body")
+	}
+}
@@ -0,0 +1,348 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+)
+
+// harTimeFormat is the ISO 8601 form HAR uses for startedDateTime.
+const harTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// HARRecorder collects the client's requests and responses in HTTP Archive
+// (HAR 1.2) format, for inspection in browser devtools or HAR analyzers.
+// Every round trip is recorded, including redirects and HEAD prechecks,
+// with headers, status, sizes, and timings but not bodies. Entries are
+// kept in memory until WriteHAR.
+type HARRecorder struct {
+	// version is the crawler version reported as the HAR creator
+	version string
+
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+// NewHARRecorder returns an empty recorder; version is reported as the
+// HAR creator's version.
+func NewHARRecorder(version string) *HARRecorder {
+	return &HARRecorder{version: version}
+}
+
+// WriteHAR writes everything recorded so far as one HAR document, entries
+// in the order their requests started.
+func (h *HARRecorder) WriteHAR(w io.Writer) error {
+	h.mu.Lock()
+	entries := make([]harEntry, len(h.entries))
+	copy(entries, h.entries)
+	h.mu.Unlock()
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].started.Before(entries[j].started)
+	})
+
+	doc := harDocument{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "web-crawler", Version: h.version},
+		Entries: entries,
+	}}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("writing HAR: %w", err)
+	}
+	return nil
+}
+
+func (h *HARRecorder) add(e harEntry) {
+	h.mu.Lock()
+	h.entries = append(h.entries, e)
+	h.mu.Unlock()
+}
+
+// The HAR 1.2 format (http://www.softwareishard.com/blog/har-12-spec/).
+// Fields starting with an underscore are custom, as the spec allows.
+type (
+	harDocument struct {
+		Log harLog `json:"log"`
+	}
+	harLog struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	}
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	harEntry struct {
+		started         time.Time
+		StartedDateTime string      `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+		ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+		// Error is why no response was received
+		Error string `json:"_error,omitempty"`
+	}
+	harRequest struct {
+		Method      string      `json:"method"`
+		URL         string      `json:"url"`
+		HTTPVersion string      `json:"httpVersion"`
+		Headers     []harNV     `json:"headers"`
+		QueryString []harNV     `json:"queryString"`
+		Cookies     []harCookie `json:"cookies"`
+		HeadersSize int         `json:"headersSize"`
+		BodySize    int         `json:"bodySize"`
+	}
+	harResponse struct {
+		Status      int         `json:"status"`
+		StatusText  string      `json:"statusText"`
+		HTTPVersion string      `json:"httpVersion"`
+		Headers     []harNV     `json:"headers"`
+		Cookies     []harCookie `json:"cookies"`
+		Content     harContent  `json:"content"`
+		RedirectURL string      `json:"redirectURL"`
+		HeadersSize int         `json:"headersSize"`
+		BodySize    int64       `json:"bodySize"`
+	}
+	harNV struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	harCookie struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	harContent struct {
+		Size     int64  `json:"size"`
+		MimeType string `json:"mimeType"`
+	}
+	// harTimings are in milliseconds, -1 where a phase didn't happen (a
+	// reused connection has no dns, connect, or ssl)
+	harTimings struct {
+		Blocked float64 `json:"blocked"`
+		DNS     float64 `json:"dns"`
+		Connect float64 `json:"connect"`
+		SSL     float64 `json:"ssl"`
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	}
+)
+
+// harTransport records every round trip through next.
+type harTransport struct {
+	next http.RoundTripper
+	har  *HARRecorder
+}
+
+func (t *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &harTrace{start: time.Now()}
+	resp, err := t.next.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace())))
+	entry := harEntry{
+		started:         trace.start,
+		StartedDateTime: trace.start.Format(harTimeFormat),
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Headers:     harHeaders(req.Header),
+			QueryString: harQuery(req),
+			Cookies:     harCookies(req.Cookies()),
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Response: harResponse{Headers: []harNV{}, Cookies: []harCookie{}, HeadersSize: -1, BodySize: -1},
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		entry.Timings, entry.Time = trace.timings(time.Now())
+		entry.ServerIPAddress = trace.serverIP()
+		t.har.add(entry)
+		return nil, err
+	}
+
+	entry.Request.HTTPVersion = resp.Proto
+	entry.Response = harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Headers:     harHeaders(resp.Header),
+		Cookies:     harCookies(resp.Cookies()),
+		Content:     harContent{MimeType: resp.Header.Get("Content-Type")},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+	}
+	entry.ServerIPAddress = trace.serverIP()
+	resp.Body = &harBody{ReadCloser: resp.Body, entry: entry, trace: trace, har: t.har}
+	return resp, nil
+}
+
+// harBody completes its entry when the body is closed, once the receive
+// time and the number of bytes read are known.
+type harBody struct {
+	io.ReadCloser
+	entry harEntry
+	trace *harTrace
+	har   *HARRecorder
+	read  int64
+	once  sync.Once
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.entry.Response.BodySize = b.read
+		b.entry.Response.Content.Size = b.read
+		b.entry.Timings, b.entry.Time = b.trace.timings(time.Now())
+		b.har.add(b.entry)
+	})
+	return err
+}
+
+// harTrace timestamps the phases of one round trip. Dials may race (Happy
+// Eyeballs), so hooks can run on several goroutines.
+type harTrace struct {
+	start time.Time
+
+	mu                  sync.Mutex
+	dnsStart, dnsDone   time.Time
+	connStart, connDone time.Time
+	tlsStart, tlsDone   time.Time
+	gotConn, wroteReq   time.Time
+	firstByte           time.Time
+	remoteAddr          net.Addr
+}
+
+func (t *harTrace) clientTrace() *httptrace.ClientTrace {
+	stamp := func(field *time.Time) {
+		t.mu.Lock()
+		if field.IsZero() {
+			*field = time.Now()
+		}
+		t.mu.Unlock()
+	}
+	return &httptrace.ClientTrace{
+		DNSStart:     func(httptrace.DNSStartInfo) { stamp(&t.dnsStart) },
+		DNSDone:      func(httptrace.DNSDoneInfo) { stamp(&t.dnsDone) },
+		ConnectStart: func(string, string) { stamp(&t.connStart) },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				stamp(&t.connDone)
+			}
+		},
+		TLSHandshakeStart: func() { stamp(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { stamp(&t.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.gotConn = time.Now()
+			t.remoteAddr = info.Conn.RemoteAddr()
+			t.mu.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { stamp(&t.wroteReq) },
+		GotFirstResponseByte: func() { stamp(&t.firstByte) },
+	}
+}
+
+// timings splits the round trip, ending at end, into HAR phases and
+// returns them with their total.
+func (t *harTrace) timings(end time.Time) (harTimings, float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := func(from, to time.Time) float64 {
+		if from.IsZero() || to.IsZero() || to.Before(from) {
+			return -1
+		}
+		return float64(to.Sub(from).Microseconds()) / 1000
+	}
+	tm := harTimings{
+		DNS:     span(t.dnsStart, t.dnsDone),
+		SSL:     span(t.tlsStart, t.tlsDone),
+		Send:    span(t.gotConn, t.wroteReq),
+		Wait:    span(t.wroteReq, t.firstByte),
+		Receive: span(t.firstByte, end),
+	}
+	// HAR counts the TLS handshake in connect as well as ssl
+	connDone := t.connDone
+	if t.tlsDone.After(connDone) {
+		connDone = t.tlsDone
+	}
+	tm.Connect = span(t.connStart, connDone)
+
+	// Blocked is the wait for a connection, less resolving and dialing it
+	tm.Blocked = span(t.start, t.gotConn)
+	if tm.Blocked >= 0 {
+		tm.Blocked = max(0, tm.Blocked-max(tm.DNS, 0)-max(tm.Connect, 0))
+	}
+
+	total := 0.0
+	for _, phase := range []float64{tm.Blocked, tm.DNS, tm.Connect, tm.Send, tm.Wait, tm.Receive} {
+		total += max(phase, 0)
+	}
+	return tm, total
+}
+
+// serverIP returns the address the request was sent to.
+func (t *harTrace) serverIP() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.remoteAddr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(t.remoteAddr.String())
+	if err != nil {
+		return t.remoteAddr.String()
+	}
+	return host
+}
+
+// harHeaders flattens h, sorted by name so the archive is stable.
+func harHeaders(h http.Header) []harNV {
+	nvs := []harNV{}
+	for name, values := range h {
+		for _, v := range values {
+			nvs = append(nvs, harNV{Name: name, Value: v})
+		}
+	}
+	sort.SliceStable(nvs, func(i, j int) bool {
+		return nvs[i].Name < nvs[j].Name
+	})
+	return nvs
+}
+
+// harQuery lists the request's query parameters, sorted by name.
+func harQuery(req *http.Request) []harNV {
+	nvs := []harNV{}
+	for name, values := range req.URL.Query() {
+		for _, v := range values {
+			nvs = append(nvs, harNV{Name: name, Value: v})
+		}
+	}
+	sort.SliceStable(nvs, func(i, j int) bool {
+		return nvs[i].Name < nvs[j].Name
+	})
+	return nvs
+}
+
+// harCookies lists cookies by name and value.
+func harCookies(cookies []*http.Cookie) []harCookie {
+	out := []harCookie{}
+	for _, c := range cookies {
+		out = append(out, harCookie{Name: c.Name, Value: c.Value})
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHARRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new?x=1", http.StatusMovedPermanently)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Write([]byte("<html>hello</html>"))
+	}))
+	defer server.Close()
+
+	har := NewHARRecorder("v1.2.3")
+	c := New(Config{HAR: har})
+	if _, err := c.Fetch(context.Background(), server.URL+"/old"); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	// A refused connection is recorded with its error
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	if _, err := c.Fetch(context.Background(), closed.URL+"/down"); err == nil {
+		t.Fatalf("Fetch() of a closed server should fail")
+	}
+
+	var buf bytes.Buffer
+	if err := har.WriteHAR(&buf); err != nil {
+		t.Fatalf("WriteHAR() error = %v", err)
+	}
+	var doc struct {
+		Log struct {
+			Version string `json:"version"`
+			Creator struct {
+				Name, Version string
+			} `json:"creator"`
+			Entries []struct {
+				StartedDateTime string  `json:"startedDateTime"`
+				Time            float64 `json:"time"`
+				Request         struct {
+					Method      string  `json:"method"`
+					URL         string  `json:"url"`
+					Headers     []harNV `json:"headers"`
+					QueryString []harNV `json:"queryString"`
+				} `json:"request"`
+				Response struct {
+					Status      int         `json:"status"`
+					RedirectURL string      `json:"redirectURL"`
+					Cookies     []harCookie `json:"cookies"`
+					Content     harContent  `json:"content"`
+					BodySize    int64       `json:"bodySize"`
+				} `json:"response"`
+				Timings         harTimings `json:"timings"`
+				ServerIPAddress string     `json:"serverIPAddress"`
+				Error           string     `json:"_error"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("HAR is not valid JSON: %v", err)
+	}
+	if doc.Log.Version != "1.2" || doc.Log.Creator.Version != "v1.2.3" {
+		t.Errorf("log version %q, creator %+v", doc.Log.Version, doc.Log.Creator)
+	}
+	entries := doc.Log.Entries
+	if len(entries) != 3 {
+		t.Fatalf("recorded %d entries, want redirect, page, and failure:\n%s", len(entries), buf.String())
+	}
+
+	redirect, page, failed := entries[0], entries[1], entries[2]
+	if redirect.Request.URL != server.URL+"/old" || redirect.Response.Status != 301 || redirect.Response.RedirectURL != "/new?x=1" {
+		t.Errorf("first entry = %s %d -> %q, want the redirect", redirect.Request.URL, redirect.Response.Status, redirect.Response.RedirectURL)
+	}
+	if page.Request.Method != "GET" || page.Response.Status != 200 || page.Response.Content.MimeType != "text/html" ||
+		page.Response.Content.Size != int64(len("<html>hello</html>")) || page.Response.BodySize != page.Response.Content.Size {
+		t.Errorf("page entry = %+v", page)
+	}
+	if len(page.Request.QueryString) != 1 || page.Request.QueryString[0] != (harNV{Name: "x", Value: "1"}) {
+		t.Errorf("query string = %+v, want x=1", page.Request.QueryString)
+	}
+	if len(page.Response.Cookies) != 1 || page.Response.Cookies[0].Name != "session" {
+		t.Errorf("response cookies = %+v", page.Response.Cookies)
+	}
+	var userAgent string
+	for _, h := range page.Request.Headers {
+		if h.Name == "User-Agent" {
+			userAgent = h.Value
+		}
+	}
+	if userAgent != DefaultUserAgent {
+		t.Errorf("request User-Agent = %q, want %q", userAgent, DefaultUserAgent)
+	}
+	if page.Timings.Wait < 0 || page.Timings.Send < 0 || page.Timings.Receive < 0 || page.Time <= 0 {
+		t.Errorf("page timings = %+v, total %v", page.Timings, page.Time)
+	}
+	if page.ServerIPAddress != "127.0.0.1" || page.StartedDateTime == "" {
+		t.Errorf("server IP %q, started %q", page.ServerIPAddress, page.StartedDateTime)
+	}
+	if failed.Response.Status != 0 || failed.Error == "" || failed.Timings.Wait != -1 {
+		t.Errorf("failed entry: status %d, error %q, wait %v", failed.Response.Status, failed.Error, failed.Timings.Wait)
+	}
+}
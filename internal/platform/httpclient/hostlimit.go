@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// hostLimiter spaces requests to the same host at least interval apart.
+// Each caller reserves the next free slot for its host under the lock, then
+// sleeps outside it, so concurrent requests to one host queue up in order
+// while requests to other hosts proceed independently.
+type hostLimiter struct {
+	interval time.Duration
+	// overrides replaces interval for specific hosts
+	overrides map[string]time.Duration
+	mu        sync.Mutex
+	next      map[string]time.Time
+}
+
+// newHostLimiter creates a per-host limiter with the given minimum interval.
+func newHostLimiter(interval time.Duration) *hostLimiter {
+	return &hostLimiter{
+		interval:  interval,
+		overrides: make(map[string]time.Duration),
+		next:      make(map[string]time.Time),
+	}
+}
+
+// wait blocks until a request to host is allowed.
+// Returns the context error if cancelled while waiting.
+func (h *hostLimiter) wait(ctx context.Context, host string) error {
+	h.mu.Lock()
+	now := time.Now()
+	slot := h.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	h.next[host] = slot.Add(h.intervalFor(host))
+	h.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// intervalFor returns the spacing for host: its override, matched on
+// host:port and then on the bare hostname, or the default interval.
+func (h *hostLimiter) intervalFor(host string) time.Duration {
+	if interval, ok := h.overrides[host]; ok {
+		return interval
+	}
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		if interval, ok := h.overrides[name]; ok {
+			return interval
+		}
+	}
+	return h.interval
+}
@@ -0,0 +1,56 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cametumbling/web-crawler/internal/crawler"
+)
+
+// headRefused are statuses servers answer HEAD with when only GET is
+// supported or HEAD is blocked, so the check is retried with GET.
+var headRefused = map[int]bool{
+	http.StatusForbidden:        true,
+	http.StatusMethodNotAllowed: true,
+	http.StatusNotImplemented:   true,
+}
+
+// Check reports whether url resolves, following redirects: it returns nil
+// for a 2xx response and an *crawler.HTTPError otherwise. It sends HEAD,
+// retrying with GET when HEAD is refused; no body is read either way.
+// Rate limits apply as for Fetch.
+func (c *Client) Check(ctx context.Context, url string) error {
+	status, finalURL, err := c.checkWith(ctx, http.MethodHead, url)
+	if err == nil && headRefused[status] {
+		status, finalURL, err = c.checkWith(ctx, http.MethodGet, url)
+	}
+	if err != nil {
+		return err
+	}
+	if status < 200 || status >= 300 {
+		return &crawler.HTTPError{StatusCode: status, URL: url, FinalURL: finalURL}
+	}
+	return nil
+}
+
+// checkWith sends one request with method and returns the final status and URL.
+func (c *Client) checkWith(ctx context.Context, method, url string) (int, string, error) {
+	if err := c.wait(ctx, url); err != nil {
+		return 0, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, "", fmt.Errorf("creating request: %w", err)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("executing request: %w", err)
+	}
+	// Closing without reading drops the connection rather than downloading a GET body
+	resp.Body.Close()
+	return resp.StatusCode, resp.Request.URL.String(), nil
+}
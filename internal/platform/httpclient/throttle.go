@@ -0,0 +1,125 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// throttleMinDelay is the per-host delay applied on the first slow-down signal
+	throttleMinDelay = 250 * time.Millisecond
+	// throttleMaxDelay caps the per-host delay
+	throttleMaxDelay = 30 * time.Second
+	// throttleRecovery scales the delay down after each healthy response
+	throttleRecovery = 0.9
+	// throttleSpikeFactor is how far above the running average latency a
+	// response must be to count as a spike
+	throttleSpikeFactor = 3
+)
+
+// throttle adapts a per-host delay to server feedback. 429 and 503 responses
+// double the delay (or honour Retry-After if longer), latency spikes increase
+// it by half, and each healthy response shrinks it until it reaches zero.
+type throttle struct {
+	mu    sync.Mutex
+	hosts map[string]*hostThrottle
+}
+
+// hostThrottle is the adaptive state for a single host.
+type hostThrottle struct {
+	delay      time.Duration
+	last       time.Time
+	avgLatency time.Duration
+}
+
+// newThrottle creates an adaptive throttle with no hosts slowed down.
+func newThrottle() *throttle {
+	return &throttle{hosts: make(map[string]*hostThrottle)}
+}
+
+// host returns the state for host, creating it if needed. Caller holds mu.
+func (t *throttle) host(host string) *hostThrottle {
+	h, ok := t.hosts[host]
+	if !ok {
+		h = &hostThrottle{}
+		t.hosts[host] = h
+	}
+	return h
+}
+
+// wait blocks until the host's current delay has elapsed since its previous
+// request. Returns the context error if cancelled while waiting.
+func (t *throttle) wait(ctx context.Context, host string) error {
+	t.mu.Lock()
+	h := t.host(host)
+	now := time.Now()
+	// Measured from the previous request using the delay as it stands now,
+	// so backoff learned from that request's response applies immediately
+	slot := h.last.Add(h.delay)
+	if slot.Before(now) {
+		slot = now
+	}
+	h.last = slot
+	t.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// observe feeds a response back into the host's delay.
+func (t *throttle) observe(host string, resp *http.Response, latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h := t.host(host)
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+		h.delay = max(h.delay*2, throttleMinDelay, retryAfter(resp))
+	case h.avgLatency > 0 && latency > h.avgLatency*throttleSpikeFactor:
+		h.delay = max(h.delay+h.delay/2, throttleMinDelay)
+	default:
+		h.delay = time.Duration(float64(h.delay) * throttleRecovery)
+		if h.delay < throttleMinDelay/10 {
+			h.delay = 0
+		}
+	}
+	h.delay = min(h.delay, throttleMaxDelay)
+
+	// Exponentially weighted running average, seeded by the first sample
+	if h.avgLatency == 0 {
+		h.avgLatency = latency
+	} else {
+		h.avgLatency = (h.avgLatency*7 + latency) / 8
+	}
+}
+
+// currentDelay returns the host's current adaptive delay.
+func (t *throttle) currentDelay(host string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.host(host).delay
+}
+
+// retryAfter parses a Retry-After header given in seconds. HTTP-date values
+// and missing headers yield zero.
+func retryAfter(resp *http.Response) time.Duration {
+	secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
@@ -0,0 +1,107 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func response(status int, retryAfter string) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: http.Header{}}
+	if retryAfter != "" {
+		resp.Header.Set("Retry-After", retryAfter)
+	}
+	return resp
+}
+
+func TestThrottle_BacksOffAndRecovers(t *testing.T) {
+	th := newThrottle()
+	const host = "example.com"
+
+	th.observe(host, response(http.StatusOK, ""), 10*time.Millisecond)
+	if d := th.currentDelay(host); d != 0 {
+		t.Fatalf("delay after healthy response = %v, want 0", d)
+	}
+
+	th.observe(host, response(http.StatusTooManyRequests, ""), 10*time.Millisecond)
+	if d := th.currentDelay(host); d != throttleMinDelay {
+		t.Fatalf("delay after first 429 = %v, want %v", d, throttleMinDelay)
+	}
+
+	th.observe(host, response(http.StatusServiceUnavailable, ""), 10*time.Millisecond)
+	if d := th.currentDelay(host); d != 2*throttleMinDelay {
+		t.Fatalf("delay after 503 = %v, want %v", d, 2*throttleMinDelay)
+	}
+
+	// Healthy responses shrink the delay back to zero
+	for i := 0; i < 100; i++ {
+		th.observe(host, response(http.StatusOK, ""), 10*time.Millisecond)
+	}
+	if d := th.currentDelay(host); d != 0 {
+		t.Errorf("delay after recovery = %v, want 0", d)
+	}
+
+	// Other hosts are unaffected
+	th.observe(host, response(http.StatusTooManyRequests, ""), 10*time.Millisecond)
+	if d := th.currentDelay("other.com"); d != 0 {
+		t.Errorf("delay for unrelated host = %v, want 0", d)
+	}
+}
+
+func TestThrottle_RetryAfterAndCap(t *testing.T) {
+	th := newThrottle()
+
+	th.observe("a.com", response(http.StatusTooManyRequests, "5"), 0)
+	if d := th.currentDelay("a.com"); d != 5*time.Second {
+		t.Errorf("delay with Retry-After: 5 = %v, want 5s", d)
+	}
+
+	th.observe("b.com", response(http.StatusTooManyRequests, "3600"), 0)
+	if d := th.currentDelay("b.com"); d != throttleMaxDelay {
+		t.Errorf("delay with huge Retry-After = %v, want cap %v", d, throttleMaxDelay)
+	}
+}
+
+func TestThrottle_LatencySpike(t *testing.T) {
+	th := newThrottle()
+	const host = "example.com"
+
+	for i := 0; i < 5; i++ {
+		th.observe(host, response(http.StatusOK, ""), 20*time.Millisecond)
+	}
+	th.observe(host, response(http.StatusOK, ""), time.Second)
+	if d := th.currentDelay(host); d != throttleMinDelay {
+		t.Errorf("delay after latency spike = %v, want %v", d, throttleMinDelay)
+	}
+}
+
+func TestFetch_AdaptiveThrottle(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := New(Config{AdaptiveThrottle: true})
+	// The backoff is measured from when the first request was scheduled, so
+	// time from there rather than from its (variable-length) completion
+	start := time.Now()
+	if _, err := c.Fetch(context.Background(), server.URL); err == nil {
+		t.Fatalf("Fetch() expected error for 503, got nil")
+	}
+
+	// The next request waits out the backoff
+	if _, err := c.Fetch(context.Background(), server.URL); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < throttleMinDelay {
+		t.Errorf("request after 503 took %v, want >= %v", elapsed, throttleMinDelay)
+	}
+}
@@ -0,0 +1,75 @@
+package natspub
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cametumbling/web-crawler/internal/crawler"
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultSubject is the default subject prefix messages are published under
+const DefaultSubject = "crawler"
+
+// LinkMessage is published for every link found on a page.
+type LinkMessage struct {
+	// URL is the discovered link, sanitized like the page's links
+	URL string `json:"url"`
+	// Page is the page the link was found on
+	Page string `json:"page"`
+}
+
+// Publisher publishes page results to NATS: each page as its JSON output
+// record on "<subject>.pages", and each link it contains as a LinkMessage
+// on "<subject>.links", so consumers can subscribe to just the one they
+// need. It is safe for concurrent use.
+type Publisher struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// Connect connects to the NATS server at url. An empty subject uses
+// DefaultSubject.
+func Connect(url, subject string) (*Publisher, error) {
+	if subject == "" {
+		subject = DefaultSubject
+	}
+	conn, err := nats.Connect(url, nats.Name("web-crawler"))
+	if err != nil {
+		return nil, fmt.Errorf("connecting to NATS: %w", err)
+	}
+	return &Publisher{conn: conn, subject: subject}, nil
+}
+
+// Publish publishes a page and its links. Messages are buffered by the
+// client and sent asynchronously; Close flushes them.
+func (p *Publisher) Publish(page crawler.PageResult) error {
+	data, err := json.Marshal(page)
+	if err != nil {
+		return fmt.Errorf("marshaling page: %w", err)
+	}
+	if err := p.conn.Publish(p.subject+".pages", data); err != nil {
+		return fmt.Errorf("publishing page: %w", err)
+	}
+
+	for _, link := range page.Links {
+		data, err := json.Marshal(LinkMessage{URL: link, Page: page.URL})
+		if err != nil {
+			return fmt.Errorf("marshaling link: %w", err)
+		}
+		if err := p.conn.Publish(p.subject+".links", data); err != nil {
+			return fmt.Errorf("publishing link: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close waits until the server has received every published message, then
+// closes the connection.
+func (p *Publisher) Close() error {
+	defer p.conn.Close()
+	if err := p.conn.Flush(); err != nil {
+		return fmt.Errorf("flushing NATS messages: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package natspub
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cametumbling/web-crawler/internal/crawler"
+)
+
+type message struct {
+	subject string
+	data    string
+}
+
+// fakeServer speaks just enough of the NATS client protocol to accept a
+// connection and record what is published.
+type fakeServer struct {
+	ln       net.Listener
+	mu       sync.Mutex
+	messages []message
+	done     chan struct{}
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeServer{ln: ln, done: make(chan struct{})}
+	go s.serve(t)
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *fakeServer) url() string {
+	return "nats://" + s.ln.Addr().String()
+}
+
+func (s *fakeServer) serve(t *testing.T) {
+	defer close(s.done)
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	if _, err := io.WriteString(conn, `INFO {"server_id":"fake","version":"2.10.0","proto":1,"max_payload":1048576}`+"\r\n"); err != nil {
+		t.Errorf("writing INFO: %v", err)
+		return
+	}
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "PING":
+			if _, err := io.WriteString(conn, "PONG\r\n"); err != nil {
+				return
+			}
+		case "PUB":
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil {
+				t.Errorf("bad PUB line %q", line)
+				return
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, message{subject: fields[1], data: string(payload[:size])})
+			s.mu.Unlock()
+		}
+	}
+}
+
+func TestPublisher_PagesAndLinks(t *testing.T) {
+	server := newFakeServer(t)
+
+	p, err := Connect(server.url(), "site")
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	page := crawler.PageResult{
+		URL:   "https://example.com/",
+		Links: []string{"https://example.com/a", "https://other.example/"},
+	}
+	if err := p.Publish(page); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	<-server.done
+
+	if len(server.messages) != 3 {
+		t.Fatalf("got %d messages, want 3: %v", len(server.messages), server.messages)
+	}
+	if m := server.messages[0]; m.subject != "site.pages" || !strings.Contains(m.data, `"url":"https://example.com/"`) {
+		t.Errorf("first message = %+v, want the page on site.pages", m)
+	}
+	for i, want := range page.Links {
+		m := server.messages[i+1]
+		var link LinkMessage
+		if err := json.Unmarshal([]byte(m.data), &link); err != nil {
+			t.Fatalf("failed to parse link message %q: %v", m.data, err)
+		}
+		if m.subject != "site.links" || link.URL != want || link.Page != page.URL {
+			t.Errorf("message %d = %s %+v, want %s from %s on site.links", i+1, m.subject, link, want, page.URL)
+		}
+	}
+}
+
+func TestConnect_Unreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	if _, err := Connect("nats://"+addr, ""); err == nil {
+		t.Errorf("Connect() to a closed port should fail")
+	}
+}
@@ -0,0 +1,269 @@
+// Package replay records a crawl's responses to a local archive and serves
+// them back, so changes to the crawl logic can be tested deterministically
+// against a previously captured site, offline. It also serves the
+// responses in WARC archives written by other crawlers.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cametumbling/web-crawler/internal/crawler"
+)
+
+// ErrNotRecorded is returned by a Replayer for a URL missing from its
+// archive.
+var ErrNotRecorded = errors.New("not in the replay archive")
+
+// entry is one line of an archive: a fetch or link check and its outcome.
+type entry struct {
+	URL string `json:"url"`
+	// Check marks a link check, which records only whether the URL resolved
+	Check        bool               `json:"check,omitempty"`
+	FinalURL     string             `json:"final_url,omitempty"`
+	StatusCode   int                `json:"status,omitempty"`
+	ContentType  string             `json:"content_type,omitempty"`
+	Header       http.Header        `json:"header,omitempty"`
+	Redirects    []crawler.Redirect `json:"redirects,omitempty"`
+	RobotsTags   []string           `json:"robots_tags,omitempty"`
+	LastModified time.Time          `json:"last_modified,omitzero"`
+	ETag         string             `json:"etag,omitempty"`
+	TTFB         time.Duration      `json:"ttfb_ns,omitempty"`
+	Duration     time.Duration      `json:"duration_ns,omitempty"`
+	// Body is base64 in the archive, as encoding/json writes []byte
+	Body  []byte         `json:"body,omitempty"`
+	Error *recordedError `json:"error,omitempty"`
+}
+
+// recordedError is a fetch failure, kept in enough detail to be rebuilt as
+// the same kind of error: the crawler classifies failures by type.
+type recordedError struct {
+	// Kind is "http", "stream", "timeout", or "other"
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	// StatusCode, FinalURL, and Redirects describe an HTTP error
+	StatusCode int                `json:"status,omitempty"`
+	FinalURL   string             `json:"final_url,omitempty"`
+	Redirects  []crawler.Redirect `json:"redirects,omitempty"`
+	// Reason describes a streaming error
+	Reason string `json:"reason,omitempty"`
+}
+
+// recordError captures err for the archive.
+func recordError(err error) *recordedError {
+	rec := &recordedError{Kind: "other", Message: err.Error()}
+	var httpErr *crawler.HTTPError
+	var streamErr *crawler.StreamError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &httpErr):
+		rec.Kind = "http"
+		rec.StatusCode = httpErr.StatusCode
+		rec.FinalURL = httpErr.FinalURL
+		rec.Redirects = httpErr.Redirects
+	case errors.As(err, &streamErr):
+		rec.Kind = "stream"
+		rec.Reason = streamErr.Reason
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		rec.Kind = "timeout"
+	}
+	return rec
+}
+
+// replayError rebuilds a recorded failure for url.
+func (rec *recordedError) replayError(url string) error {
+	switch rec.Kind {
+	case "http":
+		return &crawler.HTTPError{StatusCode: rec.StatusCode, URL: url, FinalURL: rec.FinalURL, Redirects: rec.Redirects}
+	case "stream":
+		return &crawler.StreamError{URL: url, Reason: rec.Reason}
+	case "timeout":
+		return fmt.Errorf("%s: %w", rec.Message, context.DeadlineExceeded)
+	default:
+		return errors.New(rec.Message)
+	}
+}
+
+// Recorder is a Fetcher that passes requests to an underlying Fetcher and
+// appends every response, and every failure the site caused, to an
+// archive for a Replayer. Fetches abandoned because the crawl was stopped
+// are not recorded. It is safe for concurrent use.
+type Recorder struct {
+	base crawler.Fetcher
+
+	mu  sync.Mutex
+	w   *bufio.Writer
+	enc *json.Encoder
+	// err is the first write error, returned by Close
+	err error
+}
+
+// NewRecorder returns a Recorder fetching through base and writing the
+// archive to w.
+func NewRecorder(base crawler.Fetcher, w io.Writer) *Recorder {
+	bw := bufio.NewWriter(w)
+	return &Recorder{base: base, w: bw, enc: json.NewEncoder(bw)}
+}
+
+// Fetch fetches url through the underlying Fetcher and records the outcome.
+func (r *Recorder) Fetch(ctx context.Context, url string) (*crawler.FetchResult, error) {
+	result, err := r.base.Fetch(ctx, url)
+	if err != nil {
+		r.recordFailure(ctx, entry{URL: url}, err)
+		return nil, err
+	}
+	r.record(entry{
+		URL:          url,
+		FinalURL:     result.FinalURL,
+		StatusCode:   result.StatusCode,
+		ContentType:  result.ContentType,
+		Header:       result.Header,
+		Redirects:    result.Redirects,
+		RobotsTags:   result.RobotsTags,
+		LastModified: result.LastModified,
+		ETag:         result.ETag,
+		TTFB:         result.TTFB,
+		Duration:     result.Duration,
+		Body:         result.Body,
+	})
+	return result, nil
+}
+
+// Check verifies url through the underlying Fetcher, as a link check if it
+// supports one, and records whether it resolved.
+func (r *Recorder) Check(ctx context.Context, url string) error {
+	var err error
+	if lc, ok := r.base.(crawler.LinkChecker); ok {
+		err = lc.Check(ctx, url)
+	} else {
+		_, err = r.base.Fetch(ctx, url)
+	}
+	if err != nil {
+		r.recordFailure(ctx, entry{URL: url, Check: true}, err)
+		return err
+	}
+	r.record(entry{URL: url, Check: true})
+	return nil
+}
+
+// Close flushes the archive and returns the first error writing it.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		if err := r.w.Flush(); err != nil {
+			r.err = fmt.Errorf("writing replay archive: %w", err)
+		}
+	}
+	return r.err
+}
+
+// recordFailure records a failed fetch, unless the crawl gave up on it.
+func (r *Recorder) recordFailure(ctx context.Context, e entry, err error) {
+	if ctx.Err() != nil || errors.Is(err, crawler.ErrDownloadBudget) {
+		return
+	}
+	e.Error = recordError(err)
+	r.record(e)
+}
+
+func (r *Recorder) record(e entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if err := r.enc.Encode(e); err != nil {
+		r.err = fmt.Errorf("writing replay archive: %w", err)
+	}
+}
+
+// Replayer is a Fetcher that serves archived responses, from a Recorder
+// (see Load) or a WARC file (see LoadWARC), instead of using the network.
+// Fetches return what the archived crawl saw, failures included, and URLs
+// the archive lacks fail with ErrNotRecorded. It is safe for concurrent use.
+type Replayer struct {
+	// fetches and checks map a URL to its last recorded outcome
+	fetches map[string]entry
+	checks  map[string]entry
+}
+
+// Load reads an archive written by a Recorder into a Replayer. The whole
+// archive, bodies included, is held in memory.
+func Load(r io.Reader) (*Replayer, error) {
+	p := &Replayer{fetches: make(map[string]entry), checks: make(map[string]entry)}
+	dec := json.NewDecoder(r)
+	for {
+		var e entry
+		if err := dec.Decode(&e); err == io.EOF {
+			return p, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("reading replay archive: %w", err)
+		}
+		if e.Check {
+			p.checks[e.URL] = e
+		} else {
+			p.fetches[e.URL] = e
+		}
+	}
+}
+
+// Len returns the number of URLs the Replayer can fetch.
+func (p *Replayer) Len() int {
+	return len(p.fetches)
+}
+
+// Fetch returns the recorded response or failure for url.
+func (p *Replayer) Fetch(ctx context.Context, url string) (*crawler.FetchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	e, ok := p.fetches[url]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotRecorded, url)
+	}
+	if e.Error != nil {
+		return nil, e.Error.replayError(url)
+	}
+	return &crawler.FetchResult{
+		Body:         bytes.Clone(e.Body),
+		FinalURL:     e.FinalURL,
+		ContentType:  e.ContentType,
+		Redirects:    e.Redirects,
+		RobotsTags:   e.RobotsTags,
+		LastModified: e.LastModified,
+		ETag:         e.ETag,
+		StatusCode:   e.StatusCode,
+		Header:       e.Header,
+		TTFB:         e.TTFB,
+		Duration:     e.Duration,
+	}, nil
+}
+
+// Check returns the recorded outcome of a link check of url, or of a
+// fetch if it was fetched instead.
+func (p *Replayer) Check(ctx context.Context, url string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	e, ok := p.checks[url]
+	if !ok {
+		e, ok = p.fetches[url]
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotRecorded, url)
+	}
+	if e.Error != nil {
+		return e.Error.replayError(url)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cametumbling/web-crawler/internal/crawler"
+)
+
+// siteFetcher serves fixed results and errors by URL.
+type siteFetcher struct {
+	results map[string]*crawler.FetchResult
+	errors  map[string]error
+}
+
+func (f *siteFetcher) Fetch(ctx context.Context, url string) (*crawler.FetchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err, ok := f.errors[url]; ok {
+		return nil, err
+	}
+	if result, ok := f.results[url]; ok {
+		return result, nil
+	}
+	return nil, &crawler.HTTPError{StatusCode: 404, URL: url}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	page := &crawler.FetchResult{
+		Body:         []byte("<html><a href=\"/b\">b</a></html>"),
+		FinalURL:     "https://example.com/a/",
+		ContentType:  "text/html",
+		Redirects:    []crawler.Redirect{{URL: "https://example.com/a", StatusCode: 301}},
+		RobotsTags:   []string{"noindex"},
+		LastModified: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		ETag:         `"v1"`,
+		StatusCode:   200,
+		Header:       http.Header{"Cache-Control": {"max-age=60"}},
+		TTFB:         20 * time.Millisecond,
+		Duration:     35 * time.Millisecond,
+	}
+	site := &siteFetcher{
+		results: map[string]*crawler.FetchResult{"https://example.com/a": page},
+		errors: map[string]error{
+			"https://example.com/gone":   &crawler.HTTPError{StatusCode: 410, URL: "https://example.com/gone", FinalURL: "https://example.com/gone/"},
+			"https://example.com/events": &crawler.StreamError{URL: "https://example.com/events", Reason: "text/event-stream"},
+			"https://example.com/slow":   fmt.Errorf("fetching: %w", context.DeadlineExceeded),
+		},
+	}
+
+	var archive bytes.Buffer
+	rec := NewRecorder(site, &archive)
+	ctx := context.Background()
+	for _, url := range []string{"https://example.com/a", "https://example.com/gone", "https://example.com/events", "https://example.com/slow"} {
+		rec.Fetch(ctx, url)
+	}
+	if err := rec.Check(ctx, "https://other.example/missing"); err == nil {
+		t.Fatalf("Check() of a missing page should fail")
+	}
+	// A fetch abandoned by a stopped crawl is not the site's answer
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	rec.Fetch(canceled, "https://example.com/stopped")
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	replayer, err := Load(&archive)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if replayer.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", replayer.Len())
+	}
+
+	got, err := replayer.Fetch(ctx, "https://example.com/a")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, page) {
+		t.Errorf("replayed result = %+v, want %+v", got, page)
+	}
+
+	_, err = replayer.Fetch(ctx, "https://example.com/gone")
+	var httpErr *crawler.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != 410 || httpErr.FinalURL != "https://example.com/gone/" {
+		t.Errorf("replayed HTTP error = %v, want 410", err)
+	}
+	_, err = replayer.Fetch(ctx, "https://example.com/events")
+	var streamErr *crawler.StreamError
+	if !errors.As(err, &streamErr) || streamErr.Reason != "text/event-stream" {
+		t.Errorf("replayed stream error = %v", err)
+	}
+	if _, err := replayer.Fetch(ctx, "https://example.com/slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("replayed timeout = %v, want DeadlineExceeded", err)
+	}
+	if _, err := replayer.Fetch(ctx, "https://example.com/stopped"); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("Fetch() of an unrecorded URL = %v, want ErrNotRecorded", err)
+	}
+
+	// Link checks replay their own outcome, or a fetch of the same URL
+	if err := replayer.Check(ctx, "https://other.example/missing"); !errors.As(err, &httpErr) || httpErr.StatusCode != 404 {
+		t.Errorf("replayed Check() = %v, want 404", err)
+	}
+	if err := replayer.Check(ctx, "https://example.com/a"); err != nil {
+		t.Errorf("Check() of a fetched page = %v, want nil", err)
+	}
+}
+
+func TestLoad_RejectsCorruptArchive(t *testing.T) {
+	if _, err := Load(bytes.NewBufferString("{\"url\":\"https://example.com/\"}\nnot json\n")); err == nil {
+		t.Errorf("Load() should reject a corrupt archive")
+	}
+}
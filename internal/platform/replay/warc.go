@@ -0,0 +1,285 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cametumbling/web-crawler/internal/crawler"
+	"github.com/cametumbling/web-crawler/internal/platform/httpclient"
+)
+
+// maxRedirects matches net/http's default limit, which the HTTP client keeps.
+const maxRedirects = 10
+
+// warcResponse is an archived HTTP response, keyed by its target URI.
+type warcResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// warcRevisit is a revisit record: a response whose payload was stored
+// earlier in the archive under another record.
+type warcRevisit struct {
+	uri       string
+	refersTo  string
+	digest    string
+	response  warcResponse
+	parsedErr error
+}
+
+// LoadWARC reads the HTTP responses in a WARC archive (WARC 1.0 or 1.1,
+// plain or gzip-compressed, as written by wget, Heritrix, or browsertrix)
+// into a Replayer, so reports can be re-run against an archived snapshot
+// offline. Redirects are followed within the archive, revisit records are
+// served the payload they refer to, and bodies are decoded and transcoded
+// to UTF-8 as the HTTP client would. Other record types are ignored. The
+// whole archive, bodies included, is held in memory.
+func LoadWARC(r io.Reader) (*Replayer, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		// A .warc.gz is one gzip member per record; the reader joins them
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("reading WARC: %w", err)
+		}
+		defer zr.Close()
+		br = bufio.NewReader(zr)
+	}
+
+	responses := make(map[string]warcResponse)
+	byDigest := make(map[string]string)
+	var revisits []warcRevisit
+	tp := textproto.NewReader(br)
+	for {
+		header, block, err := readWARCRecord(tp)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading WARC: %w", err)
+		}
+		uri := strings.Trim(header.Get("WARC-Target-URI"), "<>")
+		if !strings.HasPrefix(header.Get("Content-Type"), "application/http") || uri == "" {
+			continue
+		}
+		switch header.Get("WARC-Type") {
+		case "response":
+			resp, err := parseWARCResponse(block, header.Get("WARC-Truncated") != "")
+			if err != nil {
+				return nil, fmt.Errorf("reading WARC response for %s: %w", uri, err)
+			}
+			responses[uri] = resp
+			if digest := header.Get("WARC-Payload-Digest"); digest != "" {
+				byDigest[digest] = uri
+			}
+		case "revisit":
+			// The block holds the response headers only
+			resp, err := parseWARCResponse(block, true)
+			revisits = append(revisits, warcRevisit{
+				uri:       uri,
+				refersTo:  strings.Trim(header.Get("WARC-Refers-To-Target-URI"), "<>"),
+				digest:    header.Get("WARC-Payload-Digest"),
+				response:  resp,
+				parsedErr: err,
+			})
+		}
+	}
+
+	for _, rv := range revisits {
+		original, ok := responses[rv.refersTo]
+		if !ok {
+			original, ok = responses[byDigest[rv.digest]]
+		}
+		if !ok {
+			continue
+		}
+		// A "server not modified" revisit records a 304; the page is the
+		// original. An identical-payload revisit has its own status and
+		// headers.
+		if rv.parsedErr == nil && rv.response.status != http.StatusNotModified {
+			original = warcResponse{status: rv.response.status, header: rv.response.header, body: original.body}
+		}
+		responses[rv.uri] = original
+	}
+
+	p := &Replayer{fetches: make(map[string]entry, len(responses)), checks: make(map[string]entry)}
+	for uri := range responses {
+		p.fetches[uri] = resolveWARC(uri, responses)
+	}
+	return p, nil
+}
+
+// readWARCRecord reads the next record's header and content block.
+func readWARCRecord(tp *textproto.Reader) (textproto.MIMEHeader, []byte, error) {
+	// Records are separated by blank lines
+	var version string
+	for version == "" {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return nil, nil, err
+		}
+		version = strings.TrimSpace(line)
+	}
+	if !strings.HasPrefix(version, "WARC/") {
+		return nil, nil, fmt.Errorf("expected a WARC record, got %q", version)
+	}
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, nil, err
+	}
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil || length < 0 {
+		return nil, nil, fmt.Errorf("record %s: invalid Content-Length %q", header.Get("WARC-Record-ID"), header.Get("Content-Length"))
+	}
+	block := make([]byte, length)
+	if _, err := io.ReadFull(tp.R, block); err != nil {
+		return nil, nil, fmt.Errorf("record %s: %w", header.Get("WARC-Record-ID"), err)
+	}
+	return header, block, nil
+}
+
+// parseWARCResponse parses an archived HTTP response, removing its
+// transfer and content encodings. The body of a truncated record is kept
+// as far as it goes.
+func parseWARCResponse(block []byte, truncated bool) (warcResponse, error) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(block)), nil)
+	if err != nil {
+		return warcResponse{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil && !(truncated && errors.Is(err, io.ErrUnexpectedEOF)) {
+		return warcResponse{}, fmt.Errorf("reading body: %w", err)
+	}
+
+	switch strings.ToLower(resp.Header.Get("Content-Encoding")) {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return warcResponse{}, fmt.Errorf("decoding gzip body: %w", err)
+		}
+		decoded, err := io.ReadAll(zr)
+		if err != nil && !(truncated && errors.Is(err, io.ErrUnexpectedEOF)) {
+			return warcResponse{}, fmt.Errorf("decoding gzip body: %w", err)
+		}
+		body = decoded
+		// As net/http does when it decompresses a response
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+	default:
+		return warcResponse{}, fmt.Errorf("unsupported Content-Encoding %q", resp.Header.Get("Content-Encoding"))
+	}
+	return warcResponse{status: resp.StatusCode, header: resp.Header, body: body}, nil
+}
+
+// resolveWARC follows uri's redirects through the archive and returns what
+// a fetch of uri would have produced.
+func resolveWARC(uri string, responses map[string]warcResponse) entry {
+	var redirects []crawler.Redirect
+	current := uri
+	for {
+		resp := responses[current]
+		location := resp.header.Get("Location")
+		if !isRedirect(resp.status) || location == "" {
+			return warcEntry(uri, current, redirects, resp)
+		}
+		if len(redirects) == maxRedirects {
+			return entry{URL: uri, Error: &recordedError{Kind: "other", Message: fmt.Sprintf("stopped after %d redirects", maxRedirects)}}
+		}
+		redirects = append(redirects, crawler.Redirect{URL: current, StatusCode: resp.status})
+		next, err := resolveLocation(current, location)
+		if err != nil {
+			return entry{URL: uri, Error: &recordedError{Kind: "other", Message: fmt.Sprintf("redirect to %q: %v", location, err)}}
+		}
+		if _, ok := responses[next]; !ok {
+			return entry{URL: uri, Error: &recordedError{Kind: "other", Message: fmt.Sprintf("redirect to %s: %v", next, ErrNotRecorded)}}
+		}
+		current = next
+	}
+}
+
+// warcEntry builds the entry for a fetch of uri ending at finalURL's
+// archived response.
+func warcEntry(uri, finalURL string, redirects []crawler.Redirect, resp warcResponse) entry {
+	if resp.status < 200 || resp.status >= 300 {
+		return entry{URL: uri, Error: &recordedError{
+			Kind:       "http",
+			Message:    http.StatusText(resp.status),
+			StatusCode: resp.status,
+			FinalURL:   finalURL,
+			Redirects:  redirects,
+		}}
+	}
+	contentType := resp.header.Get("Content-Type")
+	body := resp.body
+	if isTextContentType(contentType) {
+		body, _ = httpclient.ToUTF8(body, contentType)
+	}
+	var modified time.Time
+	if t, err := http.ParseTime(resp.header.Get("Last-Modified")); err == nil {
+		modified = t
+	}
+	return entry{
+		URL:          uri,
+		FinalURL:     finalURL,
+		StatusCode:   resp.status,
+		ContentType:  contentType,
+		Header:       resp.header,
+		Redirects:    redirects,
+		RobotsTags:   resp.header.Values("X-Robots-Tag"),
+		LastModified: modified,
+		ETag:         resp.header.Get("ETag"),
+		Body:         body,
+	}
+}
+
+// isRedirect reports whether status is one net/http follows.
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// resolveLocation resolves a Location header against the redirecting URL.
+func resolveLocation(base, location string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	next := b.ResolveReference(ref)
+	next.Fragment = ""
+	return next.String(), nil
+}
+
+// isTextContentType reports whether the HTTP client would transcode a body
+// of this Content-Type: the HTML and CSS it parses.
+func isTextContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil && !errors.Is(err, mime.ErrInvalidMediaParameter) {
+		return false
+	}
+	return mediaType == "text/html" || mediaType == "text/css"
+}
@@ -0,0 +1,132 @@
+package replay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cametumbling/web-crawler/internal/crawler"
+)
+
+// warcRecord formats one WARC record with the given type, target, extra
+// headers, and content block.
+func warcRecord(warcType, uri, block string, headers ...string) string {
+	var b strings.Builder
+	b.WriteString("WARC/1.1\r\n")
+	fmt.Fprintf(&b, "WARC-Type: %s\r\n", warcType)
+	if uri != "" {
+		fmt.Fprintf(&b, "WARC-Target-URI: %s\r\n", uri)
+	}
+	contentType := "application/http; msgtype=response"
+	if warcType == "warcinfo" {
+		contentType = "application/warc-fields"
+	}
+	fmt.Fprintf(&b, "Content-Type: %s\r\n", contentType)
+	for _, h := range headers {
+		b.WriteString(h + "\r\n")
+	}
+	fmt.Fprintf(&b, "Content-Length: %d\r\n\r\n%s\r\n\r\n", len(block), block)
+	return b.String()
+}
+
+func gzipped(t *testing.T, s string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(s)); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	return buf.String()
+}
+
+func TestLoadWARC(t *testing.T) {
+	page := gzipped(t, "<html><a href=\"/caf\xe9\">caf\xe9</a></html>")
+	records := []string{
+		warcRecord("warcinfo", "", "software: test\r\n"),
+		warcRecord("request", "http://example.com/old", "GET /old HTTP/1.1\r\nHost: example.com\r\n\r\n"),
+		warcRecord("response", "<http://example.com/old>",
+			"HTTP/1.1 301 Moved Permanently\r\nLocation: /new\r\n\r\n"),
+		warcRecord("response", "http://example.com/new",
+			"HTTP/1.1 200 OK\r\nContent-Type: text/html; charset=iso-8859-1\r\nContent-Encoding: gzip\r\n"+
+				"X-Robots-Tag: noindex\r\nETag: \"v1\"\r\nContent-Length: "+fmt.Sprint(len(page))+"\r\n\r\n"+page,
+			"WARC-Payload-Digest: sha1:PAGE"),
+		warcRecord("response", "http://example.com/gone", "HTTP/1.1 404 Not Found\r\nContent-Length: 0\r\n\r\n"),
+		warcRecord("response", "http://example.com/away", "HTTP/1.1 302 Found\r\nLocation: http://other.example/\r\n\r\n"),
+		// Deduplicated by payload: the body is the one stored for /new
+		warcRecord("revisit", "http://example.com/copy",
+			"HTTP/1.1 200 OK\r\nContent-Type: text/html; charset=iso-8859-1\r\nContent-Length: 40\r\n\r\n",
+			"WARC-Payload-Digest: sha1:PAGE", "WARC-Profile: http://netpreserve.org/warc/1.1/revisit/identical-payload-digest"),
+	}
+	archive := strings.Join(records, "")
+
+	for _, tt := range []struct {
+		name, archive string
+	}{
+		{"plain", archive},
+		// A .warc.gz compresses each record separately
+		{"gzip", func() string {
+			var b strings.Builder
+			for _, r := range records {
+				b.WriteString(gzipped(t, r))
+			}
+			return b.String()
+		}()},
+	} {
+		p, err := LoadWARC(strings.NewReader(tt.archive))
+		if err != nil {
+			t.Fatalf("%s: LoadWARC() error = %v", tt.name, err)
+		}
+		ctx := context.Background()
+
+		result, err := p.Fetch(ctx, "http://example.com/old")
+		if err != nil {
+			t.Fatalf("%s: Fetch() error = %v", tt.name, err)
+		}
+		if string(result.Body) != "<html><a href=\"/café\">café</a></html>" {
+			t.Errorf("%s: body = %q, want it decompressed and in UTF-8", tt.name, result.Body)
+		}
+		if result.FinalURL != "http://example.com/new" || len(result.Redirects) != 1 ||
+			result.Redirects[0] != (crawler.Redirect{URL: "http://example.com/old", StatusCode: 301}) {
+			t.Errorf("%s: final URL %q, redirects %+v", tt.name, result.FinalURL, result.Redirects)
+		}
+		if result.StatusCode != 200 || result.ETag != `"v1"` || len(result.RobotsTags) != 1 || result.Header.Get("Content-Encoding") != "" {
+			t.Errorf("%s: result = %+v", tt.name, result)
+		}
+
+		if dup, err := p.Fetch(ctx, "http://example.com/copy"); err != nil || !bytes.Equal(dup.Body, result.Body) {
+			t.Errorf("%s: revisit Fetch() = %v, want the /new payload", tt.name, err)
+		}
+		var httpErr *crawler.HTTPError
+		if _, err := p.Fetch(ctx, "http://example.com/gone"); !errors.As(err, &httpErr) || httpErr.StatusCode != 404 {
+			t.Errorf("%s: Fetch() of a 404 = %v", tt.name, err)
+		}
+		if err := p.Check(ctx, "http://example.com/gone"); !errors.As(err, &httpErr) {
+			t.Errorf("%s: Check() of a 404 = %v", tt.name, err)
+		}
+		if _, err := p.Fetch(ctx, "http://example.com/away"); err == nil || !strings.Contains(err.Error(), "not in the replay archive") {
+			t.Errorf("%s: Fetch() redirecting out of the archive = %v", tt.name, err)
+		}
+		if _, err := p.Fetch(ctx, "http://example.com/unknown"); !errors.Is(err, ErrNotRecorded) {
+			t.Errorf("%s: Fetch() of an unarchived URL = %v, want ErrNotRecorded", tt.name, err)
+		}
+	}
+}
+
+func TestLoadWARC_RejectsMalformedRecords(t *testing.T) {
+	for _, archive := range []string{
+		"not a warc\r\n",
+		"WARC/1.1\r\nWARC-Type: response\r\nContent-Length: 100\r\n\r\nshort",
+		warcRecord("response", "http://example.com/", "HTTP/1.1 200 OK\r\nContent-Encoding: br\r\n\r\nxx"),
+	} {
+		if _, err := LoadWARC(strings.NewReader(archive)); err == nil {
+			t.Errorf("LoadWARC(%q) should fail", archive)
+		}
+	}
+}
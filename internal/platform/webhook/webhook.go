@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultTimeout is the default cap on a single delivery attempt
+	DefaultTimeout = 10 * time.Second
+	// DefaultRetries is the default number of retries after a failed attempt
+	DefaultRetries = 3
+	// DefaultBackoff is the default wait before the first retry; it doubles
+	// after every further failure
+	DefaultBackoff = 500 * time.Millisecond
+	// SignatureHeader carries the hex HMAC-SHA256 of the body, prefixed
+	// with "sha256=", when a secret is configured
+	SignatureHeader = "X-Crawler-Signature-256"
+)
+
+// Config holds webhook sender configuration.
+type Config struct {
+	// URL receives a POST for every payload
+	URL string
+	// Secret signs each body with HMAC-SHA256 (empty = unsigned)
+	Secret string
+	// Retries is how many times a failed delivery is retried (0 = DefaultRetries,
+	// negative = never retried)
+	Retries int
+	// Timeout caps each attempt (0 = DefaultTimeout)
+	Timeout time.Duration
+	// Backoff is the wait before the first retry (0 = DefaultBackoff)
+	Backoff time.Duration
+}
+
+// Sender POSTs JSON payloads to a webhook. It is safe for concurrent use.
+type Sender struct {
+	httpClient *http.Client
+	url        string
+	secret     []byte
+	retries    int
+	backoff    time.Duration
+}
+
+// New creates a webhook sender with the given configuration.
+func New(cfg Config) *Sender {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	retries := cfg.Retries
+	if retries == 0 {
+		retries = DefaultRetries
+	}
+	if retries < 0 {
+		retries = 0
+	}
+	backoff := cfg.Backoff
+	if backoff == 0 {
+		backoff = DefaultBackoff
+	}
+
+	return &Sender{
+		httpClient: &http.Client{Timeout: timeout},
+		url:        cfg.URL,
+		secret:     []byte(cfg.Secret),
+		retries:    retries,
+		backoff:    backoff,
+	}
+}
+
+// Send delivers v as a JSON body. Network errors, 429, and 5xx responses
+// are retried with exponential backoff; other 4xx responses are not, since
+// repeating the same body cannot fix them. It returns the last error once
+// the retries are used up or ctx is done.
+func (s *Sender) Send(ctx context.Context, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshaling payload: %w", err)
+	}
+
+	wait := s.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == s.retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// post makes one delivery attempt, reporting whether a failure is worth
+// retrying.
+func (s *Sender) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(s.secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(s.secret, body))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("posting to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection is reused
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return true, fmt.Errorf("reading webhook response: %w", err)
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook returned %s", resp.Status)
+}
+
+// Sign returns the hex HMAC-SHA256 of body under secret, the value a
+// receiver compares against SignatureHeader (after its "sha256=" prefix).
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSend_SignsAndRetries(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading body: %v", err)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+		if got, want := r.Header.Get(SignatureHeader), "sha256="+Sign([]byte("s3cret"), body); got != want {
+			t.Errorf("%s = %q, want %q", SignatureHeader, got, want)
+		}
+		if string(body) != `{"url":"https://example.com/"}` {
+			t.Errorf("body = %s", body)
+		}
+		// Fail twice before accepting
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	s := New(Config{URL: server.URL, Secret: "s3cret", Backoff: time.Millisecond})
+	if err := s.Send(context.Background(), map[string]string{"url": "https://example.com/"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if n := attempts.Load(); n != 3 {
+		t.Errorf("got %d attempts, want 3", n)
+	}
+}
+
+func TestSend_GivesUp(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		retries  int
+		attempts int32
+	}{
+		{"server error is retried", http.StatusInternalServerError, 2, 3},
+		{"rate limit is retried", http.StatusTooManyRequests, 1, 2},
+		{"client error is not retried", http.StatusBadRequest, 2, 1},
+		{"negative retries disable retrying", http.StatusBadGateway, -1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			s := New(Config{URL: server.URL, Retries: tt.retries, Backoff: time.Millisecond})
+			err := s.Send(context.Background(), struct{}{})
+			if err == nil || !strings.Contains(err.Error(), http.StatusText(tt.status)) {
+				t.Errorf("Send() error = %v, want the %d status", err, tt.status)
+			}
+			if n := attempts.Load(); n != tt.attempts {
+				t.Errorf("got %d attempts, want %d", n, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestSend_Unsigned(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get(SignatureHeader); got != "" {
+			t.Errorf("unsigned request has %s = %q", SignatureHeader, got)
+		}
+	}))
+	defer server.Close()
+
+	if err := New(Config{URL: server.URL}).Send(context.Background(), struct{}{}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+}
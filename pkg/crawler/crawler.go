@@ -0,0 +1,497 @@
+// Package crawler is the public API for embedding the web crawler in other
+// Go programs. A Crawler crawls every page on the start URL's host and
+// delivers each visited page on a typed channel:
+//
+//	c, err := crawler.New("https://example.com/", crawler.WithWorkers(4), crawler.WithMaxPages(100))
+//	if err != nil {
+//		return err
+//	}
+//	go func() {
+//		for page := range c.Results() {
+//			fmt.Println(page.URL, len(page.Links))
+//		}
+//	}()
+//	err = c.Run(ctx)
+//
+// The scheduling, scope, and normalization rules are those of the CLI;
+// see the repository README. Crawl summaries are logged with log/slog; see
+// WithLogger.
+package crawler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/cametumbling/web-crawler/internal/crawler"
+	"github.com/cametumbling/web-crawler/internal/platform/htmlparser"
+	"github.com/cametumbling/web-crawler/internal/platform/httpclient"
+	"github.com/cametumbling/web-crawler/internal/platform/replay"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Page is one visited page, with the same fields as a JSON output record.
+type Page = crawler.PageResult
+
+// Link is an extracted link with its anchor text, rel, and source tag.
+type Link = crawler.Link
+
+// DefaultTrackingParams are the analytics and ad click parameters, such as
+// utm_* and gclid, that WithStripParams usually removes.
+var DefaultTrackingParams = crawler.DefaultTrackingParams
+
+// Frontier holds the pages waiting to be crawled and decides their order;
+// see WithFrontier.
+type Frontier = crawler.Frontier
+
+// Crawl orders accepted by NewFrontier.
+const (
+	BreadthFirst = crawler.BreadthFirst
+	DepthFirst   = crawler.DepthFirst
+	Priority     = crawler.Priority
+)
+
+// NewFrontier returns an empty frontier crawling in the named order:
+// BreadthFirst (the default), DepthFirst, or Priority.
+func NewFrontier(order string) (Frontier, error) {
+	return crawler.NewFrontier(order)
+}
+
+// SpillFrontier is a breadth-first Frontier that spills to disk beyond a
+// memory limit; see NewSpillFrontier.
+type SpillFrontier = crawler.SpillFrontier
+
+// NewSpillFrontier returns a breadth-first frontier holding up to limit
+// URLs in memory and spilling the rest to a temporary file in dir (the
+// system temporary directory when empty). Close it once the crawl is done.
+func NewSpillFrontier(limit int, dir string) (*SpillFrontier, error) {
+	return crawler.NewSpillFrontier(limit, dir)
+}
+
+// ScoreFunc rates a URL for NewPriorityFrontier: higher scores are crawled
+// sooner.
+type ScoreFunc = crawler.ScoreFunc
+
+// NewPriorityFrontier returns a frontier crawling the URL with the highest
+// score(url, depth) - depth - 0.1*path segments first (nil score = 0), so
+// important sections such as /product/ come first under a page budget.
+func NewPriorityFrontier(score ScoreFunc) Frontier {
+	return crawler.NewPriorityFrontier(score)
+}
+
+// ScopePolicy decides which discovered URLs are crawled; see WithScope.
+type ScopePolicy = crawler.ScopePolicy
+
+// ScopeFunc adapts a function to a ScopePolicy.
+type ScopeFunc = crawler.ScopeFunc
+
+// HostScope is the default ScopePolicy: URLs on one hostname.
+type HostScope = crawler.HostScope
+
+// Filter decides whether a discovered URL is scheduled; see WithFilters.
+type Filter = crawler.Filter
+
+// FilterFunc returns a Filter named name that calls fn with each URL and
+// its depth (the start URL is 0).
+func FilterFunc(name string, fn func(url string, depth int) bool) Filter {
+	return crawler.FilterFunc(name, fn)
+}
+
+// DepthFilter skips URLs more than max links away from the start URL.
+func DepthFilter(max int) Filter {
+	return crawler.DepthFilter(max)
+}
+
+// RegexFilter skips URLs matching an exclude pattern and, when include
+// patterns are given, URLs matching none of them.
+func RegexFilter(include, exclude []string) (Filter, error) {
+	return crawler.RegexFilter(include, exclude)
+}
+
+// Asset is a non-anchor dependency of a page, tagged by type.
+type Asset = crawler.Asset
+
+// Redirect is a single hop in a redirect chain.
+type Redirect = crawler.Redirect
+
+// Fetcher fetches pages. Supply one with WithFetcher to replace the
+// built-in HTTP client, e.g. to add caching or authentication.
+type Fetcher = crawler.Fetcher
+
+// FetchResult is the result of a Fetcher call.
+type FetchResult = crawler.FetchResult
+
+// Result is a worker's raw result for one URL, as seen by WithResultHook.
+type Result = crawler.Result
+
+// HTTPError is returned by fetchers for non-2xx responses.
+type HTTPError = crawler.HTTPError
+
+// ErrDownloadBudget is returned by a Fetcher whose download budget is
+// spent; the crawl then stops cleanly (see WithDownloadBudget).
+var ErrDownloadBudget = crawler.ErrDownloadBudget
+
+// ErrThresholdExceeded is returned by Run when the crawl broke a quality
+// threshold (see WithFailOnBrokenLinks and WithMaxErrorRate).
+var ErrThresholdExceeded = crawler.ErrThresholdExceeded
+
+// ErrAborted is returned by Run when the crawl stopped early because too
+// many pages failed (see WithErrorLimits).
+var ErrAborted = crawler.ErrAborted
+
+// HARRecorder collects the built-in HTTP client's traffic in HTTP Archive
+// format; see WithHAR.
+type HARRecorder = httpclient.HARRecorder
+
+// NewHARRecorder returns an empty HARRecorder reporting version as its
+// creator's version.
+func NewHARRecorder(version string) *HARRecorder {
+	return httpclient.NewHARRecorder(version)
+}
+
+// Recorder is a Fetcher saving every response of another Fetcher to an
+// archive for a Replayer; see NewRecorder.
+type Recorder = replay.Recorder
+
+// NewRecorder returns a Recorder fetching through base and writing the
+// archive to w; call Close once Run returns. Pass it with WithFetcher, e.g.
+// wrapping NewFetcher().
+func NewRecorder(base Fetcher, w io.Writer) *Recorder {
+	return replay.NewRecorder(base, w)
+}
+
+// Replayer is a Fetcher serving archived responses, from a Recorder or a
+// WARC file, instead of the network, for deterministic offline crawls.
+type Replayer = replay.Replayer
+
+// LoadReplay reads an archive written by a Recorder; pass the Replayer
+// with WithFetcher.
+func LoadReplay(r io.Reader) (*Replayer, error) {
+	return replay.Load(r)
+}
+
+// LoadWARC reads the HTTP responses in a WARC archive, plain or
+// gzip-compressed, into a Replayer; pass it with WithFetcher to crawl an
+// archived snapshot offline.
+func LoadWARC(r io.Reader) (*Replayer, error) {
+	return replay.LoadWARC(r)
+}
+
+// ErrNotRecorded is returned by a Replayer for URLs its archive lacks.
+var ErrNotRecorded = replay.ErrNotRecorded
+
+// WorkerPool limits the fetches in flight across every Crawler sharing it.
+type WorkerPool = crawler.WorkerPool
+
+// NewWorkerPool returns a pool allowing size concurrent fetches.
+func NewWorkerPool(size int) *WorkerPool {
+	return crawler.NewWorkerPool(size)
+}
+
+// ErrAlreadyRun is returned by Run when the Crawler has already been run.
+var ErrAlreadyRun = errors.New("crawler: Run called more than once")
+
+// DefaultWorkers is the number of concurrent fetches when WithWorkers is not used.
+const DefaultWorkers = 8
+
+// Option configures a Crawler.
+type Option func(*options)
+
+// options collects the settings applied by Options.
+type options struct {
+	crawl   crawler.Config
+	client  httpclient.Config
+	fetcher Fetcher
+}
+
+// WithWorkers sets the number of concurrent fetches (default 8).
+func WithWorkers(n int) Option {
+	return func(o *options) { o.crawl.NumWorkers = n }
+}
+
+// WithAutoScale treats WithWorkers as a ceiling and sizes the active
+// workers to the site, between min and that ceiling: growing while pages
+// are waiting, shrinking when fetches slow down or overload errors appear.
+func WithAutoScale(min int) Option {
+	return func(o *options) {
+		o.crawl.AutoScale = true
+		o.crawl.MinWorkers = min
+	}
+}
+
+// WithMaxPages stops scheduling new pages after n have been visited (0 = unlimited).
+func WithMaxPages(n int) Option {
+	return func(o *options) { o.crawl.MaxPages = n }
+}
+
+// WithFailOnBrokenLinks makes Run return ErrThresholdExceeded if any page
+// returned 404 or 410.
+func WithFailOnBrokenLinks() Option {
+	return func(o *options) { o.crawl.FailOnBrokenLinks = true }
+}
+
+// WithMaxErrorRate makes Run return ErrThresholdExceeded if more than rate
+// (between 0 and 1) of the pages failed.
+func WithMaxErrorRate(rate float64) Option {
+	return func(o *options) { o.crawl.MaxErrorRate = rate }
+}
+
+// WithErrorLimits aborts the crawl once total pages have failed, or
+// consecutive pages in a row (0 = no limit); Run then returns ErrAborted.
+func WithErrorLimits(total, consecutive int) Option {
+	return func(o *options) {
+		o.crawl.MaxErrors = total
+		o.crawl.MaxConsecutiveErrors = consecutive
+	}
+}
+
+// WithPageRate caps the crawl at pagesPerSec pages per second, however
+// many workers and hosts (0 = no cap).
+func WithPageRate(pagesPerSec float64) Option {
+	return func(o *options) { o.crawl.MaxPagesPerSec = pagesPerSec }
+}
+
+// WithUserAgent sets the User-Agent header sent by the built-in HTTP client.
+func WithUserAgent(ua string) Option {
+	return func(o *options) { o.client.UserAgent = ua }
+}
+
+// WithTimeout sets the per-request timeout of the built-in HTTP client (default 10s).
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.client.Timeout = d }
+}
+
+// WithRateLimit sets the minimum interval between requests (0 = no limit).
+func WithRateLimit(interval time.Duration) Option {
+	return func(o *options) { o.client.RateLimit = interval }
+}
+
+// WithMaxBodySize limits how much of each HTML page is read (default 2 MiB).
+func WithMaxBodySize(n int64) Option {
+	return func(o *options) { o.client.MaxBodySize = n }
+}
+
+// WithBandwidth caps how many response body bytes per second the
+// built-in HTTP client reads, across all requests.
+func WithBandwidth(bytesPerSec int64) Option {
+	return func(o *options) { o.client.MaxBytesPerSec = bytesPerSec }
+}
+
+// WithHAR records every request the built-in HTTP client makes, with its
+// response, into rec; call rec.WriteHAR once Run returns.
+func WithHAR(rec *HARRecorder) Option {
+	return func(o *options) { o.client.HAR = rec }
+}
+
+// WithDownloadBudget stops the crawl cleanly once the built-in HTTP client
+// has downloaded totalBytes of response bodies; pages still queued are
+// dropped, not reported as failed.
+func WithDownloadBudget(totalBytes int64) Option {
+	return func(o *options) { o.client.MaxTotalBytes = totalBytes }
+}
+
+// WithFetcher replaces the built-in HTTP client. Client options such as
+// WithUserAgent and WithTimeout are ignored. A Fetcher, including one from
+// NewFetcher, may be shared by Crawlers running concurrently.
+func WithFetcher(f Fetcher) Option {
+	return func(o *options) { o.fetcher = f }
+}
+
+// WithWorkerPool shares a concurrency budget with other Crawlers: this
+// crawl still runs its own workers, but together the Crawlers in the pool
+// never have more fetches in flight than the pool allows.
+func WithWorkerPool(p *WorkerPool) Option {
+	return func(o *options) { o.crawl.Pool = p }
+}
+
+// WithAssets fills Page.Assets with each page's img, script, link, and iframe URLs.
+func WithAssets() Option {
+	return func(o *options) { o.crawl.IncludeAssets = true }
+}
+
+// WithLinkDetails fills Page.LinkDetails with each link's text, rel, and tag.
+func WithLinkDetails() Option {
+	return func(o *options) { o.crawl.LinkDetails = true }
+}
+
+// WithText fills Page.Text with each page's visible body text.
+func WithText() Option {
+	return func(o *options) { o.crawl.ExtractText = true }
+}
+
+// WithMetadata fills Page.OpenGraph and Page.StructuredData.
+func WithMetadata() Option {
+	return func(o *options) { o.crawl.HarvestMetadata = true }
+}
+
+// WithHTML fills Page.HTML with each page's raw HTML, truncated to maxBytes
+// (0 = no limit).
+func WithHTML(maxBytes int) Option {
+	return func(o *options) {
+		o.crawl.IncludeHTML = true
+		o.crawl.HTMLMaxBytes = maxBytes
+	}
+}
+
+// WithLanguages skips pages that declare a language other than the given
+// tags; pages without a declared language are kept.
+func WithLanguages(tags ...string) Option {
+	return func(o *options) { o.crawl.Languages = tags }
+}
+
+// WithRespectRobotsMeta honours nofollow and noindex from robots meta tags
+// and X-Robots-Tag headers.
+func WithRespectRobotsMeta() Option {
+	return func(o *options) { o.crawl.RespectRobotsMeta = true }
+}
+
+// WithResultHook calls fn with each raw result before the crawler acts on
+// it; fn may modify the result, e.g. to add links or metadata. Hooks run one
+// at a time on the crawl's scheduling goroutine, so they need no locking but
+// should return quickly.
+func WithResultHook(fn func(result *Result)) Option {
+	return func(o *options) { o.crawl.OnResult = fn }
+}
+
+// WithLinkDiscovered calls fn for every link found on a followed page, in
+// scope or not, with the page URL and the normalized link URL.
+func WithLinkDiscovered(fn func(from, link string)) Option {
+	return func(o *options) { o.crawl.OnLinkDiscovered = fn }
+}
+
+// WithStripParams removes these query parameters from every URL before it
+// is reported, deduplicated, or scheduled. A trailing * matches a prefix;
+// pass crawler.DefaultTrackingParams to drop analytics and ad click tags.
+func WithStripParams(names ...string) Option {
+	return func(o *options) { o.crawl.StripParams = names }
+}
+
+// WithSortQuery treats URLs whose query parameters differ only in order as
+// the same page; the first ordering found is the one fetched and reported.
+func WithSortQuery() Option {
+	return func(o *options) { o.crawl.SortQuery = true }
+}
+
+// WithCaseInsensitivePaths treats paths differing only in case as the same
+// page, for sites served by case-insensitive servers such as IIS.
+func WithCaseInsensitivePaths() Option {
+	return func(o *options) { o.crawl.CaseInsensitivePaths = true }
+}
+
+// WithFrontier sets the order pages are crawled in, e.g. depth-first with
+// NewFrontier(DepthFirst). A Frontier holds one crawl's queue, so give
+// each Crawler its own.
+func WithFrontier(f Frontier) Option {
+	return func(o *options) { o.crawl.Frontier = f }
+}
+
+// WithScope replaces the default scope, the start URL's host, with
+// policy: links it rejects are reported but not followed.
+func WithScope(policy ScopePolicy) Option {
+	return func(o *options) { o.crawl.Scope = policy }
+}
+
+// WithFilters runs filters, in order, on every in-scope URL before it is
+// scheduled. The first filter to reject a URL skips it, and the summary
+// logs how many URLs each filter skipped.
+func WithFilters(filters ...Filter) Option {
+	return func(o *options) { o.crawl.Filters = append(o.crawl.Filters, filters...) }
+}
+
+// WithBeforeFetch calls fn with each new in-scope URL before it is
+// scheduled; returning false skips the URL. The start URL is always fetched.
+func WithBeforeFetch(fn func(url string) bool) Option {
+	return func(o *options) { o.crawl.OnBeforeFetch = fn }
+}
+
+// WithTracerProvider traces the crawl with OpenTelemetry: a span for the
+// crawl and one per page, with fetch, parse, and process children. Pages
+// carry url.full, server.address, crawler.depth, and status attributes, so
+// slow hosts, parse time, and queue waits show up in the tracing backend.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) { o.crawl.TracerProvider = tp }
+}
+
+// WithLogger sends the crawl's logs (failed fetches, skipped pages, and the
+// summary reports) to logger instead of slog.Default(). Result details are
+// logged at debug level.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) { o.crawl.Logger = logger }
+}
+
+// NewFetcher returns the built-in HTTP client configured by the client
+// options (WithUserAgent, WithTimeout, WithRateLimit, WithMaxBodySize,
+// WithWorkers), for sharing one connection pool and rate limit between
+// Crawlers with WithFetcher.
+func NewFetcher(opts ...Option) Fetcher {
+	o := options{crawl: crawler.Config{NumWorkers: DefaultWorkers}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o.httpClient()
+}
+
+// httpClient builds the built-in HTTP client, keeping one idle connection
+// per worker.
+func (o *options) httpClient() Fetcher {
+	client := o.client
+	if client.MaxIdleConnsPerHost == 0 {
+		client.MaxIdleConnsPerHost = o.crawl.NumWorkers
+	}
+	return httpclient.New(client)
+}
+
+// Crawler crawls a single host. Create one with New, read Results, and
+// call Run once.
+type Crawler struct {
+	coord   *crawler.Coordinator
+	results chan Page
+	started bool
+}
+
+// New validates the options and returns a Crawler for startURL.
+func New(startURL string, opts ...Option) (*Crawler, error) {
+	o := options{crawl: crawler.Config{NumWorkers: DefaultWorkers}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	fetcher := o.fetcher
+	if fetcher == nil {
+		fetcher = o.httpClient()
+	}
+
+	results := make(chan Page, max(o.crawl.NumWorkers, 1))
+	cfg := o.crawl
+	cfg.StartURL = startURL
+	cfg.Fetcher = fetcher
+	cfg.Parser = &htmlparser.Parser{}
+	cfg.Output = io.Discard
+	cfg.Pages = results
+	coord, err := crawler.NewCoordinator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Crawler{coord: coord, results: results}, nil
+}
+
+// Results returns the channel on which every visited page is delivered,
+// including pages that failed (Page.Error is set). It is closed when Run
+// returns. The crawl waits for each page to be received, so the channel
+// must be drained from another goroutine.
+func (c *Crawler) Results() <-chan Page {
+	return c.results
+}
+
+// Run crawls until every reachable page has been visited, the page limit
+// is reached, or ctx is cancelled, and returns ctx.Err() in the last case.
+func (c *Crawler) Run(ctx context.Context) error {
+	if c.started {
+		return ErrAlreadyRun
+	}
+	c.started = true
+	defer close(c.results)
+	return c.coord.Crawl(ctx)
+}
@@ -0,0 +1,166 @@
+package crawler_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cametumbling/web-crawler/pkg/crawler"
+)
+
+func newSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/a">A</a> <a href="/missing">gone</a></body></html>`)
+	})
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html lang="en"><head><title>Page A</title></head><body><p>Hello there</p><a href="/">home</a></body></html>`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCrawler_DeliversPages(t *testing.T) {
+	server := newSite(t)
+
+	c, err := crawler.New(server.URL+"/", crawler.WithWorkers(2), crawler.WithText(), crawler.WithLinkDetails())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var pages []crawler.Page
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for page := range c.Results() {
+			pages = append(pages, page)
+		}
+	}()
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	<-done
+
+	sort.Slice(pages, func(i, j int) bool { return pages[i].URL < pages[j].URL })
+	if len(pages) != 3 {
+		t.Fatalf("got %d pages, want 3: %+v", len(pages), pages)
+	}
+	a := pages[1]
+	if a.URL != server.URL+"/a" || a.Title != "Page A" || a.Text == "" || len(a.LinkDetails) != 1 {
+		t.Errorf("page /a = %+v, want title, text, and link details", a)
+	}
+	if missing := pages[2]; missing.Error == "" {
+		t.Errorf("page /missing = %+v, want an error", missing)
+	}
+
+	if err := c.Run(context.Background()); !errors.Is(err, crawler.ErrAlreadyRun) {
+		t.Errorf("second Run() error = %v, want ErrAlreadyRun", err)
+	}
+}
+
+func TestCrawler_MaxPages(t *testing.T) {
+	server := newSite(t)
+
+	c, err := crawler.New(server.URL+"/", crawler.WithWorkers(1), crawler.WithMaxPages(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	go c.Run(context.Background())
+
+	n := 0
+	for range c.Results() {
+		n++
+	}
+	if n != 1 {
+		t.Errorf("got %d pages, want 1", n)
+	}
+}
+
+func TestCrawler_Hooks(t *testing.T) {
+	server := newSite(t)
+
+	var links int
+	c, err := crawler.New(server.URL+"/",
+		crawler.WithWorkers(1),
+		crawler.WithLinkDiscovered(func(from, link string) { links++ }),
+		crawler.WithBeforeFetch(func(url string) bool { return url != server.URL+"/missing" }),
+		crawler.WithResultHook(func(result *crawler.Result) { result.Title += "!" }),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	go c.Run(context.Background())
+
+	var titles []string
+	for page := range c.Results() {
+		titles = append(titles, page.Title)
+	}
+	sort.Strings(titles)
+	if len(titles) != 2 || titles[0] != "!" || titles[1] != "Page A!" {
+		t.Errorf("titles = %q, want the two allowed pages, enriched", titles)
+	}
+	if links != 3 {
+		t.Errorf("discovered %d links, want 3", links)
+	}
+}
+
+func TestCrawler_ConcurrentCrawlsShareFetcherAndPool(t *testing.T) {
+	sites := []*httptest.Server{newSite(t), newSite(t)}
+	fetcher := crawler.NewFetcher(crawler.WithWorkers(4))
+	pool := crawler.NewWorkerPool(2)
+
+	var wg sync.WaitGroup
+	counts := make([]int, len(sites))
+	for i, site := range sites {
+		c, err := crawler.New(site.URL+"/", crawler.WithFetcher(fetcher), crawler.WithWorkerPool(pool))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := c.Run(context.Background()); err != nil {
+				t.Errorf("Run() error = %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for page := range c.Results() {
+				if !strings.HasPrefix(page.URL, site.URL) {
+					t.Errorf("crawl of %s delivered %s", site.URL, page.URL)
+				}
+				counts[i]++
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, n := range counts {
+		if n != 3 {
+			t.Errorf("crawl %d delivered %d pages, want 3", i, n)
+		}
+	}
+}
+
+func TestNew_InvalidStartURL(t *testing.T) {
+	if _, err := crawler.New("ftp://example.com/"); err == nil {
+		t.Error("New() accepted a non-HTTP start URL")
+	}
+	if _, err := crawler.New("https://example.com/", crawler.WithWorkers(0)); err == nil {
+		t.Error("New() accepted zero workers")
+	}
+}